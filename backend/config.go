@@ -0,0 +1,601 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/shoppinglist"
+
+	"github.com/BurntSushi/toml"
+	"go.yaml.in/yaml/v3"
+)
+
+// --- Configuration ---
+
+// Config is the complete backend configuration. It is assembled in increasing order of
+// precedence from defaults, an optional YAML or TOML file (--config or CONFIG_FILE),
+// environment variables and command-line flags, then validated as a whole.
+type Config struct {
+	Env       string          `yaml:"env" toml:"env"`
+	Port      int             `yaml:"port" toml:"port"`
+	Storage   StorageConfig   `yaml:"storage" toml:"storage"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	Startup   StartupConfig   `yaml:"startup" toml:"startup"`
+	HTTP      HTTPConfig      `yaml:"http" toml:"http"`
+	TLS       TLSConfig       `yaml:"tls" toml:"tls"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Items     ItemsConfig     `yaml:"items" toml:"items"`
+	Recurring RecurringConfig `yaml:"recurring" toml:"recurring"`
+	Shutdown  ShutdownConfig  `yaml:"shutdown" toml:"shutdown"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+}
+
+// StorageConfig selects the item store.
+type StorageConfig struct {
+	Backend string `yaml:"backend" toml:"backend"` // postgres, sqlite or memory
+	File    string `yaml:"file" toml:"file"`       // Snapshot file for the memory store
+}
+
+// DatabaseConfig configures PostgreSQL (or SQLite, via a sqlite:// URL).
+type DatabaseConfig struct {
+	URL               string        `yaml:"url" toml:"url"`
+	Host              string        `yaml:"host" toml:"host"`
+	Port              int           `yaml:"port" toml:"port"`
+	User              string        `yaml:"user" toml:"user"`
+	Password          string        `yaml:"password" toml:"password"`
+	Name              string        `yaml:"name" toml:"name"`
+	SSLMode           string        `yaml:"sslmode" toml:"sslmode"`
+	SSLRootCert       string        `yaml:"sslrootcert" toml:"sslrootcert"`
+	SSLCert           string        `yaml:"sslcert" toml:"sslcert"`
+	SSLKey            string        `yaml:"sslkey" toml:"sslkey"`
+	SSLPassword       string        `yaml:"sslpassword" toml:"sslpassword"`
+	MaxConns          int           `yaml:"max_conns" toml:"max_conns"`
+	MinConns          int           `yaml:"min_conns" toml:"min_conns"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" toml:"health_check_period"`
+}
+
+// StartupConfig controls database connection retries at startup.
+type StartupConfig struct {
+	ConnectTimeout    time.Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+	ConnectBackoff    time.Duration `yaml:"connect_backoff" toml:"connect_backoff"`
+	ConnectMaxBackoff time.Duration `yaml:"connect_max_backoff" toml:"connect_max_backoff"`
+	ConnectMaxWait    time.Duration `yaml:"connect_max_wait" toml:"connect_max_wait"`
+	ServeLivez        bool          `yaml:"serve_livez" toml:"serve_livez"`
+}
+
+// HTTPConfig holds the http.Server timeouts.
+type HTTPConfig struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+}
+
+// TLSConfig enables HTTPS when CertFile and KeyFile are set.
+type TLSConfig struct {
+	CertFile   string `yaml:"cert_file" toml:"cert_file"`
+	KeyFile    string `yaml:"key_file" toml:"key_file"`
+	MinVersion string `yaml:"min_version" toml:"min_version"` // "1.2" or "1.3"
+}
+
+// RateLimitConfig sets per-client request limits; a zero rate disables that class.
+type RateLimitConfig struct {
+	ReadPerMinute  int    `yaml:"read_per_minute" toml:"read_per_minute"`
+	ReadBurst      int    `yaml:"read_burst" toml:"read_burst"`
+	WritePerMinute int    `yaml:"write_per_minute" toml:"write_per_minute"`
+	WriteBurst     int    `yaml:"write_burst" toml:"write_burst"`
+	TrustedProxies string `yaml:"trusted_proxies" toml:"trusted_proxies"` // Comma-separated IPs or CIDRs
+}
+
+// ItemsConfig limits item fields, in characters; the database allows at most
+// shoppinglist.MaxNameLength and MaxQuantityLength. SerialIDs keeps accepting serial
+// item IDs in /items/{id} next to UIDs.
+type ItemsConfig struct {
+	MaxNameLength     int  `yaml:"max_name_length" toml:"max_name_length"`
+	MaxQuantityLength int  `yaml:"max_quantity_length" toml:"max_quantity_length"`
+	SerialIDs         bool `yaml:"serial_ids" toml:"serial_ids"`
+}
+
+// RecurringConfig controls the scheduler that adds due recurring items to the list.
+type RecurringConfig struct {
+	Interval time.Duration `yaml:"interval" toml:"interval"` // 0 disables the scheduler
+}
+
+// ShutdownConfig controls graceful shutdown.
+type ShutdownConfig struct {
+	Delay   time.Duration `yaml:"delay" toml:"delay"`
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+}
+
+// LogConfig configures the process logger.
+type LogConfig struct {
+	Format string `yaml:"format" toml:"format"`
+	Level  string `yaml:"level" toml:"level"`
+}
+
+// TracingConfig configures OpenTelemetry export.
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" toml:"exporter"`
+}
+
+// defaultConfig returns the configuration used when nothing is overridden.
+func defaultConfig() Config {
+	return Config{
+		Env:     "development",
+		Port:    8080,
+		Storage: StorageConfig{Backend: "postgres"},
+		Database: DatabaseConfig{
+			Host:              "db",
+			Port:              5432,
+			User:              "user",
+			Password:          "password",
+			Name:              "shoppingdb",
+			SSLMode:           "disable",
+			MaxConns:          shoppinglist.DefaultMaxConns,
+			MaxConnLifetime:   shoppinglist.DefaultMaxConnLifetime,
+			MaxConnIdleTime:   shoppinglist.DefaultMaxConnIdleTime,
+			HealthCheckPeriod: shoppinglist.DefaultHealthCheckPeriod,
+		},
+		Startup: StartupConfig{
+			ConnectTimeout:    5 * time.Second,
+			ConnectBackoff:    500 * time.Millisecond,
+			ConnectMaxBackoff: 10 * time.Second,
+			ConnectMaxWait:    2 * time.Minute,
+		},
+		HTTP: HTTPConfig{
+			ReadTimeout:       5 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      10 * time.Second,
+			IdleTimeout:       120 * time.Second,
+		},
+		TLS: TLSConfig{MinVersion: "1.2"},
+		RateLimit: RateLimitConfig{
+			ReadPerMinute:  600,
+			ReadBurst:      100,
+			WritePerMinute: 60,
+			WriteBurst:     20,
+		},
+		Items: ItemsConfig{
+			MaxNameLength:     shoppinglist.MaxNameLength,
+			MaxQuantityLength: shoppinglist.MaxQuantityLength,
+			SerialIDs:         true,
+		},
+		Recurring: RecurringConfig{Interval: time.Minute},
+		Shutdown:  ShutdownConfig{Timeout: 10 * time.Second},
+		Log:       LogConfig{Format: "text", Level: "info"},
+		Tracing:   TracingConfig{Exporter: "none"},
+	}
+}
+
+// setting binds one Config field to its environment variable and flag.
+type setting struct {
+	env, flag, usage string
+	ptr              any // *string, *int, *bool or *time.Duration
+	secret           bool
+}
+
+// settings lists every field that can be set from the environment or a flag.
+func (c *Config) settings() []setting {
+	return []setting{
+		{"APP_ENV", "env", "environment name; \"production\" skips loading .env", &c.Env, false},
+		{"APP_PORT", "port", "HTTP listen port", &c.Port, false},
+		{"STORAGE", "storage", "item store: postgres, sqlite or memory", &c.Storage.Backend, false},
+		{"STORAGE_FILE", "storage-file", "JSON snapshot file for the memory store", &c.Storage.File, false},
+		{"DATABASE_URL", "database-url", "postgres:// or sqlite:// URL; overrides the individual DB settings (or DATABASE_URL_FILE)", &c.Database.URL, true},
+		{"DB_HOST", "db-host", "PostgreSQL host", &c.Database.Host, false},
+		{"DB_PORT", "db-port", "PostgreSQL port", &c.Database.Port, false},
+		{"DB_USER", "db-user", "PostgreSQL user", &c.Database.User, false},
+		{"DB_PASSWORD", "db-password", "PostgreSQL password; prefer DB_PASSWORD_FILE, flags are visible in ps", &c.Database.Password, true},
+		{"DB_NAME", "db-name", "PostgreSQL database name", &c.Database.Name, false},
+		{"DB_SSLMODE", "db-sslmode", "PostgreSQL sslmode: disable, allow, prefer, require, verify-ca or verify-full", &c.Database.SSLMode, false},
+		{"DB_SSLROOTCERT", "db-sslrootcert", "CA bundle that verifies the PostgreSQL server (\"system\" for the OS roots)", &c.Database.SSLRootCert, false},
+		{"DB_SSLCERT", "db-sslcert", "client certificate for PostgreSQL", &c.Database.SSLCert, false},
+		{"DB_SSLKEY", "db-sslkey", "client certificate key for PostgreSQL", &c.Database.SSLKey, false},
+		{"DB_SSLPASSWORD", "db-sslpassword", "passphrase of an encrypted DB_SSLKEY (or DB_SSLPASSWORD_FILE)", &c.Database.SSLPassword, true},
+		{"DB_POOL_MAX_CONNS", "db-pool-max-conns", "maximum pool connections", &c.Database.MaxConns, false},
+		{"DB_POOL_MIN_CONNS", "db-pool-min-conns", "connections kept open when idle", &c.Database.MinConns, false},
+		{"DB_POOL_MAX_CONN_LIFETIME", "db-pool-max-conn-lifetime", "recycle connections older than this", &c.Database.MaxConnLifetime, false},
+		{"DB_POOL_MAX_CONN_IDLE_TIME", "db-pool-max-conn-idle-time", "close connections idle longer than this", &c.Database.MaxConnIdleTime, false},
+		{"DB_POOL_HEALTH_CHECK_PERIOD", "db-pool-health-check-period", "how often idle connections are checked", &c.Database.HealthCheckPeriod, false},
+		{"DB_CONNECT_TIMEOUT", "db-connect-timeout", "time limit for one connection attempt", &c.Startup.ConnectTimeout, false},
+		{"DB_CONNECT_BACKOFF", "db-connect-backoff", "first retry delay bound at startup", &c.Startup.ConnectBackoff, false},
+		{"DB_CONNECT_MAX_BACKOFF", "db-connect-max-backoff", "cap on a single retry delay", &c.Startup.ConnectMaxBackoff, false},
+		{"DB_CONNECT_MAX_WAIT", "db-connect-max-wait", "give up connecting after this long", &c.Startup.ConnectMaxWait, false},
+		{"STARTUP_SERVE_LIVEZ", "startup-serve-livez", "answer /livez while the database comes up", &c.Startup.ServeLivez, false},
+		{"HTTP_READ_TIMEOUT", "http-read-timeout", "http.Server ReadTimeout", &c.HTTP.ReadTimeout, false},
+		{"HTTP_READ_HEADER_TIMEOUT", "http-read-header-timeout", "http.Server ReadHeaderTimeout", &c.HTTP.ReadHeaderTimeout, false},
+		{"HTTP_WRITE_TIMEOUT", "http-write-timeout", "http.Server WriteTimeout", &c.HTTP.WriteTimeout, false},
+		{"HTTP_IDLE_TIMEOUT", "http-idle-timeout", "http.Server IdleTimeout", &c.HTTP.IdleTimeout, false},
+		{"TLS_CERT_FILE", "tls-cert-file", "serve HTTPS with this certificate (PEM, reloaded when it changes)", &c.TLS.CertFile, false},
+		{"TLS_KEY_FILE", "tls-key-file", "private key for TLS_CERT_FILE", &c.TLS.KeyFile, false},
+		{"TLS_MIN_VERSION", "tls-min-version", "minimum TLS version: 1.2 or 1.3", &c.TLS.MinVersion, false},
+		{"RATE_LIMIT_READ_PER_MINUTE", "rate-limit-read-per-minute", "GET requests per client per minute; 0 disables", &c.RateLimit.ReadPerMinute, false},
+		{"RATE_LIMIT_READ_BURST", "rate-limit-read-burst", "GET requests a client may make at once", &c.RateLimit.ReadBurst, false},
+		{"RATE_LIMIT_WRITE_PER_MINUTE", "rate-limit-write-per-minute", "POST/PUT/DELETE requests per client per minute; 0 disables", &c.RateLimit.WritePerMinute, false},
+		{"RATE_LIMIT_WRITE_BURST", "rate-limit-write-burst", "POST/PUT/DELETE requests a client may make at once", &c.RateLimit.WriteBurst, false},
+		{"TRUSTED_PROXIES", "trusted-proxies", "comma-separated proxy IPs/CIDRs whose X-Forwarded-For is believed", &c.RateLimit.TrustedProxies, false},
+		{"ITEM_MAX_NAME_LENGTH", "item-max-name-length", "longest item name accepted, in characters", &c.Items.MaxNameLength, false},
+		{"ITEM_MAX_QUANTITY_LENGTH", "item-max-quantity-length", "longest item quantity accepted, in characters", &c.Items.MaxQuantityLength, false},
+		{"ITEM_SERIAL_IDS", "item-serial-ids", "accept serial item IDs in /items/{id} as well as UIDs", &c.Items.SerialIDs, false},
+		{"RECURRING_INTERVAL", "recurring-interval", "how often due recurring items are added to the list; 0 disables", &c.Recurring.Interval, false},
+		{"SHUTDOWN_DELAY", "shutdown-delay", "pre-stop delay with /readyz failing", &c.Shutdown.Delay, false},
+		{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "time allowed for in-flight requests on shutdown", &c.Shutdown.Timeout, false},
+		{"LOG_FORMAT", "log-format", "text or json", &c.Log.Format, false},
+		{"LOG_LEVEL", "log-level", "debug, info, warn or error", &c.Log.Level, false},
+		{"OTEL_TRACES_EXPORTER", "traces-exporter", "otlp, console or none", &c.Tracing.Exporter, false},
+	}
+}
+
+// loadConfig builds the Config from defaults, the config file, lookupEnv and args (without
+// the program name). printConfig reports whether --print-config was given.
+func loadConfig(args []string, lookupEnv func(string) (string, bool)) (cfg Config, printConfig bool, err error) {
+	cfg = defaultConfig()
+
+	fs := flag.NewFlagSet("shopping-list-backend", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configPath := fs.String("config", "", "YAML (.yaml, .yml) or TOML (.toml) config file; also CONFIG_FILE")
+	fs.BoolVar(&printConfig, "print-config", false, "print the effective configuration with secrets redacted, then exit")
+	for _, s := range cfg.settings() {
+		switch p := s.ptr.(type) {
+		case *string:
+			fs.StringVar(p, s.flag, *p, s.usage)
+		case *int:
+			fs.IntVar(p, s.flag, *p, s.usage)
+		case *bool:
+			fs.BoolVar(p, s.flag, *p, s.usage)
+		case *time.Duration:
+			fs.DurationVar(p, s.flag, *p, s.usage)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return cfg, false, fmt.Errorf("usage:\n%s", flagUsage(fs))
+		}
+		return cfg, false, err
+	}
+	if fs.NArg() > 0 {
+		return cfg, false, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	// Flags win, but the file and environment are applied first: remember what was set
+	// on the command line, start again from the defaults and replay the flags at the end.
+	setFlags := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) { setFlags[f.Name] = f.Value.String() })
+	cfg = defaultConfig() // Flag pointers still refer to cfg's fields
+
+	path := *configPath
+	if path == "" {
+		path, _ = lookupEnv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return cfg, false, err
+		}
+	}
+	if err := cfg.applyEnv(lookupEnv); err != nil {
+		return cfg, false, err
+	}
+	for name, value := range setFlags {
+		if err := fs.Set(name, value); err != nil {
+			return cfg, false, fmt.Errorf("invalid value %q for flag --%s: %w", value, name, err)
+		}
+	}
+
+	// A sqlite:// DATABASE_URL selects SQLite without setting STORAGE
+	if strings.HasPrefix(cfg.Database.URL, "sqlite:") {
+		cfg.Storage.Backend = "sqlite"
+	}
+	return cfg, printConfig, cfg.Validate()
+}
+
+// flagUsage renders the flag defaults for --help.
+func flagUsage(fs *flag.FlagSet) string {
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.PrintDefaults()
+	fs.SetOutput(io.Discard)
+	return buf.String()
+}
+
+// loadFile decodes a YAML or TOML file over c. Unknown keys are rejected, so typos surface.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("parsing config file %s: unknown keys %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported extension %q (expected .yaml, .yml or .toml)", path, ext)
+	}
+	return nil
+}
+
+// applyEnv overrides c with every environment variable that is set. A secret can instead be
+// read from the file named by its _FILE variant (DB_PASSWORD_FILE), as Docker and Kubernetes
+// secrets are mounted.
+func (c *Config) applyEnv(lookupEnv func(string) (string, bool)) error {
+	var errs []error
+	for _, s := range c.settings() {
+		value, ok := lookupEnv(s.env)
+		if path, isSet := lookupEnv(s.env + "_FILE"); isSet && s.secret {
+			if ok {
+				errs = append(errs, fmt.Errorf("%s and %s_FILE are both set; use one", s.env, s.env))
+				continue
+			}
+			var err error
+			if value, err = readSecretFile(path); err != nil {
+				errs = append(errs, fmt.Errorf("reading %s_FILE: %w", s.env, err))
+				continue
+			}
+			ok = true
+		}
+		if !ok {
+			continue
+		}
+		var err error
+		switch p := s.ptr.(type) {
+		case *string:
+			*p = value
+		case *int:
+			*p, err = strconv.Atoi(value)
+		case *bool:
+			*p, err = strconv.ParseBool(value)
+		case *time.Duration:
+			*p, err = time.ParseDuration(value)
+		}
+		if err != nil {
+			shown := value
+			if s.secret {
+				shown = "REDACTED"
+			}
+			cause := err
+			if inner := errors.Unwrap(err); inner != nil {
+				cause = inner // strconv errors repeat the value, which may be secret
+			}
+			errs = append(errs, fmt.Errorf("invalid %s %q: %w", s.env, shown, cause))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// readSecretFile reads a secret, dropping the trailing newline most editors and
+// "echo > file" leave behind.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Validate checks the whole configuration and reports every problem at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Port > 0 && c.Port <= 65535, "port %d: must be between 1 and 65535", c.Port)
+	switch c.Storage.Backend {
+	case "postgres":
+		if c.Database.URL != "" {
+			u, err := url.Parse(c.Database.URL)
+			check(err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql"),
+				"database url: expected a postgres://, postgresql:// or sqlite:// URL")
+		} else {
+			check(c.Database.Host != "", "database host: must not be empty")
+			check(c.Database.Port > 0 && c.Database.Port <= 65535, "database port %d: must be between 1 and 65535", c.Database.Port)
+			check(c.Database.User != "", "database user: must not be empty")
+			check(c.Database.Name != "", "database name: must not be empty")
+		}
+		switch c.Database.SSLMode {
+		case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		case "":
+			check(c.Database.URL != "", "database sslmode: must not be empty without a url")
+		default:
+			check(false, "database sslmode %q: expected disable, allow, prefer, require, verify-ca or verify-full", c.Database.SSLMode)
+		}
+		check((c.Database.SSLCert == "") == (c.Database.SSLKey == ""), "database sslcert and sslkey: set both or neither")
+		check(c.Database.MaxConns > 0, "database max_conns %d: must be positive", c.Database.MaxConns)
+		check(c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxConns,
+			"database min_conns %d: must be between 0 and max_conns (%d)", c.Database.MinConns, c.Database.MaxConns)
+	case "sqlite":
+		_, err := shoppinglist.SQLitePathFromURL(c.Database.URL)
+		check(err == nil, "storage sqlite: %v", err)
+	case "memory":
+	default:
+		check(false, "storage %q: expected \"postgres\", \"sqlite\" or \"memory\"", c.Storage.Backend)
+	}
+
+	for name, d := range map[string]time.Duration{
+		"database max_conn_lifetime":   c.Database.MaxConnLifetime,
+		"database max_conn_idle_time":  c.Database.MaxConnIdleTime,
+		"database health_check_period": c.Database.HealthCheckPeriod,
+		"startup connect_timeout":      c.Startup.ConnectTimeout,
+		"startup connect_backoff":      c.Startup.ConnectBackoff,
+		"startup connect_max_backoff":  c.Startup.ConnectMaxBackoff,
+		"shutdown timeout":             c.Shutdown.Timeout,
+	} {
+		check(d > 0, "%s %s: must be positive", name, d)
+	}
+	for name, d := range map[string]time.Duration{
+		"startup connect_max_wait": c.Startup.ConnectMaxWait,
+		"http read_timeout":        c.HTTP.ReadTimeout,
+		"http read_header_timeout": c.HTTP.ReadHeaderTimeout,
+		"http write_timeout":       c.HTTP.WriteTimeout,
+		"http idle_timeout":        c.HTTP.IdleTimeout,
+		"shutdown delay":           c.Shutdown.Delay,
+		"recurring interval":       c.Recurring.Interval,
+	} {
+		check(d >= 0, "%s %s: must not be negative", name, d)
+	}
+	check(c.Startup.ConnectMaxBackoff >= c.Startup.ConnectBackoff,
+		"startup connect_max_backoff %s: must not be below connect_backoff (%s)", c.Startup.ConnectMaxBackoff, c.Startup.ConnectBackoff)
+
+	rl := c.RateLimit
+	check(rl.ReadPerMinute >= 0 && rl.WritePerMinute >= 0, "rate_limit per_minute: must not be negative")
+	check(rl.ReadPerMinute == 0 || rl.ReadBurst > 0, "rate_limit read_burst %d: must be positive when reads are limited", rl.ReadBurst)
+	check(rl.WritePerMinute == 0 || rl.WriteBurst > 0, "rate_limit write_burst %d: must be positive when writes are limited", rl.WriteBurst)
+	if _, err := parseTrustedProxies(rl.TrustedProxies); err != nil {
+		errs = append(errs, err)
+	}
+	check(c.Items.MaxNameLength >= 1 && c.Items.MaxNameLength <= shoppinglist.MaxNameLength,
+		"items max_name_length %d: must be between 1 and %d", c.Items.MaxNameLength, shoppinglist.MaxNameLength)
+	check(c.Items.MaxQuantityLength >= 1 && c.Items.MaxQuantityLength <= shoppinglist.MaxQuantityLength,
+		"items max_quantity_length %d: must be between 1 and %d", c.Items.MaxQuantityLength, shoppinglist.MaxQuantityLength)
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "tls cert_file and key_file: set both or neither")
+	if _, err := tlsVersion(c.TLS.MinVersion); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := newLogger(io.Discard, c.Log.Format, c.Log.Level); err != nil {
+		errs = append(errs, err)
+	}
+	switch strings.ToLower(c.Tracing.Exporter) {
+	case "", "none", "otlp", "console", "stdout":
+	default:
+		check(false, "tracing exporter %q: expected \"otlp\", \"console\" or \"none\"", c.Tracing.Exporter)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Redacted returns a copy of c that is safe to print or log.
+func (c Config) Redacted() Config {
+	if c.Database.Password != "" {
+		c.Database.Password = "REDACTED"
+	}
+	if c.Database.SSLPassword != "" {
+		c.Database.SSLPassword = "REDACTED"
+	}
+	// Validate only accepts URLs, so the password is in the userinfo or the query
+	u, err := url.Parse(c.Database.URL)
+	if err != nil {
+		c.Database.URL = "REDACTED" // Cannot tell where the password is
+		return c
+	}
+	redacted := false
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "REDACTED")
+		redacted = true
+	}
+	q := u.Query()
+	for _, key := range []string{"password", "sslpassword"} {
+		if q.Has(key) {
+			q.Set(key, "REDACTED")
+			redacted = true
+		}
+	}
+	if redacted {
+		u.RawQuery = q.Encode()
+		c.Database.URL = u.String()
+	}
+	return c
+}
+
+// writeYAML prints c as a YAML config file.
+func (c Config) writeYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// RateLimitOptions converts the rate limit settings for shoppinglist.Options.
+func (c *Config) RateLimitOptions() shoppinglist.RateLimit {
+	rl := c.RateLimit
+	proxies, _ := parseTrustedProxies(rl.TrustedProxies) // Checked by Validate
+	limit := func(perMinute, burst int) shoppinglist.Limit {
+		if perMinute == 0 {
+			return shoppinglist.Limit{}
+		}
+		return shoppinglist.Limit{Rate: float64(perMinute) / 60, Burst: burst}
+	}
+	return shoppinglist.RateLimit{
+		Read:           limit(rl.ReadPerMinute, rl.ReadBurst),
+		Write:          limit(rl.WritePerMinute, rl.WriteBurst),
+		TrustedProxies: proxies,
+	}
+}
+
+// parseTrustedProxies parses a comma-separated list of IPs and CIDRs.
+func parseTrustedProxies(list string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: expected an IP or CIDR", entry)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: expected an IP or CIDR", entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// DBConfig converts the database settings for shoppinglist.ConnectDB.
+func (c *Config) DBConfig() shoppinglist.DBConfig {
+	d := c.Database
+	return shoppinglist.DBConfig{
+		URL:               d.URL,
+		Host:              d.Host,
+		Port:              d.Port,
+		User:              d.User,
+		Password:          d.Password,
+		DBName:            d.Name,
+		SSLMode:           d.SSLMode,
+		SSLRootCert:       d.SSLRootCert,
+		SSLCert:           d.SSLCert,
+		SSLKey:            d.SSLKey,
+		SSLPassword:       d.SSLPassword,
+		MaxConns:          int32(d.MaxConns),
+		MinConns:          int32(d.MinConns),
+		MaxConnLifetime:   d.MaxConnLifetime,
+		MaxConnIdleTime:   d.MaxConnIdleTime,
+		HealthCheckPeriod: d.HealthCheckPeriod,
+	}
+}
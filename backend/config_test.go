@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// --- Configuration Tests ---
+
+// envMap is a lookupEnv over a fixed set of variables.
+func envMap(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+// writeFile writes a config file into a temporary directory and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, printConfig, err := loadConfig(nil, envMap(nil))
+		if err != nil {
+			t.Fatalf("loadConfig failed: %v", err)
+		}
+		if printConfig {
+			t.Error("Expected printConfig to be false by default")
+		}
+		if cfg != defaultConfig() {
+			t.Errorf("Expected the defaults, got %+v", cfg)
+		}
+	})
+
+	t.Run("Precedence", func(t *testing.T) {
+		// The file sets the port, the environment overrides it, and a flag overrides both
+		path := writeFile(t, "config.yaml", `
+port: 9000
+database:
+  host: file-host
+  max_conns: 20
+http:
+  write_timeout: 30s
+`)
+		env := envMap(map[string]string{"CONFIG_FILE": path, "APP_PORT": "9001", "DB_HOST": "env-host"})
+		cfg, _, err := loadConfig([]string{"--port", "9002"}, env)
+		if err != nil {
+			t.Fatalf("loadConfig failed: %v", err)
+		}
+		if cfg.Port != 9002 {
+			t.Errorf("Expected the flag to win with port 9002, got %d", cfg.Port)
+		}
+		if cfg.Database.Host != "env-host" {
+			t.Errorf("Expected the environment to override the file, got host %q", cfg.Database.Host)
+		}
+		if cfg.Database.MaxConns != 20 || cfg.HTTP.WriteTimeout != 30*time.Second {
+			t.Errorf("Expected file values to apply, got max_conns %d, write_timeout %v", cfg.Database.MaxConns, cfg.HTTP.WriteTimeout)
+		}
+		if cfg.Database.User != "user" {
+			t.Errorf("Expected unset fields to keep their defaults, got user %q", cfg.Database.User)
+		}
+	})
+
+	t.Run("TOMLFileFlag", func(t *testing.T) {
+		path := writeFile(t, "config.toml", `
+[storage]
+backend = "memory"
+
+[shutdown]
+delay = "5s"
+`)
+		cfg, _, err := loadConfig([]string{"--config=" + path}, envMap(nil))
+		if err != nil {
+			t.Fatalf("loadConfig failed: %v", err)
+		}
+		if cfg.Storage.Backend != "memory" || cfg.Shutdown.Delay != 5*time.Second {
+			t.Errorf("Expected TOML values to apply, got %+v", cfg)
+		}
+	})
+
+	t.Run("SQLiteURLSelectsSQLite", func(t *testing.T) {
+		cfg, _, err := loadConfig(nil, envMap(map[string]string{"DATABASE_URL": "sqlite://items.db"}))
+		if err != nil {
+			t.Fatalf("loadConfig failed: %v", err)
+		}
+		if cfg.Storage.Backend != "sqlite" {
+			t.Errorf("Expected storage sqlite, got %q", cfg.Storage.Backend)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			args    []string
+			env     map[string]string
+			file    string // Written to config<ext> and passed with --config
+			ext     string
+			wantErr string
+		}{
+			{name: "InvalidEnvInt", env: map[string]string{"DB_PORT": "five"}, wantErr: `invalid DB_PORT "five": invalid syntax`},
+			{name: "InvalidEnvDuration", env: map[string]string{"SHUTDOWN_TIMEOUT": "soon"}, wantErr: `invalid SHUTDOWN_TIMEOUT "soon": time: invalid duration "soon"`},
+			{name: "UnknownFlag", args: []string{"--nope"}, wantErr: "nope"},
+			{name: "UnknownStorage", env: map[string]string{"STORAGE": "redis"}, wantErr: `storage "redis"`},
+			{name: "PoolMinAboveMax", args: []string{"--db-pool-max-conns=2", "--db-pool-min-conns=3"}, wantErr: "min_conns 3"},
+			{name: "PortOutOfRange", args: []string{"--port=70000"}, wantErr: "port 70000"},
+			{name: "NegativeTimeout", args: []string{"--http-idle-timeout=-1s"}, wantErr: "http idle_timeout"},
+			{name: "BadURLScheme", env: map[string]string{"DATABASE_URL": "mysql://db/shopping"}, wantErr: "database url"},
+			{name: "BadSSLMode", env: map[string]string{"DB_SSLMODE": "verify"}, wantErr: `sslmode "verify"`},
+			{name: "ClientCertWithoutKey", env: map[string]string{"DB_SSLCERT": "client.pem"}, wantErr: "sslcert and sslkey"},
+			{name: "TLSCertWithoutKey", args: []string{"--tls-cert-file=cert.pem"}, wantErr: "cert_file and key_file"},
+			{name: "BadTLSVersion", env: map[string]string{"TLS_MIN_VERSION": "1.0"}, wantErr: "TLS_MIN_VERSION"},
+			{name: "BadTrustedProxy", env: map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8, nginx"}, wantErr: `trusted proxy "nginx"`},
+			{name: "RateWithoutBurst", args: []string{"--rate-limit-write-burst=0"}, wantErr: "write_burst 0"},
+			{name: "NameLimitAboveSchema", env: map[string]string{"ITEM_MAX_NAME_LENGTH": "500"}, wantErr: "max_name_length 500"},
+			{name: "NegativeRecurringInterval", env: map[string]string{"RECURRING_INTERVAL": "-1m"}, wantErr: "recurring interval"},
+			{name: "BadLogLevel", env: map[string]string{"LOG_LEVEL": "loud"}, wantErr: "LOG_LEVEL"},
+			{name: "UnknownYAMLKey", file: "database:\n  hots: db\n", ext: ".yaml", wantErr: "hots"},
+			{name: "UnknownTOMLKey", file: "[database]\nhots = \"db\"\n", ext: ".toml", wantErr: "hots"},
+			{name: "UnsupportedExtension", file: "{}", ext: ".json", wantErr: "unsupported extension"},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				args := tc.args
+				if tc.file != "" {
+					args = append(args, "--config", writeFile(t, "config"+tc.ext, tc.file))
+				}
+				_, _, err := loadConfig(args, envMap(tc.env))
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("Expected an error containing %q, got %v", tc.wantErr, err)
+				}
+			})
+		}
+	})
+
+	t.Run("ReportsAllProblems", func(t *testing.T) {
+		_, _, err := loadConfig([]string{"--port=0", "--db-pool-max-conns=0"}, envMap(nil))
+		if err == nil || !strings.Contains(err.Error(), "port 0") || !strings.Contains(err.Error(), "max_conns 0") {
+			t.Errorf("Expected both problems to be reported, got %v", err)
+		}
+	})
+
+	t.Run("SecretFiles", func(t *testing.T) {
+		password := writeFile(t, "db_password", "s3cret pass\n")
+		url := writeFile(t, "database_url", "postgres://app:s3cret@db/shop")
+		cfg, _, err := loadConfig(nil, envMap(map[string]string{"DB_PASSWORD_FILE": password, "DATABASE_URL_FILE": url}))
+		if err != nil {
+			t.Fatalf("loadConfig failed: %v", err)
+		}
+		if cfg.Database.Password != "s3cret pass" {
+			t.Errorf("Expected the password from the file without its newline, got %q", cfg.Database.Password)
+		}
+		if cfg.Database.URL != "postgres://app:s3cret@db/shop" {
+			t.Errorf("Expected the URL from the file, got %q", cfg.Database.URL)
+		}
+
+		_, _, err = loadConfig(nil, envMap(map[string]string{"DB_PASSWORD": "x", "DB_PASSWORD_FILE": password}))
+		if err == nil || !strings.Contains(err.Error(), "both set") {
+			t.Errorf("Expected an error when both DB_PASSWORD and DB_PASSWORD_FILE are set, got %v", err)
+		}
+		_, _, err = loadConfig(nil, envMap(map[string]string{"DB_PASSWORD_FILE": filepath.Join(t.TempDir(), "missing")}))
+		if err == nil || !strings.Contains(err.Error(), "DB_PASSWORD_FILE") {
+			t.Errorf("Expected an error for a missing secret file, got %v", err)
+		}
+		// Only secrets have a _FILE variant
+		cfg, _, err = loadConfig(nil, envMap(map[string]string{"DB_HOST_FILE": password}))
+		if err != nil || cfg.Database.Host != "db" {
+			t.Errorf("Expected DB_HOST_FILE to be ignored, got host %q, err %v", cfg.Database.Host, err)
+		}
+	})
+
+	t.Run("SecretNotEchoed", func(t *testing.T) {
+		_, _, err := loadConfig(nil, envMap(map[string]string{"DB_PASSWORD": "hunter2", "DATABASE_URL": "postgres://u:hunter2@db:5432/x?pool_max_conns=abc"}))
+		if err != nil && strings.Contains(err.Error(), "hunter2") {
+			t.Errorf("Expected secrets to stay out of errors, got %v", err)
+		}
+	})
+}
+
+func TestRateLimitOptions(t *testing.T) {
+	cfg, _, err := loadConfig([]string{"--rate-limit-read-per-minute=0", "--rate-limit-write-per-minute=120"},
+		envMap(map[string]string{"TRUSTED_PROXIES": "172.16.0.0/12, 10.1.2.3"}))
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	rl := cfg.RateLimitOptions()
+	if rl.Read.Rate != 0 {
+		t.Errorf("Expected reads to be unlimited, got %+v", rl.Read)
+	}
+	if rl.Write.Rate != 2 || rl.Write.Burst != 20 {
+		t.Errorf("Expected 2 writes/s with a burst of 20, got %+v", rl.Write)
+	}
+	if len(rl.TrustedProxies) != 2 || rl.TrustedProxies[1].String() != "10.1.2.3/32" {
+		t.Errorf("Expected two trusted prefixes, got %v", rl.TrustedProxies)
+	}
+}
+
+func TestPrintConfigRedactsSecrets(t *testing.T) {
+	env := envMap(map[string]string{
+		"DB_PASSWORD":  "hunter2",
+		"DATABASE_URL": "postgres://shopper:hunter2@db:5432/shopping",
+	})
+	cfg, printConfig, err := loadConfig([]string{"--print-config"}, env)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if !printConfig {
+		t.Fatal("Expected --print-config to be reported")
+	}
+
+	var buf bytes.Buffer
+	if err := cfg.Redacted().writeYAML(&buf); err != nil {
+		t.Fatalf("writeYAML failed: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("Expected the password to be redacted, got:\n%s", out)
+	}
+	if !strings.Contains(out, "password: REDACTED") || !strings.Contains(out, "shopper:REDACTED@db:5432") {
+		t.Errorf("Expected redaction markers for the password and URL, got:\n%s", out)
+	}
+	if cfg.Database.Password != "hunter2" {
+		t.Error("Expected Redacted to leave the original config untouched")
+	}
+
+	// The printed YAML is a valid config file that loads back to the same settings
+	path := writeFile(t, "printed.yaml", out)
+	reloaded, _, err := loadConfig([]string{"--config", path}, envMap(nil))
+	if err != nil {
+		t.Fatalf("Reloading the printed config failed: %v", err)
+	}
+	if reloaded.HTTP != cfg.HTTP || reloaded.Database.MaxConnLifetime != cfg.Database.MaxConnLifetime {
+		t.Errorf("Expected the printed config to round-trip, got %+v", reloaded)
+	}
+}
+
+func TestRedactedDatabaseURL(t *testing.T) {
+	tests := []struct{ url, want string }{
+		{"postgres://shopper:hunter2@db/shopping", "postgres://shopper:REDACTED@db/shopping"},
+		{"postgres://shopper@db/shopping?password=hunter2&sslmode=require", "postgres://shopper@db/shopping?password=REDACTED&sslmode=require"},
+		{"postgres://db/shopping?sslpassword=hunter2", "postgres://db/shopping?sslpassword=REDACTED"},
+		{"postgres://shopper@db/shopping", "postgres://shopper@db/shopping"},
+		{"postgres://db/shopping?sslmode=require&application_name=list", "postgres://db/shopping?sslmode=require&application_name=list"},
+		{"postgres://shopper:hunter2@db:bad port/shopping", "REDACTED"},
+	}
+	for _, tc := range tests {
+		var cfg Config
+		cfg.Database.URL = tc.url
+		if got := cfg.Redacted().Database.URL; got != tc.want {
+			t.Errorf("Redacted(%q): expected %q, got %q", tc.url, tc.want, got)
+		}
+	}
+}
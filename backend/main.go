@@ -2,411 +2,280 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"errors" // Keep for sql.ErrNoRows check if needed, though pgx might have its own ErrNoRows
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/jackc/pgx/v5"        // Needed for DBPool interface method signatures
-	"github.com/jackc/pgx/v5/pgconn" // Needed for DBPool interface method signatures
-	"github.com/jackc/pgx/v5/pgxpool"
+	"backend/shoppinglist"
+
 	"github.com/joho/godotenv" // Optional: For local .env loading
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
-// --- Configuration ---
-
-// DBConfig holds database connection parameters
-type DBConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
-}
-
-// Item represents a shopping list item
-type Item struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Quantity  string    `json:"quantity"`
-	CreatedAt time.Time `json:"created_at,omitempty"` // omitempty for POST
-}
-
-// --- Interface for DB Operations ---
-
-// DBPool defines the interface for database operations we need,
-// allowing both real pgxpool.Pool and mocks to be used.
-type DBPool interface {
-	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
-	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
-	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
-	Ping(ctx context.Context) error
-	Close() // Required for graceful shutdown and test cleanup
-}
-
-// --- Global Variables ---
-// Use the interface type for the global variable
-var dbpool DBPool
-
-// --- Database Functions ---
-
-// connectDB initializes the database connection pool
-// It still returns the concrete type *pgxpool.Pool, which implements DBPool
-func connectDB(cfg DBConfig) (*pgxpool.Pool, error) {
-	connString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s pool_max_conns=10",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode)
-
-	config, err := pgxpool.ParseConfig(connString)
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse connection string config: %w", err)
-	}
+// serviceName is the default OpenTelemetry service.name; OTEL_SERVICE_NAME overrides it.
+const serviceName = "shopping-list-backend"
 
-	// Recommended settings for robustness
-	config.MaxConnIdleTime = 5 * time.Minute
-	config.MaxConnLifetime = 1 * time.Hour
-	config.HealthCheckPeriod = 1 * time.Minute
-
-	pool, err := pgxpool.NewWithConfig(context.Background(), config)
-	if err != nil {
-		return nil, fmt.Errorf("unable to create connection pool: %w", err)
-	}
-
-	// Test the connection
-	err = pool.Ping(context.Background())
-	if err != nil {
-		pool.Close() // Close pool if ping fails
-		return nil, fmt.Errorf("unable to ping database: %w", err)
-	}
-
-	log.Println("Successfully connected to PostgreSQL database!")
-	return pool, nil
-}
+// --- Main Function ---
 
-// createSchemaIfNotExists checks for the items table and creates it if it doesn't exist
-// Accepts the DBPool interface type
-func createSchemaIfNotExists(pool DBPool) error {
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS items (
-		id SERIAL PRIMARY KEY,
-		name TEXT NOT NULL CHECK (name <> ''),
-		quantity TEXT NOT NULL CHECK (quantity <> ''),
-		created_at TIMESTAMPTZ DEFAULT NOW()
-	);`
+// main wires configuration from file, environment and flags into the shoppinglist package and serves it.
+func main() {
+	// Deferred first so it runs last, after storage is closed and traces are flushed
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
 
-	_, err := pool.Exec(context.Background(), createTableSQL)
-	if err != nil {
-		return fmt.Errorf("error creating table schema: %w", err)
+	// Optional: Load .env file for local development
+	var envErr error
+	if os.Getenv("APP_ENV") != "production" {
+		envErr = godotenv.Load()
 	}
-	log.Println("Ensured 'items' table exists.")
-	return nil
-}
 
-// getItems retrieves all items from the database
-// Uses the global dbpool (which is of type DBPool)
-func getItems(ctx context.Context) ([]Item, error) {
-	rows, err := dbpool.Query(ctx, "SELECT id, name, quantity, created_at FROM items ORDER BY created_at DESC")
+	// Configuration: defaults, then the --config/CONFIG_FILE file, then the environment,
+	// then flags. --print-config shows the result with secrets redacted.
+	cfg, printConfig, err := loadConfig(os.Args[1:], os.LookupEnv)
 	if err != nil {
-		// Check specifically for pgx's no rows error if necessary, otherwise treat as general DB error
-		if errors.Is(err, pgx.ErrNoRows) {
-			return []Item{}, nil // Return empty slice for no rows, not an error
-		}
-		log.Printf("Error querying items: %v\n", err)
-		return nil, fmt.Errorf("database query error: %w", err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
-	defer rows.Close()
-
-	items := []Item{}
-	// Use pgx's CollectRows or Next/Scan loop
-	for rows.Next() {
-		var item Item
-		if err := rows.Scan(&item.ID, &item.Name, &item.Quantity, &item.CreatedAt); err != nil {
-			log.Printf("Error scanning item row: %v\n", err)
-			// Continue processing other rows if one fails to scan
-			continue
+	if printConfig {
+		if err := cfg.Redacted().writeYAML(os.Stdout); err != nil {
+			fatal("Could not print the configuration", "err", err)
 		}
-		items = append(items, item)
-	}
-
-	// Check for errors from iterating over rows.
-	if err := rows.Err(); err != nil {
-		log.Printf("Error after iterating rows: %v\n", err)
-		// It's often better to return the items successfully scanned along with the iteration error
-		// But for simplicity here, we return an error indicating partial results might be lost.
-		return nil, fmt.Errorf("database iteration error: %w", err)
-	}
-
-	return items, nil
-}
-
-// addItem inserts a new item into the database
-// Uses parameterized queries to prevent SQL injection.
-// Uses the global dbpool (DBPool interface)
-func addItem(ctx context.Context, newItem Item) (Item, error) {
-	// Basic validation (could be more extensive)
-	if strings.TrimSpace(newItem.Name) == "" || strings.TrimSpace(newItem.Quantity) == "" {
-		return Item{}, fmt.Errorf("item name and quantity cannot be empty")
+		return
 	}
 
-	var insertedID int
-	var createdAt time.Time
-	// Use QueryRow method from the DBPool interface
-	err := dbpool.QueryRow(ctx,
-		"INSERT INTO items (name, quantity) VALUES ($1, $2) RETURNING id, created_at",
-		newItem.Name, newItem.Quantity, // Parameters are handled safely by pgx
-	).Scan(&insertedID, &createdAt)
-
+	// Structured logging: LOG_FORMAT=json|text, LOG_LEVEL=debug|info|warn|error.
+	// SetDefault also routes any remaining standard library log output through slog.
+	logger, err := newLogger(os.Stderr, cfg.Log.Format, cfg.Log.Level)
 	if err != nil {
-		log.Printf("Error inserting item: %v\n", err)
-		return Item{}, fmt.Errorf("database insert error: %w", err)
+		slog.Error("Invalid logging configuration", "err", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+	if envErr != nil {
+		slog.Info("No .env file found or error loading, proceeding with environment variables")
 	}
 
-	newItem.ID = insertedID
-	newItem.CreatedAt = createdAt
-	log.Printf("Added item: ID=%d, Name=%s, Quantity=%s\n", newItem.ID, newItem.Name, newItem.Quantity)
-	return newItem, nil
-}
-
-// deleteItem removes an item from the database by ID
-// Uses parameterized queries.
-// Uses the global dbpool (DBPool interface)
-func deleteItem(ctx context.Context, id int) error {
-	// Use Exec method from the DBPool interface
-	cmdTag, err := dbpool.Exec(ctx, "DELETE FROM items WHERE id = $1", id)
+	// Tracing: OTEL_TRACES_EXPORTER=otlp|console|none (default). The OTLP exporter reads
+	// the standard OTEL_EXPORTER_OTLP_* variables, e.g. OTEL_EXPORTER_OTLP_ENDPOINT.
+	// Installed globally before connecting so pgx query spans use it too.
+	tp, err := newTracerProvider(context.Background(), cfg.Tracing.Exporter, os.Stdout)
 	if err != nil {
-		log.Printf("Error deleting item with ID %d: %v\n", id, err)
-		return fmt.Errorf("database delete error: %w", err)
+		fatal("Invalid tracing configuration", "err", err)
 	}
-	if cmdTag.RowsAffected() == 0 {
-		log.Printf("Attempted to delete non-existent item with ID %d\n", id)
-		// Return a distinct error for not found if needed by caller
-		return fmt.Errorf("item with ID %d not found", id)
+	if tp != nil {
+		otel.SetTracerProvider(tp)
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+			slog.Warn("OpenTelemetry error", "err", err)
+		}))
+		// Flush buffered spans on exit; runs after the server and storage are closed
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tp.Shutdown(ctx); err != nil {
+				slog.Error("Error flushing traces", "err", err)
+			}
+		}()
 	}
-	log.Printf("Deleted item with ID %d\n", id)
-	return nil
-}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var opts shoppinglist.Options
+	var dbConfig *shoppinglist.DBConfig // Set for PostgreSQL, which is connected with retries
+	switch cfg.Storage.Backend {
+	case "memory":
+		// In-memory mode lets the backend run without the Postgres container.
+		// Set STORAGE_FILE to keep the list across restarts via a JSON snapshot.
+		mem, err := shoppinglist.LoadMemoryStore(cfg.Storage.File)
+		if err != nil {
+			fatal("Could not load in-memory store", "err", err)
+		}
+		slog.Warn("Using in-memory storage; data is not shared between instances")
+		opts.Store = mem
 
-// --- HTTP Handlers ---
-// Handlers remain the same, they internally call the DB functions which now use the interface
+	case "sqlite":
+		// Embedded single-file database for single-node deployments (NAS, Raspberry Pi)
+		path, err := shoppinglist.SQLitePathFromURL(cfg.Database.URL)
+		if err != nil {
+			fatal("Invalid DATABASE_URL", "err", err)
+		}
+		lite, err := shoppinglist.OpenSQLite(context.Background(), path)
+		if err != nil {
+			fatal("Could not open the SQLite database", "err", err)
+		}
+		opts.Store = lite
 
-func itemsHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		getItemsHandler(w, r)
-	case http.MethodPost:
-		addItemHandler(w, r)
-	default:
-		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	case "postgres":
+		// The connection is made below, once the server is listening
+		db := cfg.DBConfig()
+		dbConfig = &db
 	}
-}
-
-func itemDetailHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract ID from URL path like /api/items/123
-	// Ensure path ends with the ID and not just /items/
-	pathParts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
-	if len(pathParts) < 3 || pathParts[len(pathParts)-1] == "" || pathParts[len(pathParts)-2] != "items" {
-		http.Error(w, "Bad Request: Invalid URL format or missing item ID", http.StatusBadRequest)
-		return
+	opts.Logger = logger
+	opts.Propagator = otel.GetTextMapPropagator()
+	// Per-client token buckets; behind nginx, TRUSTED_PROXIES makes X-Forwarded-For the client
+	opts.RateLimit = cfg.RateLimitOptions()
+	opts.FieldLimits = shoppinglist.FieldLimits{MaxName: cfg.Items.MaxNameLength, MaxQuantity: cfg.Items.MaxQuantityLength}
+	// ITEM_SERIAL_IDS=false ends the transition to UIDs once no client uses serial IDs
+	opts.RejectSerialIDs = !cfg.Items.SerialIDs
+
+	// Startup retries: connecting and migrating are retried with exponential backoff and jitter
+	// (DB_CONNECT_BACKOFF doubling up to DB_CONNECT_MAX_BACKOFF) for at most DB_CONNECT_MAX_WAIT,
+	// so the backend survives starting before its database.
+	connectBackoff := backoff{
+		Initial:        cfg.Startup.ConnectBackoff,
+		Max:            cfg.Startup.ConnectMaxBackoff,
+		MaxWait:        cfg.Startup.ConnectMaxWait,
+		AttemptTimeout: cfg.Startup.ConnectTimeout,
 	}
-	idStr := pathParts[len(pathParts)-1]
+	// STARTUP_SERVE_LIVEZ=true listens while the database comes up, so liveness probes pass
+	serveEarly := cfg.Startup.ServeLivez
+	// Graceful shutdown: SHUTDOWN_DELAY is the pre-stop delay during which /readyz fails but
+	// requests are still served; SHUTDOWN_TIMEOUT bounds how long in-flight requests may run.
+	shutdown := shutdownConfig{PreStopDelay: cfg.Shutdown.Delay, Timeout: cfg.Shutdown.Timeout}
 
-	id, err := strconv.Atoi(idStr)
-	if err != nil || id <= 0 {
-		http.Error(w, "Bad Request: Invalid item ID format", http.StatusBadRequest)
-		return
-	}
+	// Start HTTP Server
+	serverAddr := ":" + strconv.Itoa(cfg.Port)
 
-	// Now handle the method
-	switch r.Method {
-	case http.MethodDelete:
-		deleteItemHandler(w, r, id) // Pass the parsed ID
-	default:
-		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	// The gate answers until the app is ready, then forwards every request to it
+	gate := &startupGate{}
+	server := &http.Server{
+		Addr:              serverAddr,
+		Handler:           gate,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
 	}
-}
-
-func getItemsHandler(w http.ResponseWriter, r *http.Request) {
-	items, err := getItems(r.Context())
-	if err != nil {
-		log.Printf("Error in getItemsHandler: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+	// HTTPS: TLS_CERT_FILE and TLS_KEY_FILE, reloaded when they change; TLS_MIN_VERSION=1.2|1.3
+	if server.TLSConfig, err = newTLSConfig(cfg.TLS); err != nil {
+		fatal("Invalid TLS configuration", "err", err)
 	}
 
-	// Handle case where items might be nil if getItems returns nil on error
-	if items == nil {
-		items = []Item{} // Return empty array instead of null JSON
+	ln, err := net.Listen("tcp", serverAddr)
+	if err != nil {
+		fatal("Could not listen", "addr", serverAddr, "err", err)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(items); err != nil {
-		log.Printf("Error encoding items to JSON: %v", err)
-		// Avoid writing header again if already written by Encode
+	// SIGINT/SIGTERM start a graceful shutdown; a second signal kills the process immediately
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCtx.Done()
+		stop()
+	}()
+	ctx, cancel := context.WithCancel(sigCtx)
+	defer cancel()
+
+	// serve closes the store/pool (saving the in-memory snapshot if configured) after draining
+	served := make(chan error, 1)
+	startServing := func() {
+		slog.Info("Starting server", "addr", serverAddr, "tls", server.TLSConfig != nil)
+		go func() { served <- serve(ctx, server, ln, gate, shutdown) }()
 	}
-}
-
-func addItemHandler(w http.ResponseWriter, r *http.Request) {
-	var newItem Item
-	// Decode JSON request body
-	// Use http.MaxBytesReader to prevent large request bodies (DoS protection)
-	r.Body = http.MaxBytesReader(w, r.Body, 1024*1024) // 1MB limit
-	dec := json.NewDecoder(r.Body)
-	dec.DisallowUnknownFields() // Prevent extra fields in JSON
-
-	if err := dec.Decode(&newItem); err != nil {
-		var syntaxError *json.SyntaxError
-		var unmarshalTypeError *json.UnmarshalTypeError
-		var maxBytesError *http.MaxBytesError // Check for body too large
-
-		switch {
-		case errors.As(err, &syntaxError):
-			msg := fmt.Sprintf("Request body contains badly-formed JSON (at character %d)", syntaxError.Offset)
-			http.Error(w, msg, http.StatusBadRequest)
-		case errors.Is(err, io.ErrUnexpectedEOF):
-			http.Error(w, "Request body contains badly-formed JSON", http.StatusBadRequest)
-		case errors.As(err, &unmarshalTypeError):
-			msg := fmt.Sprintf("Request body contains an invalid value for the %q field (at character %d)", unmarshalTypeError.Field, unmarshalTypeError.Offset)
-			http.Error(w, msg, http.StatusBadRequest)
-		case strings.HasPrefix(err.Error(), "json: unknown field "):
-			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
-			msg := fmt.Sprintf("Request body contains unknown field %s", fieldName)
-			http.Error(w, msg, http.StatusBadRequest)
-		case errors.Is(err, io.EOF): // Empty body
-			http.Error(w, "Request body must not be empty", http.StatusBadRequest)
-		case errors.As(err, &maxBytesError):
-			http.Error(w, "Request body must not be larger than 1MB", http.StatusRequestEntityTooLarge)
-		default: // Catch-all for other decoding errors
-			log.Printf("Error decoding JSON body: %v", err)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError) // Keep internal errors internal
-		}
-		return
+	if serveEarly {
+		startServing()
 	}
 
-	// Input validation is handled within addItem
-	addedItem, err := addItem(r.Context(), newItem)
+	srv, err := startApp(ctx, opts, dbConfig, connectBackoff)
 	if err != nil {
-		log.Printf("Error adding item: %v", err)
-		if strings.Contains(err.Error(), "cannot be empty") {
-			http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
+		if ctx.Err() != nil {
+			slog.Info("Shutdown requested during startup")
 		} else {
-			// Other DB errors are internal
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			slog.Error("Could not initialise the shopping list server", "err", err)
+			exitCode = 1
+		}
+		if !serveEarly {
+			ln.Close()
+			return
+		}
+		cancel() // Stops the early server
+	} else {
+		gate.ready(srv)
+		slog.Info("Server ready")
+		// Stops when shutdown begins; closing the app waits for a run in progress
+		if cfg.Recurring.Interval > 0 {
+			go srv.RunScheduler(ctx, cfg.Recurring.Interval)
+		}
+		if !serveEarly {
+			startServing()
 		}
-		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated) // 201 Created
-	if err := json.NewEncoder(w).Encode(addedItem); err != nil {
-		log.Printf("Error encoding added item to JSON: %v", err)
+	if err := <-served; err != nil {
+		slog.Error("Server did not shut down cleanly", "err", err)
+		exitCode = 1
 	}
 }
 
-// deleteItemHandler now receives the parsed ID
-func deleteItemHandler(w http.ResponseWriter, r *http.Request, id int) {
-	err := deleteItem(r.Context(), id)
-	if err != nil {
-		log.Printf("Error deleting item %d: %v", id, err)
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, "Not Found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+// newLogger builds the process logger from LOG_FORMAT ("text" default, or "json")
+// and LOG_LEVEL ("info" default, or "debug", "warn", "error").
+func newLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid LOG_LEVEL %q: %w", level, err)
 		}
-		return
 	}
-
-	w.WriteHeader(http.StatusNoContent) // 204 No Content is typical for successful DELETE
-}
-
-// --- Main Function ---
-
-func main() {
-	// Optional: Load .env file for local development
-	if os.Getenv("APP_ENV") != "production" {
-		err := godotenv.Load()
-		if err != nil {
-			log.Println("No .env file found or error loading, proceeding with environment variables")
-		}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid LOG_FORMAT %q (expected \"text\" or \"json\")", format)
 	}
+}
 
-	// Database Configuration from Environment Variables
-	dbPort, _ := strconv.Atoi(getenv("DB_PORT", "5432"))
-	dbConfig := DBConfig{
-		Host:     getenv("DB_HOST", "db"),
-		Port:     dbPort,
-		User:     getenv("DB_USER", "user"),
-		Password: getenv("DB_PASSWORD", "password"),
-		DBName:   getenv("DB_NAME", "shoppingdb"),
-		SSLMode:  getenv("DB_SSLMODE", "disable"),
+// newTracerProvider builds the tracer provider for OTEL_TRACES_EXPORTER: "otlp" (OTLP over
+// HTTP), "console" (pretty-printed JSON spans on w) or "none"/"" (tracing disabled, returns nil).
+func newTracerProvider(ctx context.Context, exporter string, w io.Writer) (*sdktrace.TracerProvider, error) {
+	var exp sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(exporter) {
+	case "", "none":
+		return nil, nil
+	case "otlp":
+		exp, err = otlptracehttp.New(ctx)
+	case "console", "stdout":
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("invalid OTEL_TRACES_EXPORTER %q (expected \"otlp\", \"console\" or \"none\")", exporter)
 	}
-
-	// Connect to Database and setup pooling
-	// pool is the concrete *pgxpool.Pool type
-	pool, err := connectDB(dbConfig)
 	if err != nil {
-		log.Fatalf("Could not connect to the database: %v", err)
-	}
-	// Assign the concrete pool to the global DBPool interface variable.
-	// This works because *pgxpool.Pool implements the DBPool interface.
-	dbpool = pool
-	// VERY IMPORTANT: Defer Close() on the CONCRETE pool object returned by connectDB.
-	// If you defer dbpool.Close(), it might work, but it's less explicit.
-	// Closing the concrete pool handles the actual resource cleanup.
-	defer pool.Close()
-
-	// Create Schema if it doesn't exist, using the interface variable
-	if err := createSchemaIfNotExists(dbpool); err != nil {
-		log.Fatalf("Could not create database schema: %v", err)
+		return nil, fmt.Errorf("creating %s trace exporter: %w", exporter, err)
 	}
 
-	// Setup HTTP Router
-	mux := http.NewServeMux()
-
-	// API Routes
-	mux.HandleFunc("/items", itemsHandler)       // Handles GET /items, POST /items
-	mux.HandleFunc("/items/", itemDetailHandler) // Handles DELETE /items/{id}
-
-	// Health Check endpoint
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		// Use the global dbpool (interface) for pinging
-		if err := dbpool.Ping(r.Context()); err != nil {
-			log.Printf("Health check failed: %v", err) // Log the specific error
-			http.Error(w, "Database connection failed", http.StatusServiceUnavailable)
-			return
-		}
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintln(w, "OK")
-	})
-
-	// Start HTTP Server
-	port := getenv("APP_PORT", "8080")
-	serverAddr := ":" + port
-	log.Printf("Starting server on %s\n", serverAddr)
-
-	server := &http.Server{
-		Addr:         serverAddr,
-		Handler:      mux,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
-	}
-
-	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Fatalf("Could not listen on %s: %v\n", serverAddr, err)
+	// Later detectors win, so OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the default name
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating trace resource: %w", err)
 	}
+	return sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res)), nil
 }
 
-// Helper function to get environment variables with a default value
-func getenv(key, fallback string) string {
-	if value, ok := os.LookupEnv(key); ok {
-		return value
-	}
-	log.Printf("Environment variable %s not set, using default: %s", key, fallback)
-	return fallback
+// fatal logs an error and exits, replacing log.Fatalf now that logging is structured.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
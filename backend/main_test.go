@@ -3,844 +3,92 @@ package main
 import (
 	"bytes"
 	"context"
-	"encoding/json"
-	"errors"
-	"fmt" // Needed for io.EOF check
-	"log"
-	"net/http"
-	"net/http/httptest" // Import regexp for ExpectQuery/Exec matching
+	"io"
 	"strings"
 	"testing"
-	"time"
-
-	// Needed for pgx.ErrNoRows, interface method signatures
-	"github.com/pashagolub/pgxmock/v3" // Use v3 for pgx/v5
 )
 
-// --- Mock Setup ---
-
-// Mock Pool Creation Helper
-// Returns the mock satisfying DBPool and a cleanup function.
-func newMockPool(t *testing.T) (pgxmock.PgxPoolIface, func()) {
-	t.Helper()
-	// Use pgxmock.QueryMatcherRegexp for matching queries with regexp
-	mock, err := pgxmock.NewPool(
-		pgxmock.QueryMatcherOption(pgxmock.QueryMatcherRegexp),
-	)
-	if err != nil {
-		t.Fatalf("Failed to create mock pool: %v", err)
-	}
-	originalPool := dbpool
-	dbpool = mock
-
-	cleanup := func() {
-		// Check expectations explicitly in each test case's end if needed
-		mock.Close()
-		dbpool = originalPool
-	}
-	return mock, cleanup
-}
-
-// --- Test Suite ---
-
 // --- Utility Function Tests ---
 
-func TestGetenv(t *testing.T) {
-	testKey := "TEST_GETENV_VAR"
-	fallback := "default_value"
-
-	t.Run("Set", func(t *testing.T) {
-		t.Setenv(testKey, "set_value")
-		val := getenv(testKey, fallback)
-		if val != "set_value" {
-			t.Errorf("Getenv: expected 'set_value', got '%s'", val)
-		}
-	})
-
-	t.Run("NotSet", func(t *testing.T) {
-		// t.Setenv automatically cleans up, so the var is unset here
-		val := getenv(testKey, fallback)
-		if val != fallback {
-			t.Errorf("Getenv: expected fallback '%s', got '%s'", fallback, val)
-		}
-	})
-}
-
-// --- Database Function Tests (using Mock) ---
-
-func TestGetItems(t *testing.T) {
-	mock, cleanup := newMockPool(t)
-	defer cleanup()
-	ctx := context.Background()
-	// SIMPLIFIED: Match any SELECT query
-	query := ".*SELECT.*"
-
-	t.Run("SuccessWithItems", func(t *testing.T) {
-		now := time.Now()
-		expectedItems := []Item{
-			{ID: 1, Name: "Milk", Quantity: "1 Gallon", CreatedAt: now},
-			{ID: 2, Name: "Bread", Quantity: "1 Loaf", CreatedAt: now.Add(-time.Hour)},
-		}
-		rows := pgxmock.NewRows([]string{"id", "name", "quantity", "created_at"}).
-			AddRow(expectedItems[0].ID, expectedItems[0].Name, expectedItems[0].Quantity, expectedItems[0].CreatedAt).
-			AddRow(expectedItems[1].ID, expectedItems[1].Name, expectedItems[1].Quantity, expectedItems[1].CreatedAt)
-
-		mock.ExpectQuery(query).WillReturnRows(rows)
-
-		items, err := getItems(ctx) // Call the actual function
+func TestNewLogger(t *testing.T) {
+	t.Run("JSONDebug", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, "json", "debug")
 		if err != nil {
-			t.Fatalf("getItems failed: %v", err)
+			t.Fatalf("newLogger failed: %v", err)
 		}
-		if len(items) != len(expectedItems) {
-			t.Fatalf("Expected %d items, got %d", len(expectedItems), len(items))
-		}
-		if items[0].Name != expectedItems[0].Name || items[1].Name != expectedItems[1].Name {
-			t.Errorf("Mismatch in returned items")
-		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
+		logger.Debug("hello", "k", "v")
+		if !strings.Contains(buf.String(), `"msg":"hello"`) || !strings.Contains(buf.String(), `"k":"v"`) {
+			t.Errorf("Expected JSON debug line, got %q", buf.String())
 		}
 	})
 
-	t.Run("SuccessNoItems", func(t *testing.T) {
-		rows := pgxmock.NewRows([]string{"id", "name", "quantity", "created_at"})
-		mock.ExpectQuery(query).WillReturnRows(rows)
-
-		items, err := getItems(ctx) // Call the actual function
+	t.Run("DefaultsToTextInfo", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, "", "")
 		if err != nil {
-			t.Fatalf("getItems failed for no items: %v", err)
+			t.Fatalf("newLogger failed: %v", err)
 		}
-		if len(items) != 0 {
-			t.Fatalf("Expected 0 items, got %d", len(items))
-		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
+		logger.Debug("hidden")
+		logger.Info("shown")
+		if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "msg=shown") {
+			t.Errorf("Expected only the info line in text format, got %q", buf.String())
 		}
 	})
 
-	t.Run("DatabaseError", func(t *testing.T) {
-		dbErr := errors.New("db error")
-		mock.ExpectQuery(query).WillReturnError(dbErr)
-
-		_, err := getItems(ctx) // Call the actual function
-		if err == nil {
-			t.Fatal("Expected an error, but got nil")
-		}
-		if !strings.Contains(err.Error(), dbErr.Error()) {
-			t.Errorf("Expected error containing '%v', got '%v'", dbErr, err)
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := newLogger(&bytes.Buffer{}, "xml", ""); err == nil {
+			t.Error("Expected an error for an unknown format")
 		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("RowScanError", func(t *testing.T) {
-		now := time.Now()
-		rows := pgxmock.NewRows([]string{"id", "name", "quantity", "created_at"}).
-			AddRow(1, "Milk", "1 Gallon", now).
-			AddRow("invalid-id", "Bread", "1 Loaf", now) // Invalid data type for ID
-
-		mock.ExpectQuery(query).WillReturnRows(rows)
-
-		var logBuf bytes.Buffer
-		originalLogger := log.Writer()
-		log.SetOutput(&logBuf)
-		defer log.SetOutput(originalLogger)
-
-		items, err := getItems(ctx) // Call the actual function
-		if err != nil {
-			t.Fatalf("getItems failed unexpectedly on scan error: %v", err)
-		} // getItems logs and continues
-		if len(items) != 1 {
-			t.Fatalf("Expected 1 item after scan error, got %d", len(items))
-		}
-		if items[0].Name != "Milk" {
-			t.Errorf("Expected item 'Milk', got '%s'", items[0].Name)
-		}
-		if !strings.Contains(logBuf.String(), "Error scanning item row") {
-			t.Error("Expected log message about scanning error, but not found")
-		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("RowsIterationError", func(t *testing.T) {
-		rowsErr := errors.New("iteration failed")
-		rows := pgxmock.NewRows([]string{"id", "name", "quantity", "created_at"}).
-			AddRow(1, "Milk", "1 Gallon", time.Now()).
-			RowError(1, rowsErr) // Error after the first row
-
-		mock.ExpectQuery(query).WillReturnRows(rows)
-
-		_, err := getItems(ctx) // Call the actual function
-		if err == nil {
-			t.Fatal("Expected an error from rows.Err(), but got nil")
-		}
-		if !strings.Contains(err.Error(), "database iteration error") {
-			t.Errorf("Expected error containing 'database iteration error', got '%v'", err)
-		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
+		if _, err := newLogger(&bytes.Buffer{}, "json", "loud"); err == nil {
+			t.Error("Expected an error for an unknown level")
 		}
 	})
 }
 
-func TestAddItem(t *testing.T) {
-	mock, cleanup := newMockPool(t)
-	defer cleanup()
+func TestNewTracerProvider(t *testing.T) {
 	ctx := context.Background()
-	// SIMPLIFIED: Match any INSERT query
-	query := ".*INSERT.*"
-
-	newItem := Item{Name: "Eggs", Quantity: "1 Dozen"}
-	expectedID := 5
-	expectedTime := time.Now()
-
-	t.Run("Success", func(t *testing.T) {
-		rows := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(expectedID, expectedTime)
-		mock.ExpectQuery(query).WithArgs(newItem.Name, newItem.Quantity).WillReturnRows(rows)
-
-		addedItem, err := addItem(ctx, newItem) // Call the actual function
-		if err != nil {
-			t.Fatalf("addItem failed: %v", err)
-		}
-		if addedItem.ID != expectedID {
-			t.Errorf("Expected added item ID %d, got %d", expectedID, addedItem.ID)
-		}
-		if addedItem.Name != newItem.Name || addedItem.Quantity != newItem.Quantity {
-			t.Errorf("Added item data mismatch")
-		}
-		if addedItem.CreatedAt.Sub(expectedTime).Abs() > time.Second {
-			t.Errorf("Added item timestamp mismatch. Expected ~%v, got %v", expectedTime, addedItem.CreatedAt)
-		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("DatabaseError", func(t *testing.T) {
-		dbErr := errors.New("insert failed")
-		mock.ExpectQuery(query).WithArgs(newItem.Name, newItem.Quantity).WillReturnError(dbErr)
-
-		_, err := addItem(ctx, newItem) // Call the actual function
-		if err == nil {
-			t.Fatal("Expected an error, but got nil")
-		}
-		if !strings.Contains(err.Error(), dbErr.Error()) {
-			t.Errorf("Expected error containing '%v', got '%v'", dbErr, err)
-		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("ValidationErrorEmptyName", func(t *testing.T) {
-		invalidItem := Item{Name: "  ", Quantity: "Some"}
-		_, err := addItem(ctx, invalidItem) // Call the actual function
-		if err == nil {
-			t.Fatal("Expected validation error for empty name, but got nil")
-		}
-		if !strings.Contains(err.Error(), "cannot be empty") {
-			t.Errorf("Expected error containing 'cannot be empty', got '%v'", err)
-		}
 
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
+	t.Run("Disabled", func(t *testing.T) {
+		for _, exporter := range []string{"", "none"} {
+			tp, err := newTracerProvider(ctx, exporter, io.Discard)
+			if err != nil || tp != nil {
+				t.Errorf("newTracerProvider(%q): expected nil provider and error, got %v, %v", exporter, tp, err)
+			}
 		}
 	})
 
-	t.Run("ValidationErrorEmptyQuantity", func(t *testing.T) {
-		invalidItem := Item{Name: "Some", Quantity: " "}
-		_, err := addItem(ctx, invalidItem) // Call the actual function
-		if err == nil {
-			t.Fatal("Expected validation error for empty quantity, but got nil")
-		}
-		if !strings.Contains(err.Error(), "cannot be empty") {
-			t.Errorf("Expected error containing 'cannot be empty', got '%v'", err)
-		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
-		}
-	})
-}
-
-func TestDeleteItem(t *testing.T) {
-	mock, cleanup := newMockPool(t)
-	defer cleanup()
-	ctx := context.Background()
-	// SIMPLIFIED: Match any DELETE query
-	query := ".*DELETE.*"
-	itemID := 10
-
-	t.Run("Success", func(t *testing.T) {
-		mock.ExpectExec(query).WithArgs(itemID).WillReturnResult(pgxmock.NewResult("DELETE", 1))
-
-		err := deleteItem(ctx, itemID) // Call the actual function
+	t.Run("Console", func(t *testing.T) {
+		var buf bytes.Buffer
+		tp, err := newTracerProvider(ctx, "console", &buf)
 		if err != nil {
-			t.Fatalf("deleteItem failed: %v", err)
-		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("ItemNotFound", func(t *testing.T) {
-		mock.ExpectExec(query).WithArgs(itemID).WillReturnResult(pgxmock.NewResult("DELETE", 0))
-
-		err := deleteItem(ctx, itemID) // Call the actual function
-		if err == nil {
-			t.Fatal("Expected an error for item not found, but got nil")
-		}
-		if !strings.Contains(err.Error(), "not found") {
-			t.Errorf("Expected error containing 'not found', got '%v'", err)
+			t.Fatalf("newTracerProvider failed: %v", err)
 		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
+		_, span := tp.Tracer("test").Start(ctx, "GET /items")
+		span.End()
+		if err := tp.Shutdown(ctx); err != nil {
+			t.Fatalf("Shutdown failed: %v", err)
 		}
-	})
-
-	t.Run("DatabaseError", func(t *testing.T) {
-		dbErr := errors.New("delete failed")
-		mock.ExpectExec(query).WithArgs(itemID).WillReturnError(dbErr)
-
-		err := deleteItem(ctx, itemID) // Call the actual function
-		if err == nil {
-			t.Fatal("Expected a database error, but got nil")
-		}
-		if !strings.Contains(err.Error(), dbErr.Error()) {
-			t.Errorf("Expected error containing '%v', got '%v'", dbErr, err)
-		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
+		if !strings.Contains(buf.String(), `"GET /items"`) || !strings.Contains(buf.String(), serviceName) {
+			t.Errorf("Expected the span and service name on stdout, got %q", buf.String())
 		}
 	})
-}
-
-// --- HTTP Handler Tests ---
-
-// Helper to execute requests
-func executeRequest(req *http.Request, handler http.HandlerFunc) *httptest.ResponseRecorder {
-	rr := httptest.NewRecorder()
-	handler(rr, req) // Use the passed handler
-	return rr
-}
-
-func TestGetItemsHandler(t *testing.T) {
-	mock, cleanup := newMockPool(t)
-	defer cleanup()
-
-	handlerToTest := http.HandlerFunc(getItemsHandler)
-	req, _ := http.NewRequest("GET", "/items", nil)
-	// SIMPLIFIED: Match any SELECT query
-	query := ".*SELECT.*"
-
-	t.Run("Success", func(t *testing.T) {
-		now := time.Now()
-		expectedItems := []Item{{ID: 1, Name: "Milk", Quantity: "1 Gallon", CreatedAt: now}}
-		rows := pgxmock.NewRows([]string{"id", "name", "quantity", "created_at"}).
-			AddRow(expectedItems[0].ID, expectedItems[0].Name, expectedItems[0].Quantity, expectedItems[0].CreatedAt)
-		mock.ExpectQuery(query).WillReturnRows(rows)
-
-		rr := executeRequest(req, handlerToTest) // Call handler
 
-		if rr.Code != http.StatusOK {
-			t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
-		}
-		var items []Item
-		if err := json.NewDecoder(rr.Body).Decode(&items); err != nil {
-			t.Fatalf("Could not decode response body: %v", err)
-		}
-		if len(items) != 1 || items[0].Name != "Milk" {
-			t.Errorf("Unexpected response body: %s", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("SuccessEmpty", func(t *testing.T) {
-		rows := pgxmock.NewRows([]string{"id", "name", "quantity", "created_at"})
-		mock.ExpectQuery(query).WillReturnRows(rows)
-
-		rr := executeRequest(req, handlerToTest) // Call handler
-
-		if rr.Code != http.StatusOK {
-			t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
-		}
-		body := strings.TrimSpace(rr.Body.String())
-		if body != "[]" {
-			t.Errorf("Expected empty array '[]', got '%s'", body)
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("DatabaseError", func(t *testing.T) {
-		mock.ExpectQuery(query).WillReturnError(errors.New("db error"))
-
-		rr := executeRequest(req, handlerToTest) // Call handler
-
-		if rr.Code != http.StatusInternalServerError {
-			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "Internal Server Error") {
-			t.Errorf("Expected 'Internal Server Error', got '%s'", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-}
-
-func TestAddItemHandler(t *testing.T) {
-	mock, cleanup := newMockPool(t)
-	defer cleanup()
-	handlerToTest := http.HandlerFunc(addItemHandler)
-	// SIMPLIFIED: Match any INSERT query
-	query := ".*INSERT.*"
-
-	t.Run("Success", func(t *testing.T) {
-		newItem := Item{Name: "Cheese", Quantity: "1 Block"}
-		payload, _ := json.Marshal(newItem)
-		req, _ := http.NewRequest("POST", "/items", bytes.NewBuffer(payload))
-		req.Header.Set("Content-Type", "application/json")
-
-		expectedID := 10
-		expectedTime := time.Now()
-		rows := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(expectedID, expectedTime)
-		mock.ExpectQuery(query).WithArgs(newItem.Name, newItem.Quantity).WillReturnRows(rows)
-
-		rr := executeRequest(req, handlerToTest) // Call handler
-
-		if rr.Code != http.StatusCreated {
-			t.Errorf("Expected status %d, got %d", http.StatusCreated, rr.Code)
-		}
-		var addedItem Item
-		if err := json.NewDecoder(rr.Body).Decode(&addedItem); err != nil {
-			t.Fatalf("Could not decode response body: %v", err)
-		}
-		if addedItem.ID != expectedID || addedItem.Name != newItem.Name {
-			t.Errorf("Unexpected response body: %+v", addedItem)
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("InvalidJSONSyntax", func(t *testing.T) {
-		req, _ := http.NewRequest("POST", "/items", bytes.NewBuffer([]byte("{invalid json")))
-		req.Header.Set("Content-Type", "application/json")
-		rr := executeRequest(req, handlerToTest)
-		if rr.Code != http.StatusBadRequest {
-			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "badly-formed JSON") {
-			t.Errorf("Expected 'badly-formed JSON' error, got '%s'", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
-		}
-	})
-
-	t.Run("InvalidJSONType", func(t *testing.T) {
-		req, _ := http.NewRequest("POST", "/items", bytes.NewBuffer([]byte(`{"name": 123, "quantity": "good"}`)))
-		req.Header.Set("Content-Type", "application/json")
-		rr := executeRequest(req, handlerToTest)
-		if rr.Code != http.StatusBadRequest {
-			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "invalid value for the \"name\" field") {
-			t.Errorf("Expected type error message, got '%s'", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
-		}
-	})
-
-	t.Run("ValidationError", func(t *testing.T) {
-		invalidItem := Item{Name: "", Quantity: "Some"}
-		payload, _ := json.Marshal(invalidItem)
-		req, _ := http.NewRequest("POST", "/items", bytes.NewBuffer(payload))
-		req.Header.Set("Content-Type", "application/json")
-		rr := executeRequest(req, handlerToTest)
-		if rr.Code != http.StatusBadRequest {
-			t.Errorf("Expected status %d for validation error, got %d", http.StatusBadRequest, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "cannot be empty") {
-			t.Errorf("Expected validation error message, got '%s'", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
-		}
-	})
-
-	// ** Testing UnknownFieldsJSON with fix **
-	t.Run("UnknownFieldsJSON", func(t *testing.T) {
-		payload := `{"name": "Milk", "quantity": "1", "extra_field": "bad"}`
-		req, _ := http.NewRequest("POST", "/items", strings.NewReader(payload))
-		req.Header.Set("Content-Type", "application/json")
-
-		rr := executeRequest(req, handlerToTest) // Call handler
-
-		if rr.Code != http.StatusBadRequest {
-			t.Errorf("Expected status %d for unknown fields, got %d", http.StatusBadRequest, rr.Code)
-		}
-		// Use a less specific check for the error message
-		if !strings.Contains(strings.ToLower(rr.Body.String()), "unknown field") {
-			t.Errorf("Expected error containing 'unknown field', got '%s'", rr.Body.String())
-		}
-		// Ensure no DB expectations were violated (as none should have been set)
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
-		}
-	})
-	// ** End of UnknownFieldsJSON fix **
-
-	t.Run("EmptyRequestBody", func(t *testing.T) {
-		req, _ := http.NewRequest("POST", "/items", bytes.NewBuffer([]byte{}))
-		req.Header.Set("Content-Type", "application/json")
-		rr := executeRequest(req, handlerToTest)
-		if rr.Code != http.StatusBadRequest {
-			t.Errorf("Expected status %d for empty body, got %d", http.StatusBadRequest, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "Request body must not be empty") {
-			t.Errorf("Expected empty body error, got '%s'", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
-		}
-	})
-
-	t.Run("RequestBodyTooLarge", func(t *testing.T) {
-		largePayload := `{"name": "TooMuch", "quantity": "` + strings.Repeat("a", 1024*1024) + `"}`
-		req, _ := http.NewRequest("POST", "/items", strings.NewReader(largePayload))
-		req.Header.Set("Content-Type", "application/json")
-		rr := executeRequest(req, handlerToTest)
-		if rr.Code != http.StatusRequestEntityTooLarge {
-			t.Errorf("Expected status %d for large body, got %d", http.StatusRequestEntityTooLarge, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "Request body must not be larger than 1MB") {
-			t.Errorf("Expected large body error message, got '%s'", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
-		}
-	})
-
-	// ** Testing DatabaseError with AnyArg() **
-	t.Run("DatabaseError", func(t *testing.T) {
-		newItem := Item{Name: "Failing", Quantity: "Item"}
-		payload, _ := json.Marshal(newItem)
-		req, _ := http.NewRequest("POST", "/items", bytes.NewBuffer(payload))
-		req.Header.Set("Content-Type", "application/json")
-		dbErr := errors.New("db insert failed")
-
-		// Use broad query pattern AND AnyArg() because the previous error indicated
-		// the call was made *with* arguments, just maybe not matching exactly.
-		mock.ExpectQuery(".*INSERT.*").
-			WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()). // Expect *some* arguments
-			WillReturnError(dbErr)
-
-		rr := executeRequest(req, handlerToTest) // Call handler
-
-		if rr.Code != http.StatusInternalServerError {
-			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "Internal Server Error") {
-			t.Errorf("Expected 'Internal Server Error', got '%s'", rr.Body.String())
-		}
-		// Check expectations AFTER handler execution
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations AFTER handler execution: %s", err)
-		}
-	})
-	// ** End of DatabaseError fix **
-}
-
-func TestDeleteItemHandler(t *testing.T) {
-	mock, cleanup := newMockPool(t)
-	defer cleanup()
-
-	handlerToTest := http.HandlerFunc(itemDetailHandler)
-	// SIMPLIFIED: Match any DELETE query
-	query := ".*DELETE.*"
-
-	t.Run("Success", func(t *testing.T) {
-		itemID := 15
-		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/items/%d", itemID), nil)
-		mock.ExpectExec(query).WithArgs(itemID).WillReturnResult(pgxmock.NewResult("DELETE", 1))
-
-		rr := executeRequest(req, handlerToTest) // Call handler
-
-		if rr.Code != http.StatusNoContent {
-			t.Errorf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("InvalidIDFormat", func(t *testing.T) {
-		req, _ := http.NewRequest("DELETE", "/items/abc", nil)
-		rr := executeRequest(req, handlerToTest)
-		if rr.Code != http.StatusBadRequest {
-			t.Errorf("Expected status %d for invalid ID, got %d", http.StatusBadRequest, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "Invalid item ID format") {
-			t.Errorf("Expected 'Invalid item ID format' error, got '%s'", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
-		}
-	})
-
-	t.Run("MissingID", func(t *testing.T) {
-		req, _ := http.NewRequest("DELETE", "/items/", nil)
-		rr := executeRequest(req, handlerToTest)
-		if rr.Code != http.StatusBadRequest {
-			t.Errorf("Expected status %d for missing ID, got %d", http.StatusBadRequest, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "Invalid URL format or missing item ID") {
-			t.Errorf("Expected 'Invalid URL format or missing item ID' error, got '%s'", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
-		}
-	})
-
-	t.Run("InvalidURLPrefix", func(t *testing.T) {
-		req, _ := http.NewRequest("DELETE", "/wrongprefix/123", nil)
-		rr := executeRequest(req, handlerToTest)
-		if rr.Code != http.StatusBadRequest {
-			t.Errorf("Expected status %d for invalid URL prefix, got %d", http.StatusBadRequest, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "Invalid URL format or missing item ID") {
-			t.Errorf("Expected 'Invalid URL format or missing item ID' error, got '%s'", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
-		}
-	})
-
-	t.Run("NegativeID", func(t *testing.T) {
-		req, _ := http.NewRequest("DELETE", "/items/-5", nil)
-		rr := executeRequest(req, handlerToTest)
-		if rr.Code != http.StatusBadRequest {
-			t.Errorf("Expected status %d for negative ID, got %d", http.StatusBadRequest, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "Invalid item ID format") {
-			t.Errorf("Expected 'Invalid item ID format' error, got '%s'", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
-		}
-	})
-
-	t.Run("ItemNotFound", func(t *testing.T) {
-		itemID := 99
-		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/items/%d", itemID), nil)
-		mock.ExpectExec(query).WithArgs(itemID).WillReturnResult(pgxmock.NewResult("DELETE", 0)) // 0 rows affected
-
-		rr := executeRequest(req, handlerToTest) // Call handler
-
-		if rr.Code != http.StatusNotFound {
-			t.Errorf("Expected status %d for item not found, got %d", http.StatusNotFound, rr.Code)
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("DatabaseError", func(t *testing.T) {
-		itemID := 20
-		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/items/%d", itemID), nil)
-		dbErr := errors.New("db delete failed")
-		mock.ExpectExec(query).WithArgs(itemID).WillReturnError(dbErr)
-
-		rr := executeRequest(req, handlerToTest) // Call handler
-
-		if rr.Code != http.StatusInternalServerError {
-			t.Errorf("Expected status %d for db error, got %d", http.StatusInternalServerError, rr.Code)
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("MethodNotAllowed", func(t *testing.T) {
-		itemID := 25
-		req, _ := http.NewRequest("GET", fmt.Sprintf("/items/%d", itemID), nil) // Use GET which is disallowed
-		rr := executeRequest(req, handlerToTest)
-		if rr.Code != http.StatusMethodNotAllowed {
-			t.Errorf("Expected status %d for method not allowed, got %d", http.StatusMethodNotAllowed, rr.Code)
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations (DB call should not happen): %s", err)
-		}
-	})
-}
-
-func TestMuxHandlers(t *testing.T) {
-	mock, cleanup := newMockPool(t)
-	defer cleanup()
-
-	t.Run("ItemsHandlerMethods", func(t *testing.T) {
-		getReq, _ := http.NewRequest("GET", "/items", nil)
-		postReqBody := `{"name":"Test", "quantity":"1"}`
-		postReq, _ := http.NewRequest("POST", "/items", strings.NewReader(postReqBody))
-		postReq.Header.Set("Content-Type", "application/json")
-		putReq, _ := http.NewRequest("PUT", "/items", nil) // Disallowed
-
-		// Mock DB calls needed by GET and POST handlers
-		mock.ExpectQuery(".*SELECT.*").WillReturnRows(pgxmock.NewRows([]string{"id", "name", "quantity", "created_at"}))
-		mock.ExpectQuery(".*INSERT.*").WithArgs("Test", "1").WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
-
-		getRR := executeRequest(getReq, itemsHandler)
-		if getRR.Code == http.StatusMethodNotAllowed {
-			t.Error("GET /items should be allowed")
-		}
-
-		postRR := executeRequest(postReq, itemsHandler)
-		if postRR.Code == http.StatusMethodNotAllowed {
-			t.Error("POST /items should be allowed")
-		}
-		if postRR.Code != http.StatusCreated {
-			t.Errorf("Expected POST /items to return %d, got %d", http.StatusCreated, postRR.Code)
-		}
-
-		putRR := executeRequest(putReq, itemsHandler)
-		if putRR.Code != http.StatusMethodNotAllowed {
-			t.Errorf("Expected PUT /items to return %d, got %d", http.StatusMethodNotAllowed, putRR.Code)
-		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("ItemDetailHandlerMethods", func(t *testing.T) {
-		delReq, _ := http.NewRequest("DELETE", "/items/1", nil) // Allowed
-		postReq, _ := http.NewRequest("POST", "/items/1", nil)  // Disallowed
-
-		// Mock DB call needed by DELETE handler
-		mock.ExpectExec(".*DELETE.*").WithArgs(1).WillReturnResult(pgxmock.NewResult("DELETE", 1))
-
-		delRR := executeRequest(delReq, itemDetailHandler)
-		if delRR.Code == http.StatusMethodNotAllowed {
-			t.Error("DELETE /items/1 should be allowed")
-		}
-		if delRR.Code != http.StatusNoContent {
-			t.Errorf("Expected DELETE /items/1 to return %d, got %d", http.StatusNoContent, delRR.Code)
-		}
-
-		postRR := executeRequest(postReq, itemDetailHandler)
-		if postRR.Code != http.StatusMethodNotAllowed {
-			t.Errorf("Expected POST /items/1 to return %d, got %d", http.StatusMethodNotAllowed, postRR.Code)
-		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-}
-
-func TestHealthzHandler(t *testing.T) {
-	mock, cleanup := newMockPool(t)
-	defer cleanup()
-
-	healthzLogic := func(w http.ResponseWriter, r *http.Request) {
-		if err := dbpool.Ping(r.Context()); err != nil {
-			log.Printf("Health check failed: %v", err)
-			http.Error(w, "Database connection failed", http.StatusServiceUnavailable)
-			return
-		}
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintln(w, "OK")
-	}
-	handlerToTest := http.HandlerFunc(healthzLogic)
-
-	t.Run("Success", func(t *testing.T) {
-		req, _ := http.NewRequest("GET", "/healthz", nil)
-		mock.ExpectPing().WillReturnError(nil)
-
-		rr := executeRequest(req, handlerToTest) // Call handler
-
-		if rr.Code != http.StatusOK {
-			t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "OK") {
-			t.Errorf("Expected 'OK' in body, got '%s'", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-
-	t.Run("DBError", func(t *testing.T) {
-		req, _ := http.NewRequest("GET", "/healthz", nil)
-		dbErr := errors.New("ping failed")
-		mock.ExpectPing().WillReturnError(dbErr)
-
-		rr := executeRequest(req, handlerToTest) // Call handler
-
-		if rr.Code != http.StatusServiceUnavailable {
-			t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
-		}
-		if !strings.Contains(rr.Body.String(), "Database connection failed") {
-			t.Errorf("Expected 'Database connection failed' in body, got '%s'", rr.Body.String())
-		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
-		}
-	})
-}
-
-func TestCreateSchemaIfNotExists(t *testing.T) {
-	mock, cleanup := newMockPool(t)
-	defer cleanup()
-	// SIMPLIFIED: Match any CREATE TABLE query
-	query := ".*CREATE TABLE.*"
-
-	t.Run("Success", func(t *testing.T) {
-		mock.ExpectExec(query).WillReturnResult(pgxmock.NewResult("CREATE", 0))
-
-		err := createSchemaIfNotExists(mock) // Call function
+	t.Run("OTLP", func(t *testing.T) {
+		// Creating the exporter does not connect, so no collector is needed
+		tp, err := newTracerProvider(ctx, "otlp", io.Discard)
 		if err != nil {
-			t.Fatalf("createSchemaIfNotExists failed: %v", err)
+			t.Fatalf("newTracerProvider failed: %v", err)
 		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
+		if tp == nil {
+			t.Fatal("Expected a tracer provider")
 		}
+		_ = tp.Shutdown(ctx)
 	})
 
-	t.Run("DatabaseError", func(t *testing.T) {
-		dbErr := errors.New("permission denied")
-		mock.ExpectExec(query).WillReturnError(dbErr)
-
-		err := createSchemaIfNotExists(mock) // Call function
-		if err == nil {
-			t.Fatal("Expected an error, but got nil")
-		}
-		if !strings.Contains(err.Error(), dbErr.Error()) {
-			t.Errorf("Expected error '%v', got '%v'", dbErr, err)
-		}
-
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Unfulfilled expectations: %s", err)
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := newTracerProvider(ctx, "zipkin", io.Discard); err == nil {
+			t.Error("Expected an error, got nil")
 		}
 	})
 }
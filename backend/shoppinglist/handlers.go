@@ -0,0 +1,261 @@
+package shoppinglist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// --- HTTP Handlers ---
+
+func (s *Server) itemsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.getItemsHandler(w, r)
+	case http.MethodPost:
+		s.addItemHandler(w, r)
+	default:
+		s.methodNotAllowed(w, r, "GET, POST")
+	}
+}
+
+func (s *Server) itemDetailHandler(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL path like /api/items/123 or /api/items/{uid}/bought
+	// Ensure path ends with the ID and not just /items/
+	path, bought := strings.CutSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/bought")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 3 || pathParts[len(pathParts)-1] == "" || pathParts[len(pathParts)-2] != "items" {
+		s.writeStatus(w, r, http.StatusBadRequest, "Invalid URL format or missing item ID")
+		return
+	}
+	id, ok := s.itemID(w, r, pathParts[len(pathParts)-1])
+	if !ok {
+		return
+	}
+
+	if bought {
+		switch {
+		case s.pantry == nil:
+			s.writeStatus(w, r, http.StatusNotFound, "No such endpoint")
+		case r.Method != http.MethodPost:
+			s.methodNotAllowed(w, r, "POST")
+		default:
+			s.boughtHandler(w, r, id)
+		}
+		return
+	}
+
+	// Now handle the method
+	switch r.Method {
+	case http.MethodPut:
+		s.updateItemHandler(w, r, id)
+	case http.MethodDelete:
+		s.deleteItemHandler(w, r, id) // Pass the parsed ID
+	default:
+		s.methodNotAllowed(w, r, "PUT, DELETE")
+	}
+}
+
+func (s *Server) getItemsHandler(w http.ResponseWriter, r *http.Request) {
+	withEstimate := false
+	if include := r.URL.Query().Get("include"); include != "" {
+		if include != "estimate" {
+			s.writeStatus(w, r, http.StatusBadRequest, "Unsupported include value (expected \"estimate\")")
+			return
+		}
+		withEstimate = true
+	}
+	store, ok := s.storeFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := s.store.List(r.Context())
+	if err != nil {
+		s.requestLogger(r).Error("Error listing items", "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+
+	// Handle case where items might be nil if the store returns nil on error
+	if items == nil {
+		items = []Item{} // Return empty array instead of null JSON
+	}
+	if store != nil {
+		mappings, err := s.layouts.ListMappings(r.Context(), store.ID)
+		if err != nil {
+			s.requestLogger(r).Error("Error listing section mappings", "store", store.ID, "err", err)
+			s.writeError(w, r, err)
+			return
+		}
+		sortWalkingOrder(items, *store, mappings)
+	}
+	items = s.publicItems(items)
+	if withEstimate {
+		budget, err := s.budget(r.Context())
+		if err != nil {
+			s.requestLogger(r).Error("Error getting the budget", "err", err)
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, r, http.StatusOK, ItemsWithEstimate{Items: items, Estimate: estimate(items, budget)})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(items); err != nil {
+		s.requestLogger(r).Error("Error encoding items to JSON", "err", err)
+		// Avoid writing header again if already written by Encode
+	}
+}
+
+func (s *Server) addItemHandler(w http.ResponseWriter, r *http.Request) {
+	var newItem Item
+	if !s.decodeJSON(w, r, &newItem) {
+		return
+	}
+
+	addedItem, err := s.addItem(r.Context(), newItem)
+	if err != nil {
+		s.requestLogger(r).Warn("Error adding item", "err", err)
+		s.writeError(w, r, err) // Validation errors are 400 with field details; DB errors stay internal
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated) // 201 Created
+	if err := json.NewEncoder(w).Encode(s.publicItem(addedItem)); err != nil {
+		s.requestLogger(r).Error("Error encoding added item to JSON", "err", err)
+	}
+}
+
+// addItem normalizes newItem against the configured limits and stores it. Every way of
+// adding items (POST /items, recipes, recurring items) goes through here; the store checks
+// again with the hard limits. An item without a price gets the last-known one for its
+// name, and an explicit price is remembered. Every added item goes into the history
+// that suggestions come from.
+func (s *Server) addItem(ctx context.Context, newItem Item) (Item, error) {
+	newItem, err := normalizeItem(newItem, s.limits)
+	if err != nil {
+		return Item{}, err
+	}
+	priced := newItem.UnitPrice != nil
+	added, err := s.store.Create(ctx, s.withLastPrice(ctx, newItem))
+	if err != nil {
+		return Item{}, err
+	}
+	if priced {
+		s.rememberPrice(ctx, added)
+	}
+	s.recordUse(ctx, added)
+	return added, nil
+}
+
+// updateItemHandler replaces the name, quantity and price of an item; leaving out the
+// price clears it.
+func (s *Server) updateItemHandler(w http.ResponseWriter, r *http.Request, id int) {
+	var changed Item
+	if !s.decodeJSON(w, r, &changed) {
+		return
+	}
+	changed, err := normalizeItem(changed, s.limits)
+	if err == nil {
+		changed.ID = id
+		changed, err = s.store.Update(r.Context(), changed)
+	}
+	if err != nil {
+		s.requestLogger(r).Warn("Error updating item", "id", id, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	s.rememberPrice(r.Context(), changed)
+	s.writeJSON(w, r, http.StatusOK, s.publicItem(changed))
+}
+
+// decodeJSON decodes the request body into v. Malformed, empty or oversized bodies are
+// answered with an invalid-body problem, and false tells the handler to stop.
+func (s *Server) decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	return s.decodeBody(w, r, v, false)
+}
+
+// decodeOptionalJSON is decodeJSON for an optional body: an empty one leaves v as it is.
+// It does not go by Content-Length, which is unknown for a chunked request.
+func (s *Server) decodeOptionalJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	return s.decodeBody(w, r, v, true)
+}
+
+func (s *Server) decodeBody(w http.ResponseWriter, r *http.Request, v any, optional bool) bool {
+	// Use http.MaxBytesReader to prevent large request bodies (DoS protection)
+	r.Body = http.MaxBytesReader(w, r.Body, 1024*1024) // 1MB limit
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields() // Prevent extra fields in JSON
+
+	err := dec.Decode(v)
+	if err == nil || optional && errors.Is(err, io.EOF) {
+		return true
+	}
+	var syntaxError *json.SyntaxError
+	var unmarshalTypeError *json.UnmarshalTypeError
+	var maxBytesError *http.MaxBytesError // Check for body too large
+
+	status, detail := http.StatusBadRequest, ""
+	switch {
+	case errors.As(err, &syntaxError):
+		detail = fmt.Sprintf("Request body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		detail = "Request body contains badly-formed JSON"
+	case errors.As(err, &unmarshalTypeError):
+		detail = fmt.Sprintf("Request body contains an invalid value for the %q field (at character %d)", unmarshalTypeError.Field, unmarshalTypeError.Offset)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		detail = fmt.Sprintf("Request body contains unknown field %s", fieldName)
+	case errors.Is(err, io.EOF): // Empty body
+		detail = "Request body must not be empty"
+	case errors.As(err, &maxBytesError):
+		status, detail = http.StatusRequestEntityTooLarge, "Request body must not be larger than 1MB"
+	default: // Catch-all for other decoding errors
+		s.requestLogger(r).Error("Error decoding JSON body", "err", err)
+		s.writeStatus(w, r, http.StatusInternalServerError, "") // Keep internal errors internal
+		return false
+	}
+	s.writeProblem(w, r, Problem{Type: problemTypeInvalidBody, Title: "Invalid request body", Status: status, Detail: detail})
+	return false
+}
+
+// writeJSON sends v as a JSON response with the given status.
+func (s *Server) writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.requestLogger(r).Error("Error encoding response to JSON", "err", err)
+	}
+}
+
+// deleteItemHandler now receives the parsed ID. With ?store={id} the item counts as
+// checked off in that store, which teaches its layout where the item is.
+func (s *Server) deleteItemHandler(w http.ResponseWriter, r *http.Request, id int) {
+	store, ok := s.storeFromQuery(w, r)
+	if !ok {
+		return
+	}
+	var item Item
+	var err error
+	if store != nil {
+		item, err = s.store.Get(r.Context(), id)
+	}
+	if err == nil {
+		err = s.store.Delete(r.Context(), id)
+	}
+	if err != nil {
+		s.requestLogger(r).Warn("Error deleting item", "id", id, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	s.checkedOff(r, store, item.Name)
+
+	w.WriteHeader(http.StatusNoContent) // 204 No Content is typical for successful DELETE
+}
@@ -0,0 +1,156 @@
+package shoppinglist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// --- Liveness and Readiness ---
+
+// readinessTimeout bounds all readiness checks together, so a hung database makes
+// /readyz fail instead of hanging the probe.
+const readinessTimeout = 2 * time.Second
+
+// Check statuses reported by /readyz.
+const (
+	checkOK   = "ok"
+	checkFail = "fail"
+)
+
+// schemaVersioner is implemented by stores that record applied migrations.
+type schemaVersioner interface {
+	SchemaVersion(ctx context.Context) (int, error)
+}
+
+// checkResult is the outcome of one readiness check.
+type checkResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// readinessReport is the /readyz response body.
+type readinessReport struct {
+	Status string        `json:"status"`
+	Checks []checkResult `json:"checks"`
+	Pool   *poolStats    `json:"pool,omitempty"` // Only with ?verbose
+}
+
+// poolStats is a snapshot of the PostgreSQL connection pool.
+type poolStats struct {
+	AcquiredConns     int32 `json:"acquired_conns"`
+	IdleConns         int32 `json:"idle_conns"`
+	TotalConns        int32 `json:"total_conns"`
+	MaxConns          int32 `json:"max_conns"`
+	ConstructingConns int32 `json:"constructing_conns"`
+	EmptyAcquireCount int64 `json:"empty_acquire_count"`
+}
+
+// Drain marks the Server as shutting down: /readyz starts failing so load balancers stop
+// sending new traffic, while requests already routed here are still served.
+func (s *Server) Drain() {
+	s.draining.Store(true)
+}
+
+// livezHandler reports that the process is up. It deliberately has no dependencies, so an
+// orchestrator does not restart the backend just because the database is unavailable.
+func (s *Server) livezHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprintln(w, `{"status":"ok"}`)
+}
+
+// readyzHandler reports whether the Server should receive traffic: the database is
+// reachable, the schema is at the expected version and the Server is not draining.
+// ?verbose adds connection pool statistics. /healthz is an alias kept for older probes.
+func (s *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	report := readinessReport{Status: checkOK}
+	add := func(c checkResult) {
+		if c.Status != checkOK {
+			report.Status = checkFail
+			s.requestLogger(r).Error("Health check failed", "check", c.Name, "err", c.Error)
+		}
+		report.Checks = append(report.Checks, c)
+	}
+
+	// Error texts stay generic since /readyz may be reachable from outside; details are logged
+	var dbErr error
+	add(timedCheck("database", func() error {
+		if dbErr = s.Ping(ctx); dbErr != nil {
+			s.requestLogger(r).Error("Database ping failed", "err", dbErr)
+			return errors.New("database unreachable")
+		}
+		return nil
+	}))
+
+	if v, ok := s.base.(schemaVersioner); ok {
+		add(timedCheck("migrations", func() error {
+			if dbErr != nil {
+				return errors.New("database unreachable")
+			}
+			version, err := v.SchemaVersion(ctx)
+			if err != nil {
+				s.requestLogger(r).Error("Reading schema version failed", "err", err)
+				return errors.New("unable to read schema version")
+			}
+			// A newer schema is fine: old replicas keep serving during a rolling deploy,
+			// since migrations only ever add to it
+			if want := latestSchemaVersion(); version < want {
+				return fmt.Errorf("schema version %d, expected at least %d", version, want)
+			}
+			return nil
+		}))
+	}
+
+	add(timedCheck("draining", func() error {
+		if s.draining.Load() {
+			return errors.New("server is shutting down")
+		}
+		return nil
+	}))
+
+	if _, verbose := r.URL.Query()["verbose"]; verbose {
+		if stater, ok := s.pool.(poolStater); ok {
+			stat := stater.Stat()
+			report.Pool = &poolStats{
+				AcquiredConns:     stat.AcquiredConns(),
+				IdleConns:         stat.IdleConns(),
+				TotalConns:        stat.TotalConns(),
+				MaxConns:          stat.MaxConns(),
+				ConstructingConns: stat.ConstructingConns(),
+				EmptyAcquireCount: stat.EmptyAcquireCount(),
+			}
+		}
+	}
+
+	status := http.StatusOK
+	if report.Status != checkOK {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		s.requestLogger(r).Error("Error encoding readiness report", "err", err)
+	}
+}
+
+// timedCheck runs check and records its outcome and latency.
+func timedCheck(name string, check func() error) checkResult {
+	start := time.Now()
+	err := check()
+	c := checkResult{Name: name, Status: checkOK, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		c.Status = checkFail
+		c.Error = err.Error()
+	}
+	return c
+}
@@ -0,0 +1,183 @@
+package shoppinglist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+// getReadiness requests path from srv and decodes the readiness report.
+func getReadiness(t *testing.T, srv http.Handler, path string) (int, readinessReport) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %q", ct)
+	}
+	var report readinessReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid readiness JSON %q: %v", rr.Body.String(), err)
+	}
+	return rr.Code, report
+}
+
+// checkStatuses maps check names to their statuses.
+func checkStatuses(report readinessReport) map[string]string {
+	statuses := make(map[string]string)
+	for _, c := range report.Checks {
+		statuses[c.Name] = c.Status
+	}
+	return statuses
+}
+
+func TestLivez(t *testing.T) {
+	// Liveness must not depend on the database
+	srv := newTestServer(t, NewPostgresStore(failingPinger{}))
+	srv.Drain()
+
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest("GET", "/livez", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if rr.Body.String() != "{\"status\":\"ok\"}\n" {
+		t.Errorf("Unexpected body %q", rr.Body.String())
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	mock, cleanup := newMockPool(t)
+	defer cleanup()
+	versionQuery := "SELECT COALESCE\\(MAX\\(version\\), 0\\) FROM schema_migrations"
+
+	t.Run("Ready", func(t *testing.T) {
+		srv := newTestServer(t, NewPostgresStore(mock))
+		mock.ExpectPing()
+		mock.ExpectQuery(versionQuery).WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(latestSchemaVersion()))
+
+		code, report := getReadiness(t, srv, "/readyz")
+		if code != http.StatusOK || report.Status != checkOK {
+			t.Errorf("Expected 200/ok, got %d/%s", code, report.Status)
+		}
+		got := checkStatuses(report)
+		for _, name := range []string{"database", "migrations", "draining"} {
+			if got[name] != checkOK {
+				t.Errorf("Expected check %s to be ok, got %v", name, got)
+			}
+		}
+		if report.Pool != nil {
+			t.Error("Expected no pool stats without ?verbose")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("DatabaseDown", func(t *testing.T) {
+		srv := newTestServer(t, NewPostgresStore(mock))
+		mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+
+		// The schema version is not queried when the database is unreachable
+		code, report := getReadiness(t, srv, "/readyz")
+		if code != http.StatusServiceUnavailable || report.Status != checkFail {
+			t.Errorf("Expected 503/fail, got %d/%s", code, report.Status)
+		}
+		for _, c := range report.Checks {
+			if c.Name == "database" && (c.Status != checkFail || c.Error != "database unreachable") {
+				t.Errorf("Unexpected database check %+v", c)
+			}
+		}
+		if got := checkStatuses(report); got["migrations"] != checkFail || got["draining"] != checkOK {
+			t.Errorf("Unexpected checks %v", got)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("SchemaBehind", func(t *testing.T) {
+		srv := newTestServer(t, NewPostgresStore(mock))
+		mock.ExpectPing()
+		mock.ExpectQuery(versionQuery).WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(0))
+
+		code, report := getReadiness(t, srv, "/readyz")
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, code)
+		}
+		if got := checkStatuses(report); got["migrations"] != checkFail {
+			t.Errorf("Expected the migrations check to fail, got %v", got)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("SchemaAhead", func(t *testing.T) {
+		// A newer replica has migrated; this one must stay ready during the rollout
+		srv := newTestServer(t, NewPostgresStore(mock))
+		mock.ExpectPing()
+		mock.ExpectQuery(versionQuery).WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(latestSchemaVersion() + 1))
+
+		code, report := getReadiness(t, srv, "/readyz")
+		if code != http.StatusOK || checkStatuses(report)["migrations"] != checkOK {
+			t.Errorf("Expected 200 with the migrations check ok, got %d %v", code, checkStatuses(report))
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Draining", func(t *testing.T) {
+		srv := newTestServer(t, NewMemoryStore())
+		srv.Drain()
+
+		code, report := getReadiness(t, srv, "/readyz")
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, code)
+		}
+		// The memory store has no migrations, so that check is omitted
+		if got := checkStatuses(report); got["draining"] != checkFail || got["database"] != checkOK || len(got) != 2 {
+			t.Errorf("Unexpected checks %v", got)
+		}
+	})
+
+	t.Run("HealthzAlias", func(t *testing.T) {
+		srv := newTestServer(t, NewPostgresStore(failingPinger{}))
+		code, report := getReadiness(t, srv, "/healthz")
+		if code != http.StatusServiceUnavailable || report.Status != checkFail {
+			t.Errorf("Expected 503/fail, got %d/%s", code, report.Status)
+		}
+	})
+}
+
+func TestReadyzVerbose(t *testing.T) {
+	// pgxpool connects lazily, so an unreachable address still yields a pool with stats
+	pool, err := pgxpool.New(context.Background(), "postgres://user@127.0.0.1:1/shopping?pool_max_conns=4")
+	if err != nil {
+		t.Fatalf("pgxpool.New failed: %v", err)
+	}
+	srv, err := New(Options{Store: NewMemoryStore(), Pool: pool})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer srv.Close()
+
+	code, report := getReadiness(t, srv, "/readyz?verbose")
+	if code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, code)
+	}
+	if report.Pool == nil || report.Pool.MaxConns != 4 {
+		t.Errorf("Expected pool stats with max_conns 4, got %+v", report.Pool)
+	}
+	for _, c := range report.Checks {
+		if c.LatencyMS < 0 {
+			t.Errorf("Check %s has negative latency %v", c.Name, c.LatencyMS)
+		}
+	}
+}
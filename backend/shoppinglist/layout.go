@@ -0,0 +1,415 @@
+package shoppinglist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Stores and Aisle Layouts ---
+
+// StoreLayout is a shop and its sections (aisles, counters, ...) in the order you walk
+// past them. GET /items?store={id} sorts the list into that order.
+type StoreLayout struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Sections     []string  `json:"sections"`                 // In walking order
+	LastCheckOff *CheckOff `json:"last_check_off,omitempty"` // Read-only; nil before the first check-off
+	CreatedAt    time.Time `json:"created_at,omitempty"`     // omitempty for POST
+}
+
+// CheckOff is an item ticked off in a store, remembered so the next one can be placed
+// after it.
+type CheckOff struct {
+	Name    string    `json:"name"`
+	Section string    `json:"section,omitempty"` // Empty if the item had no section
+	At      time.Time `json:"at"`
+}
+
+// SectionMapping puts items with a given name (ignoring case) into a section of a store.
+// Learned mappings come from the order items are checked off in and keep being corrected;
+// mappings set through the API are never changed by learning.
+type SectionMapping struct {
+	Name      string    `json:"name"`
+	Section   string    `json:"section"`
+	Learned   bool      `json:"learned"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// Store layout limits, checked by every LayoutStore and the database.
+const (
+	MaxStoreSections = 100
+	MaxSectionLength = 100
+)
+
+// checkOffTripGap is the longest pause between two check-offs of the same shopping trip.
+// Learning only relates an item to the one checked off before it in the same trip.
+const checkOffTripGap = 30 * time.Minute
+
+var (
+	// ErrStoreNotFound is returned by a LayoutStore when the requested store does not exist.
+	ErrStoreNotFound = errors.New("store not found")
+	// ErrMappingNotFound is returned by a LayoutStore when a name has no section in a store.
+	ErrMappingNotFound = errors.New("section mapping not found")
+)
+
+// LayoutStore persists stores, their sections and which items are found in which section.
+// The built-in stores implement it next to ItemStore.
+type LayoutStore interface {
+	// ListStores returns all stores ordered by name.
+	ListStores(ctx context.Context) ([]StoreLayout, error)
+	// GetStore returns the store with the given ID, or ErrStoreNotFound.
+	GetStore(ctx context.Context, id int) (StoreLayout, error)
+	// CreateStore validates and stores a new store, returning it with ID and CreatedAt set.
+	CreateStore(ctx context.Context, store StoreLayout) (StoreLayout, error)
+	// UpdateStore replaces the name and sections of an existing store, or returns
+	// ErrStoreNotFound. Mappings to sections that no longer exist are kept but ignored.
+	UpdateStore(ctx context.Context, store StoreLayout) (StoreLayout, error)
+	// DeleteStore removes the store with the given ID and its mappings, or returns
+	// ErrStoreNotFound.
+	DeleteStore(ctx context.Context, id int) error
+	// ListMappings returns the mappings of a store ordered by name, or ErrStoreNotFound.
+	ListMappings(ctx context.Context, storeID int) ([]SectionMapping, error)
+	// GetMapping returns the mapping for name (ignoring case) in a store, or
+	// ErrMappingNotFound.
+	GetMapping(ctx context.Context, storeID int, name string) (SectionMapping, error)
+	// SetMapping stores mapping for its name in a store, replacing any earlier one, and
+	// returns it with UpdatedAt set, or ErrStoreNotFound.
+	SetMapping(ctx context.Context, storeID int, mapping SectionMapping) (SectionMapping, error)
+	// DeleteMapping removes the mapping for name (ignoring case) from a store, or returns
+	// ErrMappingNotFound.
+	DeleteMapping(ctx context.Context, storeID int, name string) error
+	// RecordCheckOff remembers checkOff as the store's last check-off, or returns
+	// ErrStoreNotFound.
+	RecordCheckOff(ctx context.Context, storeID int, checkOff CheckOff) error
+}
+
+// normalizeStore normalizes the name and sections of store like item fields, returning a
+// *ValidationError listing every invalid field. Section fields are named like
+// "sections[0]", and sections must be unique ignoring case.
+func normalizeStore(store StoreLayout) (StoreLayout, error) {
+	var fields []FieldError
+	name, msg := normalizeText(store.Name, MaxNameLength)
+	if msg != "" {
+		fields = append(fields, FieldError{Field: "name", Message: msg})
+	}
+	switch n := len(store.Sections); {
+	case n == 0:
+		fields = append(fields, FieldError{Field: "sections", Message: "cannot be empty"})
+	case n > MaxStoreSections:
+		fields = append(fields, FieldError{Field: "sections", Message: fmt.Sprintf("must have at most %d entries", MaxStoreSections)})
+	}
+	// Copy so the caller's slice is never modified
+	sections := make([]string, len(store.Sections))
+	seen := make(map[string]bool, len(store.Sections))
+	for i, section := range store.Sections {
+		field := "sections[" + strconv.Itoa(i) + "]"
+		section, msg := normalizeText(section, MaxSectionLength)
+		switch key := strings.ToLower(section); {
+		case msg != "":
+			fields = append(fields, FieldError{Field: field, Message: msg})
+		case seen[key]:
+			fields = append(fields, FieldError{Field: field, Message: "is listed twice"})
+		default:
+			seen[key] = true
+		}
+		sections[i] = section
+	}
+
+	if len(fields) > 0 {
+		return StoreLayout{}, &ValidationError{Resource: "store", Fields: fields}
+	}
+	store.Name, store.Sections = name, sections
+	return store, nil
+}
+
+// normalizeMapping normalizes the name and section of mapping, returning a
+// *ValidationError listing every invalid field. Whether the section exists in the store is
+// checked by the handler.
+func normalizeMapping(mapping SectionMapping, limits FieldLimits) (SectionMapping, error) {
+	limits = limits.withDefaults()
+	var fields []FieldError
+	name, msg := normalizeText(mapping.Name, limits.MaxName)
+	if msg != "" {
+		fields = append(fields, FieldError{Field: "name", Message: msg})
+	}
+	section, msg := normalizeText(mapping.Section, MaxSectionLength)
+	if msg != "" {
+		fields = append(fields, FieldError{Field: "section", Message: msg})
+	}
+	if len(fields) > 0 {
+		return SectionMapping{}, &ValidationError{Resource: "mapping", Fields: fields}
+	}
+	mapping.Name, mapping.Section = name, section
+	return mapping, nil
+}
+
+// appendStoreRow adds one row of a stores-joined-with-sections query, ordered by store,
+// to stores. section is nil for a store without sections.
+func appendStoreRow(stores []StoreLayout, s StoreLayout, section *string) []StoreLayout {
+	if n := len(stores); n == 0 || stores[n-1].ID != s.ID {
+		s.Sections = []string{}
+		stores = append(stores, s)
+	}
+	if section != nil {
+		last := &stores[len(stores)-1]
+		last.Sections = append(last.Sections, *section)
+	}
+	return stores
+}
+
+// sortStores orders stores like the SQL stores' "ORDER BY lower(name), id".
+func sortStores(stores []StoreLayout) {
+	sort.Slice(stores, func(i, j int) bool {
+		if a, b := strings.ToLower(stores[i].Name), strings.ToLower(stores[j].Name); a != b {
+			return a < b
+		}
+		return stores[i].ID < stores[j].ID
+	})
+}
+
+// sortMappings orders mappings like the SQL stores' "ORDER BY lower(name)".
+func sortMappings(mappings []SectionMapping) {
+	sort.Slice(mappings, func(i, j int) bool {
+		return strings.ToLower(mappings[i].Name) < strings.ToLower(mappings[j].Name)
+	})
+}
+
+// sectionIndex maps each section of store (lower-cased) to its position in walking order.
+func sectionIndex(store StoreLayout) map[string]int {
+	index := make(map[string]int, len(store.Sections))
+	for i, section := range store.Sections {
+		index[strings.ToLower(section)] = i
+	}
+	return index
+}
+
+// sortWalkingOrder stably sorts items by the section their name is mapped to in store.
+// Items without a mapping, or mapped to a section the store no longer has, go last;
+// within a section the list keeps its order.
+func sortWalkingOrder(items []Item, store StoreLayout, mappings []SectionMapping) {
+	index := sectionIndex(store)
+	position := make(map[string]int, len(mappings))
+	for _, m := range mappings {
+		if i, ok := index[strings.ToLower(m.Section)]; ok {
+			position[strings.ToLower(m.Name)] = i
+		}
+	}
+	key := func(item Item) int {
+		if i, ok := position[strings.ToLower(item.Name)]; ok {
+			return i
+		}
+		return len(store.Sections)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return key(items[i]) < key(items[j]) })
+}
+
+// --- Learning from Check-offs ---
+
+// learnCheckOff records that the item called name was checked off in store at now, and
+// learns its section from the item checked off just before it in the same trip: an item
+// without a mapping joins that item's section, and a learned mapping moves forward when
+// the item was picked up later in the walk than its section says. Mappings set through
+// the API are left alone.
+func (s *Server) learnCheckOff(ctx context.Context, store StoreLayout, name string, now time.Time) error {
+	index := sectionIndex(store)
+	mapping, err := s.layouts.GetMapping(ctx, store.ID, name)
+	if err != nil && !errors.Is(err, ErrMappingNotFound) {
+		return err
+	}
+	found := err == nil
+	current, mapped := index[strings.ToLower(mapping.Section)]
+	section := ""
+	if found && mapped {
+		section = store.Sections[current]
+	}
+
+	if prev := store.LastCheckOff; prev != nil && now.Sub(prev.At) <= checkOffTripGap && (!found || mapping.Learned) {
+		if p, ok := index[strings.ToLower(prev.Section)]; ok && (!found || !mapped || p > current) {
+			section = store.Sections[p]
+			if _, err := s.layouts.SetMapping(ctx, store.ID, SectionMapping{Name: name, Section: section, Learned: true}); err != nil {
+				return err
+			}
+		}
+	}
+	return s.layouts.RecordCheckOff(ctx, store.ID, CheckOff{Name: name, Section: section, At: now})
+}
+
+// storeFromQuery returns the store named by the "store" query parameter, or nil if there
+// is none. On an invalid or unknown store the problem has been written and ok is false.
+func (s *Server) storeFromQuery(w http.ResponseWriter, r *http.Request) (store *StoreLayout, ok bool) {
+	v := r.URL.Query().Get("store")
+	if v == "" {
+		return nil, true
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id <= 0 || s.layouts == nil {
+		msg := "must be the ID of a store"
+		if s.layouts == nil {
+			msg = "is not supported by this backend"
+		}
+		s.writeQueryProblem(w, r, FieldError{Field: "store", Message: msg})
+		return nil, false
+	}
+	layout, err := s.layouts.GetStore(r.Context(), id)
+	if err != nil {
+		s.requestLogger(r).Warn("Error getting store", "id", id, "err", err)
+		s.writeError(w, r, err)
+		return nil, false
+	}
+	return &layout, true
+}
+
+// checkedOff learns from an item checked off in store, if the request named one. Learning
+// is a convenience, so failures are only logged.
+func (s *Server) checkedOff(r *http.Request, store *StoreLayout, name string) {
+	if store == nil {
+		return
+	}
+	if err := s.learnCheckOff(r.Context(), *store, name, s.now()); err != nil {
+		s.requestLogger(r).Error("Error learning from check-off", "store", store.ID, "name", name, "err", err)
+	}
+}
+
+// --- Store Handlers ---
+
+func (s *Server) storesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		stores, err := s.layouts.ListStores(r.Context())
+		if err != nil {
+			s.requestLogger(r).Error("Error listing stores", "err", err)
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, r, http.StatusOK, stores)
+	case http.MethodPost:
+		var store StoreLayout
+		if !s.decodeJSON(w, r, &store) {
+			return
+		}
+		store, err := normalizeStore(store)
+		if err == nil {
+			store, err = s.layouts.CreateStore(r.Context(), store)
+		}
+		if err != nil {
+			s.requestLogger(r).Warn("Error adding store", "err", err)
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, r, http.StatusCreated, store)
+	default:
+		s.methodNotAllowed(w, r, "GET, POST")
+	}
+}
+
+// storeDetailHandler serves /stores/{id} and /stores/{id}/mappings.
+func (s *Server) storeDetailHandler(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/stores/"), "/")
+	idStr, action, _ := strings.Cut(rest, "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		s.writeStatus(w, r, http.StatusBadRequest, "Invalid store ID format")
+		return
+	}
+
+	switch action {
+	case "":
+	case "mappings":
+		s.mappingsHandler(w, r, id)
+		return
+	default:
+		s.writeStatus(w, r, http.StatusNotFound, "No such endpoint")
+		return
+	}
+
+	var store StoreLayout
+	switch r.Method {
+	case http.MethodGet:
+		store, err = s.layouts.GetStore(r.Context(), id)
+	case http.MethodPut:
+		if !s.decodeJSON(w, r, &store) {
+			return
+		}
+		store.ID = id // The path wins over any ID in the body
+		if store, err = normalizeStore(store); err == nil {
+			store, err = s.layouts.UpdateStore(r.Context(), store)
+		}
+	case http.MethodDelete:
+		if err = s.layouts.DeleteStore(r.Context(), id); err == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		s.methodNotAllowed(w, r, "GET, PUT, DELETE")
+		return
+	}
+	if err != nil {
+		s.requestLogger(r).Warn("Error handling store", "id", id, "method", r.Method, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, store)
+}
+
+// mappingsHandler lists a store's mappings, sets one (PUT with a name and section) or
+// removes one (DELETE with ?name=).
+func (s *Server) mappingsHandler(w http.ResponseWriter, r *http.Request, storeID int) {
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		var mappings []SectionMapping
+		if mappings, err = s.layouts.ListMappings(r.Context(), storeID); err == nil {
+			s.writeJSON(w, r, http.StatusOK, mappings)
+			return
+		}
+	case http.MethodPut:
+		var mapping SectionMapping
+		if !s.decodeJSON(w, r, &mapping) {
+			return
+		}
+		if mapping, err = s.setMapping(r.Context(), storeID, mapping); err == nil {
+			s.writeJSON(w, r, http.StatusOK, mapping)
+			return
+		}
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			s.writeStatus(w, r, http.StatusBadRequest, "Missing name query parameter")
+			return
+		}
+		if err = s.layouts.DeleteMapping(r.Context(), storeID, name); err == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		s.methodNotAllowed(w, r, "GET, PUT, DELETE")
+		return
+	}
+	s.requestLogger(r).Warn("Error handling mapping", "store", storeID, "method", r.Method, "err", err)
+	s.writeError(w, r, err)
+}
+
+// setMapping checks that mapping names one of the store's sections, spelled as the store
+// spells it, and stores it as set by hand.
+func (s *Server) setMapping(ctx context.Context, storeID int, mapping SectionMapping) (SectionMapping, error) {
+	mapping, err := normalizeMapping(mapping, s.limits)
+	if err != nil {
+		return SectionMapping{}, err
+	}
+	store, err := s.layouts.GetStore(ctx, storeID)
+	if err != nil {
+		return SectionMapping{}, err
+	}
+	i, ok := sectionIndex(store)[strings.ToLower(mapping.Section)]
+	if !ok {
+		return SectionMapping{}, &ValidationError{Resource: "mapping", Fields: []FieldError{{Field: "section", Message: "must be one of the store's sections"}}}
+	}
+	mapping.Section, mapping.Learned = store.Sections[i], false
+	return s.layouts.SetMapping(ctx, storeID, mapping)
+}
@@ -0,0 +1,251 @@
+package shoppinglist
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSortWalkingOrder(t *testing.T) {
+	layout := StoreLayout{Sections: []string{"Produce", "Bakery", "Dairy"}}
+	items := []Item{{Name: "Milk"}, {Name: "Batteries"}, {Name: "apples"}, {Name: "Bread"}, {Name: "Pears"}, {Name: "Glue"}}
+	mappings := []SectionMapping{
+		{Name: "milk", Section: "Dairy"},
+		{Name: "Apples", Section: "produce"},
+		{Name: "Bread", Section: "Bakery"},
+		{Name: "Pears", Section: "Produce"},
+		{Name: "Glue", Section: "Hardware"}, // No longer in the layout
+	}
+
+	sortWalkingOrder(items, layout, mappings)
+	want := []string{"apples", "Pears", "Bread", "Milk", "Batteries", "Glue"}
+	for i, item := range items {
+		if item.Name != want[i] {
+			t.Fatalf("Expected walking order %v, got %+v", want, items)
+		}
+	}
+}
+
+func TestStoreHandlers(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+
+	rr := serve(srv, "POST", "/stores", `{"name":"Corner Shop","sections":["Produce","Bakery","Dairy"]}`)
+	var shop StoreLayout
+	if err := json.Unmarshal(rr.Body.Bytes(), &shop); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	path := "/stores/" + jsonNumber(shop.ID)
+
+	t.Run("Validation", func(t *testing.T) {
+		rr := serve(srv, "POST", "/stores", `{"name":"","sections":["Dairy","dairy"]}`)
+		if p := decodeProblem(t, rr); rr.Code != http.StatusBadRequest || p.Title != "Invalid store" || len(p.Errors) != 2 {
+			t.Errorf("Expected name and duplicate section errors, got %d %+v", rr.Code, p)
+		}
+		rr = serve(srv, "PUT", path+"/mappings", `{"name":"Milk","section":"Hardware"}`)
+		if p := decodeProblem(t, rr); rr.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "section" {
+			t.Errorf("Expected a section error for a section the store lacks, got %d %+v", rr.Code, p)
+		}
+		if rr := serve(srv, "GET", "/stores/abc", ""); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for a bad ID, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if rr := serve(srv, "GET", "/stores/999", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for a missing store, got %d", http.StatusNotFound, rr.Code)
+		}
+		if rr := serve(srv, "GET", "/items?store=999", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for items in a missing store, got %d", http.StatusNotFound, rr.Code)
+		}
+		rr = serve(srv, "GET", "/items?store=x", "")
+		if p := decodeProblem(t, rr); rr.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "store" {
+			t.Errorf("Expected a store parameter error, got %d %+v", rr.Code, p)
+		}
+	})
+
+	t.Run("WalkingOrder", func(t *testing.T) {
+		for _, body := range []string{
+			`{"name":"Milk","quantity":"1 l"}`,
+			`{"name":"Batteries","quantity":"4"}`,
+			`{"name":"Bread","quantity":"1"}`,
+			`{"name":"Apples","quantity":"1 kg"}`,
+		} {
+			if rr := serve(srv, "POST", "/items", body); rr.Code != http.StatusCreated {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+			}
+		}
+		for _, body := range []string{
+			`{"name":"milk","section":"dairy"}`,
+			`{"name":"Bread","section":"Bakery"}`,
+			`{"name":"Apples","section":"Produce"}`,
+		} {
+			if rr := serve(srv, "PUT", path+"/mappings", body); rr.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+			}
+		}
+
+		rr := serve(srv, "GET", "/items?store="+jsonNumber(shop.ID), "")
+		var items []Item
+		if err := json.Unmarshal(rr.Body.Bytes(), &items); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		want := []string{"Apples", "Bread", "Milk", "Batteries"}
+		for i, item := range items {
+			if i >= len(want) || item.Name != want[i] {
+				t.Fatalf("Expected walking order %v, got %+v", want, items)
+			}
+		}
+
+		rr = serve(srv, "GET", path+"/mappings", "")
+		var mappings []SectionMapping
+		if err := json.Unmarshal(rr.Body.Bytes(), &mappings); err != nil || len(mappings) != 3 || mappings[2].Section != "Dairy" || mappings[2].Learned {
+			t.Errorf("Expected explicit mappings spelled like the store's sections, got %s", rr.Body.String())
+		}
+
+		// The estimate envelope is sorted the same way
+		rr = serve(srv, "GET", "/items?include=estimate&store="+jsonNumber(shop.ID), "")
+		var got ItemsWithEstimate
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || len(got.Items) != 4 || got.Items[0].Name != "Apples" {
+			t.Errorf("Expected sorted items with an estimate, got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("DeleteMapping", func(t *testing.T) {
+		if rr := serve(srv, "DELETE", path+"/mappings?name=APPLES", ""); rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+		}
+		rr := serve(srv, "DELETE", path+"/mappings?name=Apples", "")
+		if p := decodeProblem(t, rr); rr.Code != http.StatusNotFound || p.Title != "Mapping not found" {
+			t.Errorf("Expected a mapping 404, got %d %+v", rr.Code, p)
+		}
+		if rr := serve(srv, "DELETE", path+"/mappings", ""); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d without a name, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		rr := serve(srv, "PUT", path, `{"name":"Corner Shop","sections":["Dairy","Bakery"]}`)
+		var updated StoreLayout
+		if err := json.Unmarshal(rr.Body.Bytes(), &updated); err != nil || rr.Code != http.StatusOK || len(updated.Sections) != 2 {
+			t.Fatalf("Expected the updated store, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if rr := serve(srv, "PATCH", path, ""); rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+		}
+		if rr := serve(srv, "DELETE", path, ""); rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+		}
+		rr = serve(srv, "GET", path+"/mappings", "")
+		if p := decodeProblem(t, rr); rr.Code != http.StatusNotFound || p.Title != "Store not found" {
+			t.Errorf("Expected a store 404, got %d %+v", rr.Code, p)
+		}
+	})
+}
+
+func TestLearnFromCheckOffs(t *testing.T) {
+	store := NewMemoryStore()
+	srv := newTestServer(t, store)
+	now := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	srv.now = func() time.Time { return now }
+
+	rr := serve(srv, "POST", "/stores", `{"name":"Shop","sections":["Produce","Bakery","Dairy"]}`)
+	var shop StoreLayout
+	if err := json.Unmarshal(rr.Body.Bytes(), &shop); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	query := "?store=" + jsonNumber(shop.ID)
+	serve(srv, "PUT", "/stores/"+jsonNumber(shop.ID)+"/mappings", `{"name":"Bread","section":"Bakery"}`)
+
+	add := func(name string) int {
+		t.Helper()
+		rr := serve(srv, "POST", "/items", `{"name":"`+name+`","quantity":"1"}`)
+		var item Item
+		if err := json.Unmarshal(rr.Body.Bytes(), &item); err != nil || rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+		return item.ID
+	}
+	checkOff := func(id int) {
+		t.Helper()
+		if rr := serve(srv, "DELETE", "/items/"+jsonNumber(id)+query, ""); rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+		}
+		now = now.Add(2 * time.Minute)
+	}
+	section := func(name string) (string, bool) {
+		t.Helper()
+		m, err := store.GetMapping(t.Context(), shop.ID, name)
+		if err != nil {
+			return "", false
+		}
+		return m.Section, m.Learned
+	}
+
+	// Bread is known to be in the bakery; the rolls picked up right after join it
+	checkOff(add("Bread"))
+	checkOff(add("Rolls"))
+	if got, learned := section("Rolls"); got != "Bakery" || !learned {
+		t.Errorf("Expected Rolls to be learned in the Bakery, got %q (learned %v)", got, learned)
+	}
+
+	// A learned section moves forward when the item turns up later in the walk...
+	if _, err := store.SetMapping(t.Context(), shop.ID, SectionMapping{Name: "Milk", Section: "Dairy"}); err != nil {
+		t.Fatalf("SetMapping failed: %v", err)
+	}
+	if _, err := store.SetMapping(t.Context(), shop.ID, SectionMapping{Name: "Cream", Section: "Produce", Learned: true}); err != nil {
+		t.Fatalf("SetMapping failed: %v", err)
+	}
+	checkOff(add("Milk"))
+	checkOff(add("Cream"))
+	if got, _ := section("Cream"); got != "Dairy" {
+		t.Errorf("Expected Cream to move to Dairy, got %q", got)
+	}
+	// ...but never backwards, and explicit mappings never change
+	checkOff(add("Bread"))
+	checkOff(add("Cream"))
+	checkOff(add("Milk"))
+	if got, _ := section("Cream"); got != "Dairy" {
+		t.Errorf("Expected Cream to stay in Dairy, got %q", got)
+	}
+	serve(srv, "PUT", "/stores/"+jsonNumber(shop.ID)+"/mappings", `{"name":"Bread","section":"Bakery"}`)
+	checkOff(add("Bread"))
+	if got, learned := section("Bread"); got != "Bakery" || learned {
+		t.Errorf("Expected the explicit Bread mapping to stay, got %q (learned %v)", got, learned)
+	}
+
+	// After a long pause the next check-off starts a new trip and learns nothing
+	now = now.Add(3 * time.Hour)
+	checkOff(add("Batteries"))
+	if _, ok := section("Batteries"); ok {
+		t.Error("Expected no mapping for an item checked off after a long pause")
+	}
+
+	// Buying works the same way, and an unknown store is rejected before anything changes
+	id := add("Yogurt")
+	serve(srv, "POST", "/items/"+jsonNumber(add("Butter"))+"/bought"+query, "") // Unmapped, so nothing to learn from
+	serve(srv, "POST", "/items/"+jsonNumber(add("Cheese"))+"/bought"+query, "")
+	if _, ok := section("Cheese"); ok {
+		t.Error("Expected no mapping after an unmapped item")
+	}
+	if rr := serve(srv, "DELETE", "/items/"+jsonNumber(id)+"?store=999", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown store, got %d", http.StatusNotFound, rr.Code)
+	}
+	if _, err := store.Get(t.Context(), id); err != nil {
+		t.Errorf("Expected the item to survive a rejected check-off, got %v", err)
+	}
+	serve(srv, "PUT", "/stores/"+jsonNumber(shop.ID)+"/mappings", `{"name":"Milk","section":"Dairy"}`)
+	checkOff(add("Milk"))
+	serve(srv, "POST", "/items/"+jsonNumber(id)+"/bought"+query, "")
+	if got, learned := section("Yogurt"); got != "Dairy" || !learned {
+		t.Errorf("Expected Yogurt bought after Milk to be learned in Dairy, got %q (learned %v)", got, learned)
+	}
+}
+
+func TestStoresNotServedWithoutLayoutStore(t *testing.T) {
+	srv := newTestServer(t, struct{ ItemStore }{NewMemoryStore()})
+	if rr := serve(srv, "GET", "/stores", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+	rr := serve(srv, "GET", "/items?store=1", "")
+	if p := decodeProblem(t, rr); rr.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "store" {
+		t.Errorf("Expected a store parameter error, got %d %+v", rr.Code, p)
+	}
+}
@@ -0,0 +1,128 @@
+package shoppinglist
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newLoggedServer returns a Server over an in-memory store whose logs are captured as JSON lines.
+func newLoggedServer(t *testing.T) (*Server, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	srv, err := New(Options{Store: NewMemoryStore(), Logger: slog.New(slog.NewJSONHandler(&buf, nil))})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return srv, &buf
+}
+
+// logLines decodes captured JSON log output, one map per line.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("Invalid JSON log line %q: %v", line, err)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	srv, _ := newLoggedServer(t)
+
+	t.Run("Generated", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+		if id := rr.Header().Get(RequestIDHeader); len(id) != 32 {
+			t.Errorf("Expected a generated 32-character request ID, got %q", id)
+		}
+	})
+
+	t.Run("Propagated", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/items", nil)
+		req.Header.Set(RequestIDHeader, "nginx-abc-123")
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		if id := rr.Header().Get(RequestIDHeader); id != "nginx-abc-123" {
+			t.Errorf("Expected propagated request ID, got %q", id)
+		}
+	})
+
+	t.Run("InvalidReplaced", func(t *testing.T) {
+		for _, bad := range []string{"has space", "new\nline", strings.Repeat("a", maxRequestIDLength+1)} {
+			req := httptest.NewRequest("GET", "/items", nil)
+			req.Header.Set(RequestIDHeader, bad)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, req)
+			if id := rr.Header().Get(RequestIDHeader); id == bad || id == "" {
+				t.Errorf("Expected invalid ID %q to be replaced, got %q", bad, id)
+			}
+		}
+	})
+}
+
+func TestAccessLogAndRequestScopedLogging(t *testing.T) {
+	srv, buf := newLoggedServer(t)
+
+	req := httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"Milk","quantity":"1"}`))
+	req.Header.Set(RequestIDHeader, "req-42")
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+
+	var storeLine, accessLine map[string]any
+	for _, line := range logLines(t, buf) {
+		switch line["msg"] {
+		case "Added item":
+			storeLine = line
+		case "request":
+			accessLine = line
+		}
+	}
+
+	// The store logged through the context, so its line carries the request ID
+	if storeLine == nil || storeLine["request_id"] != "req-42" {
+		t.Errorf("Expected store log line with request_id req-42, got %v", storeLine)
+	}
+	if accessLine == nil {
+		t.Fatal("Expected an access log line")
+	}
+	want := map[string]any{"request_id": "req-42", "method": "POST", "route": "/items", "status": float64(201)}
+	for k, v := range want {
+		if accessLine[k] != v {
+			t.Errorf("Access log %s: expected %v, got %v", k, v, accessLine[k])
+		}
+	}
+	if accessLine["bytes"].(float64) != float64(rr.Body.Len()) {
+		t.Errorf("Access log bytes: expected %d, got %v", rr.Body.Len(), accessLine["bytes"])
+	}
+	if _, ok := accessLine["duration_ms"]; !ok {
+		t.Error("Access log is missing duration_ms")
+	}
+}
+
+func TestAccessLogRoutePattern(t *testing.T) {
+	srv, buf := newLoggedServer(t)
+
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest("DELETE", "/items/99", nil))
+
+	lines := logLines(t, buf)
+	last := lines[len(lines)-1]
+	if last["route"] != "/items/" || last["path"] != "/items/99" || last["status"] != float64(http.StatusNotFound) {
+		t.Errorf("Unexpected access log line: %v", last)
+	}
+}
@@ -0,0 +1,584 @@
+package shoppinglist
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// --- Prometheus Metrics ---
+
+// metricsNamespace prefixes every metric exported by the package.
+const metricsNamespace = "shoppinglist"
+
+// metrics holds the collectors a Server records into.
+type metrics struct {
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	queryErrors     *prometheus.CounterVec
+	rateLimited     *prometheus.CounterVec
+}
+
+// newMetrics creates the collectors and registers them, together with the pool and
+// domain collectors, on reg. pool may be nil (non-Postgres stores).
+func newMetrics(reg prometheus.Registerer, store ItemStore, pool DBPool) (*metrics, error) {
+	m := &metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency, by method and route pattern.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Storage operation latency, by operation (getItems, addItem, deleteItem, ...).",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_query_errors_total",
+			Help:      "Storage operations that failed with an unexpected error (not found and validation errors are excluded).",
+		}, []string{"operation"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_rate_limited_total",
+			Help:      "Requests rejected with 429 Too Many Requests, by route class (read, write).",
+		}, []string{"class"}),
+	}
+
+	cs := []prometheus.Collector{m.requests, m.requestDuration, m.queryDuration, m.queryErrors, m.rateLimited, newItemCollector(store)}
+	if stater, ok := pool.(poolStater); ok {
+		cs = append(cs, newPoolCollector(stater))
+	}
+	for _, c := range cs {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// newDefaultRegistry returns a registry with the standard Go runtime and process collectors.
+func newDefaultRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return reg
+}
+
+// observeRequest records one HTTP request.
+func (m *metrics) observeRequest(method, route string, status int, elapsed time.Duration) {
+	if route == "" {
+		route = "unmatched" // Keep label cardinality bounded for unknown paths
+	}
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+// --- Instrumented ItemStore ---
+
+// instrumentedStore wraps an ItemStore, records the duration of every operation and
+// starts a span for it, so queries traced by pgx nest under the operation.
+// Operation names keep the names of the original handler-level DB functions.
+type instrumentedStore struct {
+	ItemStore
+	metrics *metrics
+	tracer  trace.Tracer
+}
+
+// start begins one operation; the returned function records its outcome.
+func (s *instrumentedStore) start(ctx context.Context, operation string) (context.Context, func(error)) {
+	begin := time.Now()
+	ctx, span := s.tracer.Start(ctx, operation, trace.WithAttributes(attribute.String("db.operation.name", operation)))
+	return ctx, func(err error) {
+		s.metrics.queryDuration.WithLabelValues(operation).Observe(time.Since(begin).Seconds())
+		if err != nil && !isClientError(err) {
+			s.metrics.queryErrors.WithLabelValues(operation).Inc()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+func (s *instrumentedStore) List(ctx context.Context) (items []Item, err error) {
+	ctx, done := s.start(ctx, "getItems")
+	defer func() { done(err) }()
+	return s.ItemStore.List(ctx)
+}
+
+func (s *instrumentedStore) Get(ctx context.Context, id int) (item Item, err error) {
+	ctx, done := s.start(ctx, "getItem")
+	defer func() { done(err) }()
+	return s.ItemStore.Get(ctx, id)
+}
+
+func (s *instrumentedStore) Create(ctx context.Context, newItem Item) (item Item, err error) {
+	ctx, done := s.start(ctx, "addItem")
+	defer func() { done(err) }()
+	return s.ItemStore.Create(ctx, newItem)
+}
+
+func (s *instrumentedStore) Update(ctx context.Context, changed Item) (item Item, err error) {
+	ctx, done := s.start(ctx, "updateItem")
+	defer func() { done(err) }()
+	return s.ItemStore.Update(ctx, changed)
+}
+
+func (s *instrumentedStore) Delete(ctx context.Context, id int) (err error) {
+	ctx, done := s.start(ctx, "deleteItem")
+	defer func() { done(err) }()
+	return s.ItemStore.Delete(ctx, id)
+}
+
+// instrumentedRecipeStore does the same for a RecipeStore.
+type instrumentedRecipeStore struct {
+	RecipeStore
+	metrics *metrics
+	tracer  trace.Tracer
+}
+
+func (s *instrumentedRecipeStore) start(ctx context.Context, operation string) (context.Context, func(error)) {
+	return (&instrumentedStore{metrics: s.metrics, tracer: s.tracer}).start(ctx, operation)
+}
+
+func (s *instrumentedRecipeStore) ListRecipes(ctx context.Context) (recipes []Recipe, err error) {
+	ctx, done := s.start(ctx, "getRecipes")
+	defer func() { done(err) }()
+	return s.RecipeStore.ListRecipes(ctx)
+}
+
+func (s *instrumentedRecipeStore) GetRecipe(ctx context.Context, id int) (recipe Recipe, err error) {
+	ctx, done := s.start(ctx, "getRecipe")
+	defer func() { done(err) }()
+	return s.RecipeStore.GetRecipe(ctx, id)
+}
+
+func (s *instrumentedRecipeStore) CreateRecipe(ctx context.Context, newRecipe Recipe) (recipe Recipe, err error) {
+	ctx, done := s.start(ctx, "addRecipe")
+	defer func() { done(err) }()
+	return s.RecipeStore.CreateRecipe(ctx, newRecipe)
+}
+
+func (s *instrumentedRecipeStore) UpdateRecipe(ctx context.Context, changed Recipe) (recipe Recipe, err error) {
+	ctx, done := s.start(ctx, "updateRecipe")
+	defer func() { done(err) }()
+	return s.RecipeStore.UpdateRecipe(ctx, changed)
+}
+
+func (s *instrumentedRecipeStore) DeleteRecipe(ctx context.Context, id int) (err error) {
+	ctx, done := s.start(ctx, "deleteRecipe")
+	defer func() { done(err) }()
+	return s.RecipeStore.DeleteRecipe(ctx, id)
+}
+
+// instrumentedPantryStore does the same for a PantryStore.
+type instrumentedPantryStore struct {
+	PantryStore
+	metrics *metrics
+	tracer  trace.Tracer
+}
+
+func (s *instrumentedPantryStore) start(ctx context.Context, operation string) (context.Context, func(error)) {
+	return (&instrumentedStore{metrics: s.metrics, tracer: s.tracer}).start(ctx, operation)
+}
+
+func (s *instrumentedPantryStore) ListPantry(ctx context.Context) (items []PantryItem, err error) {
+	ctx, done := s.start(ctx, "getPantry")
+	defer func() { done(err) }()
+	return s.PantryStore.ListPantry(ctx)
+}
+
+func (s *instrumentedPantryStore) GetPantryItem(ctx context.Context, id int) (item PantryItem, err error) {
+	ctx, done := s.start(ctx, "getPantryItem")
+	defer func() { done(err) }()
+	return s.PantryStore.GetPantryItem(ctx, id)
+}
+
+func (s *instrumentedPantryStore) CreatePantryItem(ctx context.Context, newItem PantryItem) (item PantryItem, err error) {
+	ctx, done := s.start(ctx, "addPantryItem")
+	defer func() { done(err) }()
+	return s.PantryStore.CreatePantryItem(ctx, newItem)
+}
+
+func (s *instrumentedPantryStore) UpdatePantryItem(ctx context.Context, changed PantryItem) (item PantryItem, err error) {
+	ctx, done := s.start(ctx, "updatePantryItem")
+	defer func() { done(err) }()
+	return s.PantryStore.UpdatePantryItem(ctx, changed)
+}
+
+func (s *instrumentedPantryStore) DeletePantryItem(ctx context.Context, id int) (err error) {
+	ctx, done := s.start(ctx, "deletePantryItem")
+	defer func() { done(err) }()
+	return s.PantryStore.DeletePantryItem(ctx, id)
+}
+
+func (s *instrumentedPantryStore) ConsumeStock(ctx context.Context, id int, amount float64) (item PantryItem, err error) {
+	ctx, done := s.start(ctx, "consumeStock")
+	defer func() { done(err) }()
+	return s.PantryStore.ConsumeStock(ctx, id, amount)
+}
+
+func (s *instrumentedPantryStore) MovePurchased(ctx context.Context, itemID int, stock PantryItem) (item PantryItem, err error) {
+	ctx, done := s.start(ctx, "movePurchased")
+	defer func() { done(err) }()
+	return s.PantryStore.MovePurchased(ctx, itemID, stock)
+}
+
+type instrumentedRecurringStore struct {
+	RecurringStore
+	metrics *metrics
+	tracer  trace.Tracer
+}
+
+func (s *instrumentedRecurringStore) start(ctx context.Context, operation string) (context.Context, func(error)) {
+	return (&instrumentedStore{metrics: s.metrics, tracer: s.tracer}).start(ctx, operation)
+}
+
+func (s *instrumentedRecurringStore) ListRecurring(ctx context.Context) (items []RecurringItem, err error) {
+	ctx, done := s.start(ctx, "getRecurring")
+	defer func() { done(err) }()
+	return s.RecurringStore.ListRecurring(ctx)
+}
+
+func (s *instrumentedRecurringStore) GetRecurring(ctx context.Context, id int) (item RecurringItem, err error) {
+	ctx, done := s.start(ctx, "getRecurringItem")
+	defer func() { done(err) }()
+	return s.RecurringStore.GetRecurring(ctx, id)
+}
+
+func (s *instrumentedRecurringStore) CreateRecurring(ctx context.Context, newItem RecurringItem) (item RecurringItem, err error) {
+	ctx, done := s.start(ctx, "addRecurringItem")
+	defer func() { done(err) }()
+	return s.RecurringStore.CreateRecurring(ctx, newItem)
+}
+
+func (s *instrumentedRecurringStore) UpdateRecurring(ctx context.Context, changed RecurringItem) (item RecurringItem, err error) {
+	ctx, done := s.start(ctx, "updateRecurringItem")
+	defer func() { done(err) }()
+	return s.RecurringStore.UpdateRecurring(ctx, changed)
+}
+
+func (s *instrumentedRecurringStore) DeleteRecurring(ctx context.Context, id int) (err error) {
+	ctx, done := s.start(ctx, "deleteRecurringItem")
+	defer func() { done(err) }()
+	return s.RecurringStore.DeleteRecurring(ctx, id)
+}
+
+func (s *instrumentedRecurringStore) DueRecurring(ctx context.Context, now time.Time) (items []RecurringItem, err error) {
+	ctx, done := s.start(ctx, "getDueRecurring")
+	defer func() { done(err) }()
+	return s.RecurringStore.DueRecurring(ctx, now)
+}
+
+func (s *instrumentedRecurringStore) RecordRun(ctx context.Context, id int, ranAt, next time.Time) (err error) {
+	ctx, done := s.start(ctx, "recordRecurringRun")
+	defer func() { done(err) }()
+	return s.RecurringStore.RecordRun(ctx, id, ranAt, next)
+}
+
+// instrumentedPriceStore does the same for a PriceStore.
+type instrumentedPriceStore struct {
+	PriceStore
+	metrics *metrics
+	tracer  trace.Tracer
+}
+
+func (s *instrumentedPriceStore) start(ctx context.Context, operation string) (context.Context, func(error)) {
+	return (&instrumentedStore{metrics: s.metrics, tracer: s.tracer}).start(ctx, operation)
+}
+
+func (s *instrumentedPriceStore) ListPrices(ctx context.Context) (prices []Price, err error) {
+	ctx, done := s.start(ctx, "getPrices")
+	defer func() { done(err) }()
+	return s.PriceStore.ListPrices(ctx)
+}
+
+func (s *instrumentedPriceStore) LastPrice(ctx context.Context, name string) (price Price, err error) {
+	ctx, done := s.start(ctx, "getLastPrice")
+	defer func() { done(err) }()
+	return s.PriceStore.LastPrice(ctx, name)
+}
+
+func (s *instrumentedPriceStore) RecordPrice(ctx context.Context, newPrice Price) (price Price, err error) {
+	ctx, done := s.start(ctx, "recordPrice")
+	defer func() { done(err) }()
+	return s.PriceStore.RecordPrice(ctx, newPrice)
+}
+
+func (s *instrumentedPriceStore) GetBudget(ctx context.Context) (budget Money, err error) {
+	ctx, done := s.start(ctx, "getBudget")
+	defer func() { done(err) }()
+	return s.PriceStore.GetBudget(ctx)
+}
+
+func (s *instrumentedPriceStore) SetBudget(ctx context.Context, newBudget Money) (budget Money, err error) {
+	ctx, done := s.start(ctx, "setBudget")
+	defer func() { done(err) }()
+	return s.PriceStore.SetBudget(ctx, newBudget)
+}
+
+func (s *instrumentedPriceStore) DeleteBudget(ctx context.Context) (err error) {
+	ctx, done := s.start(ctx, "deleteBudget")
+	defer func() { done(err) }()
+	return s.PriceStore.DeleteBudget(ctx)
+}
+
+// instrumentedLayoutStore does the same for a LayoutStore.
+type instrumentedLayoutStore struct {
+	LayoutStore
+	metrics *metrics
+	tracer  trace.Tracer
+}
+
+func (s *instrumentedLayoutStore) start(ctx context.Context, operation string) (context.Context, func(error)) {
+	return (&instrumentedStore{metrics: s.metrics, tracer: s.tracer}).start(ctx, operation)
+}
+
+func (s *instrumentedLayoutStore) ListStores(ctx context.Context) (stores []StoreLayout, err error) {
+	ctx, done := s.start(ctx, "getStores")
+	defer func() { done(err) }()
+	return s.LayoutStore.ListStores(ctx)
+}
+
+func (s *instrumentedLayoutStore) GetStore(ctx context.Context, id int) (store StoreLayout, err error) {
+	ctx, done := s.start(ctx, "getStore")
+	defer func() { done(err) }()
+	return s.LayoutStore.GetStore(ctx, id)
+}
+
+func (s *instrumentedLayoutStore) CreateStore(ctx context.Context, newStore StoreLayout) (store StoreLayout, err error) {
+	ctx, done := s.start(ctx, "addStore")
+	defer func() { done(err) }()
+	return s.LayoutStore.CreateStore(ctx, newStore)
+}
+
+func (s *instrumentedLayoutStore) UpdateStore(ctx context.Context, changed StoreLayout) (store StoreLayout, err error) {
+	ctx, done := s.start(ctx, "updateStore")
+	defer func() { done(err) }()
+	return s.LayoutStore.UpdateStore(ctx, changed)
+}
+
+func (s *instrumentedLayoutStore) DeleteStore(ctx context.Context, id int) (err error) {
+	ctx, done := s.start(ctx, "deleteStore")
+	defer func() { done(err) }()
+	return s.LayoutStore.DeleteStore(ctx, id)
+}
+
+func (s *instrumentedLayoutStore) ListMappings(ctx context.Context, storeID int) (mappings []SectionMapping, err error) {
+	ctx, done := s.start(ctx, "getMappings")
+	defer func() { done(err) }()
+	return s.LayoutStore.ListMappings(ctx, storeID)
+}
+
+func (s *instrumentedLayoutStore) GetMapping(ctx context.Context, storeID int, name string) (mapping SectionMapping, err error) {
+	ctx, done := s.start(ctx, "getMapping")
+	defer func() { done(err) }()
+	return s.LayoutStore.GetMapping(ctx, storeID, name)
+}
+
+func (s *instrumentedLayoutStore) SetMapping(ctx context.Context, storeID int, newMapping SectionMapping) (mapping SectionMapping, err error) {
+	ctx, done := s.start(ctx, "setMapping")
+	defer func() { done(err) }()
+	return s.LayoutStore.SetMapping(ctx, storeID, newMapping)
+}
+
+func (s *instrumentedLayoutStore) DeleteMapping(ctx context.Context, storeID int, name string) (err error) {
+	ctx, done := s.start(ctx, "deleteMapping")
+	defer func() { done(err) }()
+	return s.LayoutStore.DeleteMapping(ctx, storeID, name)
+}
+
+func (s *instrumentedLayoutStore) RecordCheckOff(ctx context.Context, storeID int, checkOff CheckOff) (err error) {
+	ctx, done := s.start(ctx, "recordCheckOff")
+	defer func() { done(err) }()
+	return s.LayoutStore.RecordCheckOff(ctx, storeID, checkOff)
+}
+
+// instrumentedHistoryStore does the same for a HistoryStore.
+type instrumentedHistoryStore struct {
+	HistoryStore
+	metrics *metrics
+	tracer  trace.Tracer
+}
+
+func (s *instrumentedHistoryStore) start(ctx context.Context, operation string) (context.Context, func(error)) {
+	return (&instrumentedStore{metrics: s.metrics, tracer: s.tracer}).start(ctx, operation)
+}
+
+func (s *instrumentedHistoryStore) RecordUse(ctx context.Context, item Item, at time.Time) (err error) {
+	ctx, done := s.start(ctx, "recordUse")
+	defer func() { done(err) }()
+	return s.HistoryStore.RecordUse(ctx, item, at)
+}
+
+func (s *instrumentedHistoryStore) Suggest(ctx context.Context, prefix string, limit int) (suggestions []Suggestion, err error) {
+	ctx, done := s.start(ctx, "getSuggestions")
+	defer func() { done(err) }()
+	return s.HistoryStore.Suggest(ctx, prefix, limit)
+}
+
+// instrumentedSearchStore does the same for a SearchStore.
+type instrumentedSearchStore struct {
+	SearchStore
+	metrics *metrics
+	tracer  trace.Tracer
+}
+
+func (s *instrumentedSearchStore) start(ctx context.Context, operation string) (context.Context, func(error)) {
+	return (&instrumentedStore{metrics: s.metrics, tracer: s.tracer}).start(ctx, operation)
+}
+
+func (s *instrumentedSearchStore) Search(ctx context.Context, terms []string, limit int) (results []SearchResult, err error) {
+	ctx, done := s.start(ctx, "search")
+	defer func() { done(err) }()
+	return s.SearchStore.Search(ctx, terms, limit)
+}
+
+// instrumentedSyncStore does the same for a SyncStore.
+type instrumentedSyncStore struct {
+	SyncStore
+	metrics *metrics
+	tracer  trace.Tracer
+}
+
+func (s *instrumentedSyncStore) start(ctx context.Context, operation string) (context.Context, func(error)) {
+	return (&instrumentedStore{metrics: s.metrics, tracer: s.tracer}).start(ctx, operation)
+}
+
+func (s *instrumentedSyncStore) Changes(ctx context.Context, since int64, limit int) (items []SyncItem, tombstones []Tombstone, err error) {
+	ctx, done := s.start(ctx, "getChanges")
+	defer func() { done(err) }()
+	return s.SyncStore.Changes(ctx, since, limit)
+}
+
+func (s *instrumentedSyncStore) ApplyMutation(ctx context.Context, m Mutation) (result MutationResult, err error) {
+	ctx, done := s.start(ctx, "applyMutation")
+	defer func() { done(err) }()
+	return s.SyncStore.ApplyMutation(ctx, m)
+}
+
+// instrumentedUIDStore does the same for a UIDStore.
+type instrumentedUIDStore struct {
+	UIDStore
+	metrics *metrics
+	tracer  trace.Tracer
+}
+
+func (s *instrumentedUIDStore) start(ctx context.Context, operation string) (context.Context, func(error)) {
+	return (&instrumentedStore{metrics: s.metrics, tracer: s.tracer}).start(ctx, operation)
+}
+
+func (s *instrumentedUIDStore) GetByUID(ctx context.Context, uid string) (item Item, err error) {
+	ctx, done := s.start(ctx, "getByUID")
+	defer func() { done(err) }()
+	return s.UIDStore.GetByUID(ctx, uid)
+}
+
+// --- Collectors Evaluated at Scrape Time ---
+
+// poolStater is implemented by *pgxpool.Pool.
+type poolStater interface {
+	Stat() *pgxpool.Stat
+}
+
+// poolCollector exports pgxpool statistics so pool saturation is visible.
+type poolCollector struct {
+	pool          poolStater
+	acquired      *prometheus.Desc
+	idle          *prometheus.Desc
+	total         *prometheus.Desc
+	max           *prometheus.Desc
+	constructing  *prometheus.Desc
+	emptyAcquires *prometheus.Desc
+	emptyWait     *prometheus.Desc
+}
+
+func newPoolCollector(pool poolStater) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "db_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		pool:          pool,
+		acquired:      desc("acquired_conns", "Connections currently checked out of the pool."),
+		idle:          desc("idle_conns", "Idle connections in the pool."),
+		total:         desc("total_conns", "Total connections in the pool (acquired, idle and constructing)."),
+		max:           desc("max_conns", "Configured maximum pool size."),
+		constructing:  desc("constructing_conns", "Connections currently being established."),
+		emptyAcquires: desc("empty_acquire_total", "Acquires that had to wait because no connection was idle; a rising rate means the pool is saturated."),
+		emptyWait:     desc("empty_acquire_wait_seconds_total", "Total time spent waiting for a connection because the pool was empty."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.acquired, c.idle, c.total, c.max, c.constructing, c.emptyAcquires, c.emptyWait} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.constructing, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyWait, prometheus.CounterValue, stat.EmptyAcquireWaitTime().Seconds())
+}
+
+// itemCounter is implemented by stores that can count items without loading them.
+type itemCounter interface {
+	Count(ctx context.Context) (int, error)
+}
+
+// itemCollector exports the current number of items on the list.
+type itemCollector struct {
+	store ItemStore
+	desc  *prometheus.Desc
+}
+
+func newItemCollector(store ItemStore) *itemCollector {
+	return &itemCollector{
+		store: store,
+		desc:  prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "items"), "Items currently on the shopping list.", nil, nil),
+	}
+}
+
+func (c *itemCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *itemCollector) Collect(ch chan<- prometheus.Metric) {
+	// Bound the scrape so a slow database cannot hang the /metrics endpoint
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var n int
+	var err error
+	if counter, ok := c.store.(itemCounter); ok {
+		n, err = counter.Count(ctx)
+	} else {
+		var items []Item
+		items, err = c.store.List(ctx)
+		n = len(items)
+	}
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n))
+}
@@ -0,0 +1,129 @@
+package shoppinglist
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeMetrics fetches /metrics from srv and returns the exposition text.
+func scrapeMetrics(t *testing.T, srv http.Handler) string {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /metrics: expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	return rr.Body.String()
+}
+
+// assertMetricLines fails the test for every expected line missing from body.
+func assertMetricLines(t *testing.T, body string, want ...string) {
+	t.Helper()
+	for _, line := range want {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected metrics to contain %q", line)
+		}
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	srv, _ := newLoggedServer(t)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"Milk","quantity":"1"}`)),
+		httptest.NewRequest("GET", "/items", nil),
+		httptest.NewRequest("DELETE", "/items/99", nil),
+		httptest.NewRequest("GET", "/unknown", nil),
+	} {
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assertMetricLines(t, scrapeMetrics(t, srv),
+		`shoppinglist_http_requests_total{method="POST",route="/items",status="201"} 1`,
+		`shoppinglist_http_requests_total{method="GET",route="/items",status="200"} 1`,
+		`shoppinglist_http_requests_total{method="DELETE",route="/items/",status="404"} 1`,
+		`shoppinglist_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`shoppinglist_http_request_duration_seconds_count{method="GET",route="/items"} 1`,
+		`shoppinglist_db_query_duration_seconds_count{operation="addItem"} 1`,
+		`shoppinglist_db_query_duration_seconds_count{operation="recordUse"} 1`,
+		`shoppinglist_db_query_duration_seconds_count{operation="getItems"} 1`,
+		`shoppinglist_db_query_duration_seconds_count{operation="deleteItem"} 1`,
+		`shoppinglist_items 1`,
+		`go_goroutines`,
+	)
+}
+
+func TestMetricsQueryErrors(t *testing.T) {
+	mock, cleanup := newMockPool(t)
+	defer cleanup()
+	mock.ExpectQuery("SELECT id, uid::text, name, quantity, unit_price, currency, created_at FROM items").WillReturnError(errors.New("connection reset"))
+	mock.ExpectExec("DELETE FROM items").WithArgs(7).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(3))
+
+	srv := newTestServer(t, NewPostgresStore(mock))
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil))
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/items/7", nil))
+
+	// Only the database failure counts; a missing item is the client's problem
+	body := scrapeMetrics(t, srv)
+	assertMetricLines(t, body, `shoppinglist_db_query_errors_total{operation="getItems"} 1`, "shoppinglist_items 3")
+	if strings.Contains(body, `shoppinglist_db_query_errors_total{operation="deleteItem"}`) {
+		t.Error("Expected ErrNotFound not to be counted as a query error")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMetricsPoolStats(t *testing.T) {
+	// pgxpool connects lazily, so an unreachable address still yields a pool with stats
+	pool, err := pgxpool.New(context.Background(), "postgres://user@127.0.0.1:1/shopping?pool_max_conns=3")
+	if err != nil {
+		t.Fatalf("pgxpool.New failed: %v", err)
+	}
+	srv, err := New(Options{Store: NewMemoryStore(), Pool: pool})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer srv.Close()
+
+	assertMetricLines(t, scrapeMetrics(t, srv),
+		"shoppinglist_db_pool_acquired_conns 0",
+		"shoppinglist_db_pool_idle_conns 0",
+		"shoppinglist_db_pool_max_conns 3",
+		"shoppinglist_db_pool_empty_acquire_total 0",
+	)
+}
+
+func TestMetricsRegistry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if _, err := New(Options{Store: NewMemoryStore(), Registry: reg}); err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	found := false
+	for _, f := range families {
+		if f.GetName() == "shoppinglist_items" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected shoppinglist_items on the supplied registry")
+	}
+
+	// A second server cannot register the same collectors on one registry
+	if _, err := New(Options{Store: NewMemoryStore(), Registry: reg}); err == nil {
+		t.Error("Expected an error registering duplicate metrics, got nil")
+	}
+}
@@ -0,0 +1,587 @@
+package shoppinglist
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// --- Schema Migrations ---
+
+// migration is one versioned schema change, written once per SQL dialect.
+// Migrations are append-only: never edit or reorder an entry once it has shipped.
+type migration struct {
+	Version  int
+	Name     string
+	Postgres string
+	SQLite   string
+}
+
+// migrations lists every schema change in version order.
+// Version 1 uses IF NOT EXISTS so databases created before migrations existed are adopted as-is.
+var migrations = []migration{
+	{
+		Version: 1,
+		Name:    "create items table",
+		Postgres: `
+		CREATE TABLE IF NOT EXISTS items (
+			id SERIAL PRIMARY KEY,
+			name TEXT NOT NULL CHECK (name <> ''),
+			quantity TEXT NOT NULL CHECK (quantity <> ''),
+			created_at TIMESTAMPTZ DEFAULT NOW()
+		);`,
+		SQLite: `
+		CREATE TABLE IF NOT EXISTS items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL CHECK (name <> ''),
+			quantity TEXT NOT NULL CHECK (quantity <> ''),
+			created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		);`,
+	},
+	{
+		// The same rules as normalizeItem: at most MaxNameLength/MaxQuantityLength
+		// characters, no control, bidi-control, zero-width or non-ASCII space characters,
+		// single spaces only, nothing to trim and (Postgres only) NFC. Existing rows are
+		// not checked here, but PostgreSQL checks every row an UPDATE writes, so version 10
+		// repairs them before its backfill. SQLite cannot add constraints to an existing
+		// table, so triggers enforce them.
+		Version: 2,
+		Name:    "constrain item text",
+		Postgres: `
+		ALTER TABLE items
+			ADD CONSTRAINT items_name_normalized CHECK (
+				char_length(name) <= 200
+				AND name !~ '[\x01-\x1F\x7F-\xA0\u061C\u1680\u2000-\u200B\u200E\u200F\u2028-\u202F\u205F\u2060\u2066-\u2069\u3000\uFEFF]'
+				AND name !~ '^ | $|  '
+				AND name IS NFC NORMALIZED
+			) NOT VALID,
+			ADD CONSTRAINT items_quantity_normalized CHECK (
+				char_length(quantity) <= 100
+				AND quantity !~ '[\x01-\x1F\x7F-\xA0\u061C\u1680\u2000-\u200B\u200E\u200F\u2028-\u202F\u205F\u2060\u2066-\u2069\u3000\uFEFF]'
+				AND quantity !~ '^ | $|  '
+				AND quantity IS NFC NORMALIZED
+			) NOT VALID;`,
+		SQLite: `
+		CREATE TRIGGER items_normalized_insert BEFORE INSERT ON items
+		WHEN length(NEW.name) > 200 OR length(NEW.quantity) > 100
+			OR NEW.name GLOB '*[' || char(1) || '-' || char(31) || char(127) || '-' || char(160) || char(1564, 5760) || char(8192) || '-' || char(8203) || char(8206, 8207, 8232) || '-' || char(8239) || char(8287, 8288, 8294) || '-' || char(8297) || char(12288, 65279) || ']*'
+			OR NEW.quantity GLOB '*[' || char(1) || '-' || char(31) || char(127) || '-' || char(160) || char(1564, 5760) || char(8192) || '-' || char(8203) || char(8206, 8207, 8232) || '-' || char(8239) || char(8287, 8288, 8294) || '-' || char(8297) || char(12288, 65279) || ']*'
+			OR NEW.name <> trim(NEW.name, ' ') OR instr(NEW.name, '  ') > 0
+			OR NEW.quantity <> trim(NEW.quantity, ' ') OR instr(NEW.quantity, '  ') > 0
+		BEGIN
+			SELECT RAISE(ABORT, 'items: name or quantity is not normalized');
+		END;
+		CREATE TRIGGER items_normalized_update BEFORE UPDATE OF name, quantity ON items
+		WHEN length(NEW.name) > 200 OR length(NEW.quantity) > 100
+			OR NEW.name GLOB '*[' || char(1) || '-' || char(31) || char(127) || '-' || char(160) || char(1564, 5760) || char(8192) || '-' || char(8203) || char(8206, 8207, 8232) || '-' || char(8239) || char(8287, 8288, 8294) || '-' || char(8297) || char(12288, 65279) || ']*'
+			OR NEW.quantity GLOB '*[' || char(1) || '-' || char(31) || char(127) || '-' || char(160) || char(1564, 5760) || char(8192) || '-' || char(8203) || char(8206, 8207, 8232) || '-' || char(8239) || char(8287, 8288, 8294) || '-' || char(8297) || char(12288, 65279) || ']*'
+			OR NEW.name <> trim(NEW.name, ' ') OR instr(NEW.name, '  ') > 0
+			OR NEW.quantity <> trim(NEW.quantity, ' ') OR instr(NEW.quantity, '  ') > 0
+		BEGIN
+			SELECT RAISE(ABORT, 'items: name or quantity is not normalized');
+		END;`,
+	},
+	{
+		// Ingredients are kept in recipe order (position) and deleted with their recipe.
+		Version: 3,
+		Name:    "create recipes tables",
+		Postgres: `
+		CREATE TABLE recipes (
+			id SERIAL PRIMARY KEY,
+			title TEXT NOT NULL CHECK (title <> '' AND char_length(title) <= 200),
+			servings INTEGER NOT NULL CHECK (servings BETWEEN 1 AND 1000),
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE TABLE recipe_ingredients (
+			recipe_id INTEGER NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+			position INTEGER NOT NULL,
+			name TEXT NOT NULL CHECK (name <> '' AND char_length(name) <= 200),
+			quantity TEXT NOT NULL CHECK (quantity <> '' AND char_length(quantity) <= 100),
+			PRIMARY KEY (recipe_id, position)
+		);`,
+		SQLite: `
+		CREATE TABLE recipes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL CHECK (title <> '' AND length(title) <= 200),
+			servings INTEGER NOT NULL CHECK (servings BETWEEN 1 AND 1000),
+			created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		);
+		CREATE TABLE recipe_ingredients (
+			recipe_id INTEGER NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+			position INTEGER NOT NULL,
+			name TEXT NOT NULL CHECK (name <> '' AND length(name) <= 200),
+			quantity TEXT NOT NULL CHECK (quantity <> '' AND length(quantity) <= 100),
+			PRIMARY KEY (recipe_id, position)
+		);`,
+	},
+	{
+		// Names are unique ignoring case, so a purchase finds the pantry item to restock.
+		Version: 4,
+		Name:    "create pantry table",
+		Postgres: `
+		CREATE TABLE pantry_items (
+			id SERIAL PRIMARY KEY,
+			name TEXT NOT NULL CHECK (name <> '' AND char_length(name) <= 200),
+			stock NUMERIC(12, 3) NOT NULL DEFAULT 0 CHECK (stock >= 0),
+			unit TEXT NOT NULL DEFAULT '' CHECK (char_length(unit) <= 20),
+			threshold NUMERIC(12, 3) NOT NULL DEFAULT 0 CHECK (threshold >= 0),
+			best_before DATE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE UNIQUE INDEX pantry_items_name_key ON pantry_items (lower(name));`,
+		SQLite: `
+		CREATE TABLE pantry_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL CHECK (name <> '' AND length(name) <= 200),
+			stock REAL NOT NULL DEFAULT 0 CHECK (stock >= 0),
+			unit TEXT NOT NULL DEFAULT '' CHECK (length(unit) <= 20),
+			threshold REAL NOT NULL DEFAULT 0 CHECK (threshold >= 0),
+			best_before TEXT CHECK (best_before IS NULL OR date(best_before) = best_before),
+			created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		);
+		CREATE UNIQUE INDEX pantry_items_name_key ON pantry_items (lower(name));`,
+	},
+	{
+		// next_run_at is when the scheduler adds the item next; it is indexed because
+		// every scheduler run looks for rows that are due.
+		Version: 5,
+		Name:    "create recurring items table",
+		Postgres: `
+		CREATE TABLE recurring_items (
+			id SERIAL PRIMARY KEY,
+			name TEXT NOT NULL CHECK (name <> '' AND char_length(name) <= 200),
+			quantity TEXT NOT NULL CHECK (quantity <> '' AND char_length(quantity) <= 100),
+			schedule TEXT NOT NULL CHECK (schedule <> '' AND char_length(schedule) <= 100),
+			next_run_at TIMESTAMPTZ NOT NULL,
+			last_run_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX recurring_items_next_run_at_idx ON recurring_items (next_run_at);`,
+		SQLite: `
+		CREATE TABLE recurring_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL CHECK (name <> '' AND length(name) <= 200),
+			quantity TEXT NOT NULL CHECK (quantity <> '' AND length(quantity) <= 100),
+			schedule TEXT NOT NULL CHECK (schedule <> '' AND length(schedule) <= 100),
+			next_run_at TEXT NOT NULL,
+			last_run_at TEXT,
+			created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		);
+		CREATE INDEX recurring_items_next_run_at_idx ON recurring_items (next_run_at);`,
+	},
+	{
+		// Money is stored in integer minor units. item_prices remembers the last price per
+		// name (ignoring case), and list_budget holds at most one row for the single list.
+		Version: 6,
+		Name:    "add item prices and list budget",
+		Postgres: `
+		ALTER TABLE items
+			ADD COLUMN unit_price BIGINT CHECK (unit_price BETWEEN 0 AND 1000000000),
+			ADD COLUMN currency TEXT CHECK (currency ~ '^[A-Z]{3}$'),
+			ADD CONSTRAINT items_price_currency_check CHECK ((unit_price IS NULL) = (currency IS NULL));
+		CREATE TABLE item_prices (
+			name TEXT NOT NULL CHECK (name <> '' AND char_length(name) <= 200),
+			unit_price BIGINT NOT NULL CHECK (unit_price BETWEEN 0 AND 1000000000),
+			currency TEXT NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE UNIQUE INDEX item_prices_name_key ON item_prices (lower(name));
+		CREATE TABLE list_budget (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			amount BIGINT NOT NULL CHECK (amount BETWEEN 0 AND 1000000000000),
+			currency TEXT NOT NULL CHECK (currency ~ '^[A-Z]{3}$')
+		);`,
+		SQLite: `
+		ALTER TABLE items ADD COLUMN unit_price INTEGER CHECK (unit_price BETWEEN 0 AND 1000000000);
+		ALTER TABLE items ADD COLUMN currency TEXT CHECK (currency GLOB '[A-Z][A-Z][A-Z]');
+		CREATE TABLE item_prices (
+			name TEXT NOT NULL CHECK (name <> '' AND length(name) <= 200),
+			unit_price INTEGER NOT NULL CHECK (unit_price BETWEEN 0 AND 1000000000),
+			currency TEXT NOT NULL CHECK (currency GLOB '[A-Z][A-Z][A-Z]'),
+			updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		);
+		CREATE UNIQUE INDEX item_prices_name_key ON item_prices (lower(name));
+		CREATE TABLE list_budget (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			amount INTEGER NOT NULL CHECK (amount BETWEEN 0 AND 1000000000000),
+			currency TEXT NOT NULL CHECK (currency GLOB '[A-Z][A-Z][A-Z]')
+		);`,
+	},
+	{
+		// A store's sections are kept in walking order by position. section_mappings holds
+		// one section per item name (ignoring case); the last check-off is kept on the store
+		// so the next one in the same trip can learn from it.
+		Version: 7,
+		Name:    "add stores with aisle layouts",
+		Postgres: `
+		CREATE TABLE stores (
+			id SERIAL PRIMARY KEY,
+			name TEXT NOT NULL CHECK (name <> '' AND char_length(name) <= 200),
+			last_checkoff_name TEXT,
+			last_checkoff_section TEXT,
+			last_checkoff_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE TABLE store_sections (
+			store_id INTEGER NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+			position INTEGER NOT NULL,
+			name TEXT NOT NULL CHECK (name <> '' AND char_length(name) <= 100),
+			PRIMARY KEY (store_id, position)
+		);
+		CREATE UNIQUE INDEX store_sections_name_key ON store_sections (store_id, lower(name));
+		CREATE TABLE section_mappings (
+			store_id INTEGER NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+			name TEXT NOT NULL CHECK (name <> '' AND char_length(name) <= 200),
+			section TEXT NOT NULL CHECK (section <> '' AND char_length(section) <= 100),
+			learned BOOLEAN NOT NULL DEFAULT FALSE,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE UNIQUE INDEX section_mappings_name_key ON section_mappings (store_id, lower(name));`,
+		SQLite: `
+		CREATE TABLE stores (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL CHECK (name <> '' AND length(name) <= 200),
+			last_checkoff_name TEXT,
+			last_checkoff_section TEXT,
+			last_checkoff_at TEXT,
+			created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		);
+		CREATE TABLE store_sections (
+			store_id INTEGER NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+			position INTEGER NOT NULL,
+			name TEXT NOT NULL CHECK (name <> '' AND length(name) <= 100),
+			PRIMARY KEY (store_id, position)
+		);
+		CREATE UNIQUE INDEX store_sections_name_key ON store_sections (store_id, lower(name));
+		CREATE TABLE section_mappings (
+			store_id INTEGER NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+			name TEXT NOT NULL CHECK (name <> '' AND length(name) <= 200),
+			section TEXT NOT NULL CHECK (section <> '' AND length(section) <= 100),
+			learned INTEGER NOT NULL DEFAULT 0 CHECK (learned IN (0, 1)),
+			updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		);
+		CREATE UNIQUE INDEX section_mappings_name_key ON section_mappings (store_id, lower(name));`,
+	},
+	{
+		// item_history keeps one row per item name (ignoring case) that was ever added,
+		// with its rank for suggestions (see historyRank: 2^rank sums 2^(t / 30 days) over
+		// the uses, with t counted from 2024-01-01). Existing items seed it.
+		Version: 8,
+		Name:    "add item history for suggestions",
+		Postgres: `
+		CREATE TABLE item_history (
+			name TEXT NOT NULL CHECK (name <> '' AND char_length(name) <= 200),
+			quantity TEXT NOT NULL,
+			uses INTEGER NOT NULL CHECK (uses > 0),
+			last_used_at TIMESTAMPTZ NOT NULL,
+			rank DOUBLE PRECISION NOT NULL
+		);
+		CREATE UNIQUE INDEX item_history_name_key ON item_history (lower(name));
+		CREATE INDEX item_history_prefix_idx ON item_history (lower(name) text_pattern_ops);
+		CREATE INDEX item_history_rank_idx ON item_history (rank DESC);
+		INSERT INTO item_history (name, quantity, uses, last_used_at, rank)
+		SELECT DISTINCT ON (lower(name)) name, quantity,
+			count(*) OVER w, max(COALESCE(created_at, NOW())) OVER w,
+			ln(sum(power(2::float8, extract(epoch FROM COALESCE(created_at, NOW()) - TIMESTAMPTZ '2024-01-01 00:00:00+00') / 2592000)) OVER w) / ln(2::float8)
+		FROM items
+		WINDOW w AS (PARTITION BY lower(name))
+		ORDER BY lower(name), created_at DESC NULLS LAST;`,
+		SQLite: `
+		CREATE TABLE item_history (
+			name TEXT NOT NULL CHECK (name <> '' AND length(name) <= 200),
+			quantity TEXT NOT NULL,
+			uses INTEGER NOT NULL CHECK (uses > 0),
+			last_used_at TEXT NOT NULL,
+			rank REAL NOT NULL
+		);
+		CREATE UNIQUE INDEX item_history_name_key ON item_history (lower(name));
+		CREATE INDEX item_history_rank_idx ON item_history (rank DESC);
+		INSERT INTO item_history (name, quantity, uses, last_used_at, rank)
+		SELECT name, quantity, count(*), max(created_at),
+			ln(sum(pow(2, (julianday(created_at) - julianday('2024-01-01')) / 30))) / ln(2)
+		FROM items
+		GROUP BY lower(name);`,
+	},
+	{
+		Version: 9,
+		Name:    "add search indexes",
+		Postgres: `
+		CREATE EXTENSION IF NOT EXISTS pg_trgm;
+		CREATE INDEX items_name_fts_idx ON items USING GIN (to_tsvector('simple', name));
+		CREATE INDEX items_name_trgm_idx ON items USING GIN (lower(name) gin_trgm_ops);
+		CREATE INDEX item_history_name_fts_idx ON item_history USING GIN (to_tsvector('simple', name));
+		CREATE INDEX item_history_name_trgm_idx ON item_history USING GIN (lower(name) gin_trgm_ops);`,
+		// SQLite has no trigram index; its store scores names in Go instead
+		SQLite: `SELECT 1;`,
+	},
+	{
+		Version: 10,
+		Name:    "add item versions and tombstones for sync",
+		// Triggers version every write, so no code path can skip the change feed. The
+		// sequence alone would not keep version tokens safe: a transaction holding version
+		// 5 can commit after one holding 6, and a client that synced 6 in between would
+		// never see 5. The advisory lock (next to migrationLockID and schedulerLockID) is
+		// held from a transaction's first item write until commit, so versions become
+		// visible in order. It serializes item writes, which are short transactions;
+		// BenchmarkPostgresItemWrites measures the cost. The backfill rewrites every row, which the
+		// version 2 constraints would reject for rows written before them, so those rows are
+		// normalized first.
+		Postgres: `
+		CREATE FUNCTION pg_temp.repair_item_text(t TEXT, max_len INT) RETURNS TEXT LANGUAGE sql AS $$
+			SELECT COALESCE(NULLIF(normalize(rtrim(left(btrim(regexp_replace(
+				normalize(regexp_replace(regexp_replace(t,
+					'[\u061C\u200B\u200E\u200F\u202A-\u202E\u2060\u2066-\u2069\uFEFF]', '', 'g'),
+					'[\x01-\x1F\x7F-\xA0\u1680\u2000-\u200A\u2028\u2029\u202F\u205F\u3000]', ' ', 'g'), NFC),
+				' {2,}', ' ', 'g')), max_len)), NFC), ''), '?')
+		$$;
+		UPDATE items SET
+				name = pg_temp.repair_item_text(name, 200),
+				quantity = pg_temp.repair_item_text(quantity, 100)
+			WHERE name <> pg_temp.repair_item_text(name, 200)
+				OR quantity <> pg_temp.repair_item_text(quantity, 100);
+		DROP FUNCTION pg_temp.repair_item_text(TEXT, INT);
+		CREATE SEQUENCE item_versions;
+		ALTER TABLE items
+			ADD COLUMN uid UUID,
+			ADD COLUMN version BIGINT NOT NULL DEFAULT 0,
+			ADD COLUMN name_changed_at TIMESTAMPTZ,
+			ADD COLUMN quantity_changed_at TIMESTAMPTZ,
+			ADD COLUMN price_changed_at TIMESTAMPTZ;
+		UPDATE items SET version = numbered.n
+			FROM (SELECT id, row_number() OVER (ORDER BY id) AS n FROM items) numbered
+			WHERE items.id = numbered.id;
+		SELECT setval('item_versions', GREATEST(count(*), 1), count(*) > 0) FROM items;
+		CREATE UNIQUE INDEX items_uid_key ON items (uid);
+		CREATE INDEX items_version_idx ON items (version);
+		CREATE TABLE item_tombstones (
+			version BIGINT PRIMARY KEY,
+			item_id INTEGER NOT NULL,
+			uid UUID UNIQUE,
+			deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX item_tombstones_item_id_idx ON item_tombstones (item_id);
+		CREATE FUNCTION items_track_changes() RETURNS trigger LANGUAGE plpgsql AS $$
+		BEGIN
+			PERFORM pg_advisory_xact_lock(7262531003);
+			IF TG_OP = 'DELETE' THEN
+				INSERT INTO item_tombstones (version, item_id, uid) VALUES (nextval('item_versions'), OLD.id, OLD.uid);
+				RETURN OLD;
+			END IF;
+			IF TG_OP = 'UPDATE' THEN
+				IF NEW.name IS DISTINCT FROM OLD.name AND NEW.name_changed_at IS NOT DISTINCT FROM OLD.name_changed_at THEN
+					NEW.name_changed_at := NOW();
+				END IF;
+				IF NEW.quantity IS DISTINCT FROM OLD.quantity AND NEW.quantity_changed_at IS NOT DISTINCT FROM OLD.quantity_changed_at THEN
+					NEW.quantity_changed_at := NOW();
+				END IF;
+				IF (NEW.unit_price, NEW.currency) IS DISTINCT FROM (OLD.unit_price, OLD.currency)
+					AND NEW.price_changed_at IS NOT DISTINCT FROM OLD.price_changed_at THEN
+					NEW.price_changed_at := NOW();
+				END IF;
+			END IF;
+			NEW.version := nextval('item_versions');
+			RETURN NEW;
+		END
+		$$;
+		CREATE TRIGGER items_track_writes BEFORE INSERT OR UPDATE ON items
+			FOR EACH ROW EXECUTE FUNCTION items_track_changes();
+		CREATE TRIGGER items_track_deletes AFTER DELETE ON items
+			FOR EACH ROW EXECUTE FUNCTION items_track_changes();`,
+		// SQLite has a single writer, so a counter row is enough
+		SQLite: `
+		CREATE TABLE item_versions (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			value INTEGER NOT NULL
+		);
+		INSERT INTO item_versions (id, value) SELECT 1, count(*) FROM items;
+		ALTER TABLE items ADD COLUMN uid TEXT;
+		ALTER TABLE items ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE items ADD COLUMN name_changed_at TEXT;
+		ALTER TABLE items ADD COLUMN quantity_changed_at TEXT;
+		ALTER TABLE items ADD COLUMN price_changed_at TEXT;
+		UPDATE items SET version = (SELECT count(*) FROM items AS older WHERE older.id <= items.id);
+		CREATE UNIQUE INDEX items_uid_key ON items (uid);
+		CREATE INDEX items_version_idx ON items (version);
+		CREATE TABLE item_tombstones (
+			version INTEGER PRIMARY KEY,
+			item_id INTEGER NOT NULL,
+			uid TEXT UNIQUE,
+			deleted_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		);
+		CREATE INDEX item_tombstones_item_id_idx ON item_tombstones (item_id);
+		CREATE TRIGGER items_track_inserts AFTER INSERT ON items
+		BEGIN
+			UPDATE item_versions SET value = value + 1;
+			UPDATE items SET version = (SELECT value FROM item_versions) WHERE id = NEW.id;
+		END;
+		CREATE TRIGGER items_track_updates AFTER UPDATE OF name, quantity, unit_price, currency ON items
+		BEGIN
+			UPDATE item_versions SET value = value + 1;
+			UPDATE items SET
+				version = (SELECT value FROM item_versions),
+				name_changed_at = CASE WHEN NEW.name IS NOT OLD.name AND NEW.name_changed_at IS OLD.name_changed_at
+					THEN strftime('%Y-%m-%dT%H:%M:%fZ', 'now') ELSE NEW.name_changed_at END,
+				quantity_changed_at = CASE WHEN NEW.quantity IS NOT OLD.quantity AND NEW.quantity_changed_at IS OLD.quantity_changed_at
+					THEN strftime('%Y-%m-%dT%H:%M:%fZ', 'now') ELSE NEW.quantity_changed_at END,
+				price_changed_at = CASE WHEN (NEW.unit_price IS NOT OLD.unit_price OR NEW.currency IS NOT OLD.currency)
+					AND NEW.price_changed_at IS OLD.price_changed_at
+					THEN strftime('%Y-%m-%dT%H:%M:%fZ', 'now') ELSE NEW.price_changed_at END
+			WHERE id = NEW.id;
+		END;
+		CREATE TRIGGER items_track_deletes AFTER DELETE ON items
+		BEGIN
+			UPDATE item_versions SET value = value + 1;
+			INSERT INTO item_tombstones (version, item_id, uid) SELECT value, OLD.id, OLD.uid FROM item_versions;
+		END;`,
+	},
+	{
+		Version: 11,
+		Name:    "give every item a UUIDv7 uid",
+		// Existing items get UIDs stamped with their creation time. On PostgreSQL the
+		// update goes through items_track_changes, so synced clients learn the new UIDs;
+		// SQLite's triggers only watch the item fields, so it bumps the versions itself.
+		Postgres: `
+		CREATE FUNCTION items_uid(created TIMESTAMPTZ) RETURNS UUID LANGUAGE sql VOLATILE AS $$
+			SELECT overlay(overlay(replace(gen_random_uuid()::text, '-', '')
+				PLACING lpad(to_hex(floor(extract(epoch FROM created) * 1000)::bigint), 12, '0') FROM 1 FOR 12)
+				PLACING '7' FROM 13 FOR 1)::uuid
+		$$;
+		UPDATE items SET uid = items_uid(COALESCE(created_at, NOW())) WHERE uid IS NULL;
+		ALTER TABLE items
+			ALTER COLUMN uid SET DEFAULT items_uid(NOW()),
+			ALTER COLUMN uid SET NOT NULL;`,
+		SQLite: `
+		UPDATE items SET version = (SELECT value FROM item_versions)
+			+ (SELECT count(*) FROM items AS older WHERE older.uid IS NULL AND older.id <= items.id)
+		WHERE uid IS NULL;
+		UPDATE item_versions SET value = value + (SELECT count(*) FROM items WHERE uid IS NULL);
+		UPDATE items SET uid = lower(substr(stamped.t, 1, 8) || '-' || substr(stamped.t, 9, 4) || '-7' ||
+				substr(hex(randomblob(2)), 2, 3) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) ||
+				substr(hex(randomblob(2)), 2, 3) || '-' || hex(randomblob(6)))
+			FROM (SELECT id, printf('%012x', CAST(round((julianday(created_at) - 2440587.5) * 86400000) AS INTEGER)) AS t
+				FROM items WHERE uid IS NULL) AS stamped
+			WHERE items.id = stamped.id;
+		CREATE TRIGGER items_assign_uid AFTER INSERT ON items WHEN NEW.uid IS NULL
+		BEGIN
+			UPDATE items SET uid = lower(substr(stamped.t, 1, 8) || '-' || substr(stamped.t, 9, 4) || '-7' ||
+					substr(hex(randomblob(2)), 2, 3) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) ||
+					substr(hex(randomblob(2)), 2, 3) || '-' || hex(randomblob(6)))
+				FROM (SELECT printf('%012x', CAST(round((julianday(NEW.created_at) - 2440587.5) * 86400000) AS INTEGER)) AS t) AS stamped
+				WHERE items.id = NEW.id;
+		END;`,
+	},
+	{
+		// Version 6 could not add items_price_currency_check on SQLite, so triggers enforce
+		// it there. As in version 2, existing rows are not checked.
+		Version:  12,
+		Name:     "require a currency with every item price",
+		Postgres: `SELECT 1;`,
+		SQLite: `
+		CREATE TRIGGER items_price_currency_insert BEFORE INSERT ON items
+		WHEN (NEW.unit_price IS NULL) <> (NEW.currency IS NULL)
+		BEGIN
+			SELECT RAISE(ABORT, 'items: unit_price and currency must be set together');
+		END;
+		CREATE TRIGGER items_price_currency_update BEFORE UPDATE OF unit_price, currency ON items
+		WHEN (NEW.unit_price IS NULL) <> (NEW.currency IS NULL)
+		BEGIN
+			SELECT RAISE(ABORT, 'items: unit_price and currency must be set together');
+		END;`,
+	},
+}
+
+// latestSchemaVersion returns the version the schema is at once all migrations are applied.
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// migrationLockID is the Postgres advisory lock key that serializes migrations
+// when several backend replicas start at the same time.
+const migrationLockID = 7_262_531_001
+
+// migratePostgres applies all pending migrations in a single transaction.
+// DDL is transactional in Postgres, so a failed migration leaves the schema untouched.
+func migratePostgres(ctx context.Context, pool DBPool) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin migration transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("unable to acquire migration lock: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);`); err != nil {
+		return fmt.Errorf("unable to create schema_migrations table: %w", err)
+	}
+
+	var current int
+	if err := tx.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return fmt.Errorf("unable to read schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if _, err := tx.Exec(ctx, m.Postgres); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name); err != nil {
+			return fmt.Errorf("unable to record migration %d: %w", m.Version, err)
+		}
+		loggerFrom(ctx).Info("Applied migration", "version", m.Version, "name", m.Name)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("unable to commit migrations: %w", err)
+	}
+	return nil
+}
+
+// migrateSQLite applies all pending migrations in a single transaction.
+// db should be the store's writer handle so the transaction takes the write lock up front.
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to begin migration transaction: %w", err)
+	}
+	defer tx.Rollback() // No-op after a successful Commit
+
+	if _, err := tx.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		);`); err != nil {
+		return fmt.Errorf("unable to create schema_migrations table: %w", err)
+	}
+
+	var current int
+	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return fmt.Errorf("unable to read schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, m.SQLite); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+			return fmt.Errorf("unable to record migration %d: %w", m.Version, err)
+		}
+		loggerFrom(ctx).Info("Applied migration", "version", m.Version, "name", m.Name)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit migrations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,384 @@
+package shoppinglist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// --- Pantry ---
+
+// PantryItem is something kept at home. When Stock falls below Threshold, the item is
+// put on the shopping list; a zero Threshold never triggers that.
+type PantryItem struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Stock      float64   `json:"stock"`
+	Unit       string    `json:"unit"`
+	Threshold  float64   `json:"threshold"`
+	BestBefore string    `json:"best_before,omitempty"` // YYYY-MM-DD, empty if unknown
+	CreatedAt  time.Time `json:"created_at,omitempty"`  // omitempty for POST
+}
+
+// Pantry limits, checked by every PantryStore and the database. Stock and thresholds are
+// kept to three decimal places.
+const (
+	MaxPantryStock = 1_000_000
+	MaxUnitLength  = 20
+)
+
+var (
+	// ErrPantryItemNotFound is returned by a PantryStore when the requested pantry item does not exist.
+	ErrPantryItemNotFound = errors.New("pantry item not found")
+	// ErrPantryItemExists is returned when a pantry item would get the name (ignoring case)
+	// of another one.
+	ErrPantryItemExists = errors.New("pantry item already exists")
+)
+
+// PantryStore persists the pantry. It is implemented by the built-in stores next to
+// ItemStore, in the same database, so a purchase moves an item in one transaction.
+type PantryStore interface {
+	// ListPantry returns all pantry items ordered by name.
+	ListPantry(ctx context.Context) ([]PantryItem, error)
+	// GetPantryItem returns the pantry item with the given ID, or ErrPantryItemNotFound.
+	GetPantryItem(ctx context.Context, id int) (PantryItem, error)
+	// CreatePantryItem validates and stores a new pantry item, returning it with ID and
+	// CreatedAt set, or ErrPantryItemExists.
+	CreatePantryItem(ctx context.Context, item PantryItem) (PantryItem, error)
+	// UpdatePantryItem replaces every field of an existing pantry item, or returns
+	// ErrPantryItemNotFound or ErrPantryItemExists.
+	UpdatePantryItem(ctx context.Context, item PantryItem) (PantryItem, error)
+	// DeletePantryItem removes the pantry item with the given ID, or returns ErrPantryItemNotFound.
+	DeletePantryItem(ctx context.Context, id int) error
+	// ConsumeStock takes amount off the stock of a pantry item, stopping at zero.
+	ConsumeStock(ctx context.Context, id int, amount float64) (PantryItem, error)
+	// MovePurchased deletes the shopping list item itemID (or returns ErrNotFound) and adds
+	// stock.Stock to the pantry item named stock.Name, creating it if needed. The unit is
+	// only set if the pantry item has none; otherwise the stock is converted to its unit
+	// with purchasedStock, and left alone if that fails. A best-before date replaces the
+	// old one.
+	MovePurchased(ctx context.Context, itemID int, stock PantryItem) (PantryItem, error)
+}
+
+// normalizePantryItem normalizes the name and unit of item like item fields and checks its
+// numbers and date, returning a *ValidationError listing every invalid field.
+func normalizePantryItem(item PantryItem, limits FieldLimits) (PantryItem, error) {
+	limits = limits.withDefaults()
+	var fields []FieldError
+	invalid := func(field, msg string) {
+		fields = append(fields, FieldError{Field: field, Message: msg})
+	}
+
+	if name, msg := normalizeText(item.Name, limits.MaxName); msg != "" {
+		invalid("name", msg)
+	} else {
+		item.Name = name
+	}
+	if strings.TrimSpace(item.Unit) == "" {
+		item.Unit = "" // The unit is optional ("3 lemons")
+	} else if unit, msg := normalizeText(item.Unit, MaxUnitLength); msg != "" {
+		invalid("unit", msg)
+	} else {
+		item.Unit = unit
+	}
+	for _, f := range []struct {
+		name  string
+		value *float64
+	}{{"stock", &item.Stock}, {"threshold", &item.Threshold}} {
+		if !(*f.value >= 0 && *f.value <= MaxPantryStock) {
+			invalid(f.name, fmt.Sprintf("must be between 0 and %d", MaxPantryStock))
+			continue
+		}
+		*f.value = roundStock(*f.value)
+	}
+	if item.BestBefore != "" {
+		if _, err := time.Parse(time.DateOnly, item.BestBefore); err != nil {
+			invalid("best_before", "must be a date like 2006-01-02")
+		}
+	}
+
+	if len(fields) > 0 {
+		return PantryItem{}, &ValidationError{Resource: "pantry item", Fields: fields}
+	}
+	return item, nil
+}
+
+// purchasedStock is the amount a purchase adds to a pantry item whose unit is pantryUnit.
+// A purchase without a unit, or into an item without one, adds its stock as it is.
+// Otherwise the stock is converted to the pantry item's unit, and ok is false if it
+// cannot be ("500 ml" into "2 packs"), in which case the caller leaves the stock alone.
+func purchasedStock(purchase PantryItem, pantryUnit string) (amount float64, ok bool) {
+	if purchase.Unit == "" || pantryUnit == "" {
+		return purchase.Stock, true
+	}
+	amount, ok = convertUnit(purchase.Stock, purchase.Unit, pantryUnit)
+	return roundStock(amount), ok
+}
+
+// roundStock rounds x to the three decimal places the databases keep.
+func roundStock(x float64) float64 {
+	return math.Round(x*1000) / 1000
+}
+
+// sortPantry orders pantry items like the SQL stores' "ORDER BY lower(name)".
+func sortPantry(items []PantryItem) {
+	sort.Slice(items, func(i, j int) bool {
+		return strings.ToLower(items[i].Name) < strings.ToLower(items[j].Name)
+	})
+}
+
+// --- Pantry Handlers ---
+
+func (s *Server) pantryHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items, err := s.pantry.ListPantry(r.Context())
+		if err != nil {
+			s.requestLogger(r).Error("Error listing pantry", "err", err)
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, r, http.StatusOK, items)
+	case http.MethodPost:
+		var item PantryItem
+		if !s.decodeJSON(w, r, &item) {
+			return
+		}
+		item, err := normalizePantryItem(item, s.limits)
+		if err == nil {
+			item, err = s.pantry.CreatePantryItem(r.Context(), item)
+		}
+		if err != nil {
+			s.requestLogger(r).Warn("Error adding pantry item", "err", err)
+			s.writeError(w, r, err)
+			return
+		}
+		s.replenish(r, item)
+		s.writeJSON(w, r, http.StatusCreated, item)
+	default:
+		s.methodNotAllowed(w, r, "GET, POST")
+	}
+}
+
+// pantryDetailHandler serves /pantry/expiring, /pantry/{id} and /pantry/{id}/consume.
+func (s *Server) pantryDetailHandler(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/pantry/"), "/")
+	if rest == "expiring" {
+		if r.Method != http.MethodGet {
+			s.methodNotAllowed(w, r, "GET")
+			return
+		}
+		s.expiringHandler(w, r)
+		return
+	}
+	idStr, action, _ := strings.Cut(rest, "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		s.writeStatus(w, r, http.StatusBadRequest, "Invalid pantry item ID format")
+		return
+	}
+
+	switch action {
+	case "":
+	case "consume":
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, r, "POST")
+			return
+		}
+		s.consumeHandler(w, r, id)
+		return
+	default:
+		s.writeStatus(w, r, http.StatusNotFound, "No such endpoint")
+		return
+	}
+
+	var item PantryItem
+	switch r.Method {
+	case http.MethodGet:
+		item, err = s.pantry.GetPantryItem(r.Context(), id)
+	case http.MethodPut:
+		if !s.decodeJSON(w, r, &item) {
+			return
+		}
+		item.ID = id // The path wins over any ID in the body
+		if item, err = normalizePantryItem(item, s.limits); err == nil {
+			if item, err = s.pantry.UpdatePantryItem(r.Context(), item); err == nil {
+				s.replenish(r, item)
+			}
+		}
+	case http.MethodDelete:
+		if err = s.pantry.DeletePantryItem(r.Context(), id); err == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		s.methodNotAllowed(w, r, "GET, PUT, DELETE")
+		return
+	}
+	if err != nil {
+		s.requestLogger(r).Warn("Error handling pantry item", "id", id, "method", r.Method, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, item)
+}
+
+// StockChange is the response of POST /pantry/{id}/consume: the pantry item after the
+// change, and the shopping list item created if its stock fell below the threshold.
+type StockChange struct {
+	PantryItem PantryItem `json:"pantry_item"`
+	Added      *Item      `json:"added,omitempty"`
+}
+
+// consumeHandler takes {"amount": n} off the stock of a pantry item.
+func (s *Server) consumeHandler(w http.ResponseWriter, r *http.Request, id int) {
+	var body struct {
+		Amount float64 `json:"amount"`
+	}
+	if !s.decodeJSON(w, r, &body) {
+		return
+	}
+	if !(body.Amount > 0 && body.Amount <= MaxPantryStock) {
+		msg := fmt.Sprintf("must be above 0 and at most %d", MaxPantryStock)
+		s.writeProblem(w, r, Problem{
+			Type:   problemTypeValidation,
+			Title:  "Invalid amount",
+			Status: http.StatusBadRequest,
+			Detail: "amount " + msg,
+			Errors: []FieldError{{Field: "amount", Message: msg}},
+		})
+		return
+	}
+
+	item, err := s.pantry.ConsumeStock(r.Context(), id, body.Amount)
+	if err != nil {
+		s.requestLogger(r).Warn("Error consuming stock", "id", id, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, StockChange{PantryItem: item, Added: s.replenish(r, item)})
+}
+
+// replenish puts a pantry item on the shopping list, with the amount missing up to its
+// threshold, when its stock is below the threshold and nothing of that name (ignoring
+// case) is listed yet. It returns the new list item as clients see it, or nil. The stock change that led
+// here has already been stored, so failures are only logged.
+func (s *Server) replenish(r *http.Request, p PantryItem) *Item {
+	if p.Stock >= p.Threshold {
+		return nil
+	}
+	items, err := s.store.List(r.Context())
+	if err != nil {
+		s.requestLogger(r).Error("Error checking the list for a low-stock item", "name", p.Name, "err", err)
+		return nil
+	}
+	for _, item := range items {
+		if strings.EqualFold(item.Name, p.Name) {
+			return nil
+		}
+	}
+
+	qty := formatAmount(new(big.Rat).SetFloat64(p.Threshold-p.Stock), true)
+	if p.Unit != "" {
+		qty += " " + p.Unit
+	}
+	added, err := s.addItem(r.Context(), Item{Name: p.Name, Quantity: qty})
+	if err != nil {
+		s.requestLogger(r).Error("Error adding low-stock item to the list", "name", p.Name, "err", err)
+		return nil
+	}
+	s.requestLogger(r).Info("Stock below threshold, added to the list", "pantry_id", p.ID, "item_id", added.ID)
+	added = s.publicItem(added)
+	return &added
+}
+
+// expiringHandler lists pantry items whose best-before date is at most "days" days away
+// (default 7), including those already past it, soonest first.
+func (s *Server) expiringHandler(w http.ResponseWriter, r *http.Request) {
+	days := 7
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 365 {
+			msg := "must be a whole number between 0 and 365"
+			s.writeQueryProblem(w, r, FieldError{Field: "days", Message: msg})
+			return
+		}
+		days = n
+	}
+
+	items, err := s.pantry.ListPantry(r.Context())
+	if err != nil {
+		s.requestLogger(r).Error("Error listing pantry", "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	// Dates compare as text; today is the server's local date
+	cutoff := s.now().AddDate(0, 0, days).Format(time.DateOnly)
+	expiring := []PantryItem{}
+	for _, item := range items {
+		if item.BestBefore != "" && item.BestBefore <= cutoff {
+			expiring = append(expiring, item)
+		}
+	}
+	sort.SliceStable(expiring, func(i, j int) bool { return expiring[i].BestBefore < expiring[j].BestBefore })
+	s.writeJSON(w, r, http.StatusOK, expiring)
+}
+
+// Purchase is the optional body of POST /items/{id}/bought. Unset fields are taken from
+// the item's quantity: "500 g" adds 500 in unit "g", and a quantity without a leading
+// amount ("a bag") adds 1.
+type Purchase struct {
+	Stock      *float64 `json:"stock,omitempty"`
+	Unit       string   `json:"unit,omitempty"`
+	BestBefore string   `json:"best_before,omitempty"`
+}
+
+// boughtHandler moves a shopping list item into the pantry. Like DELETE /items/{id},
+// ?store={id} checks it off in that store.
+func (s *Server) boughtHandler(w http.ResponseWriter, r *http.Request, id int) {
+	var purchase Purchase
+	if !s.decodeOptionalJSON(w, r, &purchase) {
+		return
+	}
+	store, ok := s.storeFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := s.store.Get(r.Context(), id)
+	if err != nil {
+		s.requestLogger(r).Warn("Error getting bought item", "id", id, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	stock := PantryItem{Name: item.Name, Stock: 1, Unit: purchase.Unit, BestBefore: purchase.BestBefore}
+	if q, ok := parseQuantity(item.Quantity); ok {
+		stock.Stock, _ = q.amount.Float64()
+		if stock.Unit == "" && utf8.RuneCountInString(q.unit) <= MaxUnitLength {
+			stock.Unit = q.unit // A longer "unit" is more likely a description
+		}
+	}
+	if purchase.Stock != nil {
+		stock.Stock = *purchase.Stock
+	}
+
+	stock, err = normalizePantryItem(stock, s.limits)
+	if err == nil {
+		stock, err = s.pantry.MovePurchased(r.Context(), id, stock)
+	}
+	if err != nil {
+		s.requestLogger(r).Warn("Error moving bought item to the pantry", "id", id, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	s.checkedOff(r, store, item.Name)
+	s.writeJSON(w, r, http.StatusOK, stock)
+}
@@ -0,0 +1,234 @@
+package shoppinglist
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPantryHandlers(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+
+	rr := serve(srv, "POST", "/pantry", `{"name":"Rice","stock":2,"unit":"kg","threshold":1,"best_before":"2027-03-01"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	t.Run("List", func(t *testing.T) {
+		rr := serve(srv, "GET", "/pantry", "")
+		var items []PantryItem
+		if err := json.Unmarshal(rr.Body.Bytes(), &items); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %v", http.StatusOK, rr.Code, err)
+		}
+		if len(items) != 1 || items[0].Name != "Rice" || items[0].BestBefore != "2027-03-01" {
+			t.Errorf("Expected the created pantry item, got %+v", items)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		rr := serve(srv, "POST", "/pantry", `{"name":"Oats","stock":-1,"best_before":"tomorrow"}`)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		p := decodeProblem(t, rr)
+		if p.Title != "Invalid pantry item" || len(p.Errors) != 2 || p.Errors[0].Field != "stock" || p.Errors[1].Field != "best_before" {
+			t.Errorf("Expected stock and best_before errors, got %+v", p)
+		}
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		rr := serve(srv, "POST", "/pantry", `{"name":"rice"}`)
+		if rr.Code != http.StatusConflict {
+			t.Fatalf("Expected status %d, got %d", http.StatusConflict, rr.Code)
+		}
+		if p := decodeProblem(t, rr); p.Type != problemTypeConflict {
+			t.Errorf("Expected type %q, got %q", problemTypeConflict, p.Type)
+		}
+	})
+
+	t.Run("BadPaths", func(t *testing.T) {
+		tests := []struct {
+			method, path string
+			status       int
+		}{
+			{"GET", "/pantry/abc", http.StatusBadRequest},
+			{"GET", "/pantry/42", http.StatusNotFound},
+			{"GET", "/pantry/1/eat", http.StatusNotFound},
+			{"GET", "/pantry/1/consume", http.StatusMethodNotAllowed},
+			{"POST", "/pantry/expiring", http.StatusMethodNotAllowed},
+			{"PATCH", "/pantry/1", http.StatusMethodNotAllowed},
+		}
+		for _, tt := range tests {
+			if rr := serve(srv, tt.method, tt.path, ""); rr.Code != tt.status {
+				t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, rr.Code)
+			}
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if rr := serve(srv, "DELETE", "/pantry/1", ""); rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+		}
+		rr := serve(srv, "GET", "/pantry/1", "")
+		if p := decodeProblem(t, rr); rr.Code != http.StatusNotFound || p.Title != "Pantry item not found" {
+			t.Errorf("Expected a pantry item 404, got %d %+v", rr.Code, p)
+		}
+	})
+}
+
+func TestConsumeReplenishes(t *testing.T) {
+	store := NewMemoryStore()
+	srv := newTestServer(t, store)
+	if rr := serve(srv, "POST", "/pantry", `{"name":"Milk","stock":3,"unit":"l","threshold":2}`); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+	consume := func(body string) StockChange {
+		t.Helper()
+		rr := serve(srv, "POST", "/pantry/1/consume", body)
+		var change StockChange
+		if err := json.Unmarshal(rr.Body.Bytes(), &change); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		return change
+	}
+
+	if change := consume(`{"amount":1}`); change.PantryItem.Stock != 2 || change.Added != nil {
+		t.Errorf("Expected stock 2 and nothing added at the threshold, got %+v", change)
+	}
+	change := consume(`{"amount":1.5}`)
+	if change.PantryItem.Stock != 0.5 || change.Added == nil || change.Added.Name != "Milk" || change.Added.Quantity != "1.5 l" {
+		t.Fatalf("Expected Milk 1.5 l to be added below the threshold, got %+v", change)
+	}
+	if change := consume(`{"amount":1}`); change.PantryItem.Stock != 0 || change.Added != nil {
+		t.Errorf("Expected no second list item while Milk is listed, got %+v", change)
+	}
+	if items, _ := store.List(t.Context()); len(items) != 1 {
+		t.Errorf("Expected 1 item on the list, got %d", len(items))
+	}
+
+	for _, body := range []string{`{"amount":0}`, `{"amount":-1}`, `{"amount":2000000}`} {
+		rr := serve(srv, "POST", "/pantry/1/consume", body)
+		if p := decodeProblem(t, rr); rr.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "amount" {
+			t.Errorf("%s: expected an amount error, got %d %+v", body, rr.Code, p)
+		}
+	}
+}
+
+func TestBoughtMovesItemToPantry(t *testing.T) {
+	store := NewMemoryStore()
+	srv := newTestServer(t, store)
+	ctx := t.Context()
+	flour, _ := store.Create(ctx, Item{Name: "Flour", Quantity: "1 1/2 kg"})
+	bag, _ := store.Create(ctx, Item{Name: "Flour", Quantity: "a bag"})
+	soap, _ := store.Create(ctx, Item{Name: "Soap", Quantity: "2 bars of the lavender one please"})
+	bought := func(id int, body string) PantryItem {
+		t.Helper()
+		rr := serve(srv, "POST", "/items/"+jsonNumber(id)+"/bought", body)
+		var item PantryItem
+		if err := json.Unmarshal(rr.Body.Bytes(), &item); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		return item
+	}
+
+	if got := bought(flour.ID, ""); got.Name != "Flour" || got.Stock != 1.5 || got.Unit != "kg" {
+		t.Errorf("Expected 1.5 kg of Flour taken from the quantity, got %+v", got)
+	}
+	if got := bought(bag.ID, `{"stock":1,"best_before":"2027-06-30"}`); got.Stock != 2.5 || got.Unit != "kg" || got.BestBefore != "2027-06-30" {
+		t.Errorf("Expected the body to set stock and best-before, got %+v", got)
+	}
+	if got := bought(soap.ID, ""); got.Stock != 2 || got.Unit != "" {
+		t.Errorf("Expected a long unit to be dropped, got %+v", got)
+	}
+	// "500 ml" goes into a pantry item kept in litres as 0.5
+	if _, err := store.CreatePantryItem(ctx, PantryItem{Name: "Milk", Stock: 2, Unit: "L"}); err != nil {
+		t.Fatalf("CreatePantryItem failed: %v", err)
+	}
+	milk, _ := store.Create(ctx, Item{Name: "Milk", Quantity: "500 ml"})
+	if got := bought(milk.ID, ""); got.Stock != 2.5 || got.Unit != "L" {
+		t.Errorf("Expected 500 ml to make 2.5 L of Milk, got %+v", got)
+	}
+
+	// A chunked request without a body has no Content-Length
+	salt, _ := store.Create(ctx, Item{Name: "Salt", Quantity: "1"})
+	req := httptest.NewRequest("POST", "/items/"+jsonNumber(salt.ID)+"/bought", strings.NewReader(""))
+	req.ContentLength = -1
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d for a chunked request without a body, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if items, _ := store.List(ctx); len(items) != 0 {
+		t.Errorf("Expected bought items to leave the list, got %+v", items)
+	}
+
+	if rr := serve(srv, "POST", "/items/42/bought", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a missing item, got %d", http.StatusNotFound, rr.Code)
+	}
+	if rr := serve(srv, "GET", "/items/1/bought", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for GET, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+	eggs, _ := store.Create(ctx, Item{Name: "Eggs", Quantity: "6"})
+	if rr := serve(srv, "POST", "/items/"+jsonNumber(eggs.ID)+"/bought", `{"best_before":"soon"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid date, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if _, err := store.Get(ctx, eggs.ID); err != nil {
+		t.Errorf("Expected a rejected purchase to keep the item, got %v", err)
+	}
+}
+
+func TestExpiringPantryItems(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+	srv.now = func() time.Time { return time.Date(2027, 1, 10, 18, 0, 0, 0, time.Local) }
+	for _, body := range []string{
+		`{"name":"Yogurt","best_before":"2027-01-12"}`,
+		`{"name":"Cheese","best_before":"2027-01-08"}`,
+		`{"name":"Jam","best_before":"2027-06-01"}`,
+		`{"name":"Salt"}`,
+	} {
+		if rr := serve(srv, "POST", "/pantry", body); rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, rr.Code)
+		}
+	}
+	expiring := func(query string) []string {
+		t.Helper()
+		rr := serve(srv, "GET", "/pantry/expiring"+query, "")
+		var items []PantryItem
+		if err := json.Unmarshal(rr.Body.Bytes(), &items); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+
+	if got := expiring(""); len(got) != 2 || got[0] != "Cheese" || got[1] != "Yogurt" {
+		t.Errorf("Expected Cheese (expired) then Yogurt, got %v", got)
+	}
+	if got := expiring("?days=1"); len(got) != 1 || got[0] != "Cheese" {
+		t.Errorf("Expected only Cheese within a day, got %v", got)
+	}
+	if got := expiring("?days=365"); len(got) != 3 {
+		t.Errorf("Expected every dated item within a year, got %v", got)
+	}
+	if rr := serve(srv, "GET", "/pantry/expiring?days=-1", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for negative days, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestPantryNotServedWithoutPantryStore(t *testing.T) {
+	store := NewMemoryStore()
+	item, _ := store.Create(t.Context(), Item{Name: "Milk", Quantity: "1"})
+	srv := newTestServer(t, struct{ ItemStore }{store})
+	if rr := serve(srv, "GET", "/pantry", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for /pantry, got %d", http.StatusNotFound, rr.Code)
+	}
+	if rr := serve(srv, "POST", "/items/"+jsonNumber(item.ID)+"/bought", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for bought, got %d", http.StatusNotFound, rr.Code)
+	}
+}
@@ -0,0 +1,293 @@
+package shoppinglist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/text/currency"
+)
+
+// --- Prices and Budget ---
+
+// Money amounts are integers in the currency's minor unit (cents for EUR or USD, yen for
+// JPY), so they are never rounded by floating point.
+const (
+	MaxUnitPrice = 1_000_000_000     // Per unit of an item's quantity
+	MaxBudget    = 1_000_000_000_000 // For the whole list
+)
+
+// Money is an amount in minor units of an ISO 4217 currency.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Price is the last-known unit price of items with a given name (ignoring case).
+type Price struct {
+	Name      string    `json:"name"`
+	UnitPrice int64     `json:"unit_price"`
+	Currency  string    `json:"currency"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var (
+	// ErrPriceNotFound is returned by a PriceStore when no price is known for a name.
+	ErrPriceNotFound = errors.New("price not found")
+	// ErrBudgetNotSet is returned by a PriceStore when the list has no budget.
+	ErrBudgetNotSet = errors.New("budget not set")
+)
+
+// PriceStore remembers the last price paid per item name and holds the list's budget.
+// The built-in stores implement it next to ItemStore.
+type PriceStore interface {
+	// ListPrices returns every known price ordered by name.
+	ListPrices(ctx context.Context) ([]Price, error)
+	// LastPrice returns the known price for name (ignoring case), or ErrPriceNotFound.
+	LastPrice(ctx context.Context, name string) (Price, error)
+	// RecordPrice remembers price for its name, replacing any earlier one, and returns
+	// it with UpdatedAt set.
+	RecordPrice(ctx context.Context, price Price) (Price, error)
+	// GetBudget returns the list's budget, or ErrBudgetNotSet.
+	GetBudget(ctx context.Context) (Money, error)
+	// SetBudget validates and stores the list's budget.
+	SetBudget(ctx context.Context, budget Money) (Money, error)
+	// DeleteBudget removes the list's budget, or returns ErrBudgetNotSet.
+	DeleteBudget(ctx context.Context) error
+}
+
+// normalizeCurrency upper-cases code and checks it is an ISO 4217 currency, returning a
+// validation message if not.
+func normalizeCurrency(code string) (string, string) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", "must be a 3-letter ISO 4217 code such as EUR"
+	}
+	if _, err := currency.ParseISO(code); err != nil {
+		return "", "must be a 3-letter ISO 4217 code such as EUR"
+	}
+	return code, ""
+}
+
+// checkItemPrice validates the price fields of item for normalizeItem, appending to fields.
+func checkItemPrice(item *Item, fields []FieldError) []FieldError {
+	if item.UnitPrice == nil {
+		if strings.TrimSpace(item.Currency) != "" {
+			fields = append(fields, FieldError{Field: "currency", Message: "requires unit_price"})
+		}
+		item.Currency = ""
+		return fields
+	}
+	if p := *item.UnitPrice; p < 0 || p > MaxUnitPrice {
+		fields = append(fields, FieldError{Field: "unit_price", Message: fmt.Sprintf("must be between 0 and %d", MaxUnitPrice)})
+	}
+	code, msg := normalizeCurrency(item.Currency)
+	if msg != "" {
+		return append(fields, FieldError{Field: "currency", Message: msg})
+	}
+	item.Currency = code
+	return fields
+}
+
+// normalizeBudget checks a budget, returning a *ValidationError listing every invalid field.
+func normalizeBudget(budget Money) (Money, error) {
+	var fields []FieldError
+	if budget.Amount < 0 || budget.Amount > MaxBudget {
+		fields = append(fields, FieldError{Field: "amount", Message: fmt.Sprintf("must be between 0 and %d", MaxBudget)})
+	}
+	code, msg := normalizeCurrency(budget.Currency)
+	if msg != "" {
+		fields = append(fields, FieldError{Field: "currency", Message: msg})
+	}
+	if len(fields) > 0 {
+		return Money{}, &ValidationError{Resource: "budget", Fields: fields}
+	}
+	budget.Currency = code
+	return budget, nil
+}
+
+// normalizePrice checks a price to remember, returning a *ValidationError listing every
+// invalid field.
+func normalizePrice(price Price) (Price, error) {
+	var fields []FieldError
+	name, msg := normalizeText(price.Name, FieldLimits{}.withDefaults().MaxName)
+	if msg != "" {
+		fields = append(fields, FieldError{Field: "name", Message: msg})
+	}
+	item := Item{UnitPrice: &price.UnitPrice, Currency: price.Currency}
+	fields = checkItemPrice(&item, fields)
+	if len(fields) > 0 {
+		return Price{}, &ValidationError{Resource: "price", Fields: fields}
+	}
+	price.Name, price.Currency = name, item.Currency
+	return price, nil
+}
+
+// sortPrices orders prices like the SQL stores' "ORDER BY lower(name)".
+func sortPrices(prices []Price) {
+	sort.Slice(prices, func(i, j int) bool {
+		return strings.ToLower(prices[i].Name) < strings.ToLower(prices[j].Name)
+	})
+}
+
+// --- Estimates ---
+
+// Estimate is what the items on the list will roughly cost.
+type Estimate struct {
+	Totals     []Money `json:"totals"`           // One per currency, ordered by code
+	Unpriced   int     `json:"unpriced"`         // Items without a price, left out of the totals
+	Budget     *Money  `json:"budget,omitempty"` // nil if the list has no budget
+	OverBudget bool    `json:"over_budget"`      // The total in the budget's currency exceeds it
+}
+
+// ItemsWithEstimate is the response of GET /items?include=estimate.
+type ItemsWithEstimate struct {
+	Items    []Item   `json:"items"`
+	Estimate Estimate `json:"estimate"`
+}
+
+// estimate adds up the cost of every priced item: its unit price times the amount its
+// quantity starts with ("2 l" is 2, the upper end of "2-3" is 3, "a bag" is 1), rounded to
+// the nearest minor unit.
+func estimate(items []Item, budget *Money) Estimate {
+	totals := map[string]*big.Int{}
+	est := Estimate{Totals: []Money{}, Budget: budget}
+	for _, item := range items {
+		if item.UnitPrice == nil {
+			est.Unpriced++
+			continue
+		}
+		amount := big.NewRat(1, 1)
+		if q, ok := parseQuantity(item.Quantity); ok {
+			amount = q.amount
+			if q.upper != nil {
+				amount = q.upper
+			}
+		}
+		cost := roundRat(new(big.Rat).Mul(amount, new(big.Rat).SetInt64(*item.UnitPrice)))
+		if totals[item.Currency] == nil {
+			totals[item.Currency] = new(big.Int)
+		}
+		totals[item.Currency].Add(totals[item.Currency], cost)
+	}
+
+	for code, total := range totals {
+		amount := int64(math.MaxInt64) // Only reachable with absurd quantities
+		if total.IsInt64() {
+			amount = total.Int64()
+		}
+		est.Totals = append(est.Totals, Money{Amount: amount, Currency: code})
+	}
+	sort.Slice(est.Totals, func(i, j int) bool { return est.Totals[i].Currency < est.Totals[j].Currency })
+	if budget != nil {
+		for _, total := range est.Totals {
+			if total.Currency == budget.Currency && total.Amount > budget.Amount {
+				est.OverBudget = true
+			}
+		}
+	}
+	return est
+}
+
+// roundRat rounds a non-negative r to the nearest integer, halves up.
+func roundRat(r *big.Rat) *big.Int {
+	n := new(big.Int).Mul(r.Num(), big.NewInt(2))
+	n.Add(n, r.Denom())
+	return n.Quo(n, new(big.Int).Mul(r.Denom(), big.NewInt(2)))
+}
+
+// --- Price Memory ---
+
+// withLastPrice fills in the last-known price of an item added without one. Prices are
+// a convenience, so lookup failures are only logged.
+func (s *Server) withLastPrice(ctx context.Context, item Item) Item {
+	if s.prices == nil || item.UnitPrice != nil {
+		return item
+	}
+	price, err := s.prices.LastPrice(ctx, item.Name)
+	if err != nil {
+		if !errors.Is(err, ErrPriceNotFound) {
+			loggerFrom(ctx).Error("Error looking up the last price", "name", item.Name, "err", err)
+		}
+		return item
+	}
+	item.UnitPrice, item.Currency = &price.UnitPrice, price.Currency
+	return item
+}
+
+// rememberPrice records the price of an item that was saved with one.
+func (s *Server) rememberPrice(ctx context.Context, item Item) {
+	if s.prices == nil || item.UnitPrice == nil {
+		return
+	}
+	if _, err := s.prices.RecordPrice(ctx, Price{Name: item.Name, UnitPrice: *item.UnitPrice, Currency: item.Currency}); err != nil {
+		loggerFrom(ctx).Error("Error recording the price", "name", item.Name, "err", err)
+	}
+}
+
+// budget returns the list's budget, or nil if it has none or the store keeps no budgets.
+func (s *Server) budget(ctx context.Context) (*Money, error) {
+	if s.prices == nil {
+		return nil, nil
+	}
+	budget, err := s.prices.GetBudget(ctx)
+	if errors.Is(err, ErrBudgetNotSet) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// --- Price and Budget Handlers ---
+
+func (s *Server) pricesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r, "GET")
+		return
+	}
+	prices, err := s.prices.ListPrices(r.Context())
+	if err != nil {
+		s.requestLogger(r).Error("Error listing prices", "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, prices)
+}
+
+func (s *Server) budgetHandler(w http.ResponseWriter, r *http.Request) {
+	var budget Money
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		budget, err = s.prices.GetBudget(r.Context())
+	case http.MethodPut:
+		if !s.decodeJSON(w, r, &budget) {
+			return
+		}
+		if budget, err = normalizeBudget(budget); err == nil {
+			budget, err = s.prices.SetBudget(r.Context(), budget)
+		}
+	case http.MethodDelete:
+		if err = s.prices.DeleteBudget(r.Context()); err == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		s.methodNotAllowed(w, r, "GET, PUT, DELETE")
+		return
+	}
+	if err != nil {
+		s.requestLogger(r).Warn("Error handling budget", "method", r.Method, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, budget)
+}
@@ -0,0 +1,164 @@
+package shoppinglist
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	price := func(p int64) *int64 { return &p }
+	items := []Item{
+		{Name: "Milk", Quantity: "2 l", UnitPrice: price(129), Currency: "EUR"},          // 258
+		{Name: "Apples", Quantity: "1.5 kg", UnitPrice: price(299), Currency: "EUR"},     // 448.5, rounded up
+		{Name: "Eggs", Quantity: "6-10", UnitPrice: price(30), Currency: "EUR"},          // Upper end: 300
+		{Name: "Bread", Quantity: "a loaf", UnitPrice: price(250), Currency: "EUR"},      // No amount: 250
+		{Name: "Cheese", Quantity: "1/3 wheel", UnitPrice: price(1000), Currency: "CHF"}, // 333.33, rounded down
+		{Name: "Salt", Quantity: "1"},
+	}
+
+	est := estimate(items, &Money{Amount: 1200, Currency: "EUR"})
+	want := []Money{{Amount: 333, Currency: "CHF"}, {Amount: 1257, Currency: "EUR"}}
+	if len(est.Totals) != len(want) || est.Totals[0] != want[0] || est.Totals[1] != want[1] {
+		t.Errorf("Expected totals %+v, got %+v", want, est.Totals)
+	}
+	if est.Unpriced != 1 || !est.OverBudget {
+		t.Errorf("Expected one unpriced item and the EUR budget exceeded, got %+v", est)
+	}
+
+	if est := estimate(items, &Money{Amount: 1257, Currency: "EUR"}); est.OverBudget {
+		t.Error("Expected a total equal to the budget not to exceed it")
+	}
+	if est := estimate(items, &Money{Amount: 1, Currency: "USD"}); est.OverBudget {
+		t.Error("Expected a budget in another currency never to be exceeded")
+	}
+	if est := estimate(nil, nil); est.Totals == nil || len(est.Totals) != 0 || est.OverBudget {
+		t.Errorf("Expected an empty estimate, got %+v", est)
+	}
+
+	huge := []Item{{Name: "Gold", Quantity: "99999999999999", UnitPrice: price(MaxUnitPrice), Currency: "USD"}}
+	if est := estimate(huge, nil); est.Totals[0].Amount != math.MaxInt64 {
+		t.Errorf("Expected an overflowing total to saturate, got %d", est.Totals[0].Amount)
+	}
+}
+
+func TestPriceHandlers(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+
+	rr := serve(srv, "POST", "/items", `{"name":"Milk","quantity":"2 l","unit_price":129,"currency":"eur"}`)
+	var milk Item
+	if err := json.Unmarshal(rr.Body.Bytes(), &milk); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	if milk.UnitPrice == nil || *milk.UnitPrice != 129 || milk.Currency != "EUR" {
+		t.Errorf("Expected the price on the created item, got %+v", milk)
+	}
+
+	t.Run("RemembersPrices", func(t *testing.T) {
+		rr := serve(srv, "POST", "/items", `{"name":"milk","quantity":"1 l"}`)
+		var again Item
+		if err := json.Unmarshal(rr.Body.Bytes(), &again); err != nil || rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+		if again.UnitPrice == nil || *again.UnitPrice != 129 || again.Currency != "EUR" {
+			t.Errorf("Expected the last-known price to be filled in, got %+v", again)
+		}
+		serve(srv, "DELETE", "/items/"+jsonNumber(again.ID), "")
+
+		rr = serve(srv, "PUT", "/items/"+jsonNumber(milk.ID), `{"name":"Milk","quantity":"2 l","unit_price":139,"currency":"EUR"}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		rr = serve(srv, "GET", "/prices", "")
+		var prices []Price
+		if err := json.Unmarshal(rr.Body.Bytes(), &prices); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %v", http.StatusOK, rr.Code, err)
+		}
+		if len(prices) != 1 || prices[0].Name != "Milk" || prices[0].UnitPrice != 139 {
+			t.Errorf("Expected the updated price to be remembered, got %+v", prices)
+		}
+	})
+
+	t.Run("Budget", func(t *testing.T) {
+		rr := serve(srv, "GET", "/budget", "")
+		if p := decodeProblem(t, rr); rr.Code != http.StatusNotFound || p.Title != "Budget not set" {
+			t.Errorf("Expected a budget 404, got %d %+v", rr.Code, p)
+		}
+		rr = serve(srv, "PUT", "/budget", `{"amount":-5,"currency":"EURO"}`)
+		if p := decodeProblem(t, rr); rr.Code != http.StatusBadRequest || p.Title != "Invalid budget" || len(p.Errors) != 2 {
+			t.Errorf("Expected amount and currency errors, got %d %+v", rr.Code, p)
+		}
+		rr = serve(srv, "PUT", "/budget", `{"amount":250,"currency":"eur"}`)
+		var budget Money
+		if err := json.Unmarshal(rr.Body.Bytes(), &budget); err != nil || rr.Code != http.StatusOK || budget != (Money{Amount: 250, Currency: "EUR"}) {
+			t.Fatalf("Expected the budget back, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if rr := serve(srv, "POST", "/budget", ""); rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+		}
+	})
+
+	t.Run("Estimate", func(t *testing.T) {
+		rr := serve(srv, "GET", "/items?include=estimate", "")
+		var got ItemsWithEstimate
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		est := got.Estimate
+		if len(got.Items) != 1 || len(est.Totals) != 1 || est.Totals[0] != (Money{Amount: 278, Currency: "EUR"}) ||
+			est.Budget == nil || !est.OverBudget {
+			t.Errorf("Expected 2 l at 1.39 EUR to exceed the 2.50 EUR budget, got %+v", got)
+		}
+
+		if rr := serve(srv, "GET", "/items?include=everything", ""); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an unknown include, got %d", http.StatusBadRequest, rr.Code)
+		}
+		// Without include the response stays a plain array
+		var items []Item
+		if rr := serve(srv, "GET", "/items", ""); json.Unmarshal(rr.Body.Bytes(), &items) != nil {
+			t.Errorf("Expected a JSON array, got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("DeleteBudget", func(t *testing.T) {
+		if rr := serve(srv, "DELETE", "/budget", ""); rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+		}
+		rr := serve(srv, "GET", "/items?include=estimate", "")
+		var got ItemsWithEstimate
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || got.Estimate.Budget != nil || got.Estimate.OverBudget {
+			t.Errorf("Expected no budget in the estimate, got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		rr := serve(srv, "POST", "/items", `{"name":"Bread","quantity":"1","currency":"EUR"}`)
+		if p := decodeProblem(t, rr); rr.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "currency" {
+			t.Errorf("Expected a currency error, got %d %+v", rr.Code, p)
+		}
+		rr = serve(srv, "PUT", "/items/999", `{"name":"Bread","quantity":"1"}`)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for a missing item, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestPricesNotServedWithoutPriceStore(t *testing.T) {
+	srv := newTestServer(t, struct{ ItemStore }{NewMemoryStore()})
+	for _, path := range []string{"/prices", "/budget"} {
+		if rr := serve(srv, "GET", path, ""); rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusNotFound, path, rr.Code)
+		}
+	}
+	// Estimates still work from the prices on the items
+	rr := serve(srv, "POST", "/items", `{"name":"Milk","quantity":"2","unit_price":100,"currency":"EUR"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+	rr = serve(srv, "GET", "/items?include=estimate", "")
+	var got ItemsWithEstimate
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || len(got.Estimate.Totals) != 1 || got.Estimate.Totals[0].Amount != 200 {
+		t.Errorf("Expected a 2.00 EUR estimate, got %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,117 @@
+package shoppinglist
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// --- Problem Details (RFC 7807) ---
+
+// problemContentType is the media type of every error response.
+const problemContentType = "application/problem+json"
+
+// Problem types. Errors without a more specific type use "about:blank", whose title is
+// the HTTP status text.
+const (
+	problemTypeValidation  = "urn:shoppinglist:problem:validation"
+	problemTypeInvalidBody = "urn:shoppinglist:problem:invalid-body"
+	problemTypeNotFound    = "urn:shoppinglist:problem:not-found"
+	problemTypeConflict    = "urn:shoppinglist:problem:conflict"
+	problemTypeRateLimited = "urn:shoppinglist:problem:rate-limited"
+)
+
+// Problem is an RFC 7807 problem details object, extended with the request ID (to quote
+// when reporting an error) and the invalid fields of a validation error.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// writeProblem sends p, filling in the title, instance and request ID.
+func (s *Server) writeProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	p.Instance = r.URL.Path
+	p.RequestID = RequestIDFromContext(r.Context())
+
+	h := w.Header()
+	h.Set("Content-Type", problemContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Del("Content-Length")
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		s.requestLogger(r).Error("Error encoding problem details to JSON", "err", err)
+	}
+}
+
+// writeStatus sends a problem with just a status and a human-readable detail.
+func (s *Server) writeStatus(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	s.writeProblem(w, r, Problem{Status: status, Detail: detail})
+}
+
+// writeQueryProblem answers 400 for invalid query parameters, with the first one in the
+// detail.
+func (s *Server) writeQueryProblem(w http.ResponseWriter, r *http.Request, fields ...FieldError) {
+	s.writeProblem(w, r, Problem{
+		Type:   problemTypeValidation,
+		Title:  "Invalid query parameter",
+		Status: http.StatusBadRequest,
+		Detail: fields[0].Field + " " + fields[0].Message,
+		Errors: fields,
+	})
+}
+
+// writeError maps a store error to its response: the not-found errors are 404, a
+// validation error is 400 with its fields, a name clash is 409, and anything else is a
+// 500 whose cause stays in the log.
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *ValidationError
+	switch {
+	case errors.As(err, &verr):
+		s.writeProblem(w, r, Problem{
+			Type:   problemTypeValidation,
+			Title:  "Invalid " + verr.resource(),
+			Status: http.StatusBadRequest,
+			Detail: verr.Error(),
+			Errors: verr.Fields,
+		})
+	case errors.Is(err, ErrValidation):
+		s.writeProblem(w, r, Problem{Type: problemTypeValidation, Title: "Invalid item", Status: http.StatusBadRequest, Detail: err.Error()})
+	case errors.Is(err, ErrRecipeNotFound):
+		s.writeProblem(w, r, Problem{Type: problemTypeNotFound, Title: "Recipe not found", Status: http.StatusNotFound, Detail: err.Error()})
+	case errors.Is(err, ErrPantryItemNotFound):
+		s.writeProblem(w, r, Problem{Type: problemTypeNotFound, Title: "Pantry item not found", Status: http.StatusNotFound, Detail: err.Error()})
+	case errors.Is(err, ErrPantryItemExists):
+		s.writeProblem(w, r, Problem{Type: problemTypeConflict, Title: "Pantry item already exists", Status: http.StatusConflict, Detail: err.Error()})
+	case errors.Is(err, ErrRecurringItemNotFound):
+		s.writeProblem(w, r, Problem{Type: problemTypeNotFound, Title: "Recurring item not found", Status: http.StatusNotFound, Detail: err.Error()})
+	case errors.Is(err, ErrStoreNotFound):
+		s.writeProblem(w, r, Problem{Type: problemTypeNotFound, Title: "Store not found", Status: http.StatusNotFound, Detail: err.Error()})
+	case errors.Is(err, ErrMappingNotFound):
+		s.writeProblem(w, r, Problem{Type: problemTypeNotFound, Title: "Mapping not found", Status: http.StatusNotFound, Detail: err.Error()})
+	case errors.Is(err, ErrPriceNotFound):
+		s.writeProblem(w, r, Problem{Type: problemTypeNotFound, Title: "Price not found", Status: http.StatusNotFound, Detail: err.Error()})
+	case errors.Is(err, ErrBudgetNotSet):
+		s.writeProblem(w, r, Problem{Type: problemTypeNotFound, Title: "Budget not set", Status: http.StatusNotFound, Detail: err.Error()})
+	case errors.Is(err, ErrNotFound):
+		s.writeProblem(w, r, Problem{Type: problemTypeNotFound, Title: "Item not found", Status: http.StatusNotFound, Detail: err.Error()})
+	default:
+		s.writeStatus(w, r, http.StatusInternalServerError, "")
+	}
+}
+
+// methodNotAllowed answers 405 with the Allow header listing the supported methods.
+func (s *Server) methodNotAllowed(w http.ResponseWriter, r *http.Request, allow string) {
+	w.Header().Set("Allow", allow)
+	s.writeStatus(w, r, http.StatusMethodNotAllowed, r.Method+" is not supported here; use "+allow)
+}
@@ -0,0 +1,138 @@
+package shoppinglist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// --- Problem Details Tests ---
+
+// decodeProblem checks that rr is a problem+json response and returns its body.
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	if ct := rr.Header().Get("Content-Type"); ct != problemContentType {
+		t.Errorf("Expected Content-Type %s, got %q", problemContentType, ct)
+	}
+	var p Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Invalid problem JSON %q: %v", rr.Body.String(), err)
+	}
+	if p.Status != rr.Code {
+		t.Errorf("Expected the problem status to match the response status %d, got %d", rr.Code, p.Status)
+	}
+	return p
+}
+
+func TestProblemResponses(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-Request-ID", "req-1234")
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Validation", func(t *testing.T) {
+		rr := do("POST", "/items", `{"name":" ","quantity":""}`)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		p := decodeProblem(t, rr)
+		want := []FieldError{{Field: "name", Message: "cannot be empty"}, {Field: "quantity", Message: "cannot be empty"}}
+		if p.Type != problemTypeValidation || fmt.Sprint(p.Errors) != fmt.Sprint(want) {
+			t.Errorf("Expected a validation problem listing both fields, got %+v", p)
+		}
+		if p.RequestID != "req-1234" || p.Instance != "/items" {
+			t.Errorf("Expected request ID and instance, got %q and %q", p.RequestID, p.Instance)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		rr := do("DELETE", "/items/42", "")
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+		if p := decodeProblem(t, rr); p.Type != problemTypeNotFound || p.Title != "Item not found" {
+			t.Errorf("Expected a not-found problem, got %+v", p)
+		}
+	})
+
+	t.Run("UnknownPath", func(t *testing.T) {
+		rr := do("GET", "/nope", "")
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+		if p := decodeProblem(t, rr); p.Type != "about:blank" || p.Title != "Not Found" {
+			t.Errorf("Expected an about:blank problem, got %+v", p)
+		}
+	})
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		rr := do("GET", "/items/1", "")
+		if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") != "PUT, DELETE" {
+			t.Fatalf("Expected 405 with Allow: PUT, DELETE, got %d %q", rr.Code, rr.Header().Get("Allow"))
+		}
+		decodeProblem(t, rr)
+	})
+
+	t.Run("InvalidBody", func(t *testing.T) {
+		rr := do("POST", "/items", `{"name":`)
+		if p := decodeProblem(t, rr); p.Type != problemTypeInvalidBody || !strings.Contains(p.Detail, "badly-formed JSON") {
+			t.Errorf("Expected an invalid-body problem, got %+v", p)
+		}
+	})
+}
+
+func TestWriteErrorHidesInternalErrors(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+	rr := httptest.NewRecorder()
+	srv.writeError(rr, httptest.NewRequest("GET", "/items", nil), errors.New("pq: password authentication failed"))
+	p := decodeProblem(t, rr)
+	if rr.Code != http.StatusInternalServerError || strings.Contains(rr.Body.String(), "password") {
+		t.Errorf("Expected a generic 500, got %d %q", rr.Code, rr.Body.String())
+	}
+	if p.Title != "Internal Server Error" || p.Detail != "" {
+		t.Errorf("Unexpected problem %+v", p)
+	}
+}
+
+func TestWriteErrorAnswersClientErrors(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+	for _, err := range []error{
+		ErrNotFound, ErrRecipeNotFound, ErrValidation, ErrPantryItemNotFound, ErrPantryItemExists,
+		ErrRecurringItemNotFound, ErrPriceNotFound, ErrBudgetNotSet, ErrStoreNotFound, ErrMappingNotFound,
+	} {
+		if !isClientError(err) {
+			t.Errorf("Expected %v to be a client error", err)
+		}
+		rr := httptest.NewRecorder()
+		srv.writeError(rr, httptest.NewRequest("GET", "/items", nil), fmt.Errorf("wrapped: %w", err))
+		if rr.Code >= http.StatusInternalServerError {
+			t.Errorf("Expected a client error status for %v, got %d", err, rr.Code)
+		}
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	_, err := normalizeItem(Item{}, FieldLimits{})
+	err = fmt.Errorf("creating item: %w", err)
+	if !errors.Is(err, ErrValidation) {
+		t.Error("Expected errors.Is(err, ErrValidation)")
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 2 {
+		t.Fatalf("Expected a ValidationError with two fields, got %v", err)
+	}
+	if got := verr.Error(); got != "invalid item: name cannot be empty; quantity cannot be empty" {
+		t.Errorf("Unexpected message %q", got)
+	}
+	if _, err := normalizeItem(Item{Name: "Milk", Quantity: "1"}, FieldLimits{}); err != nil {
+		t.Error("Expected a valid item to pass")
+	}
+}
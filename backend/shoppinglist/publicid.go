@@ -0,0 +1,139 @@
+package shoppinglist
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Public Item IDs ---
+
+// UIDStore looks items up by UID, the public ID that /items/{id} accepts instead of the
+// serial ID. The built-in stores implement it next to ItemStore and give every item a
+// UUIDv7 when it is created.
+type UIDStore interface {
+	// GetByUID retrieves the item with the given UID, in the canonical lower-case form.
+	GetByUID(ctx context.Context, uid string) (Item, error)
+}
+
+// newItemUID returns a random UUIDv7 for an item created at the given time. Its first 48
+// bits are the time in Unix milliseconds, so UIDs sort roughly by creation time and keep
+// index inserts local, while the 74 random bits make them impossible to guess.
+func newItemUID(at time.Time) string {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(err) // crypto/rand never fails on supported platforms
+	}
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(at.UnixMilli()))
+	copy(b[:6], ms[2:])
+	b[6] = b[6]&0x0f | 0x70 // Version 7
+	b[8] = b[8]&0x3f | 0x80 // RFC 9562 variant
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	hex.Encode(s[9:13], b[4:6])
+	hex.Encode(s[14:18], b[6:8])
+	hex.Encode(s[19:23], b[8:10])
+	hex.Encode(s[24:], b[10:])
+	s[8], s[13], s[18], s[23] = '-', '-', '-', '-'
+	return string(s[:])
+}
+
+// parseUUID returns s in the canonical lower-case form if it is a UUID, such as
+// "0192a8e4-7c1b-7def-8a12-3456789abcde". Any version is accepted.
+func parseUUID(s string) (string, bool) {
+	if len(s) != 36 {
+		return "", false
+	}
+	for i, c := range s {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return "", false
+			}
+		case '0' <= c && c <= '9', 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+		default:
+			return "", false
+		}
+	}
+	return strings.ToLower(s), true
+}
+
+// itemID resolves the {id} of /items/{id}, which is the item's UID or, unless
+// Options.RejectSerialIDs is set, its serial ID. It writes the error response and
+// returns false if the item cannot be found.
+func (s *Server) itemID(w http.ResponseWriter, r *http.Request, ref string) (int, bool) {
+	if uid, ok := parseUUID(ref); ok && s.uids != nil {
+		item, err := s.uids.GetByUID(r.Context(), uid)
+		if err != nil {
+			s.requestLogger(r).Warn("Error getting item by UID", "uid", uid, "err", err)
+			s.writeError(w, r, err)
+			return 0, false
+		}
+		return item.ID, true
+	}
+	if !s.rejectSerialIDs {
+		if id, err := strconv.Atoi(ref); err == nil && id > 0 {
+			return id, true
+		}
+	}
+	s.writeStatus(w, r, http.StatusBadRequest, "Invalid item ID format")
+	return 0, false
+}
+
+// publicItems leaves the serial IDs out of items sent to clients once
+// Options.RejectSerialIDs is set, so clients only ever see the UIDs they can use.
+// It changes items in place and returns them.
+func (s *Server) publicItems(items []Item) []Item {
+	if s.rejectSerialIDs {
+		for i := range items {
+			items[i].ID = 0
+		}
+	}
+	return items
+}
+
+// publicItem is publicItems for a single item.
+func (s *Server) publicItem(item Item) Item {
+	return s.publicItems([]Item{item})[0]
+}
+
+// publicChanges is publicItems for a page of the change feed. A tombstone left before
+// its item had a UID names nothing a client can use without the serial ID, so it is
+// dropped; Next still moves past it.
+func (s *Server) publicChanges(changes ChangeSet) ChangeSet {
+	if !s.rejectSerialIDs {
+		return changes
+	}
+	for i := range changes.Upserts {
+		changes.Upserts[i].ID = 0
+	}
+	tombstones := changes.Tombstones[:0]
+	for _, t := range changes.Tombstones {
+		if t.UID != "" {
+			t.ID = 0
+			tombstones = append(tombstones, t)
+		}
+	}
+	changes.Tombstones = tombstones
+	return changes
+}
+
+// publicResult is publicItems for the result of a sync mutation.
+func (s *Server) publicResult(result MutationResult) MutationResult {
+	if s.rejectSerialIDs {
+		result.ID = 0
+		if result.Item != nil {
+			item := *result.Item
+			item.ID = 0
+			result.Item = &item
+		}
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package shoppinglist
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewItemUID(t *testing.T) {
+	at := time.Date(2026, 10, 18, 15, 8, 59, 255_000_000, time.UTC)
+	uid := newItemUID(at)
+	if got, ok := parseUUID(uid); !ok || got != uid {
+		t.Fatalf("Expected a canonical UUID, got %q", uid)
+	}
+	ms, err := strconv.ParseInt(uid[0:8]+uid[9:13], 16, 64)
+	if err != nil || ms != at.UnixMilli() {
+		t.Errorf("Expected the first 48 bits to be %d, got %d (err %v)", at.UnixMilli(), ms, err)
+	}
+	if uid[14] != '7' || (uid[19] != '8' && uid[19] != '9' && uid[19] != 'a' && uid[19] != 'b') {
+		t.Errorf("Expected version 7 and the RFC 9562 variant, got %q", uid)
+	}
+	if newItemUID(at) == uid {
+		t.Error("Expected UIDs made at the same time to differ")
+	}
+	if later := newItemUID(at.Add(time.Millisecond)); later <= uid {
+		t.Errorf("Expected a later UID to sort after %q, got %q", uid, later)
+	}
+}
+
+func TestParseUUID(t *testing.T) {
+	if got, ok := parseUUID("0192A8E4-7C1B-7DEF-8A12-3456789ABCDE"); !ok || got != "0192a8e4-7c1b-7def-8a12-3456789abcde" {
+		t.Errorf("Expected the lower-case form, got %q %v", got, ok)
+	}
+	for _, s := range []string{"", "42", "0192a8e47c1b7def8a123456789abcde", "0192a8e4-7c1b-7def-8a12-3456789abcdg", "{0192a8e4-7c1b-7def-8a12-3456789abcde}"} {
+		if _, ok := parseUUID(s); ok {
+			t.Errorf("Expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestItemDetailAcceptsUIDs(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+	add := func(name string) Item {
+		t.Helper()
+		rr := serve(srv, "POST", "/items", `{"name":"`+name+`","quantity":"1"}`)
+		var item Item
+		if err := json.Unmarshal(rr.Body.Bytes(), &item); err != nil || rr.Code != http.StatusCreated || item.UID == "" {
+			t.Fatalf("Expected an item with a UID, got %d: %s", rr.Code, rr.Body.String())
+		}
+		return item
+	}
+	milk, bread := add("Milk"), add("Bread")
+
+	rr := serve(srv, "PUT", "/items/"+milk.UID, `{"name":"Milk","quantity":"2 l"}`)
+	var updated Item
+	if err := json.Unmarshal(rr.Body.Bytes(), &updated); err != nil || rr.Code != http.StatusOK || updated.ID != milk.ID || updated.UID != milk.UID || updated.Quantity != "2 l" {
+		t.Errorf("Expected PUT by UID to update Milk, got %d: %s", rr.Code, rr.Body.String())
+	}
+	// Upper case is the same UUID
+	if rr := serve(srv, "DELETE", "/items/"+strings.ToUpper(milk.UID), ""); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected DELETE by UID to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := serve(srv, "DELETE", "/items/"+milk.UID, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a deleted UID, got %d", http.StatusNotFound, rr.Code)
+	}
+	// Serial IDs keep working during the transition
+	if rr := serve(srv, "DELETE", "/items/"+jsonNumber(bread.ID), ""); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected DELETE by serial ID to succeed, got %d", rr.Code)
+	}
+	if rr := serve(srv, "DELETE", "/items/not-an-id", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestRejectSerialIDs(t *testing.T) {
+	srv, err := New(Options{Store: NewMemoryStore(), RejectSerialIDs: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	rr := serve(srv, "POST", "/items", `{"name":"Milk","quantity":"1"}`)
+	var milk Item
+	if err := json.Unmarshal(rr.Body.Bytes(), &milk); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	if milk.ID != 0 || milk.UID == "" {
+		t.Errorf("Expected only a UID in the response, got %s", rr.Body.String())
+	}
+	if rr := serve(srv, "DELETE", "/items/1", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a serial ID, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	// No response names an item by its serial ID
+	for _, target := range []string{"/items", "/items?include=estimate", "/search?q=milk", "/sync"} {
+		if rr := serve(srv, "GET", target, ""); rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"id"`) {
+			t.Errorf("GET %s: expected status %d without IDs, got %d: %s", target, http.StatusOK, rr.Code, rr.Body.String())
+		}
+	}
+	rr = serve(srv, "PUT", "/items/"+milk.UID, `{"name":"Milk","quantity":"2"}`)
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"id"`) {
+		t.Errorf("Expected the updated item without its ID, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = serve(srv, "POST", "/sync", `{"mutations":[{"op":"upsert","uid":"`+milk.UID+`","at":"2026-10-18T12:00:00Z","quantity":"3"}]}`)
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"id"`) {
+		t.Errorf("Expected the mutation result without IDs, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = serve(srv, "POST", "/sync", `{"mutations":[{"op":"delete","id":1,"at":"2026-10-18T12:00:00Z"}]}`)
+	if problem := decodeProblem(t, rr); rr.Code != http.StatusBadRequest || len(problem.Errors) != 1 || problem.Errors[0].Field != "mutations[0].id" {
+		t.Errorf("Expected a field error for a mutation by serial ID, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := serve(srv, "DELETE", "/items/"+milk.UID, ""); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected DELETE by UID to succeed, got %d", rr.Code)
+	}
+	var changes ChangeSet
+	rr = serve(srv, "GET", "/sync?since=1", "")
+	if err := json.Unmarshal(rr.Body.Bytes(), &changes); err != nil || len(changes.Tombstones) != 1 ||
+		changes.Tombstones[0].UID != milk.UID || strings.Contains(rr.Body.String(), `"id"`) {
+		t.Errorf("Expected a tombstone with only the UID, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestPublicChangesDropsTombstonesWithoutUID(t *testing.T) {
+	srv := &Server{rejectSerialIDs: true}
+	changes := srv.publicChanges(ChangeSet{
+		Upserts:    []SyncItem{{Item: Item{ID: 1, UID: "0192a8e4-7c1b-7def-8a12-3456789abcde"}, Version: 3}},
+		Tombstones: []Tombstone{{ID: 2, Version: 4}, {ID: 3, UID: "0192a8e4-7c1b-7def-8a12-3456789abcdf", Version: 5}},
+		Next:       "5",
+	})
+	if changes.Upserts[0].ID != 0 || len(changes.Tombstones) != 1 || changes.Tombstones[0].ID != 0 || changes.Tombstones[0].Version != 5 || changes.Next != "5" {
+		t.Errorf("Expected IDs left out and the tombstone without a UID dropped, got %+v", changes)
+	}
+}
+
+func TestUIDsNeedUIDStore(t *testing.T) {
+	store := NewMemoryStore()
+	srv := newTestServer(t, struct{ ItemStore }{store})
+	milk, err := store.Create(t.Context(), Item{Name: "Milk", Quantity: "1"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if rr := serve(srv, "DELETE", "/items/"+milk.UID, ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without a UIDStore, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if rr := serve(srv, "DELETE", "/items/"+jsonNumber(milk.ID), ""); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected DELETE by serial ID to succeed, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,225 @@
+package shoppinglist
+
+import (
+	"math/big"
+	"strings"
+	"unicode/utf8"
+)
+
+// --- Quantities ---
+
+// quantity is an item quantity split into its leading amount and the rest: "2 1/2 cups"
+// is 5/2 and "cups". Free-form quantities such as "a pinch" have no amount.
+type quantity struct {
+	amount  *big.Rat
+	upper   *big.Rat // Set for ranges such as "2-3 cloves"
+	decimal bool     // Written as "1.5" rather than "1 1/2"; kept when formatting
+	sep     string   // Between the amount and the unit: " " or "" (as in "500g")
+	unit    string
+}
+
+// vulgarFractions are the Unicode fraction characters (such as U+00BD, one half) accepted
+// in amounts.
+var vulgarFractions = map[rune][2]int64{
+	0x00BC: {1, 4}, 0x00BD: {1, 2}, 0x00BE: {3, 4},
+	0x2150: {1, 7}, 0x2151: {1, 9}, 0x2152: {1, 10},
+	0x2153: {1, 3}, 0x2154: {2, 3}, 0x2155: {1, 5}, 0x2156: {2, 5}, 0x2157: {3, 5}, 0x2158: {4, 5},
+	0x2159: {1, 6}, 0x215A: {5, 6}, 0x215B: {1, 8}, 0x215C: {3, 8}, 0x215D: {5, 8}, 0x215E: {7, 8},
+}
+
+// parseQuantity splits s into amount and unit. ok is false if s does not start with an
+// amount; such quantities are never scaled or added up.
+func parseQuantity(s string) (q quantity, ok bool) {
+	amount, decimal, rest, ok := parseAmount(s)
+	if !ok {
+		return quantity{}, false
+	}
+	q.amount, q.decimal = amount, decimal
+
+	// A range: "2-3", "2 - 3", or the same with an en dash
+	if r := strings.TrimLeft(rest, " "); strings.HasPrefix(r, "-") || strings.HasPrefix(r, "\u2013") {
+		_, size := utf8.DecodeRuneInString(r)
+		if upper, dec, after, ok := parseAmount(strings.TrimLeft(r[size:], " ")); ok {
+			q.upper, q.decimal, rest = upper, q.decimal || dec, after
+		}
+	}
+
+	q.unit = strings.TrimLeft(rest, " ")
+	if q.unit != "" && len(q.unit) < len(rest) {
+		q.sep = " "
+	}
+	return q, true
+}
+
+// parseAmount reads a leading integer, decimal ("1.5"), fraction ("1/2"), mixed number
+// ("1 1/2", or a digit followed by a fraction character) or Unicode fraction from s and
+// returns what follows it.
+func parseAmount(s string) (amount *big.Rat, decimal bool, rest string, ok bool) {
+	whole, rest := leadingDigits(s)
+	if whole == "" {
+		if f, size, ok := vulgarFraction(s); ok {
+			return f, false, s[size:], true
+		}
+		return nil, false, s, false
+	}
+	amount, _ = new(big.Rat).SetString(whole)
+
+	switch {
+	case strings.HasPrefix(rest, "."):
+		if frac, after := leadingDigits(rest[1:]); frac != "" {
+			amount.SetString(whole + "." + frac)
+			return amount, true, after, true
+		}
+	case strings.HasPrefix(rest, "/"):
+		if denom, after := leadingDigits(rest[1:]); isDenominator(denom) {
+			amount.SetString(whole + "/" + denom)
+			return amount, false, after, true
+		}
+	default:
+		// A fraction after the whole number, either a character right after it or after one
+		// space ("1 1/2")
+		if f, size, ok := vulgarFraction(rest); ok {
+			return amount.Add(amount, f), false, rest[size:], true
+		}
+		if after, found := strings.CutPrefix(rest, " "); found {
+			if f, size, ok := vulgarFraction(after); ok {
+				return amount.Add(amount, f), false, after[size:], true
+			}
+			if num, tail := leadingDigits(after); num != "" && strings.HasPrefix(tail, "/") {
+				if denom, tail := leadingDigits(tail[1:]); isDenominator(denom) {
+					f, _ := new(big.Rat).SetString(num + "/" + denom)
+					return amount.Add(amount, f), false, tail, true
+				}
+			}
+		}
+	}
+	return amount, false, rest, true
+}
+
+// leadingDigits splits s after its leading ASCII digits.
+func leadingDigits(s string) (digits, rest string) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// isDenominator reports whether digits is a usable (non-empty, non-zero) denominator.
+func isDenominator(digits string) bool {
+	return strings.Trim(digits, "0") != ""
+}
+
+// vulgarFraction reads a Unicode fraction character at the start of s.
+func vulgarFraction(s string) (*big.Rat, int, bool) {
+	r, size := utf8.DecodeRuneInString(s)
+	f, ok := vulgarFractions[r]
+	if !ok {
+		return nil, 0, false
+	}
+	return big.NewRat(f[0], f[1]), size, true
+}
+
+// scaled returns q with its amounts multiplied by factor.
+func (q quantity) scaled(factor *big.Rat) quantity {
+	q.amount = new(big.Rat).Mul(q.amount, factor)
+	if q.upper != nil {
+		q.upper = new(big.Rat).Mul(q.upper, factor)
+	}
+	return q
+}
+
+func (q quantity) String() string {
+	s := formatAmount(q.amount, q.decimal)
+	if q.upper != nil {
+		s += "-" + formatAmount(q.upper, q.decimal)
+	}
+	return s + q.sep + q.unit
+}
+
+// formatAmount writes whole numbers as is, halves, thirds, quarters and eighths as
+// (mixed) fractions unless the quantity was written as a decimal, and anything else as
+// a decimal rounded to two places.
+func formatAmount(r *big.Rat, decimal bool) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	if d := r.Denom(); !decimal && d.IsInt64() {
+		switch d.Int64() {
+		case 2, 3, 4, 8:
+			whole := new(big.Int).Quo(r.Num(), d)
+			frac := new(big.Rat).Sub(r, new(big.Rat).SetInt(whole))
+			if whole.Sign() == 0 {
+				return frac.String()
+			}
+			return whole.String() + " " + frac.String()
+		}
+	}
+	s := strings.TrimRight(r.FloatString(2), "0")
+	if s = strings.TrimSuffix(s, "."); s == "0" {
+		return "0.01" // Never round an ingredient away entirely
+	}
+	return s
+}
+
+// scaleQuantity multiplies the amount in s by factor, leaving free-form quantities and a
+// factor of one untouched.
+func scaleQuantity(s string, factor *big.Rat) string {
+	q, ok := parseQuantity(s)
+	if !ok || factor.Cmp(big.NewRat(1, 1)) == 0 {
+		return s
+	}
+	return q.scaled(factor).String()
+}
+
+// addQuantities combines two quantities of the same item. Amounts in the same unit are
+// added up ("1 cup" and "1/2 cup" make "1 1/2 cup"); anything else is listed as
+// "2 cups + 1 bag". An identical free-form quantity ("to taste") is not repeated.
+func addQuantities(a, b string) string {
+	qa, okA := parseQuantity(a)
+	qb, okB := parseQuantity(b)
+	if okA && okB && qa.upper == nil && qb.upper == nil && strings.EqualFold(qa.unit, qb.unit) {
+		qa.amount = new(big.Rat).Add(qa.amount, qb.amount)
+		qa.decimal = qa.decimal || qb.decimal
+		if qa.sep == "" {
+			qa.sep = qb.sep
+		}
+		return qa.String()
+	}
+	if !okA && strings.EqualFold(a, b) {
+		return a
+	}
+	return a + " + " + b
+}
+
+// unitScale places a unit on the scale of its kind: grams for mass, millilitres for
+// volume.
+type unitScale struct {
+	kind   string
+	factor float64
+}
+
+// stockUnits are the units convertUnit knows, by lower-case name.
+var stockUnits = map[string]unitScale{
+	"mg": {"mass", 0.001}, "g": {"mass", 1}, "gram": {"mass", 1}, "grams": {"mass", 1},
+	"kg": {"mass", 1000}, "kilo": {"mass", 1000}, "kilos": {"mass", 1000}, "kilogram": {"mass", 1000}, "kilograms": {"mass", 1000},
+	"oz": {"mass", 28.349523125}, "lb": {"mass", 453.59237}, "lbs": {"mass", 453.59237},
+	"ml": {"volume", 1}, "millilitre": {"volume", 1}, "millilitres": {"volume", 1}, "milliliter": {"volume", 1}, "milliliters": {"volume", 1},
+	"cl": {"volume", 10}, "dl": {"volume", 100},
+	"l": {"volume", 1000}, "litre": {"volume", 1000}, "litres": {"volume", 1000}, "liter": {"volume", 1000}, "liters": {"volume", 1000},
+}
+
+// convertUnit converts amount from one unit to another, ignoring case. ok is false
+// unless the units are the same or both known units of the same kind ("ml" and "l",
+// but not "ml" and "kg" or "packs").
+func convertUnit(amount float64, from, to string) (converted float64, ok bool) {
+	if strings.EqualFold(from, to) {
+		return amount, true
+	}
+	a, okA := stockUnits[strings.ToLower(from)]
+	b, okB := stockUnits[strings.ToLower(to)]
+	if !okA || !okB || a.kind != b.kind {
+		return 0, false
+	}
+	return amount * a.factor / b.factor, true
+}
@@ -0,0 +1,76 @@
+package shoppinglist
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestScaleQuantity(t *testing.T) {
+	tests := []struct {
+		in     string
+		factor *big.Rat
+		want   string
+	}{
+		{"2 cups", big.NewRat(2, 1), "4 cups"},
+		{"1 1/2 cups", big.NewRat(2, 3), "1 cups"},
+		{"1/2 tsp", big.NewRat(3, 2), "3/4 tsp"},
+		{"\u00bd lemon", big.NewRat(3, 1), "1 1/2 lemon"},
+		{"1\u00bd lemons", big.NewRat(2, 1), "3 lemons"},
+		{"1.5 kg", big.NewRat(3, 1), "4.5 kg"},
+		{"500g", big.NewRat(1, 2), "250g"},
+		{"2-3 cloves", big.NewRat(2, 1), "4-6 cloves"},
+		{"2 \u2013 3 cloves", big.NewRat(1, 2), "1-1 1/2 cloves"},
+		{"3 eggs", big.NewRat(1, 7), "0.43 eggs"},
+		{"1 pinch", big.NewRat(1, 1000), "0.01 pinch"},
+		{"2", big.NewRat(5, 2), "5"},
+		{"a pinch", big.NewRat(2, 1), "a pinch"},
+		{"1 1/2 cups", big.NewRat(1, 1), "1 1/2 cups"},
+	}
+	for _, tt := range tests {
+		if got := scaleQuantity(tt.in, tt.factor); got != tt.want {
+			t.Errorf("scaleQuantity(%q, %v) = %q; expected %q", tt.in, tt.factor, got, tt.want)
+		}
+	}
+}
+
+func TestAddQuantities(t *testing.T) {
+	tests := []struct{ a, b, want string }{
+		{"1 cup", "1/2 cup", "1 1/2 cup"},
+		{"2 Cups", "1 cups", "3 Cups"},
+		{"500g", "250 g", "750 g"},
+		{"1.5 kg", "1 kg", "2.5 kg"},
+		{"2", "3", "5"},
+		{"2 cups", "1 bag", "2 cups + 1 bag"},
+		{"2-3 cloves", "1 clove", "2-3 cloves + 1 clove"},
+		{"to taste", "To taste", "to taste"},
+		{"a pinch", "1 tsp", "a pinch + 1 tsp"},
+	}
+	for _, tt := range tests {
+		if got := addQuantities(tt.a, tt.b); got != tt.want {
+			t.Errorf("addQuantities(%q, %q) = %q; expected %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestConvertUnit(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		from, to string
+		want     float64
+		ok       bool
+	}{
+		{500, "ml", "L", 0.5, true},
+		{1.5, "kg", "g", 1500, true},
+		{2, "Packs", "packs", 2, true},
+		{1, "lb", "g", 453.59237, true},
+		{500, "ml", "kg", 0, false},
+		{2, "bags", "kg", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := convertUnit(tt.amount, tt.from, tt.to)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("convertUnit(%v, %q, %q) = %v, %v; expected %v, %v", tt.amount, tt.from, tt.to, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package shoppinglist
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Rate Limiting ---
+
+// RateLimit configures per-client token-bucket rate limiting. Each client gets one bucket
+// per route class; a class with a zero Limit is not limited. Health checks and /metrics
+// are never limited, so probes and scrapers keep working under load.
+type RateLimit struct {
+	Read  Limit // GET, HEAD and OPTIONS
+	Write Limit // POST, PUT, PATCH and DELETE
+
+	// TrustedProxies are the addresses (e.g. the nginx container's network) whose
+	// X-Forwarded-For header is believed. Requests from anywhere else are keyed by
+	// their own address, so clients cannot pick a fresh identity per request.
+	TrustedProxies []netip.Prefix
+
+	// User returns the authenticated user for a request, or "" for anonymous requests,
+	// which are keyed by client IP. It runs after Options.Middleware, so an
+	// authentication middleware can store the user in the request context.
+	User func(r *http.Request) string
+}
+
+// Limit is a token bucket: Burst requests at once, refilled at Rate requests per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// enabled reports whether requests are limited at all.
+func (l Limit) enabled() bool { return l.Rate > 0 && l.Burst > 0 }
+
+// Route classes, used as bucket key prefixes and metric labels
+const (
+	routeClassRead  = "read"
+	routeClassWrite = "write"
+)
+
+// bucketEvictInterval is how often idle buckets are swept; a bucket that has refilled
+// completely carries no state and is dropped.
+const bucketEvictInterval = time.Minute
+
+// rateLimiter holds the token buckets of every client.
+type rateLimiter struct {
+	cfg RateLimit
+	now func() time.Time // Replaced in tests
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastEvict time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+func newRateLimiter(cfg RateLimit) *rateLimiter {
+	return &rateLimiter{cfg: cfg, now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// decision is the outcome of one request against its bucket.
+type decision struct {
+	allowed    bool
+	remaining  int
+	reset      time.Duration // Until the bucket is full again
+	retryAfter time.Duration // Until the next request would be allowed; zero if allowed
+}
+
+// take removes a token from key's bucket, creating it full if needed.
+func (l *rateLimiter) take(key string, limit Limit) decision {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastEvict) >= bucketEvictInterval {
+		l.evict(now)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now, limit: limit}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	d := decision{allowed: b.tokens >= 1}
+	if d.allowed {
+		b.tokens--
+	} else {
+		d.retryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	d.remaining = int(b.tokens)
+	d.reset = seconds((float64(limit.Burst) - b.tokens) / limit.Rate)
+	return d
+}
+
+// evict drops every bucket that has refilled completely.
+func (l *rateLimiter) evict(now time.Time) {
+	for key, b := range l.buckets {
+		if b.refill(now); b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastEvict = now
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+		b.last = now
+	}
+}
+
+// seconds converts a float number of seconds to a Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// routeClass returns the class a request is limited under, or "" if it is exempt.
+func routeClass(r *http.Request) string {
+	switch r.URL.Path {
+	case "/livez", "/readyz", "/healthz", "/metrics":
+		return ""
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return routeClassRead
+	default:
+		return routeClassWrite
+	}
+}
+
+// clientKey identifies the client: the authenticated user if there is one, else the IP.
+func (l *rateLimiter) clientKey(r *http.Request) string {
+	if l.cfg.User != nil {
+		if user := l.cfg.User(r); user != "" {
+			return "user:" + user
+		}
+	}
+	return "ip:" + clientIP(r, l.cfg.TrustedProxies)
+}
+
+// clientIP returns the address of the client that sent r. When the connection comes from
+// a trusted proxy, X-Forwarded-For is walked from the right (the hop nearest to us),
+// skipping further trusted proxies; entries left of the first untrusted address could
+// have been written by the client and are ignored.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !isTrusted(addr, trusted) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break // Garbage from here on cannot be trusted; fall back to the last good address
+		}
+		addr = hop.Unmap()
+		if !isTrusted(addr, trusted) {
+			break
+		}
+	}
+	return addr.String()
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitMiddleware rejects requests over their client's limit with 429 Too Many Requests.
+// Limited responses carry RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset
+// (draft-ietf-httpapi-ratelimit-headers), and a rejection adds Retry-After.
+func (s *Server) rateLimitMiddleware(l *rateLimiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		class := routeClass(r)
+		var limit Limit
+		switch class {
+		case routeClassRead:
+			limit = l.cfg.Read
+		case routeClassWrite:
+			limit = l.cfg.Write
+		}
+		if !limit.enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client := l.clientKey(r)
+		d := l.take(class+"|"+client, limit)
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		h.Set("RateLimit-Remaining", strconv.Itoa(d.remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.reset)))
+		h.Set("RateLimit-Policy", strconv.Itoa(limit.Burst)+";w="+strconv.Itoa(ceilSeconds(seconds(float64(limit.Burst)/limit.Rate))))
+		if !d.allowed {
+			s.metrics.rateLimited.WithLabelValues(class).Inc()
+			s.requestLogger(r).Debug("Rate limit exceeded", "client", client, "class", class)
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(d.retryAfter)))
+			s.writeProblem(w, r, Problem{
+				Type:   problemTypeRateLimited,
+				Title:  "Rate limit exceeded",
+				Status: http.StatusTooManyRequests,
+				Detail: "Too many " + class + " requests; retry after " + h.Get("Retry-After") + "s",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ceilSeconds rounds d up to whole seconds, as the headers require.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,204 @@
+package shoppinglist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeClock is a settable time source for the rate limiter.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newRateLimitedServer returns a Server over a MemoryStore with the given limits and a fake clock.
+func newRateLimitedServer(t *testing.T, cfg RateLimit) (*Server, *fakeClock) {
+	t.Helper()
+	srv, err := New(Options{Store: NewMemoryStore(), RateLimit: cfg})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	srv.limiter.now = clock.now
+	return srv, clock
+}
+
+// doFrom sends a request from remoteAddr with an optional X-Forwarded-For header.
+func doFrom(srv *Server, method, path, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+	var body *strings.Reader
+	if method == http.MethodPost {
+		body = strings.NewReader(`{"name":"Milk","quantity":"1"}`)
+	} else {
+		body = strings.NewReader("")
+	}
+	req := httptest.NewRequest(method, path, body)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	cfg := RateLimit{
+		Read:  Limit{Rate: 10, Burst: 5},
+		Write: Limit{Rate: 0.5, Burst: 2},
+	}
+
+	t.Run("RejectsOverBurst", func(t *testing.T) {
+		srv, _ := newRateLimitedServer(t, cfg)
+		for i := range 2 {
+			rr := doFrom(srv, "POST", "/items", "192.0.2.1:1234", "")
+			if rr.Code != http.StatusCreated {
+				t.Fatalf("Write %d: expected %d, got %d", i+1, http.StatusCreated, rr.Code)
+			}
+			if got, want := rr.Header().Get("RateLimit-Remaining"), []string{"1", "0"}[i]; got != want {
+				t.Errorf("Write %d: expected RateLimit-Remaining %s, got %s", i+1, want, got)
+			}
+		}
+
+		rr := doFrom(srv, "POST", "/items", "192.0.2.1:1234", "")
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, rr.Code)
+		}
+		if p := decodeProblem(t, rr); p.Type != problemTypeRateLimited {
+			t.Errorf("Expected a rate-limited problem, got %+v", p)
+		}
+		h := rr.Header()
+		if h.Get("Retry-After") != "2" || h.Get("RateLimit-Limit") != "2" || h.Get("RateLimit-Remaining") != "0" || h.Get("RateLimit-Reset") != "4" {
+			t.Errorf("Unexpected headers: Retry-After=%q RateLimit-Limit=%q RateLimit-Remaining=%q RateLimit-Reset=%q",
+				h.Get("Retry-After"), h.Get("RateLimit-Limit"), h.Get("RateLimit-Remaining"), h.Get("RateLimit-Reset"))
+		}
+		if got := testutil.ToFloat64(srv.metrics.rateLimited.WithLabelValues("write")); got != 1 {
+			t.Errorf("Expected 1 rate-limited write in metrics, got %v", got)
+		}
+		if items, _ := srv.base.List(t.Context()); len(items) != 2 {
+			t.Errorf("Expected the rejected request not to reach the store, got %d items", len(items))
+		}
+
+		// Reads have their own bucket
+		if rr := doFrom(srv, "GET", "/items", "192.0.2.1:1234", ""); rr.Code != http.StatusOK {
+			t.Errorf("Expected reads to be unaffected by the write limit, got %d", rr.Code)
+		}
+		// Other clients have their own buckets
+		if rr := doFrom(srv, "POST", "/items", "192.0.2.2:1234", ""); rr.Code != http.StatusCreated {
+			t.Errorf("Expected another client to be allowed, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Refills", func(t *testing.T) {
+		srv, clock := newRateLimitedServer(t, cfg)
+		for range 2 {
+			doFrom(srv, "POST", "/items", "192.0.2.1:1234", "")
+		}
+		clock.advance(1900 * time.Millisecond)
+		if rr := doFrom(srv, "POST", "/items", "192.0.2.1:1234", ""); rr.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected 429 before a token refilled, got %d", rr.Code)
+		}
+		clock.advance(100 * time.Millisecond)
+		if rr := doFrom(srv, "POST", "/items", "192.0.2.1:1234", ""); rr.Code != http.StatusCreated {
+			t.Errorf("Expected a refilled token to be accepted, got %d", rr.Code)
+		}
+	})
+
+	t.Run("ProbesExempt", func(t *testing.T) {
+		srv, _ := newRateLimitedServer(t, RateLimit{Read: Limit{Rate: 1, Burst: 1}})
+		for range 3 {
+			for _, path := range []string{"/livez", "/readyz", "/metrics"} {
+				if rr := doFrom(srv, "GET", path, "192.0.2.1:1234", ""); rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Limit") != "" {
+					t.Fatalf("GET %s: expected an unlimited 200, got %d", path, rr.Code)
+				}
+			}
+		}
+	})
+
+	t.Run("KeyedByUser", func(t *testing.T) {
+		withUser := cfg
+		withUser.User = func(r *http.Request) string { return r.Header.Get("X-Test-User") }
+		srv, _ := newRateLimitedServer(t, withUser)
+		post := func(remoteAddr, user string) int {
+			req := httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"Milk","quantity":"1"}`))
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("X-Test-User", user)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, req)
+			return rr.Code
+		}
+		// One user on two addresses shares a bucket
+		post("192.0.2.1:1", "alice")
+		post("192.0.2.2:1", "alice")
+		if code := post("192.0.2.3:1", "alice"); code != http.StatusTooManyRequests {
+			t.Errorf("Expected the user's third write to be limited, got %d", code)
+		}
+		if code := post("192.0.2.3:1", "bob"); code != http.StatusCreated {
+			t.Errorf("Expected another user on the same address to be allowed, got %d", code)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		srv, err := New(Options{Store: NewMemoryStore()})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if srv.limiter != nil {
+			t.Error("Expected no limiter for a zero RateLimit")
+		}
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("172.16.0.0/12"), netip.MustParsePrefix("10.0.0.1/32")}
+	tests := []struct {
+		name, remoteAddr, forwardedFor, want string
+	}{
+		{"Direct", "203.0.113.7:5000", "", "203.0.113.7"},
+		{"SpoofedFromUntrusted", "203.0.113.7:5000", "198.51.100.1", "203.0.113.7"},
+		{"ThroughProxy", "172.18.0.5:5000", "198.51.100.1", "198.51.100.1"},
+		{"ProxyWithoutHeader", "172.18.0.5:5000", "", "172.18.0.5"},
+		{"ClientPrependedEntries", "172.18.0.5:5000", "1.1.1.1, 198.51.100.1", "198.51.100.1"},
+		{"ChainOfProxies", "172.18.0.5:5000", "198.51.100.1, 10.0.0.1", "198.51.100.1"},
+		{"GarbageHop", "172.18.0.5:5000", "198.51.100.1, not-an-ip", "172.18.0.5"},
+		{"IPv6", "[2001:db8::1]:5000", "", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/items", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if got := clientIP(req, trusted); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRateLimiterEvictsIdleBuckets(t *testing.T) {
+	l := newRateLimiter(RateLimit{})
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	l.now = clock.now
+	limit := Limit{Rate: 0.1, Burst: 10} // A used token takes 10s to come back
+
+	l.take("read|ip:192.0.2.1", limit)
+	clock.advance(55 * time.Second)
+	l.take("read|ip:192.0.2.2", limit)
+
+	// The next sweep drops the first bucket, which has refilled, but keeps the second
+	clock.advance(bucketEvictInterval - 55*time.Second)
+	l.take("read|ip:192.0.2.3", limit)
+	if _, ok := l.buckets["read|ip:192.0.2.1"]; ok {
+		t.Error("Expected the refilled bucket to be evicted")
+	}
+	if len(l.buckets) != 2 {
+		t.Errorf("Expected 2 buckets to remain, got %d", len(l.buckets))
+	}
+}
@@ -0,0 +1,301 @@
+package shoppinglist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Recipes ---
+
+// Recipe is a dish and the ingredients it needs for Servings portions.
+type Recipe struct {
+	ID          int          `json:"id"`
+	Title       string       `json:"title"`
+	Servings    int          `json:"servings"`
+	Ingredients []Ingredient `json:"ingredients"`
+	CreatedAt   time.Time    `json:"created_at,omitempty"` // omitempty for POST
+}
+
+// Ingredient is one line of a recipe. Adding the recipe to the list turns it into an item,
+// so it follows the same rules as an item's name and quantity.
+type Ingredient struct {
+	Name     string `json:"name"`
+	Quantity string `json:"quantity"`
+}
+
+// Recipe limits, checked by every RecipeStore and the database.
+const (
+	MaxRecipeServings    = 1000
+	MaxRecipeIngredients = 100
+)
+
+// ErrRecipeNotFound is returned by a RecipeStore when the requested recipe does not exist.
+var ErrRecipeNotFound = errors.New("recipe not found")
+
+// RecipeStore persists recipes. The built-in stores implement it next to ItemStore.
+type RecipeStore interface {
+	// ListRecipes returns all recipes ordered by title.
+	ListRecipes(ctx context.Context) ([]Recipe, error)
+	// GetRecipe returns the recipe with the given ID, or ErrRecipeNotFound.
+	GetRecipe(ctx context.Context, id int) (Recipe, error)
+	// CreateRecipe validates and stores a new recipe, returning it with ID and CreatedAt set.
+	CreateRecipe(ctx context.Context, recipe Recipe) (Recipe, error)
+	// UpdateRecipe replaces the title, servings and ingredients of an existing recipe,
+	// or returns ErrRecipeNotFound.
+	UpdateRecipe(ctx context.Context, recipe Recipe) (Recipe, error)
+	// DeleteRecipe removes the recipe with the given ID, or returns ErrRecipeNotFound.
+	DeleteRecipe(ctx context.Context, id int) error
+}
+
+// normalizeRecipe normalizes the title and ingredients of recipe like item fields and
+// checks the servings and number of ingredients, returning a *ValidationError listing
+// every invalid field. Ingredient fields are named like "ingredients[0].name".
+func normalizeRecipe(recipe Recipe, limits FieldLimits) (Recipe, error) {
+	limits = limits.withDefaults()
+	var fields []FieldError
+	check := func(field string, value *string, maxLen int) {
+		normalized, msg := normalizeText(*value, maxLen)
+		if msg != "" {
+			fields = append(fields, FieldError{Field: field, Message: msg})
+			return
+		}
+		*value = normalized
+	}
+
+	check("title", &recipe.Title, MaxNameLength)
+	if recipe.Servings < 1 || recipe.Servings > MaxRecipeServings {
+		fields = append(fields, FieldError{Field: "servings", Message: fmt.Sprintf("must be between 1 and %d", MaxRecipeServings)})
+	}
+	switch n := len(recipe.Ingredients); {
+	case n == 0:
+		fields = append(fields, FieldError{Field: "ingredients", Message: "cannot be empty"})
+	case n > MaxRecipeIngredients:
+		fields = append(fields, FieldError{Field: "ingredients", Message: fmt.Sprintf("must have at most %d entries", MaxRecipeIngredients)})
+	}
+	// Copy so the caller's slice is never modified
+	ingredients := make([]Ingredient, len(recipe.Ingredients))
+	for i, ing := range recipe.Ingredients {
+		prefix := "ingredients[" + strconv.Itoa(i) + "]."
+		check(prefix+"name", &ing.Name, limits.MaxName)
+		check(prefix+"quantity", &ing.Quantity, limits.MaxQuantity)
+		ingredients[i] = ing
+	}
+	recipe.Ingredients = ingredients
+
+	if len(fields) > 0 {
+		return Recipe{}, &ValidationError{Resource: "recipe", Fields: fields}
+	}
+	return recipe, nil
+}
+
+// appendRecipeRow adds one row of a recipes-joined-with-ingredients query, ordered by
+// recipe, to recipes. name and quantity are nil for a recipe without ingredients.
+func appendRecipeRow(recipes []Recipe, r Recipe, name, quantity *string) []Recipe {
+	if n := len(recipes); n == 0 || recipes[n-1].ID != r.ID {
+		r.Ingredients = []Ingredient{}
+		recipes = append(recipes, r)
+	}
+	if name != nil && quantity != nil {
+		last := &recipes[len(recipes)-1]
+		last.Ingredients = append(last.Ingredients, Ingredient{Name: *name, Quantity: *quantity})
+	}
+	return recipes
+}
+
+// --- Recipe Handlers ---
+
+func (s *Server) recipesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		recipes, err := s.recipes.ListRecipes(r.Context())
+		if err != nil {
+			s.requestLogger(r).Error("Error listing recipes", "err", err)
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, r, http.StatusOK, recipes)
+	case http.MethodPost:
+		var recipe Recipe
+		if !s.decodeJSON(w, r, &recipe) {
+			return
+		}
+		recipe, err := normalizeRecipe(recipe, s.limits)
+		if err == nil {
+			recipe, err = s.recipes.CreateRecipe(r.Context(), recipe)
+		}
+		if err != nil {
+			s.requestLogger(r).Warn("Error adding recipe", "err", err)
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, r, http.StatusCreated, recipe)
+	default:
+		s.methodNotAllowed(w, r, "GET, POST")
+	}
+}
+
+// recipeDetailHandler serves /recipes/{id} and /recipes/{id}/add-to-list.
+func (s *Server) recipeDetailHandler(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/recipes/"), "/")
+	idStr, action, _ := strings.Cut(rest, "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		s.writeStatus(w, r, http.StatusBadRequest, "Invalid recipe ID format")
+		return
+	}
+
+	switch action {
+	case "":
+	case "add-to-list":
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, r, "POST")
+			return
+		}
+		s.addRecipeToListHandler(w, r, id)
+		return
+	default:
+		s.writeStatus(w, r, http.StatusNotFound, "No such endpoint")
+		return
+	}
+
+	var recipe Recipe
+	switch r.Method {
+	case http.MethodGet:
+		recipe, err = s.recipes.GetRecipe(r.Context(), id)
+	case http.MethodPut:
+		if !s.decodeJSON(w, r, &recipe) {
+			return
+		}
+		recipe.ID = id // The path wins over any ID in the body
+		if recipe, err = normalizeRecipe(recipe, s.limits); err == nil {
+			recipe, err = s.recipes.UpdateRecipe(r.Context(), recipe)
+		}
+	case http.MethodDelete:
+		if err = s.recipes.DeleteRecipe(r.Context(), id); err == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		s.methodNotAllowed(w, r, "GET, PUT, DELETE")
+		return
+	}
+	if err != nil {
+		s.requestLogger(r).Warn("Error handling recipe", "id", id, "method", r.Method, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, recipe)
+}
+
+// AddToListResult is the response of POST /recipes/{id}/add-to-list: the items created
+// for ingredients not yet on the list, and the existing items they were merged into.
+type AddToListResult struct {
+	RecipeID int    `json:"recipe_id"`
+	Servings int    `json:"servings"`
+	Added    []Item `json:"added"`
+	Merged   []Item `json:"merged"`
+}
+
+// addRecipeToListHandler adds the ingredients of a recipe to the list, scaled from the
+// recipe's servings to the "servings" query parameter (default: unscaled).
+func (s *Server) addRecipeToListHandler(w http.ResponseWriter, r *http.Request, id int) {
+	servings := 0 // The recipe's own
+	if v := r.URL.Query().Get("servings"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxRecipeServings {
+			msg := fmt.Sprintf("must be a whole number between 1 and %d", MaxRecipeServings)
+			s.writeQueryProblem(w, r, FieldError{Field: "servings", Message: msg})
+			return
+		}
+		servings = n
+	}
+
+	recipe, err := s.recipes.GetRecipe(r.Context(), id)
+	if err != nil {
+		s.requestLogger(r).Warn("Error getting recipe", "id", id, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	if servings == 0 {
+		servings = recipe.Servings
+	}
+
+	result, err := s.addRecipeToList(r.Context(), recipe, servings)
+	if err != nil {
+		s.requestLogger(r).Warn("Error adding recipe to list", "id", id, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	result.Added, result.Merged = s.publicItems(result.Added), s.publicItems(result.Merged)
+	s.writeJSON(w, r, http.StatusOK, result)
+}
+
+// addRecipeToList scales the ingredients of recipe to servings and puts them on the list.
+// An ingredient whose name (ignoring case) is already on the list is merged into that
+// item, adding up amounts in the same unit; the rest are created through addItem.
+//
+// Every change is validated before the first write, so a quantity that would grow past
+// the limits fails the whole request. A database error part-way leaves the earlier
+// ingredients on the list; repeating the request then merges instead of duplicating.
+func (s *Server) addRecipeToList(ctx context.Context, recipe Recipe, servings int) (AddToListResult, error) {
+	factor := big.NewRat(int64(servings), int64(recipe.Servings))
+
+	// Combine repeated ingredients first, so each name is written once
+	var planned []Item
+	index := make(map[string]int)
+	for _, ing := range recipe.Ingredients {
+		qty := scaleQuantity(ing.Quantity, factor)
+		key := strings.ToLower(ing.Name)
+		if i, ok := index[key]; ok {
+			planned[i].Quantity = addQuantities(planned[i].Quantity, qty)
+			continue
+		}
+		index[key] = len(planned)
+		planned = append(planned, Item{Name: ing.Name, Quantity: qty})
+	}
+
+	items, err := s.store.List(ctx)
+	if err != nil {
+		return AddToListResult{}, err
+	}
+	// List is newest first, so a name listed twice merges into its newest item
+	existing := make(map[string]Item, len(items))
+	for _, item := range items {
+		if key := strings.ToLower(item.Name); existing[key].ID == 0 {
+			existing[key] = item
+		}
+	}
+	for i, item := range planned {
+		if current, ok := existing[strings.ToLower(item.Name)]; ok {
+			current.Quantity = addQuantities(current.Quantity, item.Quantity)
+			item = current
+		}
+		if planned[i], err = normalizeItem(item, s.limits); err != nil {
+			return AddToListResult{}, err
+		}
+	}
+
+	result := AddToListResult{RecipeID: recipe.ID, Servings: servings, Added: []Item{}, Merged: []Item{}}
+	for _, item := range planned {
+		if item.ID != 0 {
+			merged, err := s.store.Update(ctx, item)
+			if err != nil {
+				return AddToListResult{}, fmt.Errorf("merging ingredient %q: %w", item.Name, err)
+			}
+			result.Merged = append(result.Merged, merged)
+			continue
+		}
+		added, err := s.addItem(ctx, item)
+		if err != nil {
+			return AddToListResult{}, fmt.Errorf("adding ingredient %q: %w", item.Name, err)
+		}
+		result.Added = append(result.Added, added)
+	}
+	return result, nil
+}
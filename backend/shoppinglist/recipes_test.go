@@ -0,0 +1,240 @@
+package shoppinglist
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serve sends a request through the full server and returns the recorded response.
+func serve(srv *Server, method, target, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rr
+}
+
+const pancakesJSON = `{"title":"Pancakes","servings":4,"ingredients":[
+	{"name":"Flour","quantity":"200 g"},
+	{"name":"Milk","quantity":"1 1/2 cups"},
+	{"name":"Eggs","quantity":"2"},
+	{"name":"Salt","quantity":"a pinch"}]}`
+
+func TestRecipeHandlers(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+
+	rr := serve(srv, "POST", "/recipes", pancakesJSON)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var created Recipe
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Invalid JSON response: %v", err)
+	}
+	if created.ID != 1 || len(created.Ingredients) != 4 {
+		t.Fatalf("Unexpected created recipe: %+v", created)
+	}
+
+	t.Run("List", func(t *testing.T) {
+		rr := serve(srv, "GET", "/recipes", "")
+		var recipes []Recipe
+		if err := json.Unmarshal(rr.Body.Bytes(), &recipes); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %v", http.StatusOK, rr.Code, err)
+		}
+		if len(recipes) != 1 || recipes[0].Title != "Pancakes" {
+			t.Errorf("Expected the created recipe, got %+v", recipes)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		rr := serve(srv, "PUT", "/recipes/1", `{"id":99,"title":"Pancakes","servings":2,"ingredients":[{"name":"Flour","quantity":"100 g"}]}`)
+		var updated Recipe
+		if err := json.Unmarshal(rr.Body.Bytes(), &updated); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %v", http.StatusOK, rr.Code, err)
+		}
+		if updated.ID != 1 || updated.Servings != 2 {
+			t.Errorf("Expected recipe 1 to be updated, got %+v", updated)
+		}
+		// Restore for the tests below
+		serve(srv, "PUT", "/recipes/1", pancakesJSON)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		rr := serve(srv, "POST", "/recipes", `{"title":" ","servings":0,"ingredients":[{"name":"Flour","quantity":""}]}`)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		p := decodeProblem(t, rr)
+		if p.Title != "Invalid recipe" {
+			t.Errorf("Expected title %q, got %q", "Invalid recipe", p.Title)
+		}
+		var fields []string
+		for _, f := range p.Errors {
+			fields = append(fields, f.Field)
+		}
+		if got := strings.Join(fields, ","); got != "title,servings,ingredients[0].quantity" {
+			t.Errorf("Expected errors for title, servings and the ingredient quantity, got %q", got)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		for _, method := range []string{"GET", "PUT", "DELETE"} {
+			rr := serve(srv, method, "/recipes/42", pancakesJSON)
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("%s: expected status %d, got %d", method, http.StatusNotFound, rr.Code)
+				continue
+			}
+			if p := decodeProblem(t, rr); p.Title != "Recipe not found" {
+				t.Errorf("%s: expected title %q, got %q", method, "Recipe not found", p.Title)
+			}
+		}
+		if rr := serve(srv, "POST", "/recipes/42/add-to-list", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("add-to-list: expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("BadPaths", func(t *testing.T) {
+		tests := []struct {
+			method, path string
+			status       int
+		}{
+			{"GET", "/recipes/abc", http.StatusBadRequest},
+			{"GET", "/recipes/0", http.StatusBadRequest},
+			{"GET", "/recipes/1/cook", http.StatusNotFound},
+			{"GET", "/recipes/1/add-to-list", http.StatusMethodNotAllowed},
+			{"PATCH", "/recipes/1", http.StatusMethodNotAllowed},
+			{"DELETE", "/recipes", http.StatusMethodNotAllowed},
+		}
+		for _, tt := range tests {
+			if rr := serve(srv, tt.method, tt.path, ""); rr.Code != tt.status {
+				t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, rr.Code)
+			}
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rr := serve(srv, "POST", "/recipes", pancakesJSON)
+		var recipe Recipe
+		json.Unmarshal(rr.Body.Bytes(), &recipe)
+		path := "/recipes/" + jsonNumber(recipe.ID)
+		if rr := serve(srv, "DELETE", path, ""); rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+		}
+		if rr := serve(srv, "GET", path, ""); rr.Code != http.StatusNotFound {
+			t.Errorf("Expected the deleted recipe to be gone, got %d", rr.Code)
+		}
+	})
+}
+
+func jsonNumber(n int) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
+
+func TestAddRecipeToList(t *testing.T) {
+	store := NewMemoryStore()
+	srv := newTestServer(t, store)
+	if rr := serve(srv, "POST", "/recipes", pancakesJSON); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+	ctx := t.Context()
+	milk, err := store.Create(ctx, Item{Name: "milk", Quantity: "1/2 cups"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	salt, err := store.Create(ctx, Item{Name: "Salt", Quantity: "a pinch"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	rr := serve(srv, "POST", "/recipes/1/add-to-list?servings=6", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var result AddToListResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid JSON response: %v", err)
+	}
+	if result.RecipeID != 1 || result.Servings != 6 {
+		t.Errorf("Unexpected result header: %+v", result)
+	}
+	if len(result.Added) != 2 || result.Added[0].Name != "Flour" || result.Added[0].Quantity != "300 g" ||
+		result.Added[1].Name != "Eggs" || result.Added[1].Quantity != "3" {
+		t.Errorf("Expected Flour 300 g and Eggs 3 to be added, got %+v", result.Added)
+	}
+	// 1 1/2 cups scaled by 6/4 is 2 1/4 cups, plus the half cup already listed
+	if len(result.Merged) != 2 ||
+		result.Merged[0].ID != milk.ID || result.Merged[0].Name != "milk" || result.Merged[0].Quantity != "2 3/4 cups" ||
+		result.Merged[1].ID != salt.ID || result.Merged[1].Quantity != "a pinch" {
+		t.Errorf("Expected milk and salt to be merged, got %+v", result.Merged)
+	}
+	if items, _ := store.List(ctx); len(items) != 4 {
+		t.Errorf("Expected 4 items on the list, got %d", len(items))
+	}
+
+	t.Run("DefaultServings", func(t *testing.T) {
+		rr := serve(srv, "POST", "/recipes/1/add-to-list", "")
+		var result AddToListResult
+		if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %v", http.StatusOK, rr.Code, err)
+		}
+		if result.Servings != 4 || len(result.Added) != 0 || len(result.Merged) != 4 || result.Merged[0].Quantity != "500 g" {
+			t.Errorf("Expected every ingredient to merge unscaled, got %+v", result)
+		}
+	})
+
+	t.Run("InvalidServings", func(t *testing.T) {
+		for _, v := range []string{"0", "-1", "1.5", "abc", "1001"} {
+			rr := serve(srv, "POST", "/recipes/1/add-to-list?servings="+v, "")
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("servings=%s: expected status %d, got %d", v, http.StatusBadRequest, rr.Code)
+				continue
+			}
+			if p := decodeProblem(t, rr); len(p.Errors) != 1 || p.Errors[0].Field != "servings" {
+				t.Errorf("servings=%s: expected a servings field error, got %+v", v, p.Errors)
+			}
+		}
+	})
+}
+
+func TestAddRecipeToListValidatesBeforeWriting(t *testing.T) {
+	store := NewMemoryStore()
+	srv, err := New(Options{Store: store, FieldLimits: FieldLimits{MaxQuantity: 8}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if rr := serve(srv, "POST", "/recipes", `{"title":"Soup","servings":1,"ingredients":[
+		{"name":"Leeks","quantity":"2"},{"name":"Stock","quantity":"1 litre"}]}`); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	if _, err := store.Create(t.Context(), Item{Name: "Stock", Quantity: "1 cube"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// "1 cube + 1 litre" is longer than the 8 characters allowed
+	rr := serve(srv, "POST", "/recipes/1/add-to-list", "")
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	items, _ := store.List(t.Context())
+	if len(items) != 1 || items[0].Quantity != "1 cube" {
+		t.Errorf("Expected the list to be unchanged, got %+v", items)
+	}
+}
+
+func TestRecipesNotServedWithoutRecipeStore(t *testing.T) {
+	// Embedding only the interface hides the memory store's recipe methods
+	srv := newTestServer(t, struct{ ItemStore }{NewMemoryStore()})
+	if rr := serve(srv, "GET", "/recipes", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	explicit, err := New(Options{Store: struct{ ItemStore }{NewMemoryStore()}, Recipes: NewMemoryStore()})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if rr := serve(explicit, "GET", "/recipes", ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected Options.Recipes to be served, got %d", rr.Code)
+	}
+}
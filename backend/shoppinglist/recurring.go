@@ -0,0 +1,279 @@
+package shoppinglist
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// --- Recurring Items ---
+
+// RecurringItem is a staple that the scheduler puts on the list whenever its Schedule is
+// due (see parseSchedule for the rules). NextRun is computed from the schedule, so it is
+// ignored in requests.
+type RecurringItem struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Quantity  string     `json:"quantity"`
+	Schedule  string     `json:"schedule"`
+	NextRun   time.Time  `json:"next_run,omitempty"`
+	LastRun   *time.Time `json:"last_run,omitempty"`   // nil until the first run
+	CreatedAt time.Time  `json:"created_at,omitempty"` // omitempty for POST
+}
+
+// ErrRecurringItemNotFound is returned by a RecurringStore when the requested recurring
+// item does not exist.
+var ErrRecurringItemNotFound = errors.New("recurring item not found")
+
+// RecurringStore persists recurring items. It is implemented by the built-in stores next
+// to ItemStore.
+type RecurringStore interface {
+	// ListRecurring returns all recurring items ordered by name.
+	ListRecurring(ctx context.Context) ([]RecurringItem, error)
+	// GetRecurring returns the recurring item with the given ID, or ErrRecurringItemNotFound.
+	GetRecurring(ctx context.Context, id int) (RecurringItem, error)
+	// CreateRecurring validates and stores a new recurring item, returning it with ID and
+	// CreatedAt set. A zero NextRun is set to the schedule's first occurrence.
+	CreateRecurring(ctx context.Context, item RecurringItem) (RecurringItem, error)
+	// UpdateRecurring replaces the name, quantity, schedule and next run of an existing
+	// recurring item, or returns ErrRecurringItemNotFound. The last run is kept.
+	UpdateRecurring(ctx context.Context, item RecurringItem) (RecurringItem, error)
+	// DeleteRecurring removes the recurring item with the given ID, or returns ErrRecurringItemNotFound.
+	DeleteRecurring(ctx context.Context, id int) error
+	// DueRecurring returns the recurring items whose next run is at or before now,
+	// earliest first.
+	DueRecurring(ctx context.Context, now time.Time) ([]RecurringItem, error)
+	// RecordRun sets the last and next run of a recurring item, or returns
+	// ErrRecurringItemNotFound.
+	RecordRun(ctx context.Context, id int, ranAt, next time.Time) error
+}
+
+// normalizeRecurringItem normalizes the name and quantity of item like item fields and
+// checks its schedule, returning a *ValidationError listing every invalid field. A zero
+// NextRun is set to the first occurrence of the schedule at now.
+func normalizeRecurringItem(item RecurringItem, limits FieldLimits, now time.Time) (RecurringItem, error) {
+	normalized, err := normalizeItem(Item{Name: item.Name, Quantity: item.Quantity}, limits)
+	var fields []FieldError
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		fields = verr.Fields
+	}
+	item.Name, item.Quantity = normalized.Name, normalized.Quantity
+
+	item.Schedule = strings.Join(strings.Fields(item.Schedule), " ")
+	sched, err := parseSchedule(item.Schedule)
+	switch {
+	case utf8.RuneCountInString(item.Schedule) > MaxScheduleLength:
+		fields = append(fields, FieldError{Field: "schedule", Message: "must be at most " + strconv.Itoa(MaxScheduleLength) + " characters"})
+	case err != nil:
+		fields = append(fields, FieldError{Field: "schedule", Message: err.Error()})
+	case item.NextRun.IsZero():
+		if item.NextRun = sched.first(now); item.NextRun.IsZero() {
+			fields = append(fields, FieldError{Field: "schedule", Message: "never occurs"})
+		}
+	}
+
+	if len(fields) > 0 {
+		return RecurringItem{}, &ValidationError{Resource: "recurring item", Fields: fields}
+	}
+	return item, nil
+}
+
+// sortRecurring orders recurring items like the SQL stores' "ORDER BY lower(name), id".
+func sortRecurring(items []RecurringItem) {
+	sort.Slice(items, func(i, j int) bool {
+		if a, b := strings.ToLower(items[i].Name), strings.ToLower(items[j].Name); a != b {
+			return a < b
+		}
+		return items[i].ID < items[j].ID
+	})
+}
+
+// --- Recurring Item Handlers ---
+
+func (s *Server) recurringHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items, err := s.recurring.ListRecurring(r.Context())
+		if err != nil {
+			s.requestLogger(r).Error("Error listing recurring items", "err", err)
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, r, http.StatusOK, items)
+	case http.MethodPost:
+		var item RecurringItem
+		if !s.decodeJSON(w, r, &item) {
+			return
+		}
+		item, err := s.normalizeRecurringRequest(item)
+		if err == nil {
+			item, err = s.recurring.CreateRecurring(r.Context(), item)
+		}
+		if err != nil {
+			s.requestLogger(r).Warn("Error adding recurring item", "err", err)
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, r, http.StatusCreated, item)
+	default:
+		s.methodNotAllowed(w, r, "GET, POST")
+	}
+}
+
+func (s *Server) recurringDetailHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/recurring/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		s.writeStatus(w, r, http.StatusBadRequest, "Invalid recurring item ID format")
+		return
+	}
+
+	var item RecurringItem
+	switch r.Method {
+	case http.MethodGet:
+		item, err = s.recurring.GetRecurring(r.Context(), id)
+	case http.MethodPut:
+		if !s.decodeJSON(w, r, &item) {
+			return
+		}
+		item.ID = id // The path wins over any ID in the body
+		if item, err = s.normalizeRecurringRequest(item); err == nil {
+			item, err = s.recurring.UpdateRecurring(r.Context(), item)
+		}
+	case http.MethodDelete:
+		if err = s.recurring.DeleteRecurring(r.Context(), id); err == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		s.methodNotAllowed(w, r, "GET, PUT, DELETE")
+		return
+	}
+	if err != nil {
+		s.requestLogger(r).Warn("Error handling recurring item", "id", id, "method", r.Method, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, item)
+}
+
+// normalizeRecurringRequest validates a recurring item from a request and schedules its
+// first run from now, so saving a definition always reschedules it.
+func (s *Server) normalizeRecurringRequest(item RecurringItem) (RecurringItem, error) {
+	item.NextRun = time.Time{}
+	item.LastRun = nil
+	return normalizeRecurringItem(item, s.limits, s.now())
+}
+
+// --- Scheduler ---
+
+// schedulerLocker is implemented by stores that several backend replicas can share. The
+// lock makes sure only one replica adds due items at a time; ok is false if another
+// one holds it.
+type schedulerLocker interface {
+	tryLockScheduler(ctx context.Context) (unlock func(), ok bool, err error)
+}
+
+// RunScheduler adds due recurring items to the list every interval (and once right
+// away) until ctx is canceled or the Server is closed. It does nothing if the store does
+// not support recurring items. Any number of replicas may run it against the same database.
+func (s *Server) RunScheduler(ctx context.Context, interval time.Duration) {
+	if s.recurring == nil {
+		return
+	}
+	s.schedulers.Add(1)
+	defer s.schedulers.Done()
+	ctx = ContextWithLogger(ctx, s.logger)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.closed:
+			return
+		default:
+		}
+		if _, err := s.runRecurring(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("Error running recurring items", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.closed:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runRecurring adds every due recurring item whose name (ignoring case) is not on the
+// list yet, and records the run either way. An item that could not be added is left due,
+// so the next run tries again. It returns the number of items added.
+func (s *Server) runRecurring(ctx context.Context) (int, error) {
+	unlock, ok, err := s.lockScheduler(ctx)
+	if err != nil || !ok {
+		return 0, err
+	}
+	defer unlock()
+
+	// Stored times keep milliseconds (SQLite), so drop what could not be read back
+	now := s.now().Truncate(time.Millisecond)
+	due, err := s.recurring.DueRecurring(ctx, now)
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+	items, err := s.store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	listed := make(map[string]bool, len(items))
+	for _, item := range items {
+		listed[strings.ToLower(item.Name)] = true
+	}
+
+	added := 0
+	var errs []error
+	for _, rec := range due {
+		logger := loggerFrom(ctx).With("recurring_id", rec.ID, "name", rec.Name)
+		if listed[strings.ToLower(rec.Name)] {
+			logger.Info("Recurring item already on the list, skipped")
+		} else {
+			item, err := s.addItem(ctx, Item{Name: rec.Name, Quantity: rec.Quantity})
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			listed[strings.ToLower(rec.Name)] = true
+			added++
+			logger.Info("Added recurring item to the list", "item_id", item.ID)
+		}
+		sched, err := parseSchedule(rec.Schedule) // Validated when it was stored
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.recurring.RecordRun(ctx, rec.ID, now, sched.next(now)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return added, errors.Join(errs...)
+}
+
+// lockScheduler takes the store's scheduler lock, or a process-wide one for stores that
+// belong to a single process.
+func (s *Server) lockScheduler(ctx context.Context) (unlock func(), ok bool, err error) {
+	if l, ok := s.base.(schedulerLocker); ok {
+		return l.tryLockScheduler(ctx)
+	}
+	if !s.schedulerMu.TryLock() {
+		return nil, false, nil
+	}
+	return s.schedulerMu.Unlock, true, nil
+}
@@ -0,0 +1,198 @@
+package shoppinglist
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRecurringHandlers(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+	now := time.Date(2026, 3, 4, 10, 30, 15, 0, time.UTC) // A Wednesday
+	srv.now = func() time.Time { return now }
+
+	rr := serve(srv, "POST", "/recurring", `{"name":"Milk","quantity":"2 l","schedule":"weekly on monday","next_run":"2020-01-01T00:00:00Z"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var created RecurringItem
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if want := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC); !created.NextRun.Equal(want) || created.LastRun != nil {
+		t.Errorf("Expected the next run to come from the schedule (%s), got %+v", want, created)
+	}
+
+	t.Run("List", func(t *testing.T) {
+		rr := serve(srv, "GET", "/recurring", "")
+		var items []RecurringItem
+		if err := json.Unmarshal(rr.Body.Bytes(), &items); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %v", http.StatusOK, rr.Code, err)
+		}
+		if len(items) != 1 || items[0].Name != "Milk" || items[0].Schedule != "weekly on monday" {
+			t.Errorf("Expected the created recurring item, got %+v", items)
+		}
+	})
+
+	t.Run("UpdateReschedules", func(t *testing.T) {
+		rr := serve(srv, "PUT", "/recurring/"+jsonNumber(created.ID), `{"name":"Milk","quantity":"1 l","schedule":"every 2 days"}`)
+		var updated RecurringItem
+		if err := json.Unmarshal(rr.Body.Bytes(), &updated); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		if want := now.Truncate(time.Minute); !updated.NextRun.Equal(want) || updated.Quantity != "1 l" {
+			t.Errorf("Expected an interval schedule to be due now (%s), got %+v", want, updated)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		rr := serve(srv, "POST", "/recurring", `{"name":"","quantity":"1","schedule":"fortnightly"}`)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		p := decodeProblem(t, rr)
+		if p.Title != "Invalid recurring item" || len(p.Errors) != 2 || p.Errors[0].Field != "name" || p.Errors[1].Field != "schedule" {
+			t.Errorf("Expected name and schedule errors, got %+v", p)
+		}
+	})
+
+	t.Run("BadPaths", func(t *testing.T) {
+		tests := []struct {
+			method, path string
+			status       int
+		}{
+			{"GET", "/recurring/abc", http.StatusBadRequest},
+			{"GET", "/recurring/42", http.StatusNotFound},
+			{"PATCH", "/recurring/1", http.StatusMethodNotAllowed},
+			{"DELETE", "/recurring", http.StatusMethodNotAllowed},
+		}
+		for _, tt := range tests {
+			if rr := serve(srv, tt.method, tt.path, ""); rr.Code != tt.status {
+				t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, rr.Code)
+			}
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if rr := serve(srv, "DELETE", "/recurring/"+jsonNumber(created.ID), ""); rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+		}
+		rr := serve(srv, "GET", "/recurring/"+jsonNumber(created.ID), "")
+		if p := decodeProblem(t, rr); rr.Code != http.StatusNotFound || p.Title != "Recurring item not found" {
+			t.Errorf("Expected a recurring item 404, got %d %+v", rr.Code, p)
+		}
+	})
+}
+
+func TestRunRecurring(t *testing.T) {
+	store := NewMemoryStore()
+	srv := newTestServer(t, store)
+	ctx := t.Context()
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	now := monday.Add(30 * time.Second)
+	srv.now = func() time.Time { return now }
+
+	for _, rec := range []RecurringItem{
+		{Name: "Milk", Quantity: "2 l", Schedule: "weekly on monday", NextRun: monday},
+		{Name: "Bread", Quantity: "1", Schedule: "every 2 days", NextRun: monday},
+		{Name: "Eggs", Quantity: "12", Schedule: "daily", NextRun: monday.Add(time.Hour)},
+	} {
+		if _, err := store.CreateRecurring(ctx, rec); err != nil {
+			t.Fatalf("CreateRecurring failed: %v", err)
+		}
+	}
+	if _, err := store.Create(ctx, Item{Name: "bread", Quantity: "1 loaf"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	added, err := srv.runRecurring(ctx)
+	if err != nil {
+		t.Fatalf("runRecurring failed: %v", err)
+	}
+	if added != 1 {
+		t.Errorf("Expected only Milk to be added, got %d items", added)
+	}
+	items, _ := store.List(ctx)
+	if len(items) != 2 || items[0].Name != "Milk" || items[0].Quantity != "2 l" {
+		t.Errorf("Expected Milk next to the listed bread, got %+v", items)
+	}
+
+	// Both due items record the run, whether added or skipped as duplicates
+	recs, _ := store.ListRecurring(ctx)
+	want := map[string]time.Time{
+		"Bread": time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC),
+		"Eggs":  monday.Add(time.Hour),
+		"Milk":  time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+	}
+	for _, rec := range recs {
+		if !rec.NextRun.Equal(want[rec.Name]) {
+			t.Errorf("%s: expected next run %s, got %s", rec.Name, want[rec.Name], rec.NextRun)
+		}
+		if ran := rec.LastRun != nil && rec.LastRun.Equal(now); ran != (rec.Name != "Eggs") {
+			t.Errorf("%s: unexpected last run %v", rec.Name, rec.LastRun)
+		}
+	}
+
+	// Nothing is due any more
+	if added, err := srv.runRecurring(ctx); err != nil || added != 0 {
+		t.Errorf("Expected a second run to add nothing, got %d (err %v)", added, err)
+	}
+}
+
+func TestRunRecurringSkipsWhileLocked(t *testing.T) {
+	store := NewMemoryStore()
+	srv := newTestServer(t, store)
+	ctx := t.Context()
+	if _, err := store.CreateRecurring(ctx, RecurringItem{Name: "Milk", Quantity: "1", Schedule: "daily"}); err != nil {
+		t.Fatalf("CreateRecurring failed: %v", err)
+	}
+
+	srv.schedulerMu.Lock()
+	added, err := srv.runRecurring(ctx)
+	srv.schedulerMu.Unlock()
+	if err != nil || added != 0 {
+		t.Errorf("Expected a locked run to do nothing, got %d (err %v)", added, err)
+	}
+	if added, err := srv.runRecurring(ctx); err != nil || added != 1 {
+		t.Errorf("Expected the item to be added once unlocked, got %d (err %v)", added, err)
+	}
+}
+
+func TestRunSchedulerStopsOnClose(t *testing.T) {
+	store := NewMemoryStore()
+	srv := newTestServer(t, store)
+	if _, err := store.CreateRecurring(t.Context(), RecurringItem{Name: "Milk", Quantity: "1", Schedule: "daily"}); err != nil {
+		t.Fatalf("CreateRecurring failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		srv.RunScheduler(t.Context(), time.Hour)
+		close(done)
+	}()
+	// The first run happens right away
+	deadline := time.Now().Add(5 * time.Second)
+	for n, _ := store.Count(t.Context()); n == 0; n, _ = store.Count(t.Context()) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the scheduler to add the due item")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	srv.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected RunScheduler to return after Close")
+	}
+}
+
+func TestRecurringNotServedWithoutRecurringStore(t *testing.T) {
+	srv := newTestServer(t, struct{ ItemStore }{NewMemoryStore()})
+	if rr := serve(srv, "GET", "/recurring", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for /recurring, got %d", http.StatusNotFound, rr.Code)
+	}
+	// The scheduler has nothing to do and returns at once
+	srv.RunScheduler(t.Context(), time.Hour)
+}
@@ -0,0 +1,246 @@
+package shoppinglist
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Schedules ---
+
+// MaxScheduleLength caps the length of a recurring item's schedule rule.
+const MaxScheduleLength = 100
+
+// schedule says when a recurring item is due. Times are in the location of the time
+// passed in, which for the scheduler is the server's local time zone.
+type schedule interface {
+	// first returns when an item created (or rescheduled) at now is first due.
+	first(now time.Time) time.Time
+	// next returns the first occurrence after a run at after, or the zero time if
+	// there is none.
+	next(after time.Time) time.Time
+}
+
+// parseSchedule parses a schedule rule, ignoring case and extra spaces:
+//
+//	daily, every day        every day at midnight, starting now
+//	every N days            at midnight N days after the last run, starting now
+//	weekly on monday        at midnight on the given days; a list like
+//	every mon, thu          "monday, thursday" or "mon and thu" also works
+//	0 7 * * 1-5             a 5-field cron expression (minute hour day month weekday)
+func parseSchedule(rule string) (schedule, error) {
+	rule = strings.Join(strings.Fields(strings.ToLower(rule)), " ")
+	switch {
+	case rule == "":
+		return nil, errors.New("cannot be empty")
+	case rule == "daily" || rule == "every day":
+		return intervalSchedule{days: 1}, nil
+	case strings.HasPrefix(rule, "every ") && strings.HasSuffix(rule, " days"):
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rule, "every "), " days"))
+		if err != nil || n < 1 || n > 365 {
+			return nil, errors.New(`must say "every N days" with N between 1 and 365`)
+		}
+		return intervalSchedule{days: n}, nil
+	case strings.HasPrefix(rule, "weekly on "), strings.HasPrefix(rule, "every "):
+		list := strings.TrimPrefix(strings.TrimPrefix(rule, "weekly on "), "every ")
+		list = strings.NewReplacer(" and ", ",", " ", "").Replace(list)
+		var days bitset
+		for _, name := range strings.Split(list, ",") {
+			d, ok := weekdayNumber(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown day %q", name)
+			}
+			days = days.set(d)
+		}
+		return &cronSchedule{minute: bitset(0).set(0), hour: bitset(0).set(0), dom: allDays, month: allMonths, dow: days, domStar: true}, nil
+	}
+	return parseCron(rule)
+}
+
+// weekdayNumber returns the cron weekday (0 is Sunday) for a full or three-letter English name.
+func weekdayNumber(name string) (int, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || name == full[:3] {
+			return int(d), true
+		}
+	}
+	return 0, false
+}
+
+// intervalSchedule is due at midnight every days days, counted from the last run.
+type intervalSchedule struct {
+	days int
+}
+
+func (s intervalSchedule) first(now time.Time) time.Time {
+	return now.Truncate(time.Minute)
+}
+
+func (s intervalSchedule) next(after time.Time) time.Time {
+	return time.Date(after.Year(), after.Month(), after.Day()+s.days, 0, 0, 0, 0, after.Location())
+}
+
+// --- Cron Expressions ---
+
+// bitset holds the allowed values of one cron field (all fit in 0-63).
+type bitset uint64
+
+func (b bitset) set(v int) bitset { return b | 1<<uint(v) }
+func (b bitset) has(v int) bool   { return b&(1<<uint(v)) != 0 }
+
+// bitRange returns a bitset with every step-th value from lo to hi.
+func bitRange(lo, hi, step int) bitset {
+	var b bitset
+	for v := lo; v <= hi; v += step {
+		b = b.set(v)
+	}
+	return b
+}
+
+var (
+	allDays   = bitRange(1, 31, 1)
+	allMonths = bitRange(1, 12, 1)
+)
+
+// cronSchedule is due at every minute matching all fields. As in cron, when both the
+// day of month and the weekday are restricted, a day matching either is enough.
+type cronSchedule struct {
+	minute, hour, dom, month, dow bitset
+	domStar, dowStar              bool
+}
+
+// cronField describes the values one field of a cron expression accepts.
+type cronField struct {
+	name   string
+	lo, hi int
+	names  func(string) (int, bool) // Optional, e.g. weekday names
+}
+
+var cronFields = [5]cronField{
+	{name: "minute", lo: 0, hi: 59},
+	{name: "hour", lo: 0, hi: 23},
+	{name: "day of month", lo: 1, hi: 31},
+	{name: "month", lo: 1, hi: 12, names: monthNumber},
+	{name: "weekday", lo: 0, hi: 7, names: weekdayNumber}, // 0 and 7 are both Sunday
+}
+
+// monthNumber returns the month for a three-letter English name.
+func monthNumber(name string) (int, bool) {
+	for m := time.January; m <= time.December; m++ {
+		if name == strings.ToLower(m.String()[:3]) {
+			return int(m), true
+		}
+	}
+	return 0, false
+}
+
+// parseCron parses a 5-field cron expression. Each field is "*" or a comma-separated
+// list of values and ranges ("1-5"), either optionally with a step ("*/15", "0-30/10").
+func parseCron(rule string) (*cronSchedule, error) {
+	parts := strings.Fields(rule)
+	if len(parts) != 5 {
+		return nil, errors.New(`must be "daily", "every N days", "weekly on <day>" or a cron expression like "0 7 * * 1"`)
+	}
+	var sets [5]bitset
+	for i, part := range parts {
+		b, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = b
+	}
+	c := &cronSchedule{minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4]}
+	if c.dow.has(7) {
+		c.dow = c.dow.set(0)
+	}
+	c.domStar = strings.HasPrefix(parts[2], "*")
+	c.dowStar = strings.HasPrefix(parts[4], "*")
+	return c, nil
+}
+
+// parseCronField parses one field of a cron expression.
+func parseCronField(s string, f cronField) (bitset, error) {
+	value := func(v string) (int, error) {
+		if f.names != nil {
+			if n, ok := f.names(v); ok {
+				return n, nil
+			}
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < f.lo || n > f.hi {
+			return 0, fmt.Errorf("%s %q must be between %d and %d", f.name, v, f.lo, f.hi)
+		}
+		return n, nil
+	}
+
+	var b bitset
+	for _, item := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 || n > f.hi {
+				return 0, fmt.Errorf("%s step %q must be between 1 and %d", f.name, stepStr, f.hi)
+			}
+			step = n
+		}
+		lo, hi := f.lo, f.hi
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = value(loStr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = value(hiStr); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.hi // "5/10" means 5, 15, 25, ...
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("%s range %q is backwards", f.name, rng)
+			}
+		}
+		b |= bitRange(lo, hi, step)
+	}
+	return b, nil
+}
+
+// first is the current minute if it matches, so a rule added at its due time runs now.
+func (c *cronSchedule) first(now time.Time) time.Time {
+	return c.next(now.Add(-time.Minute))
+}
+
+func (c *cronSchedule) next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// Five years covers every valid day, including February 29
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		y, m, d := t.Date()
+		switch {
+		case !c.month.has(int(m)):
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+		case !c.hour.has(t.Hour()):
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, t.Location())
+		case !c.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := c.dom.has(t.Day()), c.dow.has(int(t.Weekday()))
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
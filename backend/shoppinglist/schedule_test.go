@@ -0,0 +1,70 @@
+package shoppinglist
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	// Wednesday, 4 March 2026, 10:30
+	now := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		rule        string
+		first, next string // Formatted like time.DateTime
+	}{
+		{rule: "daily", first: "2026-03-04 10:30:00", next: "2026-03-05 00:00:00"},
+		{rule: "Every 3  days", first: "2026-03-04 10:30:00", next: "2026-03-07 00:00:00"},
+		{rule: "weekly on Monday", first: "2026-03-09 00:00:00", next: "2026-03-09 00:00:00"},
+		{rule: "every mon and thu", first: "2026-03-05 00:00:00", next: "2026-03-05 00:00:00"},
+		{rule: "weekly on wednesday, sat", first: "2026-03-07 00:00:00", next: "2026-03-07 00:00:00"},
+		{rule: "30 10 * * *", first: "2026-03-04 10:30:00", next: "2026-03-05 10:30:00"},
+		{rule: "*/15 8-18 * * mon-fri", first: "2026-03-04 10:30:00", next: "2026-03-04 10:45:00"},
+		{rule: "0 9 1,15 * *", first: "2026-03-15 09:00:00", next: "2026-03-15 09:00:00"},
+		{rule: "0 0 1 jan *", first: "2027-01-01 00:00:00", next: "2027-01-01 00:00:00"},
+		{rule: "0 0 29 2 *", first: "2028-02-29 00:00:00", next: "2028-02-29 00:00:00"},
+		{rule: "0 0 13 * 5", first: "2026-03-06 00:00:00", next: "2026-03-06 00:00:00"}, // 13th or a Friday
+		{rule: "0 12 * * 7", first: "2026-03-08 12:00:00", next: "2026-03-08 12:00:00"},
+	}
+	for _, tt := range tests {
+		sched, err := parseSchedule(tt.rule)
+		if err != nil {
+			t.Errorf("parseSchedule(%q) failed: %v", tt.rule, err)
+			continue
+		}
+		if got := sched.first(now).Format(time.DateTime); got != tt.first {
+			t.Errorf("%q: first = %s, want %s", tt.rule, got, tt.first)
+		}
+		if got := sched.next(now).Format(time.DateTime); got != tt.next {
+			t.Errorf("%q: next = %s, want %s", tt.rule, got, tt.next)
+		}
+	}
+}
+
+func TestParseScheduleRejects(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"weekly",
+		"every 0 days",
+		"every 400 days",
+		"weekly on funday",
+		"0 7 * *",
+		"60 * * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	} {
+		if _, err := parseSchedule(rule); err == nil {
+			t.Errorf("parseSchedule(%q): expected an error", rule)
+		}
+	}
+	// Valid syntax, but no such day
+	sched, err := parseSchedule("0 0 31 4 *")
+	if err != nil {
+		t.Fatalf("parseSchedule failed: %v", err)
+	}
+	if next := sched.next(time.Now()); !next.IsZero() {
+		t.Errorf("Expected no occurrence of April 31, got %s", next)
+	}
+}
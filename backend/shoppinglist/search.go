@@ -0,0 +1,225 @@
+package shoppinglist
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// --- Search ---
+
+// Where a search result was found.
+const (
+	SearchSourceList    = "list"    // An item on the list
+	SearchSourceHistory = "history" // A name from the item history, including deleted items
+)
+
+// SearchResult is an item or history entry matching a search.
+type SearchResult struct {
+	Source    string    `json:"source"`
+	ID        *int      `json:"id,omitempty"`  // Only for items on the list, unless Options.RejectSerialIDs is set
+	UID       string    `json:"uid,omitempty"` // Only for items on the list
+	Name      string    `json:"name"`
+	Quantity  string    `json:"quantity"`
+	Highlight string    `json:"highlight"` // Name as HTML, matching words in <mark>
+	Score     float64   `json:"score"`     // Relevance from 0 to 1
+	At        time.Time `json:"at"`        // When the item was added, or the name last used
+}
+
+// Search limits for GET /search.
+const (
+	DefaultSearchResults = 20
+	MaxSearchResults     = 100
+)
+
+// searchThreshold is the least word similarity for a fuzzy match. It is pg_trgm's
+// default word_similarity_threshold, which the PostgreSQL store relies on.
+const searchThreshold = 0.6
+
+// SearchStore searches the items on the list and the item history. The built-in stores
+// implement it next to HistoryStore.
+//
+// A name matches when every search term starts one of its words (full-text search) or
+// when the terms are similar enough to its words by trigrams, so "tomatos" finds
+// "Cherry tomatoes". The score is the mean of the two: 1 for an exact word, at least
+// 0.5 for any full-text match, and below 0.5 for a fuzzy one.
+type SearchStore interface {
+	// Search returns up to limit results for terms, best first. Highlight is left empty
+	// for the Server to fill in.
+	Search(ctx context.Context, terms []string, limit int) ([]SearchResult, error)
+}
+
+// searchTerms splits a query into lower-cased words of letters and digits.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), isNotWordRune)
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// trigrams returns the trigrams of a lower-cased word the way pg_trgm does: padded with
+// two spaces in front and one behind, so short words and word starts count.
+func trigrams(word string) map[string]bool {
+	runes := []rune("  " + word + " ")
+	set := make(map[string]bool, len(runes)-2)
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = true
+	}
+	return set
+}
+
+// wordSimilarity is the share of the term's trigrams that the word has too.
+func wordSimilarity(term, word string) float64 {
+	want, have := trigrams(term), trigrams(word)
+	common := 0
+	for t := range want {
+		if have[t] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(want))
+}
+
+// scoreSearch scores a name for terms the way the PostgreSQL store does. The similarity
+// approximates pg_trgm's word_similarity by comparing each term with the name's most
+// similar word.
+func scoreSearch(terms []string, name string) (score float64, ok bool) {
+	words := searchTerms(name)
+	exact := true
+	common, total := 0.0, 0.0
+	for _, term := range terms {
+		prefixed, best := false, 0.0
+		for _, word := range words {
+			prefixed = prefixed || strings.HasPrefix(word, term)
+			best = max(best, wordSimilarity(term, word))
+		}
+		exact = exact && prefixed
+		n := float64(len(trigrams(term)))
+		common += best * n
+		total += n
+	}
+	similarity := common / total
+	if !exact && similarity < searchThreshold {
+		return 0, false
+	}
+	if exact {
+		return (1 + similarity) / 2, true
+	}
+	return similarity / 2, true
+}
+
+// rankSearch scores candidates for the stores that search in Go, and returns the best
+// limit of them.
+func rankSearch(terms []string, candidates []SearchResult, limit int) []SearchResult {
+	results := []SearchResult{}
+	for _, c := range candidates {
+		if score, ok := scoreSearch(terms, c.Name); ok {
+			c.Score = score
+			results = append(results, c)
+		}
+	}
+	sortSearchResults(results)
+	return results[:min(limit, len(results))]
+}
+
+// sortSearchResults orders results by score, then name, with list items before history.
+func sortSearchResults(results []SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if a, b := strings.ToLower(results[i].Name), strings.ToLower(results[j].Name); a != b {
+			return a < b
+		}
+		return results[i].Source == SearchSourceList && results[j].Source != SearchSourceList
+	})
+}
+
+// highlight returns name as HTML with the words matching terms in <mark>. A word
+// matches when a term starts it or is similar enough to it.
+func highlight(terms []string, name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && !isNotWordRune(runes[j]) {
+			j++
+		}
+		if j == i {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+		word := string(runes[i:j])
+		if highlighted(terms, strings.ToLower(word)) {
+			b.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func highlighted(terms []string, word string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) || wordSimilarity(term, word) >= searchThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Search Handler ---
+
+// searchHandler serves GET /search?q=tomatoes&limit=20.
+func (s *Server) searchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r, "GET")
+		return
+	}
+
+	query := r.URL.Query()
+	var fields []FieldError
+	q, msg := normalizeText(query.Get("q"), MaxNameLength)
+	terms := searchTerms(q)
+	switch {
+	case msg != "":
+		fields = append(fields, FieldError{Field: "q", Message: msg})
+	case len(terms) == 0:
+		fields = append(fields, FieldError{Field: "q", Message: "must contain a letter or digit"})
+	}
+	limit := DefaultSearchResults
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxSearchResults {
+			fields = append(fields, FieldError{Field: "limit", Message: fmt.Sprintf("must be a whole number between 1 and %d", MaxSearchResults)})
+		}
+		limit = n
+	}
+	if len(fields) > 0 {
+		s.writeQueryProblem(w, r, fields...)
+		return
+	}
+
+	results, err := s.search.Search(r.Context(), terms, limit)
+	if err != nil {
+		s.requestLogger(r).Error("Error searching", "q", q, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	for i := range results {
+		results[i].Highlight = highlight(terms, results[i].Name)
+		if s.rejectSerialIDs {
+			results[i].ID = nil
+		}
+	}
+	s.writeJSON(w, r, http.StatusOK, results)
+}
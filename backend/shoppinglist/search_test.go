@@ -0,0 +1,99 @@
+package shoppinglist
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestScoreSearch(t *testing.T) {
+	tests := []struct {
+		terms []string
+		name  string
+		ok    bool
+		score float64
+	}{
+		{[]string{"milk"}, "Milk", true, 1},
+		{[]string{"mil"}, "Oat milk", true, 0.875},     // Prefix of a word: full-text match
+		{[]string{"tomatos"}, "Tomatoes", true, 0.375}, // Six of eight trigrams
+		{[]string{"cherry", "tom"}, "Cherry tomatoes", true, (1 + 10.0/11) / 2},
+		{[]string{"cherry", "bread"}, "Cherry tomatoes", false, 0},
+		{[]string{"milk"}, "Cheese", false, 0},
+	}
+	for _, tt := range tests {
+		score, ok := scoreSearch(tt.terms, tt.name)
+		if ok != tt.ok || math.Abs(score-tt.score) > 1e-9 {
+			t.Errorf("scoreSearch(%v, %q) = %v, %v; want %v, %v", tt.terms, tt.name, score, ok, tt.score, tt.ok)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		terms []string
+		name  string
+		want  string
+	}{
+		{[]string{"tomatos"}, "Cherry tomatoes", "Cherry <mark>tomatoes</mark>"},
+		{[]string{"ch"}, "Fish & chips", "Fish &amp; <mark>chips</mark>"},
+		{[]string{"b"}, "<b>Bold</b>", "&lt;<mark>b</mark>&gt;<mark>Bold</mark>&lt;/<mark>b</mark>&gt;"},
+		{[]string{"crème"}, "Crème fraîche", "<mark>Crème</mark> fraîche"},
+	}
+	for _, tt := range tests {
+		if got := highlight(tt.terms, tt.name); got != tt.want {
+			t.Errorf("highlight(%v, %q) = %q, want %q", tt.terms, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSearchHandler(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+	for _, body := range []string{`{"name":"Tomatoes","quantity":"6"}`, `{"name":"Tomato sauce","quantity":"1 jar"}`} {
+		if rr := serve(srv, "POST", "/items", body); rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+	}
+	// Deleted items are still found through the history
+	if rr := serve(srv, "DELETE", "/items/2", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+
+	rr := serve(srv, "GET", "/search?q=tomatos", "")
+	var results []SearchResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &results); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Source+":"+r.Highlight)
+	}
+	// Equally similar, so by name and with the list first
+	want := []string{"history:<mark>Tomato</mark> sauce", "list:<mark>Tomatoes</mark>", "history:<mark>Tomatoes</mark>"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+			break
+		}
+	}
+
+	for _, target := range []string{"/search", "/search?q=%20-%20", "/search?q=milk&limit=101"} {
+		rr := serve(srv, "GET", target, "")
+		if p := decodeProblem(t, rr); rr.Code != http.StatusBadRequest || len(p.Errors) != 1 {
+			t.Errorf("GET %s: expected one field error, got %d %+v", target, rr.Code, p)
+		}
+	}
+	if rr := serve(srv, "POST", "/search?q=milk", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
+
+func TestSearchNotServedWithoutSearchStore(t *testing.T) {
+	srv := newTestServer(t, struct{ ItemStore }{NewMemoryStore()})
+	if rr := serve(srv, "GET", "/search?q=milk", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
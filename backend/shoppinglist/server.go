@@ -0,0 +1,330 @@
+// Package shoppinglist implements the shopping list API as an embeddable http.Handler.
+//
+// The standalone backend (package main) is a thin wrapper around this package; other Go
+// services can mount the same API inside their own mux:
+//
+//	srv, err := shoppinglist.New(shoppinglist.Options{Pool: pool, PathPrefix: "/shopping"})
+//	if err != nil { ... }
+//	defer srv.Close()
+//	mux.Handle("/shopping/", srv)
+package shoppinglist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware wraps an http.Handler, e.g. to add authentication or logging.
+type Middleware func(http.Handler) http.Handler
+
+// Options configures a Server. Either Store or Pool must be set.
+type Options struct {
+	// Store is the item storage backend. If nil, a PostgresStore is created on Pool.
+	Store ItemStore
+
+	// Recipes stores recipes for the /recipes endpoints. Defaults to Store (or the
+	// PostgresStore created on Pool) if it implements RecipeStore; when nothing does, the
+	// endpoints are not served. The Server does not close it.
+	Recipes RecipeStore
+
+	// Pool is a PostgreSQL pool used when Store is nil. New applies pending schema
+	// migrations to it before returning.
+	Pool DBPool
+
+	// PathPrefix is stripped from incoming request paths, so the API can be mounted
+	// below e.g. "/shopping". It must start with "/" and not end with one. Empty means root.
+	PathPrefix string
+
+	// Logger receives the server's structured log output, including one access-log
+	// line per request. Defaults to slog.Default().
+	Logger *slog.Logger
+
+	// Middleware wraps every route. The first entry is the outermost handler.
+	Middleware []Middleware
+
+	// Health is pinged by /readyz (and /healthz). Defaults to Store (if it implements Pinger), then Pool.
+	Health Pinger
+
+	// Registry receives the server's Prometheus collectors and is served on /metrics.
+	// Defaults to a new registry with the Go runtime and process collectors. Pass the
+	// host application's registry to expose everything on one endpoint.
+	Registry *prometheus.Registry
+
+	// TracerProvider creates the server and store spans. Defaults to the global
+	// provider (otel.GetTracerProvider()), which is a no-op unless one was installed.
+	TracerProvider trace.TracerProvider
+
+	// Propagator extracts the incoming trace context. Defaults to W3C Trace Context
+	// (the traceparent/tracestate headers).
+	Propagator propagation.TextMapPropagator
+
+	// RateLimit limits requests per client and route class. The zero value disables it.
+	RateLimit RateLimit
+
+	// FieldLimits caps item name and quantity lengths for API requests. Zero fields use
+	// the hard limits, MaxNameLength and MaxQuantityLength, which cannot be exceeded.
+	FieldLimits FieldLimits
+
+	// RejectSerialIDs makes /items/{id} accept only item UIDs, ending the transition from
+	// the serial IDs, which give away how many items there were. Until then both work.
+	RejectSerialIDs bool
+}
+
+// Server serves the shopping list API. It is safe for concurrent use.
+type Server struct {
+	store   ItemStore    // Instrumented; handlers go through this
+	base    ItemStore    // The store as given, owned by the Server
+	recipes RecipeStore  // Instrumented; nil when recipes are not supported
+	pantry  PantryStore  // Instrumented; nil unless the store implements PantryStore
+	prices  PriceStore   // Instrumented; nil unless the store implements PriceStore
+	layouts LayoutStore  // Instrumented; nil unless the store implements LayoutStore
+	history HistoryStore // Instrumented; nil unless the store implements HistoryStore
+	search  SearchStore  // Instrumented; nil unless the store implements SearchStore
+	sync    SyncStore    // Instrumented; nil unless the store implements SyncStore
+	uids    UIDStore     // Instrumented; nil unless the store implements UIDStore
+	pool    DBPool
+
+	recurring   RecurringStore // Instrumented; nil unless the store implements RecurringStore
+	schedulerMu sync.Mutex     // Serializes scheduler runs for stores without a schedulerLocker
+	schedulers  sync.WaitGroup // Running RunScheduler calls, waited for by Close
+	closed      chan struct{}  // Closed by Close to stop RunScheduler
+	closeOnce   sync.Once
+
+	logger  *slog.Logger
+	health  Pinger
+	metrics *metrics
+	limiter *rateLimiter // nil when rate limiting is disabled
+	limits  FieldLimits
+	handler http.Handler
+	now     func() time.Time // Replaced in tests
+
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	draining atomic.Bool // Set by Drain; fails /readyz
+
+	rejectSerialIDs bool // /items/{id} accepts only UIDs
+}
+
+// New validates opts and returns a ready-to-serve Server.
+// The Server takes ownership of Options.Store and Options.Pool; Close releases them.
+func New(opts Options) (*Server, error) {
+	if opts.Store == nil && opts.Pool == nil {
+		return nil, errors.New("shoppinglist: Options.Store or Options.Pool is required")
+	}
+	if opts.PathPrefix != "" && (!strings.HasPrefix(opts.PathPrefix, "/") || strings.HasSuffix(opts.PathPrefix, "/")) {
+		return nil, fmt.Errorf("shoppinglist: invalid PathPrefix %q (must start with \"/\" and not end with one)", opts.PathPrefix)
+	}
+	if err := opts.FieldLimits.validate(); err != nil {
+		return nil, fmt.Errorf("shoppinglist: invalid %w", err)
+	}
+
+	s := &Server{
+		base:       opts.Store,
+		pool:       opts.Pool,
+		logger:     opts.Logger,
+		health:     opts.Health,
+		propagator: opts.Propagator,
+		limits:     opts.FieldLimits.withDefaults(),
+		now:        time.Now,
+		closed:     make(chan struct{}),
+
+		rejectSerialIDs: opts.RejectSerialIDs,
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+	tp := opts.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	s.tracer = tp.Tracer(tracerName)
+	if s.propagator == nil {
+		s.propagator = propagation.TraceContext{}
+	}
+	if s.base == nil {
+		if err := CreateSchemaIfNotExists(ContextWithLogger(context.Background(), s.logger), opts.Pool); err != nil {
+			return nil, fmt.Errorf("shoppinglist: %w", err)
+		}
+		s.base = NewPostgresStore(opts.Pool)
+	}
+	if s.health == nil {
+		if p, ok := s.base.(Pinger); ok {
+			s.health = p
+		} else if s.pool != nil {
+			s.health = s.pool
+		}
+	}
+
+	reg := opts.Registry
+	if reg == nil {
+		reg = newDefaultRegistry()
+	}
+	m, err := newMetrics(reg, s.base, s.pool)
+	if err != nil {
+		return nil, fmt.Errorf("shoppinglist: registering metrics: %w", err)
+	}
+	s.metrics = m
+	s.store = &instrumentedStore{ItemStore: s.base, metrics: m, tracer: s.tracer}
+	recipes := opts.Recipes
+	if recipes == nil {
+		recipes, _ = s.base.(RecipeStore)
+	}
+	if recipes != nil {
+		s.recipes = &instrumentedRecipeStore{RecipeStore: recipes, metrics: m, tracer: s.tracer}
+	}
+	// The pantry shares the item store's database, so it cannot be configured separately
+	if pantry, ok := s.base.(PantryStore); ok {
+		s.pantry = &instrumentedPantryStore{PantryStore: pantry, metrics: m, tracer: s.tracer}
+	}
+	if prices, ok := s.base.(PriceStore); ok {
+		s.prices = &instrumentedPriceStore{PriceStore: prices, metrics: m, tracer: s.tracer}
+	}
+	if layouts, ok := s.base.(LayoutStore); ok {
+		s.layouts = &instrumentedLayoutStore{LayoutStore: layouts, metrics: m, tracer: s.tracer}
+	}
+	if history, ok := s.base.(HistoryStore); ok {
+		s.history = &instrumentedHistoryStore{HistoryStore: history, metrics: m, tracer: s.tracer}
+	}
+	if search, ok := s.base.(SearchStore); ok {
+		s.search = &instrumentedSearchStore{SearchStore: search, metrics: m, tracer: s.tracer}
+	}
+	if sync, ok := s.base.(SyncStore); ok {
+		s.sync = &instrumentedSyncStore{SyncStore: sync, metrics: m, tracer: s.tracer}
+	}
+	if uids, ok := s.base.(UIDStore); ok {
+		s.uids = &instrumentedUIDStore{UIDStore: uids, metrics: m, tracer: s.tracer}
+	}
+	if recurring, ok := s.base.(RecurringStore); ok {
+		s.recurring = &instrumentedRecurringStore{RecurringStore: recurring, metrics: m, tracer: s.tracer}
+	}
+	if opts.RateLimit.Read.enabled() || opts.RateLimit.Write.enabled() {
+		s.limiter = newRateLimiter(opts.RateLimit)
+	}
+
+	s.handler = s.routes(opts.PathPrefix, opts.Middleware, reg)
+	return s, nil
+}
+
+// routes builds the mux, applies middleware and strips the path prefix.
+func (s *Server) routes(prefix string, middleware []Middleware, reg *prometheus.Registry) http.Handler {
+	mux := http.NewServeMux()
+
+	// API Routes
+	mux.HandleFunc("/items", s.itemsHandler)           // Handles GET /items, POST /items
+	mux.HandleFunc("/items/", s.itemDetailHandler)     // Handles PUT/DELETE /items/{id} and POST /items/{id}/bought
+	mux.HandleFunc("/items/suggest", s.suggestHandler) // Handles GET /items/suggest; 404 without a HistoryStore
+	if s.recipes != nil {
+		mux.HandleFunc("/recipes", s.recipesHandler)       // Handles GET /recipes, POST /recipes
+		mux.HandleFunc("/recipes/", s.recipeDetailHandler) // Handles /recipes/{id} and /recipes/{id}/add-to-list
+	}
+	if s.pantry != nil {
+		mux.HandleFunc("/pantry", s.pantryHandler)        // Handles GET /pantry, POST /pantry
+		mux.HandleFunc("/pantry/", s.pantryDetailHandler) // Handles /pantry/{id}, /pantry/{id}/consume and /pantry/expiring
+	}
+	if s.prices != nil {
+		mux.HandleFunc("/prices", s.pricesHandler) // Handles GET /prices
+		mux.HandleFunc("/budget", s.budgetHandler) // Handles GET/PUT/DELETE /budget
+	}
+	if s.layouts != nil {
+		mux.HandleFunc("/stores", s.storesHandler)       // Handles GET /stores, POST /stores
+		mux.HandleFunc("/stores/", s.storeDetailHandler) // Handles /stores/{id} and /stores/{id}/mappings
+	}
+	if s.search != nil {
+		mux.HandleFunc("/search", s.searchHandler) // Handles GET /search
+	}
+	if s.sync != nil {
+		mux.HandleFunc("/sync", s.syncHandler) // Handles GET /sync, POST /sync
+	}
+	if s.recurring != nil {
+		mux.HandleFunc("/recurring", s.recurringHandler)        // Handles GET /recurring, POST /recurring
+		mux.HandleFunc("/recurring/", s.recurringDetailHandler) // Handles GET/PUT/DELETE /recurring/{id}
+	}
+
+	// Health Check endpoints: liveness has no dependencies, readiness checks the database
+	mux.HandleFunc("/livez", s.livezHandler)
+	mux.HandleFunc("/readyz", s.readyzHandler)
+	mux.HandleFunc("/healthz", s.readyzHandler) // Compatibility alias for /readyz
+
+	// Prometheus scrape endpoint; a failing collector (e.g. item count while the
+	// database is down) is logged and skipped rather than failing the whole scrape
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{
+		ErrorLog:      slog.NewLogLogger(s.logger.Handler(), slog.LevelError),
+		ErrorHandling: promhttp.ContinueOnError,
+	}))
+
+	// Unknown paths get a problem+json 404 instead of the mux's plain-text one
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern == "" {
+			s.writeStatus(w, r, http.StatusNotFound, "No such endpoint")
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+	// Rate limiting runs inside user middleware, so RateLimit.User can see who authenticated
+	if s.limiter != nil {
+		h = s.rateLimitMiddleware(s.limiter, h)
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	// Request IDs, tracing, access logging and metrics wrap user middleware so every
+	// request is recorded
+	h = s.accessLogMiddleware(mux, h)
+	h = s.tracingMiddleware(mux, h)
+	h = s.requestIDMiddleware(h)
+	if prefix != "" {
+		h = http.StripPrefix(prefix, h)
+	}
+	return h
+}
+
+// requestLogger returns the request-scoped logger, falling back to the server's logger.
+func (s *Server) requestLogger(r *http.Request) *slog.Logger {
+	if logger, ok := r.Context().Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return s.logger
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// Close stops RunScheduler, waiting for a run in progress, then releases the store (if it
+// implements io.Closer) and the pool.
+func (s *Server) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	s.schedulers.Wait()
+	var errs []error
+	if c, ok := s.base.(io.Closer); ok {
+		errs = append(errs, c.Close())
+	}
+	if s.pool != nil {
+		s.pool.Close()
+	}
+	return errors.Join(errs...)
+}
+
+// Ping reports whether the storage backend is reachable.
+func (s *Server) Ping(ctx context.Context) error {
+	if s.health == nil {
+		return nil
+	}
+	return s.health.Ping(ctx)
+}
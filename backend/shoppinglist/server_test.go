@@ -0,0 +1,162 @@
+package shoppinglist
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewOptionsValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "NoStoreOrPool", opts: Options{}},
+		{name: "PrefixWithoutLeadingSlash", opts: Options{Store: NewMemoryStore(), PathPrefix: "shopping"}},
+		{name: "PrefixWithTrailingSlash", opts: Options{Store: NewMemoryStore(), PathPrefix: "/shopping/"}},
+		{name: "NameLimitAboveHardLimit", opts: Options{Store: NewMemoryStore(), FieldLimits: FieldLimits{MaxName: MaxNameLength + 1}}},
+		{name: "NegativeQuantityLimit", opts: Options{Store: NewMemoryStore(), FieldLimits: FieldLimits{MaxQuantity: -1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.opts); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestNewWithPool(t *testing.T) {
+	mock, cleanup := newMockPool(t)
+	defer cleanup()
+
+	t.Run("AppliesMigrations", func(t *testing.T) {
+		expectMigrationStart(mock, latestSchemaVersion())
+		mock.ExpectCommit()
+
+		srv, err := New(Options{Pool: mock})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if _, ok := srv.base.(*PostgresStore); !ok {
+			t.Errorf("Expected a PostgresStore, got %T", srv.base)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("MigrationError", func(t *testing.T) {
+		mock.ExpectBegin().WillReturnError(errors.New("connection refused"))
+
+		if _, err := New(Options{Pool: mock}); err == nil {
+			t.Fatal("Expected an error, got nil")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestServerPathPrefix(t *testing.T) {
+	store := NewMemoryStore()
+	if _, err := store.Create(context.Background(), Item{Name: "Milk", Quantity: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(Options{Store: store, PathPrefix: "/shopping"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	// Mount inside a host application's mux, as an embedding service would
+	mux := http.NewServeMux()
+	mux.Handle("/shopping/", srv)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/shopping/items", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Milk") {
+		t.Errorf("GET /shopping/items: expected 200 with item, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("DELETE", "/shopping/items/1", nil))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("DELETE /shopping/items/1: expected %d, got %d", http.StatusNoContent, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("GET /items without prefix: expected %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestServerMiddleware(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	srv, err := New(Options{Store: NewMemoryStore(), Middleware: []Middleware{mark("outer"), mark("inner")}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Errorf("Expected middleware order outer,inner; got %v", order)
+	}
+}
+
+func TestServerLogger(t *testing.T) {
+	var buf bytes.Buffer
+	srv, err := New(Options{Store: NewPostgresStore(failingPinger{}), Logger: slog.New(slog.NewTextHandler(&buf, nil))})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest("GET", "/healthz", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+	if !strings.Contains(buf.String(), "Health check failed") {
+		t.Errorf("Expected health failure in injected logger, got %q", buf.String())
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	mock, cleanup := newMockPool(t)
+	defer cleanup()
+	mock.ExpectClose()
+
+	// The server owns the pool it was given and closes it
+	srv, err := New(Options{Store: NewMemoryStore(), Pool: mock})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := srv.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+// failingPinger is a DBPool whose Ping always fails; no other method may be called.
+type failingPinger struct{ DBPool }
+
+func (failingPinger) Ping(ctx context.Context) error { return errors.New("connection refused") }
@@ -0,0 +1,91 @@
+package shoppinglist
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Item represents a shopping list item
+type Item struct {
+	ID        int       `json:"id,omitempty"`  // Left out once Options.RejectSerialIDs is set
+	UID       string    `json:"uid,omitempty"` // Public ID, a UUIDv7 assigned by the store
+	Name      string    `json:"name"`
+	Quantity  string    `json:"quantity"`
+	UnitPrice *int64    `json:"unit_price,omitempty"` // Minor units per unit of Quantity
+	Currency  string    `json:"currency,omitempty"`   // ISO 4217, set with UnitPrice
+	CreatedAt time.Time `json:"created_at,omitempty"` // omitempty for POST
+}
+
+// --- Storage Abstraction ---
+
+// ErrNotFound is returned by an ItemStore when the requested item does not exist.
+var ErrNotFound = errors.New("item not found")
+
+// ErrValidation is matched by every validation failure. Use errors.Is(err, ErrValidation)
+// to detect one, and errors.As with a *ValidationError for the individual fields.
+var ErrValidation = errors.New("invalid item")
+
+// FieldError describes why one field of an item is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by a store when an item or recipe fails validation.
+type ValidationError struct {
+	Resource string // What was invalid: "item" if empty, or "recipe"
+	Fields   []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + " " + f.Message
+	}
+	return "invalid " + e.resource() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) resource() string {
+	if e.Resource == "" {
+		return "item"
+	}
+	return e.Resource
+}
+
+// Is makes errors.Is(err, ErrValidation) true for a *ValidationError.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// ItemStore is the domain-level interface for persisting shopping list items.
+// Handlers depend only on this interface, so any backend (Postgres, in-memory, ...)
+// can be injected, and every implementation must pass the shared conformance suite.
+type ItemStore interface {
+	// List returns all items, newest first.
+	List(ctx context.Context) ([]Item, error)
+	// Get returns the item with the given ID, or ErrNotFound.
+	Get(ctx context.Context, id int) (Item, error)
+	// Create validates and stores a new item, returning it with ID and CreatedAt set. The
+	// built-in stores set UID too.
+	Create(ctx context.Context, item Item) (Item, error)
+	// Update replaces the name, quantity and price of an existing item, or returns ErrNotFound.
+	Update(ctx context.Context, item Item) (Item, error)
+	// Delete removes the item with the given ID, or returns ErrNotFound.
+	Delete(ctx context.Context, id int) error
+}
+
+// Pinger is implemented by stores and pools that can report whether their backend is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// isClientError reports whether err is an expected, caller-caused store error
+// (as opposed to a database failure).
+func isClientError(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrRecipeNotFound) || errors.Is(err, ErrValidation) ||
+		errors.Is(err, ErrPantryItemNotFound) || errors.Is(err, ErrPantryItemExists) || errors.Is(err, ErrRecurringItemNotFound) ||
+		errors.Is(err, ErrPriceNotFound) || errors.Is(err, ErrBudgetNotSet) || errors.Is(err, ErrStoreNotFound) ||
+		errors.Is(err, ErrMappingNotFound)
+}
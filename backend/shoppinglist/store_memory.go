@@ -0,0 +1,1186 @@
+package shoppinglist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- In-Memory ItemStore ---
+
+// MemoryStore is a thread-safe ItemStore kept entirely in memory.
+// It mirrors the SQL semantics (sequential IDs, server-assigned created_at,
+// newest-first ordering) so the backend can run without Postgres.
+// If a snapshot path is set, the contents are loaded on start and written on Close.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	items  map[int]Item
+	nextID int
+	path   string // Snapshot file, empty disables persistence
+
+	recipes      map[int]Recipe
+	nextRecipeID int
+
+	pantry       map[int]PantryItem
+	nextPantryID int
+
+	recurring       map[int]RecurringItem
+	nextRecurringID int
+
+	prices map[string]Price // By lower-cased name
+	budget *Money
+
+	stores      map[int]StoreLayout
+	nextStoreID int
+	mappings    map[int]map[string]SectionMapping // By store ID, then lower-cased name
+
+	history map[string]historyEntry // By lower-cased name
+
+	versions   int64            // Last version handed out
+	syncs      map[int]itemSync // By item ID
+	tombstones []Tombstone      // In version order
+}
+
+// itemSync is what MemoryStore keeps for the sync of an item, like the extra items
+// columns of the SQL stores.
+type itemSync struct {
+	Version    int64     `json:"version"`
+	NameAt     time.Time `json:"name_changed_at"`
+	QuantityAt time.Time `json:"quantity_changed_at"`
+	PriceAt    time.Time `json:"price_changed_at"`
+}
+
+// memorySnapshot is the on-disk JSON format used by MemoryStore.
+// Snapshots written before recipes, the pantry, recurring items, prices, stores, the
+// item history or sync existed simply have none.
+type memorySnapshot struct {
+	NextID          int              `json:"next_id"`
+	Items           []Item           `json:"items"`
+	NextRecipeID    int              `json:"next_recipe_id,omitempty"`
+	Recipes         []Recipe         `json:"recipes,omitempty"`
+	NextPantryID    int              `json:"next_pantry_id,omitempty"`
+	Pantry          []PantryItem     `json:"pantry,omitempty"`
+	NextRecurringID int              `json:"next_recurring_id,omitempty"`
+	Recurring       []RecurringItem  `json:"recurring,omitempty"`
+	Prices          []Price          `json:"prices,omitempty"`
+	Budget          *Money           `json:"budget,omitempty"`
+	NextStoreID     int              `json:"next_store_id,omitempty"`
+	Stores          []StoreLayout    `json:"stores,omitempty"`
+	Mappings        []storeMapping   `json:"mappings,omitempty"`
+	History         []historyEntry   `json:"history,omitempty"`
+	Versions        int64            `json:"versions,omitempty"`
+	Syncs           []memoryItemSync `json:"syncs,omitempty"`
+	Tombstones      []Tombstone      `json:"tombstones,omitempty"`
+}
+
+// memoryItemSync is an itemSync in a snapshot, tagged with its item.
+type memoryItemSync struct {
+	ItemID int `json:"item_id"`
+	itemSync
+}
+
+// storeMapping is a SectionMapping in a snapshot, tagged with its store.
+type storeMapping struct {
+	StoreID int `json:"store_id"`
+	SectionMapping
+}
+
+// NewMemoryStore returns an empty, non-persistent in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		items:        make(map[int]Item),
+		nextID:       1,
+		recipes:      make(map[int]Recipe),
+		nextRecipeID: 1,
+		pantry:       make(map[int]PantryItem),
+		nextPantryID: 1,
+
+		recurring:       make(map[int]RecurringItem),
+		nextRecurringID: 1,
+
+		prices: make(map[string]Price),
+
+		stores:      make(map[int]StoreLayout),
+		nextStoreID: 1,
+		mappings:    make(map[int]map[string]SectionMapping),
+
+		history: make(map[string]historyEntry),
+
+		syncs: make(map[int]itemSync),
+	}
+}
+
+// LoadMemoryStore returns an in-memory store that snapshots to path.
+// A missing snapshot file is not an error; the store simply starts empty.
+func LoadMemoryStore(path string) (*MemoryStore, error) {
+	s := NewMemoryStore()
+	s.path = path
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		slog.Info("No snapshot found, starting with an empty list", "path", path)
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read snapshot: %w", err)
+	}
+
+	var snap memorySnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("unable to parse snapshot %s: %w", path, err)
+	}
+	for _, item := range snap.Items {
+		if item.UID == "" {
+			item.UID = newItemUID(item.CreatedAt) // Snapshots taken before UIDs existed
+		}
+		s.items[item.ID] = item
+		if item.ID >= s.nextID {
+			s.nextID = item.ID + 1
+		}
+	}
+	// Never reuse IDs of items deleted before the snapshot was taken
+	if snap.NextID > s.nextID {
+		s.nextID = snap.NextID
+	}
+	for _, recipe := range snap.Recipes {
+		s.recipes[recipe.ID] = recipe
+		if recipe.ID >= s.nextRecipeID {
+			s.nextRecipeID = recipe.ID + 1
+		}
+	}
+	if snap.NextRecipeID > s.nextRecipeID {
+		s.nextRecipeID = snap.NextRecipeID
+	}
+	for _, item := range snap.Pantry {
+		s.pantry[item.ID] = item
+		if item.ID >= s.nextPantryID {
+			s.nextPantryID = item.ID + 1
+		}
+	}
+	if snap.NextPantryID > s.nextPantryID {
+		s.nextPantryID = snap.NextPantryID
+	}
+	for _, item := range snap.Recurring {
+		s.recurring[item.ID] = item
+		if item.ID >= s.nextRecurringID {
+			s.nextRecurringID = item.ID + 1
+		}
+	}
+	if snap.NextRecurringID > s.nextRecurringID {
+		s.nextRecurringID = snap.NextRecurringID
+	}
+	for _, price := range snap.Prices {
+		s.prices[strings.ToLower(price.Name)] = price
+	}
+	s.budget = snap.Budget
+	for _, store := range snap.Stores {
+		s.stores[store.ID] = store
+		if store.ID >= s.nextStoreID {
+			s.nextStoreID = store.ID + 1
+		}
+	}
+	if snap.NextStoreID > s.nextStoreID {
+		s.nextStoreID = snap.NextStoreID
+	}
+	for _, m := range snap.Mappings {
+		if s.mappings[m.StoreID] == nil {
+			s.mappings[m.StoreID] = make(map[string]SectionMapping)
+		}
+		s.mappings[m.StoreID][strings.ToLower(m.Name)] = m.SectionMapping
+	}
+	for _, entry := range snap.History {
+		s.history[strings.ToLower(entry.Name)] = entry
+	}
+	s.versions, s.tombstones = snap.Versions, snap.Tombstones
+	for _, meta := range snap.Syncs {
+		if _, ok := s.items[meta.ItemID]; ok {
+			s.syncs[meta.ItemID] = meta.itemSync
+		}
+	}
+	// Items from snapshots taken before sync get versions in ID order
+	var unversioned []int
+	for id := range s.items {
+		if _, ok := s.syncs[id]; !ok {
+			unversioned = append(unversioned, id)
+		}
+	}
+	sort.Ints(unversioned)
+	for _, id := range unversioned {
+		s.trackWrite(s.items[id], nil)
+	}
+	slog.Info("Loaded items from snapshot", "count", len(s.items), "recipes", len(s.recipes), "pantry", len(s.pantry), "recurring", len(s.recurring), "path", path)
+	return s, nil
+}
+
+// List returns all items, newest first
+func (s *MemoryStore) List(ctx context.Context) ([]Item, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]Item, 0, len(s.items))
+	for _, item := range s.items {
+		items = append(items, item)
+	}
+	sortNewestFirst(items)
+	return items, nil
+}
+
+// Get returns a single item by ID
+func (s *MemoryStore) Get(ctx context.Context, id int) (Item, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return Item{}, fmt.Errorf("item with ID %d: %w", id, ErrNotFound)
+	}
+	return item, nil
+}
+
+// GetByUID returns a single item by UID
+func (s *MemoryStore) GetByUID(ctx context.Context, uid string) (Item, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, item := range s.items {
+		if item.UID == uid {
+			return item, nil
+		}
+	}
+	return Item{}, fmt.Errorf("item with UID %s: %w", uid, ErrNotFound)
+}
+
+// Create validates and stores a new item
+func (s *MemoryStore) Create(ctx context.Context, newItem Item) (Item, error) {
+	newItem, err := normalizeItem(newItem, FieldLimits{})
+	if err != nil {
+		return Item{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	newItem.ID = s.nextID
+	newItem.CreatedAt = time.Now().UTC()
+	newItem.UID = newItemUID(newItem.CreatedAt)
+	s.nextID++
+	s.items[newItem.ID] = newItem
+	s.trackWrite(newItem, nil)
+	loggerFrom(ctx).Info("Added item", "id", newItem.ID, "name", newItem.Name, "quantity", newItem.Quantity)
+	return newItem, nil
+}
+
+// Update changes the name, quantity and price of an existing item
+func (s *MemoryStore) Update(ctx context.Context, item Item) (Item, error) {
+	item, err := normalizeItem(item, FieldLimits{})
+	if err != nil {
+		return Item{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.items[item.ID]
+	if !ok {
+		return Item{}, fmt.Errorf("item with ID %d: %w", item.ID, ErrNotFound)
+	}
+	old := existing
+	existing.Name = item.Name
+	existing.Quantity = item.Quantity
+	existing.UnitPrice, existing.Currency = item.UnitPrice, item.Currency
+	s.items[item.ID] = existing
+	s.trackWrite(existing, &old)
+	loggerFrom(ctx).Info("Updated item", "id", existing.ID, "name", existing.Name, "quantity", existing.Quantity)
+	return existing, nil
+}
+
+// Delete removes an item by ID
+func (s *MemoryStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.items[id]; !ok {
+		loggerFrom(ctx).Info("Attempted to delete non-existent item", "id", id)
+		return fmt.Errorf("item with ID %d: %w", id, ErrNotFound)
+	}
+	s.trackDelete(id)
+	delete(s.items, id)
+	loggerFrom(ctx).Info("Deleted item", "id", id)
+	return nil
+}
+
+// Count returns the number of items.
+func (s *MemoryStore) Count(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.items), nil
+}
+
+// ListRecipes returns all recipes ordered by title
+func (s *MemoryStore) ListRecipes(ctx context.Context) ([]Recipe, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	recipes := make([]Recipe, 0, len(s.recipes))
+	for _, recipe := range s.recipes {
+		recipes = append(recipes, copyRecipe(recipe))
+	}
+	sortByTitle(recipes)
+	return recipes, nil
+}
+
+// GetRecipe returns a single recipe by ID
+func (s *MemoryStore) GetRecipe(ctx context.Context, id int) (Recipe, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	recipe, ok := s.recipes[id]
+	if !ok {
+		return Recipe{}, fmt.Errorf("recipe with ID %d: %w", id, ErrRecipeNotFound)
+	}
+	return copyRecipe(recipe), nil
+}
+
+// CreateRecipe validates and stores a new recipe
+func (s *MemoryStore) CreateRecipe(ctx context.Context, recipe Recipe) (Recipe, error) {
+	recipe, err := normalizeRecipe(recipe, FieldLimits{})
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recipe.ID = s.nextRecipeID
+	recipe.CreatedAt = time.Now().UTC()
+	s.nextRecipeID++
+	s.recipes[recipe.ID] = recipe
+	loggerFrom(ctx).Info("Added recipe", "id", recipe.ID, "title", recipe.Title)
+	return copyRecipe(recipe), nil
+}
+
+// UpdateRecipe replaces the title, servings and ingredients of an existing recipe
+func (s *MemoryStore) UpdateRecipe(ctx context.Context, recipe Recipe) (Recipe, error) {
+	recipe, err := normalizeRecipe(recipe, FieldLimits{})
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.recipes[recipe.ID]
+	if !ok {
+		return Recipe{}, fmt.Errorf("recipe with ID %d: %w", recipe.ID, ErrRecipeNotFound)
+	}
+	recipe.CreatedAt = existing.CreatedAt
+	s.recipes[recipe.ID] = recipe
+	loggerFrom(ctx).Info("Updated recipe", "id", recipe.ID, "title", recipe.Title)
+	return copyRecipe(recipe), nil
+}
+
+// DeleteRecipe removes a recipe by ID
+func (s *MemoryStore) DeleteRecipe(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.recipes[id]; !ok {
+		return fmt.Errorf("recipe with ID %d: %w", id, ErrRecipeNotFound)
+	}
+	delete(s.recipes, id)
+	loggerFrom(ctx).Info("Deleted recipe", "id", id)
+	return nil
+}
+
+// copyRecipe returns recipe with its own ingredient slice, so callers cannot modify the store.
+func copyRecipe(recipe Recipe) Recipe {
+	recipe.Ingredients = append([]Ingredient{}, recipe.Ingredients...)
+	return recipe
+}
+
+// ListPantry returns all pantry items ordered by name
+func (s *MemoryStore) ListPantry(ctx context.Context) ([]PantryItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]PantryItem, 0, len(s.pantry))
+	for _, item := range s.pantry {
+		items = append(items, item)
+	}
+	sortPantry(items)
+	return items, nil
+}
+
+// GetPantryItem returns a single pantry item by ID
+func (s *MemoryStore) GetPantryItem(ctx context.Context, id int) (PantryItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, ok := s.pantry[id]
+	if !ok {
+		return PantryItem{}, fmt.Errorf("pantry item with ID %d: %w", id, ErrPantryItemNotFound)
+	}
+	return item, nil
+}
+
+// CreatePantryItem validates and stores a new pantry item
+func (s *MemoryStore) CreatePantryItem(ctx context.Context, item PantryItem) (PantryItem, error) {
+	item, err := normalizePantryItem(item, FieldLimits{})
+	if err != nil {
+		return PantryItem{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.pantryByName(item.Name); ok {
+		return PantryItem{}, fmt.Errorf("pantry item %q: %w", item.Name, ErrPantryItemExists)
+	}
+	item.ID = s.nextPantryID
+	item.CreatedAt = time.Now().UTC()
+	s.nextPantryID++
+	s.pantry[item.ID] = item
+	loggerFrom(ctx).Info("Added pantry item", "id", item.ID, "name", item.Name, "stock", item.Stock)
+	return item, nil
+}
+
+// UpdatePantryItem replaces every field of an existing pantry item
+func (s *MemoryStore) UpdatePantryItem(ctx context.Context, item PantryItem) (PantryItem, error) {
+	item, err := normalizePantryItem(item, FieldLimits{})
+	if err != nil {
+		return PantryItem{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.pantry[item.ID]
+	if !ok {
+		return PantryItem{}, fmt.Errorf("pantry item with ID %d: %w", item.ID, ErrPantryItemNotFound)
+	}
+	if other, ok := s.pantryByName(item.Name); ok && other.ID != item.ID {
+		return PantryItem{}, fmt.Errorf("pantry item %q: %w", item.Name, ErrPantryItemExists)
+	}
+	item.CreatedAt = existing.CreatedAt
+	s.pantry[item.ID] = item
+	loggerFrom(ctx).Info("Updated pantry item", "id", item.ID, "name", item.Name, "stock", item.Stock)
+	return item, nil
+}
+
+// DeletePantryItem removes a pantry item by ID
+func (s *MemoryStore) DeletePantryItem(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.pantry[id]; !ok {
+		return fmt.Errorf("pantry item with ID %d: %w", id, ErrPantryItemNotFound)
+	}
+	delete(s.pantry, id)
+	loggerFrom(ctx).Info("Deleted pantry item", "id", id)
+	return nil
+}
+
+// ConsumeStock takes amount off the stock of a pantry item, stopping at zero
+func (s *MemoryStore) ConsumeStock(ctx context.Context, id int, amount float64) (PantryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.pantry[id]
+	if !ok {
+		return PantryItem{}, fmt.Errorf("pantry item with ID %d: %w", id, ErrPantryItemNotFound)
+	}
+	item.Stock = max(roundStock(item.Stock-amount), 0)
+	s.pantry[id] = item
+	loggerFrom(ctx).Info("Consumed stock", "id", id, "amount", amount, "stock", item.Stock)
+	return item, nil
+}
+
+// MovePurchased deletes a shopping list item and adds its stock to the pantry
+func (s *MemoryStore) MovePurchased(ctx context.Context, itemID int, stock PantryItem) (PantryItem, error) {
+	stock, err := normalizePantryItem(stock, FieldLimits{})
+	if err != nil {
+		return PantryItem{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.items[itemID]; !ok {
+		return PantryItem{}, fmt.Errorf("item with ID %d: %w", itemID, ErrNotFound)
+	}
+	item, ok := s.pantryByName(stock.Name)
+	if !ok {
+		item = PantryItem{ID: s.nextPantryID, Name: stock.Name, CreatedAt: time.Now().UTC()}
+		s.nextPantryID++
+	}
+	amount, ok := purchasedStock(stock, item.Unit)
+	if !ok {
+		loggerFrom(ctx).Warn("Purchased stock is in a different unit; pantry stock left unchanged",
+			"item_id", itemID, "pantry_id", item.ID, "unit", stock.Unit, "pantry_unit", item.Unit)
+	}
+	item.Stock = roundStock(item.Stock + amount)
+	if item.Unit == "" {
+		item.Unit = stock.Unit
+	}
+	if stock.BestBefore != "" {
+		item.BestBefore = stock.BestBefore
+	}
+	s.trackDelete(itemID)
+	delete(s.items, itemID)
+	s.pantry[item.ID] = item
+	loggerFrom(ctx).Info("Moved purchased item to the pantry", "item_id", itemID, "pantry_id", item.ID, "stock", item.Stock)
+	return item, nil
+}
+
+// pantryByName finds a pantry item by name, ignoring case. The caller holds s.mu.
+func (s *MemoryStore) pantryByName(name string) (PantryItem, bool) {
+	for _, item := range s.pantry {
+		if strings.EqualFold(item.Name, name) {
+			return item, true
+		}
+	}
+	return PantryItem{}, false
+}
+
+// ListRecurring returns all recurring items ordered by name
+func (s *MemoryStore) ListRecurring(ctx context.Context) ([]RecurringItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]RecurringItem, 0, len(s.recurring))
+	for _, item := range s.recurring {
+		items = append(items, item)
+	}
+	sortRecurring(items)
+	return items, nil
+}
+
+// GetRecurring returns a single recurring item by ID
+func (s *MemoryStore) GetRecurring(ctx context.Context, id int) (RecurringItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, ok := s.recurring[id]
+	if !ok {
+		return RecurringItem{}, fmt.Errorf("recurring item with ID %d: %w", id, ErrRecurringItemNotFound)
+	}
+	return item, nil
+}
+
+// CreateRecurring validates and stores a new recurring item
+func (s *MemoryStore) CreateRecurring(ctx context.Context, item RecurringItem) (RecurringItem, error) {
+	item, err := normalizeRecurringItem(item, FieldLimits{}, time.Now())
+	if err != nil {
+		return RecurringItem{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item.ID = s.nextRecurringID
+	item.LastRun = nil
+	item.CreatedAt = time.Now().UTC()
+	s.nextRecurringID++
+	s.recurring[item.ID] = item
+	loggerFrom(ctx).Info("Added recurring item", "id", item.ID, "name", item.Name, "schedule", item.Schedule)
+	return item, nil
+}
+
+// UpdateRecurring replaces the definition and next run of an existing recurring item
+func (s *MemoryStore) UpdateRecurring(ctx context.Context, item RecurringItem) (RecurringItem, error) {
+	item, err := normalizeRecurringItem(item, FieldLimits{}, time.Now())
+	if err != nil {
+		return RecurringItem{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.recurring[item.ID]
+	if !ok {
+		return RecurringItem{}, fmt.Errorf("recurring item with ID %d: %w", item.ID, ErrRecurringItemNotFound)
+	}
+	item.LastRun = existing.LastRun
+	item.CreatedAt = existing.CreatedAt
+	s.recurring[item.ID] = item
+	loggerFrom(ctx).Info("Updated recurring item", "id", item.ID, "name", item.Name, "schedule", item.Schedule)
+	return item, nil
+}
+
+// DeleteRecurring removes a recurring item by ID
+func (s *MemoryStore) DeleteRecurring(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.recurring[id]; !ok {
+		return fmt.Errorf("recurring item with ID %d: %w", id, ErrRecurringItemNotFound)
+	}
+	delete(s.recurring, id)
+	loggerFrom(ctx).Info("Deleted recurring item", "id", id)
+	return nil
+}
+
+// DueRecurring returns the recurring items due at now, earliest first
+func (s *MemoryStore) DueRecurring(ctx context.Context, now time.Time) ([]RecurringItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	due := []RecurringItem{}
+	for _, item := range s.recurring {
+		if !item.NextRun.After(now) {
+			due = append(due, item)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextRun.Equal(due[j].NextRun) {
+			return due[i].NextRun.Before(due[j].NextRun)
+		}
+		return due[i].ID < due[j].ID
+	})
+	return due, nil
+}
+
+// RecordRun sets the last and next run of a recurring item
+func (s *MemoryStore) RecordRun(ctx context.Context, id int, ranAt, next time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.recurring[id]
+	if !ok {
+		return fmt.Errorf("recurring item with ID %d: %w", id, ErrRecurringItemNotFound)
+	}
+	item.LastRun = &ranAt
+	item.NextRun = next
+	s.recurring[id] = item
+	return nil
+}
+
+// ListPrices returns every remembered price ordered by name
+func (s *MemoryStore) ListPrices(ctx context.Context) ([]Price, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prices := make([]Price, 0, len(s.prices))
+	for _, price := range s.prices {
+		prices = append(prices, price)
+	}
+	sortPrices(prices)
+	return prices, nil
+}
+
+// LastPrice returns the remembered price for a name, ignoring case
+func (s *MemoryStore) LastPrice(ctx context.Context, name string) (Price, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	price, ok := s.prices[strings.ToLower(name)]
+	if !ok {
+		return Price{}, fmt.Errorf("price of %q: %w", name, ErrPriceNotFound)
+	}
+	return price, nil
+}
+
+// RecordPrice validates and remembers a price, replacing the one for the same name
+func (s *MemoryStore) RecordPrice(ctx context.Context, price Price) (Price, error) {
+	price, err := normalizePrice(price)
+	if err != nil {
+		return Price{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	price.UpdatedAt = time.Now().UTC()
+	s.prices[strings.ToLower(price.Name)] = price
+	loggerFrom(ctx).Info("Recorded price", "name", price.Name, "unit_price", price.UnitPrice, "currency", price.Currency)
+	return price, nil
+}
+
+// GetBudget returns the list's budget
+func (s *MemoryStore) GetBudget(ctx context.Context) (Money, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.budget == nil {
+		return Money{}, ErrBudgetNotSet
+	}
+	return *s.budget, nil
+}
+
+// SetBudget validates and stores the list's budget
+func (s *MemoryStore) SetBudget(ctx context.Context, budget Money) (Money, error) {
+	budget, err := normalizeBudget(budget)
+	if err != nil {
+		return Money{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.budget = &budget
+	loggerFrom(ctx).Info("Set budget", "amount", budget.Amount, "currency", budget.Currency)
+	return budget, nil
+}
+
+// DeleteBudget removes the list's budget
+func (s *MemoryStore) DeleteBudget(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.budget == nil {
+		return ErrBudgetNotSet
+	}
+	s.budget = nil
+	loggerFrom(ctx).Info("Deleted budget")
+	return nil
+}
+
+// --- In-Memory LayoutStore ---
+
+// ListStores returns all stores ordered by name
+func (s *MemoryStore) ListStores(ctx context.Context) ([]StoreLayout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stores := make([]StoreLayout, 0, len(s.stores))
+	for _, store := range s.stores {
+		stores = append(stores, copyStore(store))
+	}
+	sortStores(stores)
+	return stores, nil
+}
+
+// GetStore returns a single store by ID
+func (s *MemoryStore) GetStore(ctx context.Context, id int) (StoreLayout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	store, ok := s.stores[id]
+	if !ok {
+		return StoreLayout{}, fmt.Errorf("store with ID %d: %w", id, ErrStoreNotFound)
+	}
+	return copyStore(store), nil
+}
+
+// CreateStore validates and stores a new store
+func (s *MemoryStore) CreateStore(ctx context.Context, store StoreLayout) (StoreLayout, error) {
+	store, err := normalizeStore(store)
+	if err != nil {
+		return StoreLayout{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store.ID = s.nextStoreID
+	store.LastCheckOff = nil
+	store.CreatedAt = time.Now().UTC()
+	s.nextStoreID++
+	s.stores[store.ID] = store
+	loggerFrom(ctx).Info("Added store", "id", store.ID, "name", store.Name)
+	return copyStore(store), nil
+}
+
+// UpdateStore replaces the name and sections of an existing store
+func (s *MemoryStore) UpdateStore(ctx context.Context, store StoreLayout) (StoreLayout, error) {
+	store, err := normalizeStore(store)
+	if err != nil {
+		return StoreLayout{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.stores[store.ID]
+	if !ok {
+		return StoreLayout{}, fmt.Errorf("store with ID %d: %w", store.ID, ErrStoreNotFound)
+	}
+	store.LastCheckOff, store.CreatedAt = existing.LastCheckOff, existing.CreatedAt
+	s.stores[store.ID] = store
+	loggerFrom(ctx).Info("Updated store", "id", store.ID, "name", store.Name)
+	return copyStore(store), nil
+}
+
+// DeleteStore removes a store and its mappings by ID
+func (s *MemoryStore) DeleteStore(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.stores[id]; !ok {
+		return fmt.Errorf("store with ID %d: %w", id, ErrStoreNotFound)
+	}
+	delete(s.stores, id)
+	delete(s.mappings, id)
+	loggerFrom(ctx).Info("Deleted store", "id", id)
+	return nil
+}
+
+// ListMappings returns the mappings of a store ordered by name
+func (s *MemoryStore) ListMappings(ctx context.Context, storeID int) ([]SectionMapping, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.stores[storeID]; !ok {
+		return nil, fmt.Errorf("store with ID %d: %w", storeID, ErrStoreNotFound)
+	}
+	mappings := make([]SectionMapping, 0, len(s.mappings[storeID]))
+	for _, mapping := range s.mappings[storeID] {
+		mappings = append(mappings, mapping)
+	}
+	sortMappings(mappings)
+	return mappings, nil
+}
+
+// GetMapping returns the mapping for a name in a store
+func (s *MemoryStore) GetMapping(ctx context.Context, storeID int, name string) (SectionMapping, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	mapping, ok := s.mappings[storeID][strings.ToLower(name)]
+	if !ok {
+		return SectionMapping{}, fmt.Errorf("%q in store %d: %w", name, storeID, ErrMappingNotFound)
+	}
+	return mapping, nil
+}
+
+// SetMapping validates and stores a mapping, replacing the one for the same name
+func (s *MemoryStore) SetMapping(ctx context.Context, storeID int, mapping SectionMapping) (SectionMapping, error) {
+	mapping, err := normalizeMapping(mapping, FieldLimits{})
+	if err != nil {
+		return SectionMapping{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.stores[storeID]; !ok {
+		return SectionMapping{}, fmt.Errorf("store with ID %d: %w", storeID, ErrStoreNotFound)
+	}
+	if s.mappings[storeID] == nil {
+		s.mappings[storeID] = make(map[string]SectionMapping)
+	}
+	mapping.UpdatedAt = time.Now().UTC()
+	s.mappings[storeID][strings.ToLower(mapping.Name)] = mapping
+	loggerFrom(ctx).Info("Set section mapping", "store", storeID, "name", mapping.Name, "section", mapping.Section, "learned", mapping.Learned)
+	return mapping, nil
+}
+
+// DeleteMapping removes the mapping for a name from a store
+func (s *MemoryStore) DeleteMapping(ctx context.Context, storeID int, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(name)
+	if _, ok := s.mappings[storeID][key]; !ok {
+		return fmt.Errorf("%q in store %d: %w", name, storeID, ErrMappingNotFound)
+	}
+	delete(s.mappings[storeID], key)
+	loggerFrom(ctx).Info("Deleted section mapping", "store", storeID, "name", name)
+	return nil
+}
+
+// RecordCheckOff remembers the last item checked off in a store
+func (s *MemoryStore) RecordCheckOff(ctx context.Context, storeID int, checkOff CheckOff) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, ok := s.stores[storeID]
+	if !ok {
+		return fmt.Errorf("store with ID %d: %w", storeID, ErrStoreNotFound)
+	}
+	checkOff.At = checkOff.At.UTC()
+	store.LastCheckOff = &checkOff
+	s.stores[storeID] = store
+	return nil
+}
+
+// copyStore returns store with its own sections and check-off, so callers cannot modify
+// the store.
+func copyStore(store StoreLayout) StoreLayout {
+	store.Sections = append([]string{}, store.Sections...)
+	if store.LastCheckOff != nil {
+		checkOff := *store.LastCheckOff
+		store.LastCheckOff = &checkOff
+	}
+	return store
+}
+
+// --- In-Memory HistoryStore ---
+
+// RecordUse counts one more use of an item's name and remembers its quantity
+func (s *MemoryStore) RecordUse(ctx context.Context, item Item, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(item.Name)
+	entry, ok := s.history[key]
+	rank := historyRank(at)
+	if ok {
+		rank = addHistoryRanks(entry.Rank, rank)
+	}
+	s.history[key] = historyEntry{
+		Suggestion: Suggestion{Name: item.Name, Quantity: item.Quantity, Uses: entry.Uses + 1, LastUsed: at.UTC()},
+		Rank:       rank,
+	}
+	return nil
+}
+
+// Suggest returns the best-ranked names starting with a prefix
+func (s *MemoryStore) Suggest(ctx context.Context, prefix string, limit int) ([]Suggestion, error) {
+	s.mu.RLock()
+	var matches []historyEntry
+	prefix = strings.ToLower(prefix)
+	for key, entry := range s.history {
+		if strings.HasPrefix(key, prefix) {
+			matches = append(matches, entry)
+		}
+	}
+	s.mu.RUnlock()
+
+	sortHistory(matches)
+	suggestions := make([]Suggestion, 0, min(limit, len(matches)))
+	for _, entry := range matches[:min(limit, len(matches))] {
+		suggestions = append(suggestions, entry.Suggestion)
+	}
+	return suggestions, nil
+}
+
+// --- In-Memory SearchStore ---
+
+// Search scores every item and history entry against the terms
+func (s *MemoryStore) Search(ctx context.Context, terms []string, limit int) ([]SearchResult, error) {
+	s.mu.RLock()
+	candidates := make([]SearchResult, 0, len(s.items)+len(s.history))
+	for _, item := range s.items {
+		id := item.ID
+		candidates = append(candidates, SearchResult{Source: SearchSourceList, ID: &id, UID: item.UID, Name: item.Name, Quantity: item.Quantity, At: item.CreatedAt})
+	}
+	for _, entry := range s.history {
+		candidates = append(candidates, SearchResult{Source: SearchSourceHistory, Name: entry.Name, Quantity: entry.Quantity, At: entry.LastUsed})
+	}
+	s.mu.RUnlock()
+	return rankSearch(terms, candidates, limit), nil
+}
+
+// --- In-Memory SyncStore ---
+
+// trackWrite gives a written item the next version and dates the fields that changed
+// from old, like the SQL stores' triggers. The caller holds s.mu.
+func (s *MemoryStore) trackWrite(item Item, old *Item) {
+	meta, ok := s.syncs[item.ID]
+	if !ok {
+		meta = itemSync{NameAt: item.CreatedAt, QuantityAt: item.CreatedAt, PriceAt: item.CreatedAt}
+	}
+	if old != nil {
+		now := time.Now().UTC()
+		if item.Name != old.Name {
+			meta.NameAt = now
+		}
+		if item.Quantity != old.Quantity {
+			meta.QuantityAt = now
+		}
+		if !samePrice(item.UnitPrice, item.Currency, old.UnitPrice, old.Currency) {
+			meta.PriceAt = now
+		}
+	}
+	s.versions++
+	meta.Version = s.versions
+	s.syncs[item.ID] = meta
+}
+
+// trackDelete leaves a tombstone for an item about to be deleted. The caller holds s.mu.
+func (s *MemoryStore) trackDelete(id int) {
+	s.versions++
+	s.tombstones = append(s.tombstones, Tombstone{ID: id, UID: s.items[id].UID, Version: s.versions, DeletedAt: time.Now().UTC()})
+	delete(s.syncs, id)
+}
+
+// Changes returns the items and tombstones with a version above since
+func (s *MemoryStore) Changes(ctx context.Context, since int64, limit int) ([]SyncItem, []Tombstone, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := []SyncItem{}
+	for id, meta := range s.syncs {
+		if meta.Version > since {
+			items = append(items, SyncItem{Item: s.items[id], Version: meta.Version})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Version < items[j].Version })
+	tombstones := []Tombstone{}
+	for _, t := range s.tombstones {
+		if t.Version > since && len(tombstones) < limit {
+			tombstones = append(tombstones, t)
+		}
+	}
+	return items[:min(limit, len(items))], tombstones, nil
+}
+
+// ApplyMutation resolves an offline change against the stored item and writes it
+func (s *MemoryStore) ApplyMutation(ctx context.Context, m Mutation) (MutationResult, error) {
+	m, err := normalizeMutation(m, FieldLimits{})
+	if err != nil {
+		return MutationResult{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current *syncRecord
+	for id, meta := range s.syncs {
+		if (m.UID != "" && s.items[id].UID == m.UID) || (m.UID == "" && id == m.ID) {
+			current = &syncRecord{SyncItem: SyncItem{Item: s.items[id], Version: meta.Version}, NameAt: meta.NameAt, QuantityAt: meta.QuantityAt, PriceAt: meta.PriceAt}
+			break
+		}
+	}
+	var deleted *Tombstone
+	for i, t := range s.tombstones {
+		if current == nil && ((m.UID != "" && t.UID == m.UID) || (m.UID == "" && t.ID == m.ID)) {
+			deleted = &s.tombstones[i]
+		}
+	}
+
+	plan := resolveMutation(current, deleted, m)
+	r := plan.Record
+	switch plan.Action {
+	case syncCreate:
+		r.ID, r.CreatedAt = s.nextID, time.Now().UTC()
+		s.nextID++
+	case syncDelete:
+		s.trackDelete(r.ID)
+		delete(s.items, r.ID)
+		loggerFrom(ctx).Info("Applied mutation", "uid", plan.Result.UID, "id", plan.Result.ID, "status", plan.Result.Status)
+		return plan.Result, nil
+	case syncUpdate:
+	default:
+		return plan.Result, nil
+	}
+	s.versions++
+	r.Version = s.versions
+	s.items[r.ID] = r.Item
+	s.syncs[r.ID] = itemSync{Version: r.Version, NameAt: r.NameAt, QuantityAt: r.QuantityAt, PriceAt: r.PriceAt}
+	plan.Result.ID, plan.Result.Item = r.ID, &r.SyncItem
+	loggerFrom(ctx).Info("Applied mutation", "uid", plan.Result.UID, "id", plan.Result.ID, "status", plan.Result.Status)
+	return plan.Result, nil
+}
+
+// Ping always succeeds; the in-memory store has no external dependency.
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Save writes the current contents to the snapshot file, if one is configured.
+// The file is written to a temporary name and renamed so a crash never leaves a partial snapshot.
+func (s *MemoryStore) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+	snap := memorySnapshot{NextID: s.nextID, Items: make([]Item, 0, len(s.items)), NextRecipeID: s.nextRecipeID, NextPantryID: s.nextPantryID, NextRecurringID: s.nextRecurringID, Budget: s.budget, NextStoreID: s.nextStoreID}
+	for _, item := range s.items {
+		snap.Items = append(snap.Items, item)
+	}
+	for _, recipe := range s.recipes {
+		snap.Recipes = append(snap.Recipes, recipe)
+	}
+	for _, item := range s.pantry {
+		snap.Pantry = append(snap.Pantry, item)
+	}
+	for _, item := range s.recurring {
+		snap.Recurring = append(snap.Recurring, item)
+	}
+	for _, price := range s.prices {
+		snap.Prices = append(snap.Prices, price)
+	}
+	for _, store := range s.stores {
+		snap.Stores = append(snap.Stores, store)
+	}
+	for storeID, mappings := range s.mappings {
+		for _, mapping := range mappings {
+			snap.Mappings = append(snap.Mappings, storeMapping{StoreID: storeID, SectionMapping: mapping})
+		}
+	}
+	for _, entry := range s.history {
+		snap.History = append(snap.History, entry)
+	}
+	snap.Versions, snap.Tombstones = s.versions, s.tombstones
+	for id, meta := range s.syncs {
+		snap.Syncs = append(snap.Syncs, memoryItemSync{ItemID: id, itemSync: meta})
+	}
+	s.mu.RUnlock()
+	sortNewestFirst(snap.Items)
+	sortByTitle(snap.Recipes)
+	sortPantry(snap.Pantry)
+	sortRecurring(snap.Recurring)
+	sortPrices(snap.Prices)
+	sortStores(snap.Stores)
+	sort.Slice(snap.Mappings, func(i, j int) bool {
+		if a, b := snap.Mappings[i].StoreID, snap.Mappings[j].StoreID; a != b {
+			return a < b
+		}
+		return strings.ToLower(snap.Mappings[i].Name) < strings.ToLower(snap.Mappings[j].Name)
+	})
+	sortHistory(snap.History)
+	sort.Slice(snap.Syncs, func(i, j int) bool { return snap.Syncs[i].ItemID < snap.Syncs[j].ItemID })
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode snapshot: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("unable to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once the rename succeeded
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("unable to replace snapshot: %w", err)
+	}
+	slog.Info("Saved items to snapshot", "count", len(snap.Items), "path", s.path)
+	return nil
+}
+
+// Close persists the snapshot (if configured). The store must not be used afterwards.
+func (s *MemoryStore) Close() error {
+	return s.Save()
+}
+
+// sortByTitle orders recipes like the SQL stores' "ORDER BY lower(title), id".
+func sortByTitle(recipes []Recipe) {
+	sort.Slice(recipes, func(i, j int) bool {
+		if a, b := strings.ToLower(recipes[i].Title), strings.ToLower(recipes[j].Title); a != b {
+			return a < b
+		}
+		return recipes[i].ID < recipes[j].ID
+	})
+}
+
+// sortNewestFirst orders items like the SQL stores' "ORDER BY created_at DESC".
+// Ties are broken by ID so the order is stable for items created in the same instant.
+func sortNewestFirst(items []Item) {
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.After(items[j].CreatedAt)
+		}
+		return items[i].ID > items[j].ID
+	})
+}
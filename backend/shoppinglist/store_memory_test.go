@@ -0,0 +1,303 @@
+package shoppinglist
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreConformance(t *testing.T) {
+	testItemStoreConformance(t, func(t *testing.T) ItemStore {
+		return NewMemoryStore()
+	})
+	testRecipeStoreConformance(t, func(t *testing.T) RecipeStore {
+		return NewMemoryStore()
+	})
+	testPantryStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		PantryStore
+	} {
+		return NewMemoryStore()
+	})
+	testRecurringStoreConformance(t, func(t *testing.T) RecurringStore {
+		return NewMemoryStore()
+	})
+	testPriceStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		PriceStore
+	} {
+		return NewMemoryStore()
+	})
+	testLayoutStoreConformance(t, func(t *testing.T) LayoutStore {
+		return NewMemoryStore()
+	})
+	testHistoryStoreConformance(t, func(t *testing.T) HistoryStore {
+		return NewMemoryStore()
+	})
+	testSearchStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		HistoryStore
+		SearchStore
+	} {
+		return NewMemoryStore()
+	})
+	testSyncStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		SyncStore
+	} {
+		return NewMemoryStore()
+	})
+	testUIDStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		UIDStore
+		SyncStore
+	} {
+		return NewMemoryStore()
+	})
+}
+
+func TestMemoryStoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "items.json")
+
+	t.Run("MissingFileStartsEmpty", func(t *testing.T) {
+		store, err := LoadMemoryStore(path)
+		if err != nil {
+			t.Fatalf("LoadMemoryStore failed: %v", err)
+		}
+		items, _ := store.List(ctx)
+		if len(items) != 0 {
+			t.Errorf("Expected empty store, got %d items", len(items))
+		}
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		store, err := LoadMemoryStore(path)
+		if err != nil {
+			t.Fatalf("LoadMemoryStore failed: %v", err)
+		}
+		milk, _ := store.Create(ctx, Item{Name: "Milk", Quantity: "1"})
+		bread, _ := store.Create(ctx, Item{Name: "Bread", Quantity: "2"})
+		if err := store.Delete(ctx, bread.ID); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if err := store.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		reloaded, err := LoadMemoryStore(path)
+		if err != nil {
+			t.Fatalf("LoadMemoryStore failed: %v", err)
+		}
+		got, err := reloaded.Get(ctx, milk.ID)
+		if err != nil {
+			t.Fatalf("Get after reload failed: %v", err)
+		}
+		if got.Name != "Milk" || !got.CreatedAt.Equal(milk.CreatedAt) {
+			t.Errorf("Unexpected reloaded item: %+v", got)
+		}
+		// IDs of deleted items must not be reused after a restart
+		eggs, _ := reloaded.Create(ctx, Item{Name: "Eggs", Quantity: "12"})
+		if eggs.ID <= bread.ID {
+			t.Errorf("Expected new ID greater than %d, got %d", bread.ID, eggs.ID)
+		}
+	})
+
+	t.Run("CorruptFile", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "bad.json")
+		if err := os.WriteFile(bad, []byte("{not json"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadMemoryStore(bad); err == nil {
+			t.Error("Expected an error for a corrupt snapshot, got nil")
+		}
+	})
+
+	t.Run("NoPathIsNotPersisted", func(t *testing.T) {
+		store := NewMemoryStore()
+		if _, err := store.Create(ctx, Item{Name: "Tea", Quantity: "1"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Close(); err != nil {
+			t.Errorf("Close without snapshot path should not fail, got %v", err)
+		}
+	})
+}
+
+func TestMemoryStoreConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			item, err := store.Create(ctx, Item{Name: "Item", Quantity: "1"})
+			if err != nil {
+				t.Errorf("Create failed: %v", err)
+				return
+			}
+			if _, err := store.List(ctx); err != nil {
+				t.Errorf("List failed: %v", err)
+			}
+			if err := store.Delete(ctx, item.ID); err != nil && !errors.Is(err, ErrNotFound) {
+				t.Errorf("Delete failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	items, _ := store.List(ctx)
+	if len(items) != 0 {
+		t.Errorf("Expected all items deleted, got %d", len(items))
+	}
+}
+
+func TestMemoryStoreSnapshotKeepsRecipesAndPantry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	store, err := LoadMemoryStore(path)
+	if err != nil {
+		t.Fatalf("LoadMemoryStore failed: %v", err)
+	}
+	created, err := store.CreateRecipe(t.Context(), Recipe{Title: "Toast", Servings: 1, Ingredients: []Ingredient{{Name: "Bread", Quantity: "2 slices"}}})
+	if err != nil {
+		t.Fatalf("CreateRecipe failed: %v", err)
+	}
+	if _, err := store.CreatePantryItem(t.Context(), PantryItem{Name: "Butter", Stock: 0.25, Unit: "kg", BestBefore: "2027-02-01"}); err != nil {
+		t.Fatalf("CreatePantryItem failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := LoadMemoryStore(path)
+	if err != nil {
+		t.Fatalf("LoadMemoryStore failed: %v", err)
+	}
+	got, err := reopened.GetRecipe(t.Context(), created.ID)
+	if err != nil || got.Title != "Toast" || len(got.Ingredients) != 1 {
+		t.Fatalf("Expected the recipe to survive a restart, got %+v (err %v)", got, err)
+	}
+	next, err := reopened.CreateRecipe(t.Context(), Recipe{Title: "Jam", Servings: 1, Ingredients: []Ingredient{{Name: "Fruit", Quantity: "1 kg"}}})
+	if err != nil || next.ID != created.ID+1 {
+		t.Errorf("Expected IDs to continue after a restart, got %d (err %v)", next.ID, err)
+	}
+	if err := reopened.DeleteRecipe(t.Context(), 99); !errors.Is(err, ErrRecipeNotFound) {
+		t.Errorf("Expected ErrRecipeNotFound, got %v", err)
+	}
+	pantry, err := reopened.ListPantry(t.Context())
+	if err != nil || len(pantry) != 1 || pantry[0].Stock != 0.25 || pantry[0].BestBefore != "2027-02-01" {
+		t.Errorf("Expected the pantry to survive a restart, got %+v (err %v)", pantry, err)
+	}
+	if next, err := reopened.CreatePantryItem(t.Context(), PantryItem{Name: "Salt"}); err != nil || next.ID != pantry[0].ID+1 {
+		t.Errorf("Expected pantry IDs to continue after a restart, got %d (err %v)", next.ID, err)
+	}
+}
+
+func TestMemoryStoreSnapshotKeepsPrices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	store, err := LoadMemoryStore(path)
+	if err != nil {
+		t.Fatalf("LoadMemoryStore failed: %v", err)
+	}
+	if _, err := store.RecordPrice(t.Context(), Price{Name: "Milk", UnitPrice: 129, Currency: "EUR"}); err != nil {
+		t.Fatalf("RecordPrice failed: %v", err)
+	}
+	if _, err := store.SetBudget(t.Context(), Money{Amount: 5000, Currency: "EUR"}); err != nil {
+		t.Fatalf("SetBudget failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := LoadMemoryStore(path)
+	if err != nil {
+		t.Fatalf("LoadMemoryStore failed: %v", err)
+	}
+	if price, err := reopened.LastPrice(t.Context(), "milk"); err != nil || price.UnitPrice != 129 {
+		t.Errorf("Expected the price to survive a restart, got %+v (err %v)", price, err)
+	}
+	if budget, err := reopened.GetBudget(t.Context()); err != nil || budget.Amount != 5000 {
+		t.Errorf("Expected the budget to survive a restart, got %+v (err %v)", budget, err)
+	}
+}
+
+func TestMemoryStoreSnapshotKeepsStores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	store, err := LoadMemoryStore(path)
+	if err != nil {
+		t.Fatalf("LoadMemoryStore failed: %v", err)
+	}
+	shop, err := store.CreateStore(t.Context(), StoreLayout{Name: "Shop", Sections: []string{"Produce", "Dairy"}})
+	if err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+	if _, err := store.SetMapping(t.Context(), shop.ID, SectionMapping{Name: "Milk", Section: "Dairy", Learned: true}); err != nil {
+		t.Fatalf("SetMapping failed: %v", err)
+	}
+	if err := store.RecordCheckOff(t.Context(), shop.ID, CheckOff{Name: "Milk", Section: "Dairy", At: time.Now()}); err != nil {
+		t.Fatalf("RecordCheckOff failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := LoadMemoryStore(path)
+	if err != nil {
+		t.Fatalf("LoadMemoryStore failed: %v", err)
+	}
+	if got, err := reopened.GetStore(t.Context(), shop.ID); err != nil || len(got.Sections) != 2 || got.LastCheckOff == nil {
+		t.Errorf("Expected the store and its last check-off to survive a restart, got %+v (err %v)", got, err)
+	}
+	if m, err := reopened.GetMapping(t.Context(), shop.ID, "milk"); err != nil || m.Section != "Dairy" || !m.Learned {
+		t.Errorf("Expected the mapping to survive a restart, got %+v (err %v)", m, err)
+	}
+	if next, err := reopened.CreateStore(t.Context(), StoreLayout{Name: "Other", Sections: []string{"A"}}); err != nil || next.ID == shop.ID {
+		t.Errorf("Expected a fresh store ID after a restart, got %+v (err %v)", next, err)
+	}
+}
+
+func TestMemoryStoreSnapshotKeepsSyncState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	store, err := LoadMemoryStore(path)
+	if err != nil {
+		t.Fatalf("LoadMemoryStore failed: %v", err)
+	}
+	name, quantity := "Milk", "1 l"
+	at := time.Now().UTC().Add(-time.Hour)
+	created, err := store.ApplyMutation(t.Context(), Mutation{Op: MutationUpsert, UID: "0192a8e4-7c1b-7def-8a12-3456789abcde", At: at, Name: &name, Quantity: &quantity})
+	if err != nil {
+		t.Fatalf("ApplyMutation failed: %v", err)
+	}
+	bread, _ := store.Create(t.Context(), Item{Name: "Bread", Quantity: "1"})
+	if err := store.Delete(t.Context(), bread.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := LoadMemoryStore(path)
+	if err != nil {
+		t.Fatalf("LoadMemoryStore failed: %v", err)
+	}
+	items, tombstones, err := reopened.Changes(t.Context(), 0, 10)
+	if err != nil || len(items) != 1 || items[0].UID != created.UID || items[0].Version != created.Item.Version || len(tombstones) != 1 || tombstones[0].ID != bread.ID {
+		t.Fatalf("Expected the feed to survive a restart, got %+v %+v (err %v)", items, tombstones, err)
+	}
+	// Field clocks survive too, so an older offline edit still loses
+	older := "2 l"
+	result, err := reopened.ApplyMutation(t.Context(), Mutation{Op: MutationUpsert, UID: created.UID, At: at.Add(-time.Minute), Quantity: &older})
+	if err != nil || len(result.Conflicts) != 1 {
+		t.Errorf("Expected a conflict after a restart, got %+v (err %v)", result, err)
+	}
+	next, _ := reopened.Create(t.Context(), Item{Name: "Eggs", Quantity: "6"})
+	if items, _, _ := reopened.Changes(t.Context(), tombstones[0].Version, 10); len(items) != 1 || items[0].ID != next.ID {
+		t.Errorf("Expected versions to continue after a restart, got %+v", items)
+	}
+}
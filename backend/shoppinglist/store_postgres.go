@@ -0,0 +1,1607 @@
+package shoppinglist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"        // Needed for DBPool interface method signatures
+	"github.com/jackc/pgx/v5/pgconn" // Needed for DBPool interface method signatures
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+)
+
+// --- Configuration ---
+
+// DBConfig holds database connection parameters
+type DBConfig struct {
+	// URL is a postgres:// connection URL. When set it is used instead of the
+	// individual Host, Port, User, Password, DBName and SSLMode fields.
+	URL string
+
+	Host     string
+	Port     int
+	User     string
+	Password string
+	DBName   string
+	SSLMode  string // disable, allow, prefer, require, verify-ca or verify-full
+
+	// TLS files, as in libpq. They also apply on top of URL. SSLRootCert is the CA bundle
+	// the server certificate must chain to ("system" for the OS roots); SSLCert and SSLKey
+	// are a client certificate, and SSLPassword decrypts an encrypted SSLKey.
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+	SSLPassword string
+
+	// Pool settings; zero values use the defaults below
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+}
+
+// Pool defaults applied for zero DBConfig fields
+const (
+	DefaultMaxConns          = 10
+	DefaultMaxConnLifetime   = 1 * time.Hour
+	DefaultMaxConnIdleTime   = 5 * time.Minute
+	DefaultHealthCheckPeriod = 1 * time.Minute
+)
+
+// --- Interface for DB Operations ---
+
+// DBPool defines the interface for database operations we need,
+// allowing both real pgxpool.Pool and mocks to be used.
+type DBPool interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error) // Used by schema migrations
+	Ping(ctx context.Context) error
+	Close() // Required for graceful shutdown and test cleanup
+}
+
+// --- Database Functions ---
+
+// poolConfig turns cfg into a pgxpool configuration, applying pool defaults.
+func poolConfig(cfg DBConfig) (*pgxpool.Config, error) {
+	tlsParams := []struct{ key, value string }{
+		{"sslrootcert", cfg.SSLRootCert},
+		{"sslcert", cfg.SSLCert},
+		{"sslkey", cfg.SSLKey},
+		{"sslpassword", cfg.SSLPassword},
+	}
+	connString := cfg.URL
+	if connString == "" {
+		connString = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			quoteConnValue(cfg.Host), cfg.Port, quoteConnValue(cfg.User), quoteConnValue(cfg.Password),
+			quoteConnValue(cfg.DBName), quoteConnValue(cfg.SSLMode))
+		for _, p := range tlsParams {
+			if p.value != "" {
+				connString += " " + p.key + "=" + quoteConnValue(p.value)
+			}
+		}
+	} else if cfg.SSLRootCert+cfg.SSLCert+cfg.SSLKey+cfg.SSLPassword != "" {
+		u, err := url.Parse(connString)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse connection string config: %w", err)
+		}
+		q := u.Query()
+		for _, p := range tlsParams {
+			if p.value != "" {
+				q.Set(p.key, p.value)
+			}
+		}
+		u.RawQuery = q.Encode()
+		connString = u.String()
+	}
+
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse connection string config: %w", err)
+	}
+
+	// Recommended settings for robustness
+	config.MaxConns = valueOr(cfg.MaxConns, DefaultMaxConns)
+	config.MinConns = cfg.MinConns
+	config.MaxConnLifetime = valueOr(cfg.MaxConnLifetime, DefaultMaxConnLifetime)
+	config.MaxConnIdleTime = valueOr(cfg.MaxConnIdleTime, DefaultMaxConnIdleTime)
+	config.HealthCheckPeriod = valueOr(cfg.HealthCheckPeriod, DefaultHealthCheckPeriod)
+
+	// Trace every query as a child of the current span (no-op unless a tracer provider is installed)
+	config.ConnConfig.Tracer = NewQueryTracer(otel.GetTracerProvider())
+	return config, nil
+}
+
+// quoteConnValue quotes a keyword/value connection string value, so passwords with spaces
+// or quotes (common in generated secrets) parse correctly.
+func quoteConnValue(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// valueOr returns v, or fallback if v is zero.
+func valueOr[T comparable](v, fallback T) T {
+	var zero T
+	if v == zero {
+		return fallback
+	}
+	return v
+}
+
+// ConnectDB initializes the database connection pool and pings it once; ctx bounds the ping.
+// It still returns the concrete type *pgxpool.Pool, which implements DBPool.
+// Returned errors never contain the password, so they are safe to log.
+func ConnectDB(ctx context.Context, cfg DBConfig) (*pgxpool.Pool, error) {
+	pool, err := connectDB(ctx, cfg)
+	if err != nil {
+		return nil, redactSecrets(err, cfg.Password, connStringPassword(cfg.URL), cfg.SSLPassword)
+	}
+	return pool, nil
+}
+
+func connectDB(ctx context.Context, cfg DBConfig) (*pgxpool.Pool, error) {
+	config, err := poolConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create connection pool: %w", err)
+	}
+
+	// Test the connection
+	err = pool.Ping(ctx)
+	if err != nil {
+		pool.Close() // Close pool if ping fails
+		return nil, fmt.Errorf("unable to ping database: %w", err)
+	}
+
+	loggerFrom(ctx).Info("Successfully connected to PostgreSQL database", "host", config.ConnConfig.Host, "database", config.ConnConfig.Database)
+	return pool, nil
+}
+
+// --- Secret Redaction ---
+
+// redactedError hides secrets in the message of the error it wraps. Unwrap still returns
+// the original, so errors.Is and errors.As keep working.
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactSecrets replaces every non-empty secret in err's message with REDACTED.
+func redactSecrets(err error, secrets ...string) error {
+	msg := err.Error()
+	redacted := msg
+	for _, secret := range secrets {
+		if secret != "" {
+			redacted = strings.ReplaceAll(redacted, secret, "REDACTED")
+		}
+	}
+	if redacted == msg {
+		return err
+	}
+	return &redactedError{err: err, msg: redacted}
+}
+
+// connStringPassword returns the password in a connection string, in any form pgx accepts:
+// a URL's userinfo or password query parameter, or a keyword/value password. Strings that
+// do not parse (which are exactly the ones whose errors might echo them) are searched as
+// text, and then the password is returned as written.
+func connStringPassword(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	if config, err := pgconn.ParseConfig(raw); err == nil {
+		return config.Password
+	}
+	_, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		if m := connPasswordKeyword.FindStringSubmatch(raw); m != nil {
+			return m[1] + m[2]
+		}
+		return ""
+	}
+	rest, query, _ := strings.Cut(rest, "?")
+	if q, err := url.ParseQuery(query); err == nil && q.Has("password") {
+		return q.Get("password") // Overrides the userinfo, as in pgx
+	}
+	at := strings.LastIndex(rest, "@")
+	if at < 0 {
+		return ""
+	}
+	_, password, _ := strings.Cut(rest[:at], ":")
+	return password
+}
+
+// connPasswordKeyword matches the password in a keyword/value connection string. The
+// first group is a quoted value, the second an unquoted one.
+var connPasswordKeyword = regexp.MustCompile(`(?:^|\s)password\s*=\s*(?:'((?:[^'\\]|\\.)*)'|(\S+))`)
+
+// CreateSchemaIfNotExists brings the schema up to date by applying any pending migrations
+// Accepts the DBPool interface type
+func CreateSchemaIfNotExists(ctx context.Context, pool DBPool) error {
+	if err := migratePostgres(ctx, pool); err != nil {
+		return fmt.Errorf("error creating table schema: %w", err)
+	}
+	loggerFrom(ctx).Info("Database schema is up to date", "version", latestSchemaVersion())
+	return nil
+}
+
+// --- Postgres ItemStore ---
+
+// PostgresStore is the ItemStore implementation backed by PostgreSQL.
+// It works with any DBPool, so tests can inject a pgxmock pool.
+type PostgresStore struct {
+	pool DBPool
+}
+
+// NewPostgresStore returns an ItemStore that runs its queries on the given pool.
+func NewPostgresStore(pool DBPool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+// postgresItemColumns are the items columns read by scanPostgresItem.
+const postgresItemColumns = "id, uid::text, name, quantity, unit_price, currency, created_at"
+
+// scanPostgresItem reads one item row; an unpriced item has a NULL currency.
+func scanPostgresItem(row pgx.Row) (Item, error) {
+	var item Item
+	var currency *string
+	if err := row.Scan(&item.ID, &item.UID, &item.Name, &item.Quantity, &item.UnitPrice, &currency, &item.CreatedAt); err != nil {
+		return Item{}, err
+	}
+	if currency != nil {
+		item.Currency = *currency
+	}
+	return item, nil
+}
+
+// postgresCurrency stores the currency of an unpriced item as NULL.
+func postgresCurrency(code string) *string {
+	if code == "" {
+		return nil
+	}
+	return &code
+}
+
+// List retrieves all items from the database, newest first
+func (s *PostgresStore) List(ctx context.Context) ([]Item, error) {
+	rows, err := s.pool.Query(ctx, "SELECT "+postgresItemColumns+" FROM items ORDER BY created_at DESC")
+	if err != nil {
+		// Check specifically for pgx's no rows error if necessary, otherwise treat as general DB error
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []Item{}, nil // Return empty slice for no rows, not an error
+		}
+		loggerFrom(ctx).Error("Error querying items", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	items := []Item{}
+	// Use pgx's CollectRows or Next/Scan loop
+	for rows.Next() {
+		item, err := scanPostgresItem(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning item row", "err", err)
+			// Continue processing other rows if one fails to scan
+			continue
+		}
+		items = append(items, item)
+	}
+
+	// Check for errors from iterating over rows.
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating rows", "err", err)
+		// It's often better to return the items successfully scanned along with the iteration error
+		// But for simplicity here, we return an error indicating partial results might be lost.
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+
+	return items, nil
+}
+
+// Get retrieves a single item by ID
+func (s *PostgresStore) Get(ctx context.Context, id int) (Item, error) {
+	item, err := scanPostgresItem(s.pool.QueryRow(ctx,
+		"SELECT "+postgresItemColumns+" FROM items WHERE id = $1", id,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Item{}, fmt.Errorf("item with ID %d: %w", id, ErrNotFound)
+		}
+		loggerFrom(ctx).Error("Error querying item", "id", id, "err", err)
+		return Item{}, fmt.Errorf("database query error: %w", err)
+	}
+	return item, nil
+}
+
+// GetByUID retrieves a single item by UID
+func (s *PostgresStore) GetByUID(ctx context.Context, uid string) (Item, error) {
+	item, err := scanPostgresItem(s.pool.QueryRow(ctx,
+		"SELECT "+postgresItemColumns+" FROM items WHERE uid = $1::uuid", uid,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Item{}, fmt.Errorf("item with UID %s: %w", uid, ErrNotFound)
+		}
+		loggerFrom(ctx).Error("Error querying item", "uid", uid, "err", err)
+		return Item{}, fmt.Errorf("database query error: %w", err)
+	}
+	return item, nil
+}
+
+// Create inserts a new item into the database
+// Uses parameterized queries to prevent SQL injection.
+func (s *PostgresStore) Create(ctx context.Context, newItem Item) (Item, error) {
+	newItem, err := normalizeItem(newItem, FieldLimits{})
+	if err != nil {
+		return Item{}, err
+	}
+
+	// The uid column defaults to a UUIDv7 made by items_uid(), like id and created_at
+	var insertedID int
+	var uid string
+	var createdAt time.Time
+	err = s.pool.QueryRow(ctx,
+		"INSERT INTO items (name, quantity, unit_price, currency) VALUES ($1, $2, $3, $4) RETURNING id, uid::text, created_at",
+		newItem.Name, newItem.Quantity, newItem.UnitPrice, postgresCurrency(newItem.Currency), // Parameters are handled safely by pgx
+	).Scan(&insertedID, &uid, &createdAt)
+
+	if err != nil {
+		loggerFrom(ctx).Error("Error inserting item", "err", err)
+		return Item{}, fmt.Errorf("database insert error: %w", err)
+	}
+
+	newItem.ID = insertedID
+	newItem.UID = uid
+	newItem.CreatedAt = createdAt
+	loggerFrom(ctx).Info("Added item", "id", newItem.ID, "name", newItem.Name, "quantity", newItem.Quantity)
+	return newItem, nil
+}
+
+// Update changes the name, quantity and price of an existing item
+func (s *PostgresStore) Update(ctx context.Context, item Item) (Item, error) {
+	item, err := normalizeItem(item, FieldLimits{})
+	if err != nil {
+		return Item{}, err
+	}
+
+	err = s.pool.QueryRow(ctx,
+		"UPDATE items SET name = $1, quantity = $2, unit_price = $3, currency = $4 WHERE id = $5 RETURNING uid::text, created_at",
+		item.Name, item.Quantity, item.UnitPrice, postgresCurrency(item.Currency), item.ID,
+	).Scan(&item.UID, &item.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Item{}, fmt.Errorf("item with ID %d: %w", item.ID, ErrNotFound)
+		}
+		loggerFrom(ctx).Error("Error updating item", "id", item.ID, "err", err)
+		return Item{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Updated item", "id", item.ID, "name", item.Name, "quantity", item.Quantity)
+	return item, nil
+}
+
+// Delete removes an item from the database by ID
+func (s *PostgresStore) Delete(ctx context.Context, id int) error {
+	cmdTag, err := s.pool.Exec(ctx, "DELETE FROM items WHERE id = $1", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting item", "id", id, "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		loggerFrom(ctx).Info("Attempted to delete non-existent item", "id", id)
+		return fmt.Errorf("item with ID %d: %w", id, ErrNotFound)
+	}
+	loggerFrom(ctx).Info("Deleted item", "id", id)
+	return nil
+}
+
+// --- PostgreSQL RecipeStore ---
+
+// postgresRecipeQuery selects recipes with their ingredients, one row per ingredient.
+const postgresRecipeQuery = `
+	SELECT r.id, r.title, r.servings, r.created_at, i.name, i.quantity
+	FROM recipes r LEFT JOIN recipe_ingredients i ON i.recipe_id = r.id`
+
+// queryRecipes runs a recipe query and assembles its rows.
+func (s *PostgresStore) queryRecipes(ctx context.Context, query string, args ...any) ([]Recipe, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipes := []Recipe{}
+	for rows.Next() {
+		var r Recipe
+		var name, quantity *string
+		if err := rows.Scan(&r.ID, &r.Title, &r.Servings, &r.CreatedAt, &name, &quantity); err != nil {
+			return nil, err
+		}
+		recipes = appendRecipeRow(recipes, r, name, quantity)
+	}
+	return recipes, rows.Err()
+}
+
+// ListRecipes retrieves all recipes, ordered by title
+func (s *PostgresStore) ListRecipes(ctx context.Context) ([]Recipe, error) {
+	recipes, err := s.queryRecipes(ctx, postgresRecipeQuery+" ORDER BY lower(r.title), r.id, i.position")
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying recipes", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	return recipes, nil
+}
+
+// GetRecipe retrieves a single recipe by ID
+func (s *PostgresStore) GetRecipe(ctx context.Context, id int) (Recipe, error) {
+	recipes, err := s.queryRecipes(ctx, postgresRecipeQuery+" WHERE r.id = $1 ORDER BY i.position", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying recipe", "id", id, "err", err)
+		return Recipe{}, fmt.Errorf("database query error: %w", err)
+	}
+	if len(recipes) == 0 {
+		return Recipe{}, fmt.Errorf("recipe with ID %d: %w", id, ErrRecipeNotFound)
+	}
+	return recipes[0], nil
+}
+
+// CreateRecipe inserts a new recipe and its ingredients in one transaction
+func (s *PostgresStore) CreateRecipe(ctx context.Context, recipe Recipe) (Recipe, error) {
+	recipe, err := normalizeRecipe(recipe, FieldLimits{})
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	err = s.writeTx(ctx, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx,
+			"INSERT INTO recipes (title, servings) VALUES ($1, $2) RETURNING id, created_at",
+			recipe.Title, recipe.Servings,
+		).Scan(&recipe.ID, &recipe.CreatedAt); err != nil {
+			return err
+		}
+		return insertPostgresIngredients(ctx, tx, recipe)
+	})
+	if err != nil {
+		loggerFrom(ctx).Error("Error inserting recipe", "err", err)
+		return Recipe{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Added recipe", "id", recipe.ID, "title", recipe.Title)
+	return recipe, nil
+}
+
+// UpdateRecipe replaces a recipe and its ingredients in one transaction
+func (s *PostgresStore) UpdateRecipe(ctx context.Context, recipe Recipe) (Recipe, error) {
+	recipe, err := normalizeRecipe(recipe, FieldLimits{})
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	err = s.writeTx(ctx, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx,
+			"UPDATE recipes SET title = $1, servings = $2 WHERE id = $3 RETURNING created_at",
+			recipe.Title, recipe.Servings, recipe.ID,
+		).Scan(&recipe.CreatedAt); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, "DELETE FROM recipe_ingredients WHERE recipe_id = $1", recipe.ID); err != nil {
+			return err
+		}
+		return insertPostgresIngredients(ctx, tx, recipe)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Recipe{}, fmt.Errorf("recipe with ID %d: %w", recipe.ID, ErrRecipeNotFound)
+		}
+		loggerFrom(ctx).Error("Error updating recipe", "id", recipe.ID, "err", err)
+		return Recipe{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Updated recipe", "id", recipe.ID, "title", recipe.Title)
+	return recipe, nil
+}
+
+// DeleteRecipe removes a recipe by ID; its ingredients go with it (ON DELETE CASCADE)
+func (s *PostgresStore) DeleteRecipe(ctx context.Context, id int) error {
+	cmdTag, err := s.pool.Exec(ctx, "DELETE FROM recipes WHERE id = $1", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting recipe", "id", id, "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("recipe with ID %d: %w", id, ErrRecipeNotFound)
+	}
+	loggerFrom(ctx).Info("Deleted recipe", "id", id)
+	return nil
+}
+
+// writeTx runs fn in a transaction, committing if it succeeds.
+func (s *PostgresStore) writeTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) // No-op after a successful Commit
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// insertPostgresIngredients stores the ingredients of recipe in order, in one statement.
+func insertPostgresIngredients(ctx context.Context, tx pgx.Tx, recipe Recipe) error {
+	names := make([]string, len(recipe.Ingredients))
+	quantities := make([]string, len(recipe.Ingredients))
+	for i, ing := range recipe.Ingredients {
+		names[i], quantities[i] = ing.Name, ing.Quantity
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO recipe_ingredients (recipe_id, position, name, quantity)
+		SELECT $1, t.position, t.name, t.quantity
+		FROM unnest($2::text[], $3::text[]) WITH ORDINALITY AS t(name, quantity, position)`,
+		recipe.ID, names, quantities)
+	return err
+}
+
+// --- PostgreSQL PantryStore ---
+
+// postgresPantryColumns are the pantry_items columns read by scanPostgresPantryItem.
+// The NUMERIC columns are cast so they scan into float64.
+const postgresPantryColumns = "id, name, stock::float8, unit, threshold::float8, best_before, created_at"
+
+// scanPostgresPantryItem reads one pantry item row, formatting the DATE column.
+func scanPostgresPantryItem(row pgx.Row) (PantryItem, error) {
+	var item PantryItem
+	var bestBefore *time.Time
+	if err := row.Scan(&item.ID, &item.Name, &item.Stock, &item.Unit, &item.Threshold, &bestBefore, &item.CreatedAt); err != nil {
+		return PantryItem{}, err
+	}
+	if bestBefore != nil {
+		item.BestBefore = bestBefore.Format(time.DateOnly)
+	}
+	return item, nil
+}
+
+// postgresDate converts a best-before date for a DATE parameter; empty is NULL.
+// The date has been validated by normalizePantryItem.
+func postgresDate(date string) *time.Time {
+	if date == "" {
+		return nil
+	}
+	t, _ := time.Parse(time.DateOnly, date)
+	return &t
+}
+
+// isPostgresUniqueViolation reports whether err is a unique_violation.
+func isPostgresUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// ListPantry retrieves all pantry items, ordered by name
+func (s *PostgresStore) ListPantry(ctx context.Context) ([]PantryItem, error) {
+	rows, err := s.pool.Query(ctx, "SELECT "+postgresPantryColumns+" FROM pantry_items ORDER BY lower(name)")
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying pantry", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	items := []PantryItem{}
+	for rows.Next() {
+		item, err := scanPostgresPantryItem(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning pantry row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating pantry rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return items, nil
+}
+
+// GetPantryItem retrieves a single pantry item by ID
+func (s *PostgresStore) GetPantryItem(ctx context.Context, id int) (PantryItem, error) {
+	item, err := scanPostgresPantryItem(s.pool.QueryRow(ctx, "SELECT "+postgresPantryColumns+" FROM pantry_items WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return PantryItem{}, fmt.Errorf("pantry item with ID %d: %w", id, ErrPantryItemNotFound)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying pantry item", "id", id, "err", err)
+		return PantryItem{}, fmt.Errorf("database query error: %w", err)
+	}
+	return item, nil
+}
+
+// CreatePantryItem inserts a new pantry item unless its name is taken
+func (s *PostgresStore) CreatePantryItem(ctx context.Context, item PantryItem) (PantryItem, error) {
+	item, err := normalizePantryItem(item, FieldLimits{})
+	if err != nil {
+		return PantryItem{}, err
+	}
+
+	created, err := scanPostgresPantryItem(s.pool.QueryRow(ctx, `
+		INSERT INTO pantry_items (name, stock, unit, threshold, best_before) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING
+		RETURNING `+postgresPantryColumns,
+		item.Name, item.Stock, item.Unit, item.Threshold, postgresDate(item.BestBefore)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return PantryItem{}, fmt.Errorf("pantry item %q: %w", item.Name, ErrPantryItemExists)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error inserting pantry item", "err", err)
+		return PantryItem{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Added pantry item", "id", created.ID, "name", created.Name, "stock", created.Stock)
+	return created, nil
+}
+
+// UpdatePantryItem replaces every field of an existing pantry item
+func (s *PostgresStore) UpdatePantryItem(ctx context.Context, item PantryItem) (PantryItem, error) {
+	item, err := normalizePantryItem(item, FieldLimits{})
+	if err != nil {
+		return PantryItem{}, err
+	}
+
+	updated, err := scanPostgresPantryItem(s.pool.QueryRow(ctx, `
+		UPDATE pantry_items SET name = $1, stock = $2, unit = $3, threshold = $4, best_before = $5
+		WHERE id = $6
+		RETURNING `+postgresPantryColumns,
+		item.Name, item.Stock, item.Unit, item.Threshold, postgresDate(item.BestBefore), item.ID))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return PantryItem{}, fmt.Errorf("pantry item with ID %d: %w", item.ID, ErrPantryItemNotFound)
+	case isPostgresUniqueViolation(err):
+		return PantryItem{}, fmt.Errorf("pantry item %q: %w", item.Name, ErrPantryItemExists)
+	case err != nil:
+		loggerFrom(ctx).Error("Error updating pantry item", "id", item.ID, "err", err)
+		return PantryItem{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Updated pantry item", "id", updated.ID, "name", updated.Name, "stock", updated.Stock)
+	return updated, nil
+}
+
+// DeletePantryItem removes a pantry item by ID
+func (s *PostgresStore) DeletePantryItem(ctx context.Context, id int) error {
+	cmdTag, err := s.pool.Exec(ctx, "DELETE FROM pantry_items WHERE id = $1", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting pantry item", "id", id, "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("pantry item with ID %d: %w", id, ErrPantryItemNotFound)
+	}
+	loggerFrom(ctx).Info("Deleted pantry item", "id", id)
+	return nil
+}
+
+// ConsumeStock takes amount off the stock of a pantry item in one statement, stopping at zero
+func (s *PostgresStore) ConsumeStock(ctx context.Context, id int, amount float64) (PantryItem, error) {
+	item, err := scanPostgresPantryItem(s.pool.QueryRow(ctx, `
+		UPDATE pantry_items SET stock = GREATEST(stock - $1, 0)
+		WHERE id = $2
+		RETURNING `+postgresPantryColumns,
+		amount, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return PantryItem{}, fmt.Errorf("pantry item with ID %d: %w", id, ErrPantryItemNotFound)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error consuming stock", "id", id, "err", err)
+		return PantryItem{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Consumed stock", "id", id, "amount", amount, "stock", item.Stock)
+	return item, nil
+}
+
+// MovePurchased deletes a shopping list item and adds its stock to the pantry in one transaction
+func (s *PostgresStore) MovePurchased(ctx context.Context, itemID int, stock PantryItem) (PantryItem, error) {
+	stock, err := normalizePantryItem(stock, FieldLimits{})
+	if err != nil {
+		return PantryItem{}, err
+	}
+
+	var item PantryItem
+	err = s.writeTx(ctx, func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx, "DELETE FROM items WHERE id = $1", itemID)
+		if err != nil {
+			return err
+		}
+		if cmdTag.RowsAffected() == 0 {
+			return fmt.Errorf("item with ID %d: %w", itemID, ErrNotFound)
+		}
+		var unit string
+		err = tx.QueryRow(ctx, "SELECT unit FROM pantry_items WHERE lower(name) = lower($1) FOR UPDATE", stock.Name).Scan(&unit)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		amount, ok := purchasedStock(stock, unit)
+		if !ok {
+			loggerFrom(ctx).Warn("Purchased stock is in a different unit; pantry stock left unchanged",
+				"item_id", itemID, "unit", stock.Unit, "pantry_unit", unit)
+		}
+		item, err = scanPostgresPantryItem(tx.QueryRow(ctx, `
+			INSERT INTO pantry_items (name, stock, unit, best_before) VALUES ($1, $2, $3, $4)
+			ON CONFLICT ((lower(name))) DO UPDATE SET
+				stock = pantry_items.stock + EXCLUDED.stock,
+				unit = CASE WHEN pantry_items.unit = '' THEN EXCLUDED.unit ELSE pantry_items.unit END,
+				best_before = COALESCE(EXCLUDED.best_before, pantry_items.best_before)
+			RETURNING `+postgresPantryColumns,
+			stock.Name, amount, stock.Unit, postgresDate(stock.BestBefore)))
+		return err
+	})
+	if errors.Is(err, ErrNotFound) {
+		return PantryItem{}, err
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error moving purchased item", "item_id", itemID, "err", err)
+		return PantryItem{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Moved purchased item to the pantry", "item_id", itemID, "pantry_id", item.ID, "stock", item.Stock)
+	return item, nil
+}
+
+// --- PostgreSQL RecurringStore ---
+
+// postgresRecurringColumns are the recurring_items columns read by scanPostgresRecurringItem.
+const postgresRecurringColumns = "id, name, quantity, schedule, next_run_at, last_run_at, created_at"
+
+// scanPostgresRecurringItem reads one recurring item row.
+func scanPostgresRecurringItem(row pgx.Row) (RecurringItem, error) {
+	var item RecurringItem
+	if err := row.Scan(&item.ID, &item.Name, &item.Quantity, &item.Schedule, &item.NextRun, &item.LastRun, &item.CreatedAt); err != nil {
+		return RecurringItem{}, err
+	}
+	return item, nil
+}
+
+// queryRecurring runs a query returning postgresRecurringColumns rows.
+func (s *PostgresStore) queryRecurring(ctx context.Context, query string, args ...any) ([]RecurringItem, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying recurring items", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	items := []RecurringItem{}
+	for rows.Next() {
+		item, err := scanPostgresRecurringItem(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning recurring item row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating recurring item rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return items, nil
+}
+
+// ListRecurring retrieves all recurring items, ordered by name
+func (s *PostgresStore) ListRecurring(ctx context.Context) ([]RecurringItem, error) {
+	return s.queryRecurring(ctx, "SELECT "+postgresRecurringColumns+" FROM recurring_items ORDER BY lower(name), id")
+}
+
+// GetRecurring retrieves a single recurring item by ID
+func (s *PostgresStore) GetRecurring(ctx context.Context, id int) (RecurringItem, error) {
+	item, err := scanPostgresRecurringItem(s.pool.QueryRow(ctx, "SELECT "+postgresRecurringColumns+" FROM recurring_items WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return RecurringItem{}, fmt.Errorf("recurring item with ID %d: %w", id, ErrRecurringItemNotFound)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying recurring item", "id", id, "err", err)
+		return RecurringItem{}, fmt.Errorf("database query error: %w", err)
+	}
+	return item, nil
+}
+
+// CreateRecurring inserts a new recurring item
+func (s *PostgresStore) CreateRecurring(ctx context.Context, item RecurringItem) (RecurringItem, error) {
+	item, err := normalizeRecurringItem(item, FieldLimits{}, time.Now())
+	if err != nil {
+		return RecurringItem{}, err
+	}
+
+	created, err := scanPostgresRecurringItem(s.pool.QueryRow(ctx, `
+		INSERT INTO recurring_items (name, quantity, schedule, next_run_at) VALUES ($1, $2, $3, $4)
+		RETURNING `+postgresRecurringColumns,
+		item.Name, item.Quantity, item.Schedule, item.NextRun))
+	if err != nil {
+		loggerFrom(ctx).Error("Error inserting recurring item", "err", err)
+		return RecurringItem{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Added recurring item", "id", created.ID, "name", created.Name, "schedule", created.Schedule)
+	return created, nil
+}
+
+// UpdateRecurring replaces the definition and next run of an existing recurring item
+func (s *PostgresStore) UpdateRecurring(ctx context.Context, item RecurringItem) (RecurringItem, error) {
+	item, err := normalizeRecurringItem(item, FieldLimits{}, time.Now())
+	if err != nil {
+		return RecurringItem{}, err
+	}
+
+	updated, err := scanPostgresRecurringItem(s.pool.QueryRow(ctx, `
+		UPDATE recurring_items SET name = $1, quantity = $2, schedule = $3, next_run_at = $4
+		WHERE id = $5
+		RETURNING `+postgresRecurringColumns,
+		item.Name, item.Quantity, item.Schedule, item.NextRun, item.ID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return RecurringItem{}, fmt.Errorf("recurring item with ID %d: %w", item.ID, ErrRecurringItemNotFound)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error updating recurring item", "id", item.ID, "err", err)
+		return RecurringItem{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Updated recurring item", "id", updated.ID, "name", updated.Name, "schedule", updated.Schedule)
+	return updated, nil
+}
+
+// DeleteRecurring removes a recurring item by ID
+func (s *PostgresStore) DeleteRecurring(ctx context.Context, id int) error {
+	cmdTag, err := s.pool.Exec(ctx, "DELETE FROM recurring_items WHERE id = $1", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting recurring item", "id", id, "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("recurring item with ID %d: %w", id, ErrRecurringItemNotFound)
+	}
+	loggerFrom(ctx).Info("Deleted recurring item", "id", id)
+	return nil
+}
+
+// DueRecurring retrieves the recurring items due at now, earliest first
+func (s *PostgresStore) DueRecurring(ctx context.Context, now time.Time) ([]RecurringItem, error) {
+	return s.queryRecurring(ctx,
+		"SELECT "+postgresRecurringColumns+" FROM recurring_items WHERE next_run_at <= $1 ORDER BY next_run_at, id",
+		now)
+}
+
+// RecordRun sets the last and next run of a recurring item
+func (s *PostgresStore) RecordRun(ctx context.Context, id int, ranAt, next time.Time) error {
+	cmdTag, err := s.pool.Exec(ctx,
+		"UPDATE recurring_items SET last_run_at = $1, next_run_at = $2 WHERE id = $3",
+		ranAt, next, id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error recording recurring run", "id", id, "err", err)
+		return fmt.Errorf("database update error: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("recurring item with ID %d: %w", id, ErrRecurringItemNotFound)
+	}
+	return nil
+}
+
+// schedulerLockID is the Postgres advisory lock key held while a replica adds due
+// recurring items, so replicas sharing the database never add them twice.
+const schedulerLockID = 7_262_531_002
+
+// tryLockScheduler takes the scheduler lock without waiting. It is a transaction-level
+// lock, so it is released by unlock or, should the replica die, with its connection.
+func (s *PostgresStore) tryLockScheduler(ctx context.Context) (unlock func(), ok bool, err error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to begin scheduler lock transaction: %w", err)
+	}
+	if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", schedulerLockID).Scan(&ok); err != nil || !ok {
+		tx.Rollback(ctx)
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to take scheduler lock: %w", err)
+		}
+		loggerFrom(ctx).Debug("Scheduler lock held by another replica")
+		return nil, false, nil
+	}
+	// Release the lock even if ctx was canceled during the run
+	return func() { tx.Rollback(context.WithoutCancel(ctx)) }, true, nil
+}
+
+// --- PostgreSQL PriceStore ---
+
+// postgresPriceColumns are the item_prices columns read by scanPostgresPrice.
+const postgresPriceColumns = "name, unit_price, currency, updated_at"
+
+// scanPostgresPrice reads one price row.
+func scanPostgresPrice(row pgx.Row) (Price, error) {
+	var price Price
+	if err := row.Scan(&price.Name, &price.UnitPrice, &price.Currency, &price.UpdatedAt); err != nil {
+		return Price{}, err
+	}
+	return price, nil
+}
+
+// ListPrices retrieves every remembered price, ordered by name
+func (s *PostgresStore) ListPrices(ctx context.Context) ([]Price, error) {
+	rows, err := s.pool.Query(ctx, "SELECT "+postgresPriceColumns+" FROM item_prices ORDER BY lower(name)")
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying prices", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	prices := []Price{}
+	for rows.Next() {
+		price, err := scanPostgresPrice(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning price row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		prices = append(prices, price)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating price rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return prices, nil
+}
+
+// LastPrice retrieves the remembered price for a name, ignoring case
+func (s *PostgresStore) LastPrice(ctx context.Context, name string) (Price, error) {
+	price, err := scanPostgresPrice(s.pool.QueryRow(ctx, "SELECT "+postgresPriceColumns+" FROM item_prices WHERE lower(name) = lower($1)", name))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Price{}, fmt.Errorf("price of %q: %w", name, ErrPriceNotFound)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying price", "name", name, "err", err)
+		return Price{}, fmt.Errorf("database query error: %w", err)
+	}
+	return price, nil
+}
+
+// RecordPrice inserts a price, replacing the one remembered for the same name
+func (s *PostgresStore) RecordPrice(ctx context.Context, price Price) (Price, error) {
+	price, err := normalizePrice(price)
+	if err != nil {
+		return Price{}, err
+	}
+
+	recorded, err := scanPostgresPrice(s.pool.QueryRow(ctx, `
+		INSERT INTO item_prices (name, unit_price, currency) VALUES ($1, $2, $3)
+		ON CONFLICT ((lower(name))) DO UPDATE SET
+			name = EXCLUDED.name,
+			unit_price = EXCLUDED.unit_price,
+			currency = EXCLUDED.currency,
+			updated_at = NOW()
+		RETURNING `+postgresPriceColumns,
+		price.Name, price.UnitPrice, price.Currency))
+	if err != nil {
+		loggerFrom(ctx).Error("Error recording price", "name", price.Name, "err", err)
+		return Price{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Recorded price", "name", recorded.Name, "unit_price", recorded.UnitPrice, "currency", recorded.Currency)
+	return recorded, nil
+}
+
+// GetBudget retrieves the list's budget
+func (s *PostgresStore) GetBudget(ctx context.Context) (Money, error) {
+	var budget Money
+	err := s.pool.QueryRow(ctx, "SELECT amount, currency FROM list_budget WHERE id = 1").Scan(&budget.Amount, &budget.Currency)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Money{}, ErrBudgetNotSet
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying budget", "err", err)
+		return Money{}, fmt.Errorf("database query error: %w", err)
+	}
+	return budget, nil
+}
+
+// SetBudget inserts or replaces the list's budget
+func (s *PostgresStore) SetBudget(ctx context.Context, budget Money) (Money, error) {
+	budget, err := normalizeBudget(budget)
+	if err != nil {
+		return Money{}, err
+	}
+
+	if _, err := s.pool.Exec(ctx, `
+		INSERT INTO list_budget (id, amount, currency) VALUES (1, $1, $2)
+		ON CONFLICT (id) DO UPDATE SET amount = EXCLUDED.amount, currency = EXCLUDED.currency`,
+		budget.Amount, budget.Currency); err != nil {
+		loggerFrom(ctx).Error("Error setting budget", "err", err)
+		return Money{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Set budget", "amount", budget.Amount, "currency", budget.Currency)
+	return budget, nil
+}
+
+// DeleteBudget removes the list's budget
+func (s *PostgresStore) DeleteBudget(ctx context.Context) error {
+	cmdTag, err := s.pool.Exec(ctx, "DELETE FROM list_budget WHERE id = 1")
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting budget", "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrBudgetNotSet
+	}
+	loggerFrom(ctx).Info("Deleted budget")
+	return nil
+}
+
+// --- PostgreSQL LayoutStore ---
+
+// postgresStoreQuery selects stores with their sections, one row per section.
+const postgresStoreQuery = `
+	SELECT s.id, s.name, s.last_checkoff_name, s.last_checkoff_section, s.last_checkoff_at, s.created_at, x.name
+	FROM stores s LEFT JOIN store_sections x ON x.store_id = s.id`
+
+// queryStores runs a store query and assembles its rows.
+func (s *PostgresStore) queryStores(ctx context.Context, query string, args ...any) ([]StoreLayout, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stores := []StoreLayout{}
+	for rows.Next() {
+		var st StoreLayout
+		var checkOffName, checkOffSection, section *string
+		var checkOffAt *time.Time
+		if err := rows.Scan(&st.ID, &st.Name, &checkOffName, &checkOffSection, &checkOffAt, &st.CreatedAt, &section); err != nil {
+			return nil, err
+		}
+		if checkOffName != nil && checkOffAt != nil {
+			st.LastCheckOff = &CheckOff{Name: *checkOffName, At: *checkOffAt}
+			if checkOffSection != nil {
+				st.LastCheckOff.Section = *checkOffSection
+			}
+		}
+		stores = appendStoreRow(stores, st, section)
+	}
+	return stores, rows.Err()
+}
+
+// ListStores retrieves all stores, ordered by name
+func (s *PostgresStore) ListStores(ctx context.Context) ([]StoreLayout, error) {
+	stores, err := s.queryStores(ctx, postgresStoreQuery+" ORDER BY lower(s.name), s.id, x.position")
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying stores", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	return stores, nil
+}
+
+// GetStore retrieves a single store by ID
+func (s *PostgresStore) GetStore(ctx context.Context, id int) (StoreLayout, error) {
+	stores, err := s.queryStores(ctx, postgresStoreQuery+" WHERE s.id = $1 ORDER BY x.position", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying store", "id", id, "err", err)
+		return StoreLayout{}, fmt.Errorf("database query error: %w", err)
+	}
+	if len(stores) == 0 {
+		return StoreLayout{}, fmt.Errorf("store with ID %d: %w", id, ErrStoreNotFound)
+	}
+	return stores[0], nil
+}
+
+// CreateStore inserts a new store and its sections in one transaction
+func (s *PostgresStore) CreateStore(ctx context.Context, store StoreLayout) (StoreLayout, error) {
+	store, err := normalizeStore(store)
+	if err != nil {
+		return StoreLayout{}, err
+	}
+
+	store.LastCheckOff = nil
+	err = s.writeTx(ctx, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx,
+			"INSERT INTO stores (name) VALUES ($1) RETURNING id, created_at",
+			store.Name,
+		).Scan(&store.ID, &store.CreatedAt); err != nil {
+			return err
+		}
+		return insertPostgresSections(ctx, tx, store)
+	})
+	if err != nil {
+		loggerFrom(ctx).Error("Error inserting store", "err", err)
+		return StoreLayout{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Added store", "id", store.ID, "name", store.Name)
+	return store, nil
+}
+
+// UpdateStore replaces the name and sections of a store in one transaction
+func (s *PostgresStore) UpdateStore(ctx context.Context, store StoreLayout) (StoreLayout, error) {
+	store, err := normalizeStore(store)
+	if err != nil {
+		return StoreLayout{}, err
+	}
+
+	err = s.writeTx(ctx, func(tx pgx.Tx) error {
+		var checkOffName, checkOffSection *string
+		var checkOffAt *time.Time
+		if err := tx.QueryRow(ctx,
+			"UPDATE stores SET name = $1 WHERE id = $2 RETURNING created_at, last_checkoff_name, last_checkoff_section, last_checkoff_at",
+			store.Name, store.ID,
+		).Scan(&store.CreatedAt, &checkOffName, &checkOffSection, &checkOffAt); err != nil {
+			return err
+		}
+		store.LastCheckOff = nil
+		if checkOffName != nil && checkOffAt != nil {
+			store.LastCheckOff = &CheckOff{Name: *checkOffName, At: *checkOffAt}
+			if checkOffSection != nil {
+				store.LastCheckOff.Section = *checkOffSection
+			}
+		}
+		if _, err := tx.Exec(ctx, "DELETE FROM store_sections WHERE store_id = $1", store.ID); err != nil {
+			return err
+		}
+		return insertPostgresSections(ctx, tx, store)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return StoreLayout{}, fmt.Errorf("store with ID %d: %w", store.ID, ErrStoreNotFound)
+		}
+		loggerFrom(ctx).Error("Error updating store", "id", store.ID, "err", err)
+		return StoreLayout{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Updated store", "id", store.ID, "name", store.Name)
+	return store, nil
+}
+
+// DeleteStore removes a store by ID; its sections and mappings go with it (ON DELETE CASCADE)
+func (s *PostgresStore) DeleteStore(ctx context.Context, id int) error {
+	cmdTag, err := s.pool.Exec(ctx, "DELETE FROM stores WHERE id = $1", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting store", "id", id, "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("store with ID %d: %w", id, ErrStoreNotFound)
+	}
+	loggerFrom(ctx).Info("Deleted store", "id", id)
+	return nil
+}
+
+// insertPostgresSections stores the sections of store in walking order, in one statement.
+func insertPostgresSections(ctx context.Context, tx pgx.Tx, store StoreLayout) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO store_sections (store_id, position, name)
+		SELECT $1, t.position, t.name
+		FROM unnest($2::text[]) WITH ORDINALITY AS t(name, position)`,
+		store.ID, store.Sections)
+	return err
+}
+
+// isPostgresForeignKeyViolation reports whether err is a foreign_key_violation, as when a
+// mapping is set for a store that does not exist.
+func isPostgresForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+// postgresMappingColumns are the section_mappings columns read by scanPostgresMapping.
+const postgresMappingColumns = "name, section, learned, updated_at"
+
+// scanPostgresMapping reads one mapping row.
+func scanPostgresMapping(row pgx.Row) (SectionMapping, error) {
+	var mapping SectionMapping
+	if err := row.Scan(&mapping.Name, &mapping.Section, &mapping.Learned, &mapping.UpdatedAt); err != nil {
+		return SectionMapping{}, err
+	}
+	return mapping, nil
+}
+
+// ListMappings retrieves the mappings of a store, ordered by name
+func (s *PostgresStore) ListMappings(ctx context.Context, storeID int) ([]SectionMapping, error) {
+	var exists bool
+	if err := s.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM stores WHERE id = $1)", storeID).Scan(&exists); err != nil {
+		loggerFrom(ctx).Error("Error querying store", "id", storeID, "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("store with ID %d: %w", storeID, ErrStoreNotFound)
+	}
+
+	rows, err := s.pool.Query(ctx, "SELECT "+postgresMappingColumns+" FROM section_mappings WHERE store_id = $1 ORDER BY lower(name)", storeID)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying section mappings", "store", storeID, "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	mappings := []SectionMapping{}
+	for rows.Next() {
+		mapping, err := scanPostgresMapping(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning section mapping row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		mappings = append(mappings, mapping)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating section mapping rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return mappings, nil
+}
+
+// GetMapping retrieves the mapping for a name in a store, ignoring case
+func (s *PostgresStore) GetMapping(ctx context.Context, storeID int, name string) (SectionMapping, error) {
+	mapping, err := scanPostgresMapping(s.pool.QueryRow(ctx,
+		"SELECT "+postgresMappingColumns+" FROM section_mappings WHERE store_id = $1 AND lower(name) = lower($2)", storeID, name))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return SectionMapping{}, fmt.Errorf("%q in store %d: %w", name, storeID, ErrMappingNotFound)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying section mapping", "store", storeID, "name", name, "err", err)
+		return SectionMapping{}, fmt.Errorf("database query error: %w", err)
+	}
+	return mapping, nil
+}
+
+// SetMapping inserts a mapping, replacing the one for the same name in the store
+func (s *PostgresStore) SetMapping(ctx context.Context, storeID int, mapping SectionMapping) (SectionMapping, error) {
+	mapping, err := normalizeMapping(mapping, FieldLimits{})
+	if err != nil {
+		return SectionMapping{}, err
+	}
+
+	set, err := scanPostgresMapping(s.pool.QueryRow(ctx, `
+		INSERT INTO section_mappings (store_id, name, section, learned) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (store_id, (lower(name))) DO UPDATE SET
+			name = EXCLUDED.name,
+			section = EXCLUDED.section,
+			learned = EXCLUDED.learned,
+			updated_at = NOW()
+		RETURNING `+postgresMappingColumns,
+		storeID, mapping.Name, mapping.Section, mapping.Learned))
+	if err != nil {
+		if isPostgresForeignKeyViolation(err) {
+			return SectionMapping{}, fmt.Errorf("store with ID %d: %w", storeID, ErrStoreNotFound)
+		}
+		loggerFrom(ctx).Error("Error setting section mapping", "store", storeID, "name", mapping.Name, "err", err)
+		return SectionMapping{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Set section mapping", "store", storeID, "name", set.Name, "section", set.Section, "learned", set.Learned)
+	return set, nil
+}
+
+// DeleteMapping removes the mapping for a name from a store, ignoring case
+func (s *PostgresStore) DeleteMapping(ctx context.Context, storeID int, name string) error {
+	cmdTag, err := s.pool.Exec(ctx, "DELETE FROM section_mappings WHERE store_id = $1 AND lower(name) = lower($2)", storeID, name)
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting section mapping", "store", storeID, "name", name, "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("%q in store %d: %w", name, storeID, ErrMappingNotFound)
+	}
+	loggerFrom(ctx).Info("Deleted section mapping", "store", storeID, "name", name)
+	return nil
+}
+
+// RecordCheckOff stores the last item checked off in a store
+func (s *PostgresStore) RecordCheckOff(ctx context.Context, storeID int, checkOff CheckOff) error {
+	var section *string
+	if checkOff.Section != "" {
+		section = &checkOff.Section
+	}
+	cmdTag, err := s.pool.Exec(ctx,
+		"UPDATE stores SET last_checkoff_name = $1, last_checkoff_section = $2, last_checkoff_at = $3 WHERE id = $4",
+		checkOff.Name, section, checkOff.At, storeID)
+	if err != nil {
+		loggerFrom(ctx).Error("Error recording check-off", "store", storeID, "err", err)
+		return fmt.Errorf("database update error: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("store with ID %d: %w", storeID, ErrStoreNotFound)
+	}
+	return nil
+}
+
+// --- PostgreSQL HistoryStore ---
+
+// RecordUse counts one more use of an item's name and remembers its quantity
+func (s *PostgresStore) RecordUse(ctx context.Context, item Item, at time.Time) error {
+	// rank: log2(2^old + 2^new), see addHistoryRanks
+	if _, err := s.pool.Exec(ctx, `
+		INSERT INTO item_history (name, quantity, uses, last_used_at, rank) VALUES ($1, $2, 1, $3, $4)
+		ON CONFLICT ((lower(name))) DO UPDATE SET
+			name = EXCLUDED.name,
+			quantity = EXCLUDED.quantity,
+			uses = item_history.uses + 1,
+			last_used_at = EXCLUDED.last_used_at,
+			rank = GREATEST(item_history.rank, EXCLUDED.rank)
+				+ ln(1 + power(2::float8, -abs(item_history.rank - EXCLUDED.rank))) / ln(2::float8)`,
+		item.Name, item.Quantity, at, historyRank(at)); err != nil {
+		loggerFrom(ctx).Error("Error recording item history", "name", item.Name, "err", err)
+		return fmt.Errorf("database insert error: %w", err)
+	}
+	return nil
+}
+
+// Suggest retrieves the best-ranked names starting with a prefix, ignoring case
+func (s *PostgresStore) Suggest(ctx context.Context, prefix string, limit int) ([]Suggestion, error) {
+	// The LIKE prefix match uses item_history_prefix_idx (text_pattern_ops)
+	rows, err := s.pool.Query(ctx, `
+		SELECT name, quantity, uses, last_used_at FROM item_history
+		WHERE lower(name) LIKE lower($1)
+		ORDER BY rank DESC, lower(name)
+		LIMIT $2`, escapeLike(prefix)+"%", limit)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying suggestions", "prefix", prefix, "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []Suggestion{}
+	for rows.Next() {
+		var suggestion Suggestion
+		if err := rows.Scan(&suggestion.Name, &suggestion.Quantity, &suggestion.Uses, &suggestion.LastUsed); err != nil {
+			loggerFrom(ctx).Error("Error scanning suggestion row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating suggestion rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return suggestions, nil
+}
+
+// --- PostgreSQL SearchStore ---
+
+// Search matches names with full-text search on their words, or with pg_trgm word
+// similarity for misspellings. Both tables have GIN indexes for each.
+func (s *PostgresStore) Search(ctx context.Context, terms []string, limit int) ([]SearchResult, error) {
+	// Every term is a prefix match: 'tom':* & 'sau':*. Terms are letters and digits only,
+	// so quoting them is enough.
+	lexemes := make([]string, len(terms))
+	for i, term := range terms {
+		lexemes[i] = "'" + term + "':*"
+	}
+	rows, err := s.pool.Query(ctx, `
+		WITH query AS (SELECT to_tsquery('simple', $1) AS tsq, $2::text AS text),
+		matches AS (
+			SELECT 'list' AS source, i.id, i.uid::text AS uid, i.name, i.quantity, i.created_at AS at,
+				to_tsvector('simple', i.name) @@ q.tsq AS exact,
+				word_similarity(q.text, lower(i.name)) AS similarity
+			FROM items i, query q
+			WHERE to_tsvector('simple', i.name) @@ q.tsq OR q.text <% lower(i.name)
+			UNION ALL
+			SELECT 'history', NULL, '', h.name, h.quantity, h.last_used_at,
+				to_tsvector('simple', h.name) @@ q.tsq,
+				word_similarity(q.text, lower(h.name))
+			FROM item_history h, query q
+			WHERE to_tsvector('simple', h.name) @@ q.tsq OR q.text <% lower(h.name)
+		)
+		SELECT source, id, uid, name, quantity, at, ((exact::int + similarity) / 2)::float8 AS score
+		FROM matches
+		ORDER BY score DESC, lower(name), source = 'history'
+		LIMIT $3`, strings.Join(lexemes, " & "), strings.Join(terms, " "), limit)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying search results", "terms", terms, "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var r SearchResult
+		if err := rows.Scan(&r.Source, &r.ID, &r.UID, &r.Name, &r.Quantity, &r.At, &r.Score); err != nil {
+			loggerFrom(ctx).Error("Error scanning search row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating search rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return results, nil
+}
+
+// --- PostgreSQL SyncStore ---
+
+// postgresSyncColumns are the items columns read by scanPostgresSyncRecord. Change times
+// left NULL date from the item's creation.
+const postgresSyncColumns = postgresItemColumns + `, version,
+	COALESCE(name_changed_at, created_at), COALESCE(quantity_changed_at, created_at), COALESCE(price_changed_at, created_at)`
+
+// scanPostgresSyncRecord reads an item row with its sync columns.
+func scanPostgresSyncRecord(row pgx.Row) (syncRecord, error) {
+	var rec syncRecord
+	var currency *string
+	if err := row.Scan(&rec.ID, &rec.UID, &rec.Name, &rec.Quantity, &rec.UnitPrice, &currency, &rec.CreatedAt,
+		&rec.Version, &rec.NameAt, &rec.QuantityAt, &rec.PriceAt); err != nil {
+		return syncRecord{}, err
+	}
+	if currency != nil {
+		rec.Currency = *currency
+	}
+	return rec, nil
+}
+
+// scanPostgresTombstone reads an item_tombstones row.
+func scanPostgresTombstone(row pgx.Row) (Tombstone, error) {
+	var t Tombstone
+	var uid *string
+	if err := row.Scan(&t.ID, &uid, &t.Version, &t.DeletedAt); err != nil {
+		return Tombstone{}, err
+	}
+	if uid != nil {
+		t.UID = *uid
+	}
+	return t, nil
+}
+
+// Changes retrieves the items and tombstones with a version above since
+func (s *PostgresStore) Changes(ctx context.Context, since int64, limit int) ([]SyncItem, []Tombstone, error) {
+	// Both lists must come from one snapshot, or a change committed in between could be
+	// skipped by the next token
+	tx, err := s.pool.Begin(ctx)
+	if err == nil {
+		_, err = tx.Exec(ctx, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY")
+		defer tx.Rollback(ctx)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying changes", "since", since, "err", err)
+		return nil, nil, fmt.Errorf("database query error: %w", err)
+	}
+
+	items := []SyncItem{}
+	rows, err := tx.Query(ctx, "SELECT "+postgresSyncColumns+" FROM items WHERE version > $1 ORDER BY version LIMIT $2", since, limit)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying changes", "since", since, "err", err)
+		return nil, nil, fmt.Errorf("database query error: %w", err)
+	}
+	for rows.Next() {
+		rec, err := scanPostgresSyncRecord(rows)
+		if err != nil {
+			rows.Close()
+			loggerFrom(ctx).Error("Error scanning change row", "err", err)
+			return nil, nil, fmt.Errorf("database scan error: %w", err)
+		}
+		items = append(items, rec.SyncItem)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating change rows", "err", err)
+		return nil, nil, fmt.Errorf("database iteration error: %w", err)
+	}
+
+	tombstones := []Tombstone{}
+	rows, err = tx.Query(ctx, "SELECT item_id, uid::text, version, deleted_at FROM item_tombstones WHERE version > $1 ORDER BY version LIMIT $2", since, limit)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying tombstones", "since", since, "err", err)
+		return nil, nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		t, err := scanPostgresTombstone(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning tombstone row", "err", err)
+			return nil, nil, fmt.Errorf("database scan error: %w", err)
+		}
+		tombstones = append(tombstones, t)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating tombstone rows", "err", err)
+		return nil, nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return items, tombstones, nil
+}
+
+// ApplyMutation resolves an offline change against the stored item and writes it
+func (s *PostgresStore) ApplyMutation(ctx context.Context, m Mutation) (MutationResult, error) {
+	m, err := normalizeMutation(m, FieldLimits{})
+	if err != nil {
+		return MutationResult{}, err
+	}
+
+	var plan syncPlan
+	apply := func(tx pgx.Tx) error {
+		var err error
+		plan, err = applyPostgresMutation(ctx, tx, m)
+		return err
+	}
+	err = s.writeTx(ctx, apply)
+	if isPostgresUniqueViolation(err) {
+		// Another request created the UID first; resolve against its item
+		err = s.writeTx(ctx, apply)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error applying mutation", "uid", m.UID, "id", m.ID, "err", err)
+		return MutationResult{}, fmt.Errorf("database update error: %w", err)
+	}
+	if plan.Action != "" {
+		loggerFrom(ctx).Info("Applied mutation", "uid", plan.Result.UID, "id", plan.Result.ID, "status", plan.Result.Status)
+	}
+	return plan.Result, nil
+}
+
+// applyPostgresMutation locks the targeted item, resolves the mutation and writes it.
+func applyPostgresMutation(ctx context.Context, tx pgx.Tx, m Mutation) (syncPlan, error) {
+	itemTarget, tombstoneTarget, arg := "id = $1", "item_id = $1", any(m.ID)
+	if m.UID != "" {
+		itemTarget, tombstoneTarget, arg = "uid = $1::uuid", "uid = $1::uuid", m.UID
+	}
+	var current *syncRecord
+	rec, err := scanPostgresSyncRecord(tx.QueryRow(ctx, "SELECT "+postgresSyncColumns+" FROM items WHERE "+itemTarget+" FOR UPDATE", arg))
+	switch {
+	case err == nil:
+		current = &rec
+	case !errors.Is(err, pgx.ErrNoRows):
+		return syncPlan{}, err
+	}
+	var deleted *Tombstone
+	if current == nil {
+		t, err := scanPostgresTombstone(tx.QueryRow(ctx,
+			"SELECT item_id, uid::text, version, deleted_at FROM item_tombstones WHERE "+tombstoneTarget+" ORDER BY version DESC LIMIT 1", arg))
+		switch {
+		case err == nil:
+			deleted = &t
+		case !errors.Is(err, pgx.ErrNoRows):
+			return syncPlan{}, err
+		}
+	}
+
+	plan := resolveMutation(current, deleted, m)
+	r := plan.Record
+	var row pgx.Row
+	switch plan.Action {
+	case syncCreate:
+		row = tx.QueryRow(ctx, `
+			INSERT INTO items (uid, name, quantity, unit_price, currency, name_changed_at, quantity_changed_at, price_changed_at)
+			VALUES ($1::uuid, $2, $3, $4, $5, $6, $7, $8) RETURNING `+postgresSyncColumns,
+			r.UID, r.Name, r.Quantity, r.UnitPrice, postgresCurrency(r.Currency), r.NameAt, r.QuantityAt, r.PriceAt)
+	case syncUpdate:
+		row = tx.QueryRow(ctx, `
+			UPDATE items SET name = $1, quantity = $2, unit_price = $3, currency = $4,
+				name_changed_at = $5, quantity_changed_at = $6, price_changed_at = $7
+			WHERE id = $8 RETURNING `+postgresSyncColumns,
+			r.Name, r.Quantity, r.UnitPrice, postgresCurrency(r.Currency), r.NameAt, r.QuantityAt, r.PriceAt, r.ID)
+	case syncDelete:
+		_, err := tx.Exec(ctx, "DELETE FROM items WHERE id = $1", r.ID)
+		return plan, err
+	default:
+		return plan, nil
+	}
+	// The trigger has set the version by the time RETURNING runs
+	written, err := scanPostgresSyncRecord(row)
+	if err != nil {
+		return syncPlan{}, err
+	}
+	plan.Result.ID, plan.Result.Item = written.ID, &written.SyncItem
+	return plan, nil
+}
+
+// Count returns the number of items without loading them.
+func (s *PostgresStore) Count(ctx context.Context) (int, error) {
+	var n int
+	if err := s.pool.QueryRow(ctx, "SELECT COUNT(*) FROM items").Scan(&n); err != nil {
+		return 0, fmt.Errorf("database query error: %w", err)
+	}
+	return n, nil
+}
+
+// SchemaVersion returns the latest applied migration version.
+func (s *PostgresStore) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	if err := s.pool.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("unable to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Ping checks that the database is reachable.
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
+}
@@ -0,0 +1,1466 @@
+package shoppinglist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite" // Pure-Go SQLite driver (no cgo), registers "sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// --- SQLite ItemStore ---
+
+// sqliteTimeLayout matches the strftime('%Y-%m-%dT%H:%M:%fZ') column default.
+// The fixed width keeps created_at sortable as text.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000Z"
+
+// sqlitePragmas are applied to every connection: WAL lets readers run alongside
+// the writer, and busy_timeout makes brief lock contention wait instead of failing.
+const sqlitePragmas = "_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=synchronous(NORMAL)&_pragma=foreign_keys(1)"
+
+// SQLiteStore is the ItemStore implementation backed by an embedded SQLite file.
+// Reads use a small connection pool; all writes go through a single connection,
+// which serializes writers and avoids SQLITE_BUSY errors under concurrent requests.
+type SQLiteStore struct {
+	db     *sql.DB // Readers
+	writer *sql.DB // Single connection, transactions start with BEGIN IMMEDIATE
+}
+
+// SQLitePathFromURL extracts the file path from a sqlite:// URL.
+// sqlite:///var/lib/shopping/items.db is absolute; sqlite://items.db is relative to the working directory.
+func SQLitePathFromURL(databaseURL string) (string, error) {
+	path, ok := strings.CutPrefix(databaseURL, "sqlite://")
+	if !ok {
+		return "", fmt.Errorf("not a sqlite:// URL: %q", databaseURL)
+	}
+	if path == "" || path == "/" {
+		return "", fmt.Errorf("sqlite URL %q has no file path", databaseURL)
+	}
+	return path, nil
+}
+
+// sqliteDSN turns a file path into a "file:" URI. The characters that end or escape the
+// path part of a URI are percent-encoded, so a path containing "#" or "?" is opened as is.
+func sqliteDSN(path string) string {
+	return "file:" + strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+}
+
+// OpenSQLite opens (creating if needed) the SQLite database at path and applies pending migrations.
+func OpenSQLite(ctx context.Context, path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(path)+"?"+sqlitePragmas)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open sqlite database: %w", err)
+	}
+	writer, err := sql.Open("sqlite", sqliteDSN(path)+"?"+sqlitePragmas+"&_txlock=immediate")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to open sqlite database: %w", err)
+	}
+	writer.SetMaxOpenConns(1)
+	s := &SQLiteStore{db: db, writer: writer}
+
+	if err := s.Ping(ctx); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("unable to open sqlite database %s: %w", path, err)
+	}
+	if err := migrateSQLite(ctx, writer); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("error creating table schema: %w", err)
+	}
+
+	loggerFrom(ctx).Info("Opened SQLite database", "path", path, "schema_version", latestSchemaVersion())
+	return s, nil
+}
+
+// sqliteItemColumns are the items columns read by scanSQLiteItem.
+const sqliteItemColumns = "id, uid, name, quantity, unit_price, currency, created_at"
+
+// scanSQLiteItem reads an item row, converting the text created_at column.
+func scanSQLiteItem(row interface{ Scan(...any) error }) (Item, error) {
+	var item Item
+	var uid, currency sql.NullString
+	var createdAt string
+	if err := row.Scan(&item.ID, &uid, &item.Name, &item.Quantity, &item.UnitPrice, &currency, &createdAt); err != nil {
+		return Item{}, err
+	}
+	item.UID, item.Currency = uid.String, currency.String
+	t, err := parseSQLiteTime(createdAt)
+	if err != nil {
+		return Item{}, err
+	}
+	item.CreatedAt = t
+	return item, nil
+}
+
+// parseSQLiteTime converts a timestamp column value such as created_at.
+func parseSQLiteTime(s string) (time.Time, error) {
+	t, err := time.Parse(sqliteTimeLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", s, err)
+	}
+	return t, nil
+}
+
+// sqliteCurrency stores the currency of an unpriced item as NULL.
+func sqliteCurrency(code string) sql.NullString {
+	return sql.NullString{String: code, Valid: code != ""}
+}
+
+// sqliteTime formats t like the timestamp columns, so stored times compare as text.
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// List retrieves all items, newest first
+func (s *SQLiteStore) List(ctx context.Context) ([]Item, error) {
+	// created_at has millisecond precision, so ties fall back to insertion order
+	rows, err := s.db.QueryContext(ctx, "SELECT "+sqliteItemColumns+" FROM items ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying items", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	items := []Item{}
+	for rows.Next() {
+		item, err := scanSQLiteItem(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning item row", "err", err)
+			// Continue processing other rows if one fails to scan
+			continue
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return items, nil
+}
+
+// Get retrieves a single item by ID
+func (s *SQLiteStore) Get(ctx context.Context, id int) (Item, error) {
+	item, err := scanSQLiteItem(s.db.QueryRowContext(ctx,
+		"SELECT "+sqliteItemColumns+" FROM items WHERE id = ?", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Item{}, fmt.Errorf("item with ID %d: %w", id, ErrNotFound)
+		}
+		loggerFrom(ctx).Error("Error querying item", "id", id, "err", err)
+		return Item{}, fmt.Errorf("database query error: %w", err)
+	}
+	return item, nil
+}
+
+// GetByUID retrieves a single item by UID
+func (s *SQLiteStore) GetByUID(ctx context.Context, uid string) (Item, error) {
+	item, err := scanSQLiteItem(s.db.QueryRowContext(ctx,
+		"SELECT "+sqliteItemColumns+" FROM items WHERE uid = ?", uid))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Item{}, fmt.Errorf("item with UID %s: %w", uid, ErrNotFound)
+		}
+		loggerFrom(ctx).Error("Error querying item", "uid", uid, "err", err)
+		return Item{}, fmt.Errorf("database query error: %w", err)
+	}
+	return item, nil
+}
+
+// Create inserts a new item
+func (s *SQLiteStore) Create(ctx context.Context, newItem Item) (Item, error) {
+	newItem, err := normalizeItem(newItem, FieldLimits{})
+	if err != nil {
+		return Item{}, err
+	}
+
+	item, err := scanSQLiteItem(s.writer.QueryRowContext(ctx,
+		"INSERT INTO items (uid, name, quantity, unit_price, currency) VALUES (?, ?, ?, ?, ?) RETURNING "+sqliteItemColumns,
+		newItemUID(time.Now()), newItem.Name, newItem.Quantity, newItem.UnitPrice, sqliteCurrency(newItem.Currency)))
+	if err != nil {
+		loggerFrom(ctx).Error("Error inserting item", "err", err)
+		return Item{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Added item", "id", item.ID, "name", item.Name, "quantity", item.Quantity)
+	return item, nil
+}
+
+// Update changes the name, quantity and price of an existing item
+func (s *SQLiteStore) Update(ctx context.Context, item Item) (Item, error) {
+	item, err := normalizeItem(item, FieldLimits{})
+	if err != nil {
+		return Item{}, err
+	}
+
+	updated, err := scanSQLiteItem(s.writer.QueryRowContext(ctx,
+		"UPDATE items SET name = ?, quantity = ?, unit_price = ?, currency = ? WHERE id = ? RETURNING "+sqliteItemColumns,
+		item.Name, item.Quantity, item.UnitPrice, sqliteCurrency(item.Currency), item.ID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Item{}, fmt.Errorf("item with ID %d: %w", item.ID, ErrNotFound)
+		}
+		loggerFrom(ctx).Error("Error updating item", "id", item.ID, "err", err)
+		return Item{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Updated item", "id", updated.ID, "name", updated.Name, "quantity", updated.Quantity)
+	return updated, nil
+}
+
+// Delete removes an item by ID
+func (s *SQLiteStore) Delete(ctx context.Context, id int) error {
+	res, err := s.writer.ExecContext(ctx, "DELETE FROM items WHERE id = ?", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting item", "id", id, "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		loggerFrom(ctx).Info("Attempted to delete non-existent item", "id", id)
+		return fmt.Errorf("item with ID %d: %w", id, ErrNotFound)
+	}
+	loggerFrom(ctx).Info("Deleted item", "id", id)
+	return nil
+}
+
+// --- SQLite RecipeStore ---
+
+// sqliteRecipeQuery selects recipes with their ingredients, one row per ingredient.
+const sqliteRecipeQuery = `
+	SELECT r.id, r.title, r.servings, r.created_at, i.name, i.quantity
+	FROM recipes r LEFT JOIN recipe_ingredients i ON i.recipe_id = r.id`
+
+// querySQLiteRecipes runs a recipe query and assembles its rows.
+func (s *SQLiteStore) querySQLiteRecipes(ctx context.Context, query string, args ...any) ([]Recipe, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipes := []Recipe{}
+	for rows.Next() {
+		var r Recipe
+		var createdAt string
+		var name, quantity *string
+		if err := rows.Scan(&r.ID, &r.Title, &r.Servings, &createdAt, &name, &quantity); err != nil {
+			return nil, err
+		}
+		if r.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+			return nil, err
+		}
+		recipes = appendRecipeRow(recipes, r, name, quantity)
+	}
+	return recipes, rows.Err()
+}
+
+// ListRecipes retrieves all recipes, ordered by title
+func (s *SQLiteStore) ListRecipes(ctx context.Context) ([]Recipe, error) {
+	recipes, err := s.querySQLiteRecipes(ctx, sqliteRecipeQuery+" ORDER BY lower(r.title), r.id, i.position")
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying recipes", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	return recipes, nil
+}
+
+// GetRecipe retrieves a single recipe by ID
+func (s *SQLiteStore) GetRecipe(ctx context.Context, id int) (Recipe, error) {
+	recipes, err := s.querySQLiteRecipes(ctx, sqliteRecipeQuery+" WHERE r.id = ? ORDER BY i.position", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying recipe", "id", id, "err", err)
+		return Recipe{}, fmt.Errorf("database query error: %w", err)
+	}
+	if len(recipes) == 0 {
+		return Recipe{}, fmt.Errorf("recipe with ID %d: %w", id, ErrRecipeNotFound)
+	}
+	return recipes[0], nil
+}
+
+// CreateRecipe inserts a new recipe and its ingredients in one transaction
+func (s *SQLiteStore) CreateRecipe(ctx context.Context, recipe Recipe) (Recipe, error) {
+	recipe, err := normalizeRecipe(recipe, FieldLimits{})
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	err = s.writeTx(ctx, func(tx *sql.Tx) error {
+		var createdAt string
+		if err := tx.QueryRowContext(ctx,
+			"INSERT INTO recipes (title, servings) VALUES (?, ?) RETURNING id, created_at",
+			recipe.Title, recipe.Servings).Scan(&recipe.ID, &createdAt); err != nil {
+			return err
+		}
+		t, err := parseSQLiteTime(createdAt)
+		if err != nil {
+			return err
+		}
+		recipe.CreatedAt = t
+		return insertSQLiteIngredients(ctx, tx, recipe)
+	})
+	if err != nil {
+		loggerFrom(ctx).Error("Error inserting recipe", "err", err)
+		return Recipe{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Added recipe", "id", recipe.ID, "title", recipe.Title)
+	return recipe, nil
+}
+
+// UpdateRecipe replaces a recipe and its ingredients in one transaction
+func (s *SQLiteStore) UpdateRecipe(ctx context.Context, recipe Recipe) (Recipe, error) {
+	recipe, err := normalizeRecipe(recipe, FieldLimits{})
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	err = s.writeTx(ctx, func(tx *sql.Tx) error {
+		var createdAt string
+		if err := tx.QueryRowContext(ctx,
+			"UPDATE recipes SET title = ?, servings = ? WHERE id = ? RETURNING created_at",
+			recipe.Title, recipe.Servings, recipe.ID).Scan(&createdAt); err != nil {
+			return err
+		}
+		t, err := parseSQLiteTime(createdAt)
+		if err != nil {
+			return err
+		}
+		recipe.CreatedAt = t
+		if _, err := tx.ExecContext(ctx, "DELETE FROM recipe_ingredients WHERE recipe_id = ?", recipe.ID); err != nil {
+			return err
+		}
+		return insertSQLiteIngredients(ctx, tx, recipe)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Recipe{}, fmt.Errorf("recipe with ID %d: %w", recipe.ID, ErrRecipeNotFound)
+		}
+		loggerFrom(ctx).Error("Error updating recipe", "id", recipe.ID, "err", err)
+		return Recipe{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Updated recipe", "id", recipe.ID, "title", recipe.Title)
+	return recipe, nil
+}
+
+// DeleteRecipe removes a recipe by ID; its ingredients go with it
+func (s *SQLiteStore) DeleteRecipe(ctx context.Context, id int) error {
+	res, err := s.writer.ExecContext(ctx, "DELETE FROM recipes WHERE id = ?", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting recipe", "id", id, "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("recipe with ID %d: %w", id, ErrRecipeNotFound)
+	}
+	loggerFrom(ctx).Info("Deleted recipe", "id", id)
+	return nil
+}
+
+// writeTx runs fn in a write transaction, committing if it succeeds.
+func (s *SQLiteStore) writeTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // No-op after a successful Commit
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertSQLiteIngredients stores the ingredients of recipe in order.
+func insertSQLiteIngredients(ctx context.Context, tx *sql.Tx, recipe Recipe) error {
+	for i, ing := range recipe.Ingredients {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO recipe_ingredients (recipe_id, position, name, quantity) VALUES (?, ?, ?, ?)",
+			recipe.ID, i+1, ing.Name, ing.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --- SQLite PantryStore ---
+
+// sqlitePantryColumns are the pantry_items columns read by scanSQLitePantryItem.
+const sqlitePantryColumns = "id, name, stock, unit, threshold, best_before, created_at"
+
+// scanSQLitePantryItem reads one pantry item row, converting the text timestamp.
+func scanSQLitePantryItem(row interface{ Scan(...any) error }) (PantryItem, error) {
+	var item PantryItem
+	var bestBefore sql.NullString
+	var createdAt string
+	if err := row.Scan(&item.ID, &item.Name, &item.Stock, &item.Unit, &item.Threshold, &bestBefore, &createdAt); err != nil {
+		return PantryItem{}, err
+	}
+	item.BestBefore = bestBefore.String
+	t, err := parseSQLiteTime(createdAt)
+	if err != nil {
+		return PantryItem{}, err
+	}
+	item.CreatedAt = t
+	return item, nil
+}
+
+// sqliteDate stores an empty best-before date as NULL.
+func sqliteDate(date string) sql.NullString {
+	return sql.NullString{String: date, Valid: date != ""}
+}
+
+// isSQLiteUniqueViolation reports whether err is a UNIQUE constraint failure.
+func isSQLiteUniqueViolation(err error) bool {
+	var serr *sqlite.Error
+	return errors.As(err, &serr) && serr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// ListPantry retrieves all pantry items, ordered by name
+func (s *SQLiteStore) ListPantry(ctx context.Context) ([]PantryItem, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+sqlitePantryColumns+" FROM pantry_items ORDER BY lower(name)")
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying pantry", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	items := []PantryItem{}
+	for rows.Next() {
+		item, err := scanSQLitePantryItem(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning pantry row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating pantry rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return items, nil
+}
+
+// GetPantryItem retrieves a single pantry item by ID
+func (s *SQLiteStore) GetPantryItem(ctx context.Context, id int) (PantryItem, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+sqlitePantryColumns+" FROM pantry_items WHERE id = ?", id)
+	item, err := scanSQLitePantryItem(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return PantryItem{}, fmt.Errorf("pantry item with ID %d: %w", id, ErrPantryItemNotFound)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying pantry item", "id", id, "err", err)
+		return PantryItem{}, fmt.Errorf("database query error: %w", err)
+	}
+	return item, nil
+}
+
+// CreatePantryItem inserts a new pantry item unless its name is taken
+func (s *SQLiteStore) CreatePantryItem(ctx context.Context, item PantryItem) (PantryItem, error) {
+	item, err := normalizePantryItem(item, FieldLimits{})
+	if err != nil {
+		return PantryItem{}, err
+	}
+
+	row := s.writer.QueryRowContext(ctx, `
+		INSERT INTO pantry_items (name, stock, unit, threshold, best_before) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING
+		RETURNING `+sqlitePantryColumns,
+		item.Name, item.Stock, item.Unit, item.Threshold, sqliteDate(item.BestBefore))
+	created, err := scanSQLitePantryItem(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return PantryItem{}, fmt.Errorf("pantry item %q: %w", item.Name, ErrPantryItemExists)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error inserting pantry item", "err", err)
+		return PantryItem{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Added pantry item", "id", created.ID, "name", created.Name, "stock", created.Stock)
+	return created, nil
+}
+
+// UpdatePantryItem replaces every field of an existing pantry item
+func (s *SQLiteStore) UpdatePantryItem(ctx context.Context, item PantryItem) (PantryItem, error) {
+	item, err := normalizePantryItem(item, FieldLimits{})
+	if err != nil {
+		return PantryItem{}, err
+	}
+
+	row := s.writer.QueryRowContext(ctx, `
+		UPDATE pantry_items SET name = ?, stock = ?, unit = ?, threshold = ?, best_before = ?
+		WHERE id = ?
+		RETURNING `+sqlitePantryColumns,
+		item.Name, item.Stock, item.Unit, item.Threshold, sqliteDate(item.BestBefore), item.ID)
+	updated, err := scanSQLitePantryItem(row)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return PantryItem{}, fmt.Errorf("pantry item with ID %d: %w", item.ID, ErrPantryItemNotFound)
+	case isSQLiteUniqueViolation(err):
+		return PantryItem{}, fmt.Errorf("pantry item %q: %w", item.Name, ErrPantryItemExists)
+	case err != nil:
+		loggerFrom(ctx).Error("Error updating pantry item", "id", item.ID, "err", err)
+		return PantryItem{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Updated pantry item", "id", updated.ID, "name", updated.Name, "stock", updated.Stock)
+	return updated, nil
+}
+
+// DeletePantryItem removes a pantry item by ID
+func (s *SQLiteStore) DeletePantryItem(ctx context.Context, id int) error {
+	res, err := s.writer.ExecContext(ctx, "DELETE FROM pantry_items WHERE id = ?", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting pantry item", "id", id, "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("pantry item with ID %d: %w", id, ErrPantryItemNotFound)
+	}
+	loggerFrom(ctx).Info("Deleted pantry item", "id", id)
+	return nil
+}
+
+// ConsumeStock takes amount off the stock of a pantry item in one statement, stopping at zero
+func (s *SQLiteStore) ConsumeStock(ctx context.Context, id int, amount float64) (PantryItem, error) {
+	row := s.writer.QueryRowContext(ctx, `
+		UPDATE pantry_items SET stock = max(round(stock - ?, 3), 0)
+		WHERE id = ?
+		RETURNING `+sqlitePantryColumns,
+		amount, id)
+	item, err := scanSQLitePantryItem(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return PantryItem{}, fmt.Errorf("pantry item with ID %d: %w", id, ErrPantryItemNotFound)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error consuming stock", "id", id, "err", err)
+		return PantryItem{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Consumed stock", "id", id, "amount", amount, "stock", item.Stock)
+	return item, nil
+}
+
+// MovePurchased deletes a shopping list item and adds its stock to the pantry in one transaction
+func (s *SQLiteStore) MovePurchased(ctx context.Context, itemID int, stock PantryItem) (PantryItem, error) {
+	stock, err := normalizePantryItem(stock, FieldLimits{})
+	if err != nil {
+		return PantryItem{}, err
+	}
+
+	var item PantryItem
+	err = s.writeTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM items WHERE id = ?", itemID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("item with ID %d: %w", itemID, ErrNotFound)
+		}
+		var unit string
+		err = tx.QueryRowContext(ctx, "SELECT unit FROM pantry_items WHERE lower(name) = lower(?)", stock.Name).Scan(&unit)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		amount, ok := purchasedStock(stock, unit)
+		if !ok {
+			loggerFrom(ctx).Warn("Purchased stock is in a different unit; pantry stock left unchanged",
+				"item_id", itemID, "unit", stock.Unit, "pantry_unit", unit)
+		}
+		item, err = scanSQLitePantryItem(tx.QueryRowContext(ctx, `
+			INSERT INTO pantry_items (name, stock, unit, best_before) VALUES (?, ?, ?, ?)
+			ON CONFLICT (lower(name)) DO UPDATE SET
+				stock = round(pantry_items.stock + excluded.stock, 3),
+				unit = CASE WHEN pantry_items.unit = '' THEN excluded.unit ELSE pantry_items.unit END,
+				best_before = coalesce(excluded.best_before, pantry_items.best_before)
+			RETURNING `+sqlitePantryColumns,
+			stock.Name, amount, stock.Unit, sqliteDate(stock.BestBefore)))
+		return err
+	})
+	if errors.Is(err, ErrNotFound) {
+		return PantryItem{}, err
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error moving purchased item", "item_id", itemID, "err", err)
+		return PantryItem{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Moved purchased item to the pantry", "item_id", itemID, "pantry_id", item.ID, "stock", item.Stock)
+	return item, nil
+}
+
+// --- SQLite RecurringStore ---
+
+// sqliteRecurringColumns are the recurring_items columns read by scanSQLiteRecurringItem.
+const sqliteRecurringColumns = "id, name, quantity, schedule, next_run_at, last_run_at, created_at"
+
+// scanSQLiteRecurringItem reads one recurring item row, converting the text timestamps.
+func scanSQLiteRecurringItem(row interface{ Scan(...any) error }) (RecurringItem, error) {
+	var item RecurringItem
+	var nextRun, createdAt string
+	var lastRun sql.NullString
+	if err := row.Scan(&item.ID, &item.Name, &item.Quantity, &item.Schedule, &nextRun, &lastRun, &createdAt); err != nil {
+		return RecurringItem{}, err
+	}
+	var err error
+	if item.NextRun, err = parseSQLiteTime(nextRun); err != nil {
+		return RecurringItem{}, err
+	}
+	if lastRun.Valid {
+		t, err := parseSQLiteTime(lastRun.String)
+		if err != nil {
+			return RecurringItem{}, err
+		}
+		item.LastRun = &t
+	}
+	if item.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+		return RecurringItem{}, err
+	}
+	return item, nil
+}
+
+// querySQLiteRecurring runs a query returning sqliteRecurringColumns rows.
+func (s *SQLiteStore) querySQLiteRecurring(ctx context.Context, query string, args ...any) ([]RecurringItem, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying recurring items", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	items := []RecurringItem{}
+	for rows.Next() {
+		item, err := scanSQLiteRecurringItem(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning recurring item row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating recurring item rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return items, nil
+}
+
+// ListRecurring retrieves all recurring items, ordered by name
+func (s *SQLiteStore) ListRecurring(ctx context.Context) ([]RecurringItem, error) {
+	return s.querySQLiteRecurring(ctx, "SELECT "+sqliteRecurringColumns+" FROM recurring_items ORDER BY lower(name), id")
+}
+
+// GetRecurring retrieves a single recurring item by ID
+func (s *SQLiteStore) GetRecurring(ctx context.Context, id int) (RecurringItem, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+sqliteRecurringColumns+" FROM recurring_items WHERE id = ?", id)
+	item, err := scanSQLiteRecurringItem(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RecurringItem{}, fmt.Errorf("recurring item with ID %d: %w", id, ErrRecurringItemNotFound)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying recurring item", "id", id, "err", err)
+		return RecurringItem{}, fmt.Errorf("database query error: %w", err)
+	}
+	return item, nil
+}
+
+// CreateRecurring inserts a new recurring item
+func (s *SQLiteStore) CreateRecurring(ctx context.Context, item RecurringItem) (RecurringItem, error) {
+	item, err := normalizeRecurringItem(item, FieldLimits{}, time.Now())
+	if err != nil {
+		return RecurringItem{}, err
+	}
+
+	row := s.writer.QueryRowContext(ctx, `
+		INSERT INTO recurring_items (name, quantity, schedule, next_run_at) VALUES (?, ?, ?, ?)
+		RETURNING `+sqliteRecurringColumns,
+		item.Name, item.Quantity, item.Schedule, sqliteTime(item.NextRun))
+	created, err := scanSQLiteRecurringItem(row)
+	if err != nil {
+		loggerFrom(ctx).Error("Error inserting recurring item", "err", err)
+		return RecurringItem{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Added recurring item", "id", created.ID, "name", created.Name, "schedule", created.Schedule)
+	return created, nil
+}
+
+// UpdateRecurring replaces the definition and next run of an existing recurring item
+func (s *SQLiteStore) UpdateRecurring(ctx context.Context, item RecurringItem) (RecurringItem, error) {
+	item, err := normalizeRecurringItem(item, FieldLimits{}, time.Now())
+	if err != nil {
+		return RecurringItem{}, err
+	}
+
+	row := s.writer.QueryRowContext(ctx, `
+		UPDATE recurring_items SET name = ?, quantity = ?, schedule = ?, next_run_at = ?
+		WHERE id = ?
+		RETURNING `+sqliteRecurringColumns,
+		item.Name, item.Quantity, item.Schedule, sqliteTime(item.NextRun), item.ID)
+	updated, err := scanSQLiteRecurringItem(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RecurringItem{}, fmt.Errorf("recurring item with ID %d: %w", item.ID, ErrRecurringItemNotFound)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error updating recurring item", "id", item.ID, "err", err)
+		return RecurringItem{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Updated recurring item", "id", updated.ID, "name", updated.Name, "schedule", updated.Schedule)
+	return updated, nil
+}
+
+// DeleteRecurring removes a recurring item by ID
+func (s *SQLiteStore) DeleteRecurring(ctx context.Context, id int) error {
+	res, err := s.writer.ExecContext(ctx, "DELETE FROM recurring_items WHERE id = ?", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting recurring item", "id", id, "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("recurring item with ID %d: %w", id, ErrRecurringItemNotFound)
+	}
+	loggerFrom(ctx).Info("Deleted recurring item", "id", id)
+	return nil
+}
+
+// DueRecurring retrieves the recurring items due at now, earliest first
+func (s *SQLiteStore) DueRecurring(ctx context.Context, now time.Time) ([]RecurringItem, error) {
+	return s.querySQLiteRecurring(ctx,
+		"SELECT "+sqliteRecurringColumns+" FROM recurring_items WHERE next_run_at <= ? ORDER BY next_run_at, id",
+		sqliteTime(now))
+}
+
+// RecordRun sets the last and next run of a recurring item
+func (s *SQLiteStore) RecordRun(ctx context.Context, id int, ranAt, next time.Time) error {
+	res, err := s.writer.ExecContext(ctx,
+		"UPDATE recurring_items SET last_run_at = ?, next_run_at = ? WHERE id = ?",
+		sqliteTime(ranAt), sqliteTime(next), id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error recording recurring run", "id", id, "err", err)
+		return fmt.Errorf("database update error: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("recurring item with ID %d: %w", id, ErrRecurringItemNotFound)
+	}
+	return nil
+}
+
+// --- SQLite PriceStore ---
+
+// sqlitePriceColumns are the item_prices columns read by scanSQLitePrice.
+const sqlitePriceColumns = "name, unit_price, currency, updated_at"
+
+// scanSQLitePrice reads one price row, converting the text timestamp.
+func scanSQLitePrice(row interface{ Scan(...any) error }) (Price, error) {
+	var price Price
+	var updatedAt string
+	if err := row.Scan(&price.Name, &price.UnitPrice, &price.Currency, &updatedAt); err != nil {
+		return Price{}, err
+	}
+	t, err := parseSQLiteTime(updatedAt)
+	if err != nil {
+		return Price{}, err
+	}
+	price.UpdatedAt = t
+	return price, nil
+}
+
+// ListPrices retrieves every remembered price, ordered by name
+func (s *SQLiteStore) ListPrices(ctx context.Context) ([]Price, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+sqlitePriceColumns+" FROM item_prices ORDER BY lower(name)")
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying prices", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	prices := []Price{}
+	for rows.Next() {
+		price, err := scanSQLitePrice(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning price row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		prices = append(prices, price)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating price rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return prices, nil
+}
+
+// LastPrice retrieves the remembered price for a name, ignoring case
+func (s *SQLiteStore) LastPrice(ctx context.Context, name string) (Price, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+sqlitePriceColumns+" FROM item_prices WHERE lower(name) = lower(?)", name)
+	price, err := scanSQLitePrice(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Price{}, fmt.Errorf("price of %q: %w", name, ErrPriceNotFound)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying price", "name", name, "err", err)
+		return Price{}, fmt.Errorf("database query error: %w", err)
+	}
+	return price, nil
+}
+
+// RecordPrice inserts a price, replacing the one remembered for the same name
+func (s *SQLiteStore) RecordPrice(ctx context.Context, price Price) (Price, error) {
+	price, err := normalizePrice(price)
+	if err != nil {
+		return Price{}, err
+	}
+
+	row := s.writer.QueryRowContext(ctx, `
+		INSERT INTO item_prices (name, unit_price, currency) VALUES (?, ?, ?)
+		ON CONFLICT (lower(name)) DO UPDATE SET
+			name = excluded.name,
+			unit_price = excluded.unit_price,
+			currency = excluded.currency,
+			updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+		RETURNING `+sqlitePriceColumns,
+		price.Name, price.UnitPrice, price.Currency)
+	recorded, err := scanSQLitePrice(row)
+	if err != nil {
+		loggerFrom(ctx).Error("Error recording price", "name", price.Name, "err", err)
+		return Price{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Recorded price", "name", recorded.Name, "unit_price", recorded.UnitPrice, "currency", recorded.Currency)
+	return recorded, nil
+}
+
+// GetBudget retrieves the list's budget
+func (s *SQLiteStore) GetBudget(ctx context.Context) (Money, error) {
+	var budget Money
+	err := s.db.QueryRowContext(ctx, "SELECT amount, currency FROM list_budget WHERE id = 1").Scan(&budget.Amount, &budget.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Money{}, ErrBudgetNotSet
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying budget", "err", err)
+		return Money{}, fmt.Errorf("database query error: %w", err)
+	}
+	return budget, nil
+}
+
+// SetBudget inserts or replaces the list's budget
+func (s *SQLiteStore) SetBudget(ctx context.Context, budget Money) (Money, error) {
+	budget, err := normalizeBudget(budget)
+	if err != nil {
+		return Money{}, err
+	}
+
+	if _, err := s.writer.ExecContext(ctx, `
+		INSERT INTO list_budget (id, amount, currency) VALUES (1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET amount = excluded.amount, currency = excluded.currency`,
+		budget.Amount, budget.Currency); err != nil {
+		loggerFrom(ctx).Error("Error setting budget", "err", err)
+		return Money{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Set budget", "amount", budget.Amount, "currency", budget.Currency)
+	return budget, nil
+}
+
+// DeleteBudget removes the list's budget
+func (s *SQLiteStore) DeleteBudget(ctx context.Context) error {
+	res, err := s.writer.ExecContext(ctx, "DELETE FROM list_budget WHERE id = 1")
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting budget", "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrBudgetNotSet
+	}
+	loggerFrom(ctx).Info("Deleted budget")
+	return nil
+}
+
+// --- SQLite LayoutStore ---
+
+// sqliteStoreQuery selects stores with their sections, one row per section.
+const sqliteStoreQuery = `
+	SELECT s.id, s.name, s.last_checkoff_name, s.last_checkoff_section, s.last_checkoff_at, s.created_at, x.name
+	FROM stores s LEFT JOIN store_sections x ON x.store_id = s.id`
+
+// querySQLiteStores runs a store query and assembles its rows.
+func (s *SQLiteStore) querySQLiteStores(ctx context.Context, query string, args ...any) ([]StoreLayout, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stores := []StoreLayout{}
+	for rows.Next() {
+		var st StoreLayout
+		var createdAt string
+		var checkOffName, checkOffSection, checkOffAt, section *string
+		if err := rows.Scan(&st.ID, &st.Name, &checkOffName, &checkOffSection, &checkOffAt, &createdAt, &section); err != nil {
+			return nil, err
+		}
+		if st.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+			return nil, err
+		}
+		if checkOffName != nil && checkOffAt != nil {
+			st.LastCheckOff = &CheckOff{Name: *checkOffName}
+			if checkOffSection != nil {
+				st.LastCheckOff.Section = *checkOffSection
+			}
+			if st.LastCheckOff.At, err = parseSQLiteTime(*checkOffAt); err != nil {
+				return nil, err
+			}
+		}
+		stores = appendStoreRow(stores, st, section)
+	}
+	return stores, rows.Err()
+}
+
+// ListStores retrieves all stores, ordered by name
+func (s *SQLiteStore) ListStores(ctx context.Context) ([]StoreLayout, error) {
+	stores, err := s.querySQLiteStores(ctx, sqliteStoreQuery+" ORDER BY lower(s.name), s.id, x.position")
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying stores", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	return stores, nil
+}
+
+// GetStore retrieves a single store by ID
+func (s *SQLiteStore) GetStore(ctx context.Context, id int) (StoreLayout, error) {
+	stores, err := s.querySQLiteStores(ctx, sqliteStoreQuery+" WHERE s.id = ? ORDER BY x.position", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying store", "id", id, "err", err)
+		return StoreLayout{}, fmt.Errorf("database query error: %w", err)
+	}
+	if len(stores) == 0 {
+		return StoreLayout{}, fmt.Errorf("store with ID %d: %w", id, ErrStoreNotFound)
+	}
+	return stores[0], nil
+}
+
+// CreateStore inserts a new store and its sections in one transaction
+func (s *SQLiteStore) CreateStore(ctx context.Context, store StoreLayout) (StoreLayout, error) {
+	store, err := normalizeStore(store)
+	if err != nil {
+		return StoreLayout{}, err
+	}
+
+	store.LastCheckOff = nil
+	err = s.writeTx(ctx, func(tx *sql.Tx) error {
+		var createdAt string
+		if err := tx.QueryRowContext(ctx,
+			"INSERT INTO stores (name) VALUES (?) RETURNING id, created_at",
+			store.Name).Scan(&store.ID, &createdAt); err != nil {
+			return err
+		}
+		t, err := parseSQLiteTime(createdAt)
+		if err != nil {
+			return err
+		}
+		store.CreatedAt = t
+		return insertSQLiteSections(ctx, tx, store)
+	})
+	if err != nil {
+		loggerFrom(ctx).Error("Error inserting store", "err", err)
+		return StoreLayout{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Added store", "id", store.ID, "name", store.Name)
+	return store, nil
+}
+
+// UpdateStore replaces the name and sections of a store in one transaction
+func (s *SQLiteStore) UpdateStore(ctx context.Context, store StoreLayout) (StoreLayout, error) {
+	store, err := normalizeStore(store)
+	if err != nil {
+		return StoreLayout{}, err
+	}
+
+	err = s.writeTx(ctx, func(tx *sql.Tx) error {
+		var createdAt string
+		var checkOffName, checkOffSection, checkOffAt *string
+		if err := tx.QueryRowContext(ctx,
+			"UPDATE stores SET name = ? WHERE id = ? RETURNING created_at, last_checkoff_name, last_checkoff_section, last_checkoff_at",
+			store.Name, store.ID).Scan(&createdAt, &checkOffName, &checkOffSection, &checkOffAt); err != nil {
+			return err
+		}
+		if store.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+			return err
+		}
+		store.LastCheckOff = nil
+		if checkOffName != nil && checkOffAt != nil {
+			store.LastCheckOff = &CheckOff{Name: *checkOffName}
+			if checkOffSection != nil {
+				store.LastCheckOff.Section = *checkOffSection
+			}
+			if store.LastCheckOff.At, err = parseSQLiteTime(*checkOffAt); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM store_sections WHERE store_id = ?", store.ID); err != nil {
+			return err
+		}
+		return insertSQLiteSections(ctx, tx, store)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return StoreLayout{}, fmt.Errorf("store with ID %d: %w", store.ID, ErrStoreNotFound)
+		}
+		loggerFrom(ctx).Error("Error updating store", "id", store.ID, "err", err)
+		return StoreLayout{}, fmt.Errorf("database update error: %w", err)
+	}
+	loggerFrom(ctx).Info("Updated store", "id", store.ID, "name", store.Name)
+	return store, nil
+}
+
+// DeleteStore removes a store by ID; its sections and mappings go with it
+func (s *SQLiteStore) DeleteStore(ctx context.Context, id int) error {
+	res, err := s.writer.ExecContext(ctx, "DELETE FROM stores WHERE id = ?", id)
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting store", "id", id, "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("store with ID %d: %w", id, ErrStoreNotFound)
+	}
+	loggerFrom(ctx).Info("Deleted store", "id", id)
+	return nil
+}
+
+// insertSQLiteSections stores the sections of store in walking order.
+func insertSQLiteSections(ctx context.Context, tx *sql.Tx, store StoreLayout) error {
+	for i, section := range store.Sections {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO store_sections (store_id, position, name) VALUES (?, ?, ?)",
+			store.ID, i+1, section); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSQLiteForeignKeyViolation reports whether err is a FOREIGN KEY constraint error, as
+// when a mapping is set for a store that does not exist.
+func isSQLiteForeignKeyViolation(err error) bool {
+	var serr *sqlite.Error
+	return errors.As(err, &serr) && serr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY
+}
+
+// sqliteMappingColumns are the section_mappings columns read by scanSQLiteMapping.
+const sqliteMappingColumns = "name, section, learned, updated_at"
+
+// scanSQLiteMapping reads one mapping row, converting the text timestamp.
+func scanSQLiteMapping(row interface{ Scan(...any) error }) (SectionMapping, error) {
+	var mapping SectionMapping
+	var updatedAt string
+	if err := row.Scan(&mapping.Name, &mapping.Section, &mapping.Learned, &updatedAt); err != nil {
+		return SectionMapping{}, err
+	}
+	t, err := parseSQLiteTime(updatedAt)
+	if err != nil {
+		return SectionMapping{}, err
+	}
+	mapping.UpdatedAt = t
+	return mapping, nil
+}
+
+// ListMappings retrieves the mappings of a store, ordered by name
+func (s *SQLiteStore) ListMappings(ctx context.Context, storeID int) ([]SectionMapping, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM stores WHERE id = ?)", storeID).Scan(&exists); err != nil {
+		loggerFrom(ctx).Error("Error querying store", "id", storeID, "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("store with ID %d: %w", storeID, ErrStoreNotFound)
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+sqliteMappingColumns+" FROM section_mappings WHERE store_id = ? ORDER BY lower(name)", storeID)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying section mappings", "store", storeID, "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	mappings := []SectionMapping{}
+	for rows.Next() {
+		mapping, err := scanSQLiteMapping(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning section mapping row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		mappings = append(mappings, mapping)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating section mapping rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return mappings, nil
+}
+
+// GetMapping retrieves the mapping for a name in a store, ignoring case
+func (s *SQLiteStore) GetMapping(ctx context.Context, storeID int, name string) (SectionMapping, error) {
+	row := s.db.QueryRowContext(ctx,
+		"SELECT "+sqliteMappingColumns+" FROM section_mappings WHERE store_id = ? AND lower(name) = lower(?)", storeID, name)
+	mapping, err := scanSQLiteMapping(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SectionMapping{}, fmt.Errorf("%q in store %d: %w", name, storeID, ErrMappingNotFound)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying section mapping", "store", storeID, "name", name, "err", err)
+		return SectionMapping{}, fmt.Errorf("database query error: %w", err)
+	}
+	return mapping, nil
+}
+
+// SetMapping inserts a mapping, replacing the one for the same name in the store
+func (s *SQLiteStore) SetMapping(ctx context.Context, storeID int, mapping SectionMapping) (SectionMapping, error) {
+	mapping, err := normalizeMapping(mapping, FieldLimits{})
+	if err != nil {
+		return SectionMapping{}, err
+	}
+
+	row := s.writer.QueryRowContext(ctx, `
+		INSERT INTO section_mappings (store_id, name, section, learned) VALUES (?, ?, ?, ?)
+		ON CONFLICT (store_id, lower(name)) DO UPDATE SET
+			name = excluded.name,
+			section = excluded.section,
+			learned = excluded.learned,
+			updated_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+		RETURNING `+sqliteMappingColumns,
+		storeID, mapping.Name, mapping.Section, mapping.Learned)
+	set, err := scanSQLiteMapping(row)
+	if err != nil {
+		if isSQLiteForeignKeyViolation(err) {
+			return SectionMapping{}, fmt.Errorf("store with ID %d: %w", storeID, ErrStoreNotFound)
+		}
+		loggerFrom(ctx).Error("Error setting section mapping", "store", storeID, "name", mapping.Name, "err", err)
+		return SectionMapping{}, fmt.Errorf("database insert error: %w", err)
+	}
+	loggerFrom(ctx).Info("Set section mapping", "store", storeID, "name", set.Name, "section", set.Section, "learned", set.Learned)
+	return set, nil
+}
+
+// DeleteMapping removes the mapping for a name from a store, ignoring case
+func (s *SQLiteStore) DeleteMapping(ctx context.Context, storeID int, name string) error {
+	res, err := s.writer.ExecContext(ctx, "DELETE FROM section_mappings WHERE store_id = ? AND lower(name) = lower(?)", storeID, name)
+	if err != nil {
+		loggerFrom(ctx).Error("Error deleting section mapping", "store", storeID, "name", name, "err", err)
+		return fmt.Errorf("database delete error: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%q in store %d: %w", name, storeID, ErrMappingNotFound)
+	}
+	loggerFrom(ctx).Info("Deleted section mapping", "store", storeID, "name", name)
+	return nil
+}
+
+// RecordCheckOff stores the last item checked off in a store
+func (s *SQLiteStore) RecordCheckOff(ctx context.Context, storeID int, checkOff CheckOff) error {
+	res, err := s.writer.ExecContext(ctx,
+		"UPDATE stores SET last_checkoff_name = ?, last_checkoff_section = ?, last_checkoff_at = ? WHERE id = ?",
+		checkOff.Name, sql.NullString{String: checkOff.Section, Valid: checkOff.Section != ""}, sqliteTime(checkOff.At), storeID)
+	if err != nil {
+		loggerFrom(ctx).Error("Error recording check-off", "store", storeID, "err", err)
+		return fmt.Errorf("database update error: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("store with ID %d: %w", storeID, ErrStoreNotFound)
+	}
+	return nil
+}
+
+// --- SQLite HistoryStore ---
+
+// RecordUse counts one more use of an item's name and remembers its quantity
+func (s *SQLiteStore) RecordUse(ctx context.Context, item Item, at time.Time) error {
+	// rank: log2(2^old + 2^new), see addHistoryRanks
+	if _, err := s.writer.ExecContext(ctx, `
+		INSERT INTO item_history (name, quantity, uses, last_used_at, rank) VALUES (?, ?, 1, ?, ?)
+		ON CONFLICT (lower(name)) DO UPDATE SET
+			name = excluded.name,
+			quantity = excluded.quantity,
+			uses = item_history.uses + 1,
+			last_used_at = excluded.last_used_at,
+			rank = max(item_history.rank, excluded.rank) + ln(1 + pow(2, -abs(item_history.rank - excluded.rank))) / ln(2)`,
+		item.Name, item.Quantity, sqliteTime(at), historyRank(at)); err != nil {
+		loggerFrom(ctx).Error("Error recording item history", "name", item.Name, "err", err)
+		return fmt.Errorf("database insert error: %w", err)
+	}
+	return nil
+}
+
+// Suggest retrieves the best-ranked names starting with a prefix, ignoring case
+func (s *SQLiteStore) Suggest(ctx context.Context, prefix string, limit int) ([]Suggestion, error) {
+	// A range on lower(name) uses item_history_name_key; U+10FFFF sorts after any
+	// character that can follow the prefix.
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT name, quantity, uses, last_used_at FROM item_history
+		WHERE lower(name) >= lower(?1) AND lower(name) < lower(?1) || char(1114111)
+		ORDER BY rank DESC, lower(name)
+		LIMIT ?2`, prefix, limit)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying suggestions", "prefix", prefix, "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []Suggestion{}
+	for rows.Next() {
+		var suggestion Suggestion
+		var lastUsed string
+		if err := rows.Scan(&suggestion.Name, &suggestion.Quantity, &suggestion.Uses, &lastUsed); err != nil {
+			loggerFrom(ctx).Error("Error scanning suggestion row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		if suggestion.LastUsed, err = parseSQLiteTime(lastUsed); err != nil {
+			loggerFrom(ctx).Error("Error scanning suggestion row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating suggestion rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return suggestions, nil
+}
+
+// --- SQLite SearchStore ---
+
+// Search scores every item and history entry against the terms. SQLite has no trigram
+// index, so this reads both tables; they stay small for a household.
+func (s *SQLiteStore) Search(ctx context.Context, terms []string, limit int) ([]SearchResult, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, uid, name, quantity, created_at FROM items
+		UNION ALL
+		SELECT NULL, NULL, name, quantity, last_used_at FROM item_history`)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying search candidates", "err", err)
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []SearchResult
+	for rows.Next() {
+		var c SearchResult
+		var uid sql.NullString
+		var at string
+		if err := rows.Scan(&c.ID, &uid, &c.Name, &c.Quantity, &at); err != nil {
+			loggerFrom(ctx).Error("Error scanning search row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		if c.At, err = parseSQLiteTime(at); err != nil {
+			loggerFrom(ctx).Error("Error scanning search row", "err", err)
+			return nil, fmt.Errorf("database scan error: %w", err)
+		}
+		c.UID = uid.String
+		c.Source = SearchSourceList
+		if c.ID == nil {
+			c.Source = SearchSourceHistory
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating search rows", "err", err)
+		return nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return rankSearch(terms, candidates, limit), nil
+}
+
+// --- SQLite SyncStore ---
+
+// sqliteSyncColumns are the items columns read by scanSQLiteSyncRecord. Change times left
+// NULL date from the item's creation.
+const sqliteSyncColumns = sqliteItemColumns + `, version,
+	COALESCE(name_changed_at, created_at), COALESCE(quantity_changed_at, created_at), COALESCE(price_changed_at, created_at)`
+
+// scanSQLiteSyncRecord reads an item row with its sync columns.
+func scanSQLiteSyncRecord(row interface{ Scan(...any) error }) (syncRecord, error) {
+	var rec syncRecord
+	var uid, currency sql.NullString
+	var createdAt, nameAt, quantityAt, priceAt string
+	if err := row.Scan(&rec.ID, &uid, &rec.Name, &rec.Quantity, &rec.UnitPrice, &currency, &createdAt,
+		&rec.Version, &nameAt, &quantityAt, &priceAt); err != nil {
+		return syncRecord{}, err
+	}
+	rec.UID, rec.Currency = uid.String, currency.String
+	for _, t := range []struct {
+		dst *time.Time
+		src string
+	}{{&rec.CreatedAt, createdAt}, {&rec.NameAt, nameAt}, {&rec.QuantityAt, quantityAt}, {&rec.PriceAt, priceAt}} {
+		parsed, err := parseSQLiteTime(t.src)
+		if err != nil {
+			return syncRecord{}, err
+		}
+		*t.dst = parsed
+	}
+	return rec, nil
+}
+
+// scanSQLiteTombstone reads an item_tombstones row.
+func scanSQLiteTombstone(row interface{ Scan(...any) error }) (Tombstone, error) {
+	var t Tombstone
+	var uid sql.NullString
+	var deletedAt string
+	if err := row.Scan(&t.ID, &uid, &t.Version, &deletedAt); err != nil {
+		return Tombstone{}, err
+	}
+	t.UID = uid.String
+	var err error
+	t.DeletedAt, err = parseSQLiteTime(deletedAt)
+	return t, err
+}
+
+// Changes retrieves the items and tombstones with a version above since
+func (s *SQLiteStore) Changes(ctx context.Context, since int64, limit int) ([]SyncItem, []Tombstone, error) {
+	// Both lists must come from one snapshot, or a change committed in between could be
+	// skipped by the next token
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying changes", "since", since, "err", err)
+		return nil, nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer tx.Rollback()
+
+	items := []SyncItem{}
+	rows, err := tx.QueryContext(ctx, "SELECT "+sqliteSyncColumns+" FROM items WHERE version > ? ORDER BY version LIMIT ?", since, limit)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying changes", "since", since, "err", err)
+		return nil, nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		rec, err := scanSQLiteSyncRecord(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning change row", "err", err)
+			return nil, nil, fmt.Errorf("database scan error: %w", err)
+		}
+		items = append(items, rec.SyncItem)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating change rows", "err", err)
+		return nil, nil, fmt.Errorf("database iteration error: %w", err)
+	}
+
+	tombstones := []Tombstone{}
+	rows, err = tx.QueryContext(ctx, "SELECT item_id, uid, version, deleted_at FROM item_tombstones WHERE version > ? ORDER BY version LIMIT ?", since, limit)
+	if err != nil {
+		loggerFrom(ctx).Error("Error querying tombstones", "since", since, "err", err)
+		return nil, nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		t, err := scanSQLiteTombstone(rows)
+		if err != nil {
+			loggerFrom(ctx).Error("Error scanning tombstone row", "err", err)
+			return nil, nil, fmt.Errorf("database scan error: %w", err)
+		}
+		tombstones = append(tombstones, t)
+	}
+	if err := rows.Err(); err != nil {
+		loggerFrom(ctx).Error("Error after iterating tombstone rows", "err", err)
+		return nil, nil, fmt.Errorf("database iteration error: %w", err)
+	}
+	return items, tombstones, nil
+}
+
+// ApplyMutation resolves an offline change against the stored item and writes it
+func (s *SQLiteStore) ApplyMutation(ctx context.Context, m Mutation) (MutationResult, error) {
+	m, err := normalizeMutation(m, FieldLimits{})
+	if err != nil {
+		return MutationResult{}, err
+	}
+
+	var plan syncPlan
+	err = s.writeTx(ctx, func(tx *sql.Tx) error {
+		itemTarget, tombstoneTarget, arg := "id = ?", "item_id = ?", any(m.ID)
+		if m.UID != "" {
+			itemTarget, tombstoneTarget, arg = "uid = ?", "uid = ?", m.UID
+		}
+		var current *syncRecord
+		rec, err := scanSQLiteSyncRecord(tx.QueryRowContext(ctx, "SELECT "+sqliteSyncColumns+" FROM items WHERE "+itemTarget, arg))
+		switch {
+		case err == nil:
+			current = &rec
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+		var deleted *Tombstone
+		if current == nil {
+			t, err := scanSQLiteTombstone(tx.QueryRowContext(ctx,
+				"SELECT item_id, uid, version, deleted_at FROM item_tombstones WHERE "+tombstoneTarget+" ORDER BY version DESC LIMIT 1", arg))
+			switch {
+			case err == nil:
+				deleted = &t
+			case !errors.Is(err, sql.ErrNoRows):
+				return err
+			}
+		}
+
+		plan = resolveMutation(current, deleted, m)
+		r := plan.Record
+		id := r.ID
+		switch plan.Action {
+		case syncCreate:
+			if err := tx.QueryRowContext(ctx, `
+				INSERT INTO items (uid, name, quantity, unit_price, currency, name_changed_at, quantity_changed_at, price_changed_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+				r.UID, r.Name, r.Quantity, r.UnitPrice, sqliteCurrency(r.Currency),
+				sqliteTime(r.NameAt), sqliteTime(r.QuantityAt), sqliteTime(r.PriceAt)).Scan(&id); err != nil {
+				return err
+			}
+		case syncUpdate:
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE items SET name = ?, quantity = ?, unit_price = ?, currency = ?,
+					name_changed_at = ?, quantity_changed_at = ?, price_changed_at = ?
+				WHERE id = ?`,
+				r.Name, r.Quantity, r.UnitPrice, sqliteCurrency(r.Currency),
+				sqliteTime(r.NameAt), sqliteTime(r.QuantityAt), sqliteTime(r.PriceAt), r.ID); err != nil {
+				return err
+			}
+		case syncDelete:
+			_, err := tx.ExecContext(ctx, "DELETE FROM items WHERE id = ?", r.ID)
+			return err
+		default:
+			return nil
+		}
+		// The triggers set the version after the write
+		written, err := scanSQLiteSyncRecord(tx.QueryRowContext(ctx, "SELECT "+sqliteSyncColumns+" FROM items WHERE id = ?", id))
+		if err != nil {
+			return err
+		}
+		plan.Result.ID, plan.Result.Item = written.ID, &written.SyncItem
+		return nil
+	})
+	if err != nil {
+		loggerFrom(ctx).Error("Error applying mutation", "uid", m.UID, "id", m.ID, "err", err)
+		return MutationResult{}, fmt.Errorf("database update error: %w", err)
+	}
+	if plan.Action != "" {
+		loggerFrom(ctx).Info("Applied mutation", "uid", plan.Result.UID, "id", plan.Result.ID, "status", plan.Result.Status)
+	}
+	return plan.Result, nil
+}
+
+// Count returns the number of items without loading them.
+func (s *SQLiteStore) Count(ctx context.Context) (int, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM items").Scan(&n); err != nil {
+		return 0, fmt.Errorf("database query error: %w", err)
+	}
+	return n, nil
+}
+
+// SchemaVersion returns the latest applied migration version.
+func (s *SQLiteStore) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	if err := s.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("unable to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Ping checks that the database file can be reached by both handles.
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return err
+	}
+	return s.writer.PingContext(ctx)
+}
+
+// Close closes both connection handles.
+func (s *SQLiteStore) Close() error {
+	return errors.Join(s.writer.Close(), s.db.Close())
+}
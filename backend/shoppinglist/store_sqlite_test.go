@@ -0,0 +1,279 @@
+package shoppinglist
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newSQLiteStore opens a fresh SQLite database in a temporary directory.
+func newSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	store, err := OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "items.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteStoreConformance(t *testing.T) {
+	testItemStoreConformance(t, func(t *testing.T) ItemStore {
+		return newSQLiteStore(t)
+	})
+	testRecipeStoreConformance(t, func(t *testing.T) RecipeStore {
+		return newSQLiteStore(t)
+	})
+	testPantryStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		PantryStore
+	} {
+		return newSQLiteStore(t)
+	})
+	testRecurringStoreConformance(t, func(t *testing.T) RecurringStore {
+		return newSQLiteStore(t)
+	})
+	testPriceStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		PriceStore
+	} {
+		return newSQLiteStore(t)
+	})
+	testLayoutStoreConformance(t, func(t *testing.T) LayoutStore {
+		return newSQLiteStore(t)
+	})
+	testHistoryStoreConformance(t, func(t *testing.T) HistoryStore {
+		return newSQLiteStore(t)
+	})
+	testSearchStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		HistoryStore
+		SearchStore
+	} {
+		return newSQLiteStore(t)
+	})
+	testSyncStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		SyncStore
+	} {
+		return newSQLiteStore(t)
+	})
+	testUIDStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		UIDStore
+		SyncStore
+	} {
+		return newSQLiteStore(t)
+	})
+}
+
+func TestSQLitePathFromURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{url: "sqlite:///var/lib/shopping/items.db", want: "/var/lib/shopping/items.db"},
+		{url: "sqlite://items.db", want: "items.db"},
+		{url: "sqlite://", wantErr: true},
+		{url: "sqlite:///", wantErr: true},
+		{url: "postgres://db/shopping", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SQLitePathFromURL(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SQLitePathFromURL(%q): unexpected error state: %v", tt.url, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("SQLitePathFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestSQLiteStoreReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "items.db")
+
+	store, err := OpenSQLite(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenSQLite failed: %v", err)
+	}
+	created, err := store.Create(ctx, Item{Name: "Milk", Quantity: "1"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	store.Close()
+
+	// Reopening must keep the data and not re-run migrations
+	reopened, err := OpenSQLite(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenSQLite (reopen) failed: %v", err)
+	}
+	defer reopened.Close()
+	got, err := reopened.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get after reopen failed: %v", err)
+	}
+	if got.Name != "Milk" || !got.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("Unexpected item after reopen: %+v", got)
+	}
+
+	var version int
+	if err := reopened.db.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		t.Fatalf("Reading schema version failed: %v", err)
+	}
+	if version != latestSchemaVersion() {
+		t.Errorf("Expected schema version %d, got %d", latestSchemaVersion(), version)
+	}
+}
+
+func TestSQLiteStoreOpensPathWithURIChars(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "a#b?c%20")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	path := filepath.Join(dir, "items.db")
+	store, err := OpenSQLite(context.Background(), path)
+	if err != nil {
+		t.Fatalf("OpenSQLite failed: %v", err)
+	}
+	store.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the database at %s, got %v", path, err)
+	}
+}
+
+func TestSQLiteStoreConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.Create(ctx, Item{Name: "Item", Quantity: "1"}); err != nil {
+				t.Errorf("Create failed: %v", err)
+			}
+			if _, err := store.List(ctx); err != nil {
+				t.Errorf("List failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	items, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(items) != 20 {
+		t.Errorf("Expected 20 items, got %d", len(items))
+	}
+}
+
+func TestSQLiteRejectsUnnormalizedText(t *testing.T) {
+	store := newSQLiteStore(t)
+	ctx := context.Background()
+	created, err := store.Create(ctx, Item{Name: "Milk", Quantity: "1"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Writes that bypass normalizeItem are stopped by the schema
+	for _, name := range []string{" Milk", "Whole  milk", "Milk\n", "Milk\u202e", "Mi\u200blk", strings.Repeat("a", MaxNameLength+1)} {
+		if _, err := store.writer.ExecContext(ctx, "INSERT INTO items (name, quantity) VALUES (?, '1')", name); err == nil {
+			t.Errorf("Expected INSERT of %q to be rejected", name)
+		}
+		if _, err := store.writer.ExecContext(ctx, "UPDATE items SET name = ? WHERE id = ?", name, created.ID); err == nil {
+			t.Errorf("Expected UPDATE to %q to be rejected", name)
+		}
+	}
+	if _, err := store.writer.ExecContext(ctx, "INSERT INTO items (name, quantity) VALUES (?, '1')", "Caf\u00e9 au lait \U0001F468\u200d\U0001F373"); err != nil {
+		t.Errorf("Expected a normalized name to be accepted, got %v", err)
+	}
+}
+
+func TestSQLiteRequiresCurrencyWithPrice(t *testing.T) {
+	store := newSQLiteStore(t)
+	ctx := context.Background()
+	price := int64(250)
+	created, err := store.Create(ctx, Item{Name: "Milk", Quantity: "1", UnitPrice: &price, Currency: "EUR"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Like items_price_currency_check on PostgreSQL: both or neither
+	for _, stmt := range []string{
+		"INSERT INTO items (name, quantity, unit_price) VALUES ('Bread', '1', 250)",
+		"INSERT INTO items (name, quantity, currency) VALUES ('Bread', '1', 'EUR')",
+	} {
+		if _, err := store.writer.ExecContext(ctx, stmt); err == nil {
+			t.Errorf("Expected %q to be rejected", stmt)
+		}
+	}
+	for _, stmt := range []string{
+		"UPDATE items SET unit_price = NULL WHERE id = ?",
+		"UPDATE items SET currency = NULL WHERE id = ?",
+	} {
+		if _, err := store.writer.ExecContext(ctx, stmt, created.ID); err == nil {
+			t.Errorf("Expected %q to be rejected", stmt)
+		}
+	}
+	if _, err := store.writer.ExecContext(ctx, "UPDATE items SET unit_price = NULL, currency = NULL WHERE id = ?", created.ID); err != nil {
+		t.Errorf("Expected clearing both to be accepted, got %v", err)
+	}
+}
+
+func TestSQLiteAssignsUIDsToRawInserts(t *testing.T) {
+	store := newSQLiteStore(t)
+	ctx := context.Background()
+
+	// Rows written without a uid get a UUIDv7 stamped with created_at, like the backfill
+	if _, err := store.writer.ExecContext(ctx, "INSERT INTO items (name, quantity, created_at) VALUES ('Milk', '1', '2026-10-18T15:08:59.255Z')"); err != nil {
+		t.Fatalf("INSERT failed: %v", err)
+	}
+	items, err := store.List(ctx)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Expected one item, got %+v (err %v)", items, err)
+	}
+	uid, ok := parseUUID(items[0].UID)
+	if !ok || !strings.HasPrefix(uid, "01a14f8f-13f7-7") || !strings.ContainsRune("89ab", rune(uid[19])) {
+		t.Errorf("Expected a UUIDv7 for 2026-10-18T15:08:59.255Z, got %q", items[0].UID)
+	}
+	if got, err := store.GetByUID(ctx, uid); err != nil || got.ID != items[0].ID {
+		t.Errorf("Expected GetByUID to find the item, got %+v (err %v)", got, err)
+	}
+}
+
+func TestSQLiteMigratesLegacyItems(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "items.db")
+
+	// Items written before version 2 may not be normalized; later backfills must not trip over them
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, migrations[0].SQLite); err != nil {
+		t.Fatalf("Creating the version 1 items table failed: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO items (name, quantity) VALUES ('  Whole  milk ', '1')"); err != nil {
+		t.Fatalf("Seeding a legacy item failed: %v", err)
+	}
+	db.Close()
+
+	store, err := OpenSQLite(ctx, path)
+	if err != nil {
+		t.Fatalf("Migrating legacy items failed: %v", err)
+	}
+	defer store.Close()
+	item, err := store.Get(ctx, 1)
+	if err != nil || item.Name != "  Whole  milk " || item.UID == "" {
+		t.Errorf("Expected the legacy item with a uid, got %+v (err %v)", item, err)
+	}
+}
@@ -0,0 +1,1378 @@
+package shoppinglist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// --- ItemStore Conformance Suite ---
+
+// testItemStoreConformance runs the shared behavioural contract against an ItemStore
+// implementation. newStore must return a fresh, empty store for every call.
+func testItemStoreConformance(t *testing.T, newStore func(t *testing.T) ItemStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	t.Run("ListEmpty", func(t *testing.T) {
+		store := newStore(t)
+		items, err := store.List(ctx)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if items == nil || len(items) != 0 {
+			t.Errorf("Expected non-nil empty slice, got %#v", items)
+		}
+	})
+
+	t.Run("CreateAssignsIDAndCreatedAt", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.Create(ctx, Item{Name: "Milk", Quantity: "1 Gallon"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if created.ID <= 0 {
+			t.Errorf("Expected positive ID, got %d", created.ID)
+		}
+		if created.CreatedAt.IsZero() {
+			t.Error("Expected CreatedAt to be set")
+		}
+		if created.Name != "Milk" || created.Quantity != "1 Gallon" {
+			t.Errorf("Unexpected created item: %+v", created)
+		}
+
+		other, err := store.Create(ctx, Item{Name: "Bread", Quantity: "1 Loaf"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if other.ID == created.ID {
+			t.Errorf("Expected distinct IDs, both were %d", other.ID)
+		}
+	})
+
+	t.Run("CreateValidation", func(t *testing.T) {
+		store := newStore(t)
+		for _, item := range []Item{{Name: " ", Quantity: "1"}, {Name: "Milk", Quantity: ""}} {
+			if _, err := store.Create(ctx, item); !errors.Is(err, ErrValidation) {
+				t.Errorf("Create(%+v): expected ErrValidation, got %v", item, err)
+			}
+		}
+		items, err := store.List(ctx)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(items) != 0 {
+			t.Errorf("Expected invalid items not to be stored, got %d items", len(items))
+		}
+	})
+
+	t.Run("CreateNormalizes", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.Create(ctx, Item{Name: "  Cafe\u0301\tau\u200b  lait ", Quantity: "1\u00a0L"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if created.Name != "Caf\u00e9 au lait" || created.Quantity != "1 L" {
+			t.Errorf("Expected normalized fields, got %q, %q", created.Name, created.Quantity)
+		}
+		got, err := store.Get(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if got.Name != created.Name || got.Quantity != created.Quantity {
+			t.Errorf("Expected the normalized item to be stored, got %+v", got)
+		}
+		if _, err := store.Create(ctx, Item{Name: strings.Repeat("a", MaxNameLength+1), Quantity: "1"}); !errors.Is(err, ErrValidation) {
+			t.Errorf("Create(long name): expected ErrValidation, got %v", err)
+		}
+	})
+
+	t.Run("ListNewestFirst", func(t *testing.T) {
+		store := newStore(t)
+		for _, name := range []string{"First", "Second", "Third"} {
+			if _, err := store.Create(ctx, Item{Name: name, Quantity: "1"}); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+		}
+		items, err := store.List(ctx)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(items) != 3 {
+			t.Fatalf("Expected 3 items, got %d", len(items))
+		}
+		if items[0].Name != "Third" || items[1].Name != "Second" || items[2].Name != "First" {
+			t.Errorf("Expected newest-first order, got %s, %s, %s", items[0].Name, items[1].Name, items[2].Name)
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.Create(ctx, Item{Name: "Eggs", Quantity: "12"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		got, err := store.Get(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if got.ID != created.ID || got.Name != "Eggs" || got.Quantity != "12" {
+			t.Errorf("Unexpected item: %+v", got)
+		}
+		if _, err := store.Get(ctx, created.ID+1000); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(missing): expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.Create(ctx, Item{Name: "Cheese", Quantity: "1 Block"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		updated, err := store.Update(ctx, Item{ID: created.ID, Name: "Cheddar", Quantity: "2 Blocks"})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if updated.Name != "Cheddar" || updated.Quantity != "2 Blocks" || !updated.CreatedAt.Equal(created.CreatedAt) {
+			t.Errorf("Unexpected updated item: %+v (created %+v)", updated, created)
+		}
+		got, err := store.Get(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if got.Name != "Cheddar" {
+			t.Errorf("Expected update to persist, got %+v", got)
+		}
+		if _, err := store.Update(ctx, Item{ID: created.ID + 1000, Name: "X", Quantity: "1"}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Update(missing): expected ErrNotFound, got %v", err)
+		}
+		if _, err := store.Update(ctx, Item{ID: created.ID, Name: "", Quantity: "1"}); !errors.Is(err, ErrValidation) {
+			t.Errorf("Update(invalid): expected ErrValidation, got %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.Create(ctx, Item{Name: "Apples", Quantity: "6"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := store.Delete(ctx, created.ID); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if _, err := store.Get(ctx, created.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(deleted): expected ErrNotFound, got %v", err)
+		}
+		if err := store.Delete(ctx, created.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete(deleted): expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		store := newStore(t)
+		counter, ok := store.(itemCounter)
+		if !ok {
+			t.Skipf("%T does not implement Count", store)
+		}
+		for _, name := range []string{"Milk", "Bread"} {
+			if _, err := store.Create(ctx, Item{Name: name, Quantity: "1"}); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+		}
+		if n, err := counter.Count(ctx); err != nil || n != 2 {
+			t.Errorf("Count: expected 2, got %d (err %v)", n, err)
+		}
+	})
+}
+
+// testRecipeStoreConformance runs the shared RecipeStore contract. newStore must return
+// a fresh, empty store for every call.
+func testRecipeStoreConformance(t *testing.T, newStore func(t *testing.T) RecipeStore) {
+	t.Helper()
+	ctx := context.Background()
+	pancakes := Recipe{
+		Title:       " Pancakes ",
+		Servings:    4,
+		Ingredients: []Ingredient{{Name: "Flour", Quantity: "200 g"}, {Name: "Milk", Quantity: "300 ml"}, {Name: "Eggs", Quantity: "2"}},
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.CreateRecipe(ctx, pancakes)
+		if err != nil {
+			t.Fatalf("CreateRecipe failed: %v", err)
+		}
+		if created.ID <= 0 || created.CreatedAt.IsZero() || created.Title != "Pancakes" {
+			t.Errorf("Unexpected created recipe: %+v", created)
+		}
+		got, err := store.GetRecipe(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetRecipe failed: %v", err)
+		}
+		if got.Title != "Pancakes" || got.Servings != 4 || fmt.Sprint(got.Ingredients) != fmt.Sprint(pancakes.Ingredients) {
+			t.Errorf("Expected the recipe with its ingredients in order, got %+v", got)
+		}
+		if _, err := store.GetRecipe(ctx, created.ID+1000); !errors.Is(err, ErrRecipeNotFound) {
+			t.Errorf("GetRecipe(missing): expected ErrRecipeNotFound, got %v", err)
+		}
+	})
+
+	t.Run("CreateValidation", func(t *testing.T) {
+		store := newStore(t)
+		for _, recipe := range []Recipe{
+			{Title: "", Servings: 1, Ingredients: pancakes.Ingredients},
+			{Title: "Toast", Servings: 0, Ingredients: pancakes.Ingredients},
+			{Title: "Toast", Servings: 1},
+			{Title: "Toast", Servings: 1, Ingredients: []Ingredient{{Name: "Bread", Quantity: " "}}},
+		} {
+			if _, err := store.CreateRecipe(ctx, recipe); !errors.Is(err, ErrValidation) {
+				t.Errorf("CreateRecipe(%+v): expected ErrValidation, got %v", recipe, err)
+			}
+		}
+		if recipes, err := store.ListRecipes(ctx); err != nil || len(recipes) != 0 {
+			t.Errorf("Expected invalid recipes not to be stored, got %d (err %v)", len(recipes), err)
+		}
+	})
+
+	t.Run("ListByTitle", func(t *testing.T) {
+		store := newStore(t)
+		for _, title := range []string{"waffles", "Pancakes", "Crepes"} {
+			recipe := pancakes
+			recipe.Title = title
+			if _, err := store.CreateRecipe(ctx, recipe); err != nil {
+				t.Fatalf("CreateRecipe failed: %v", err)
+			}
+		}
+		recipes, err := store.ListRecipes(ctx)
+		if err != nil {
+			t.Fatalf("ListRecipes failed: %v", err)
+		}
+		if len(recipes) != 3 || recipes[0].Title != "Crepes" || recipes[1].Title != "Pancakes" || recipes[2].Title != "waffles" {
+			t.Fatalf("Expected recipes ordered by title, got %+v", recipes)
+		}
+		if len(recipes[1].Ingredients) != 3 {
+			t.Errorf("Expected listed recipes to include their ingredients, got %+v", recipes[1])
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.CreateRecipe(ctx, pancakes)
+		if err != nil {
+			t.Fatalf("CreateRecipe failed: %v", err)
+		}
+		changed := Recipe{ID: created.ID, Title: "Crepes", Servings: 2, Ingredients: []Ingredient{{Name: "Flour", Quantity: "100 g"}}}
+		updated, err := store.UpdateRecipe(ctx, changed)
+		if err != nil {
+			t.Fatalf("UpdateRecipe failed: %v", err)
+		}
+		if !updated.CreatedAt.Equal(created.CreatedAt) {
+			t.Errorf("Expected CreatedAt to be kept, got %v (created %v)", updated.CreatedAt, created.CreatedAt)
+		}
+		got, err := store.GetRecipe(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetRecipe failed: %v", err)
+		}
+		if got.Title != "Crepes" || got.Servings != 2 || len(got.Ingredients) != 1 {
+			t.Errorf("Expected the update to replace the ingredients, got %+v", got)
+		}
+		changed.ID += 1000
+		if _, err := store.UpdateRecipe(ctx, changed); !errors.Is(err, ErrRecipeNotFound) {
+			t.Errorf("UpdateRecipe(missing): expected ErrRecipeNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.CreateRecipe(ctx, pancakes)
+		if err != nil {
+			t.Fatalf("CreateRecipe failed: %v", err)
+		}
+		if err := store.DeleteRecipe(ctx, created.ID); err != nil {
+			t.Fatalf("DeleteRecipe failed: %v", err)
+		}
+		if _, err := store.GetRecipe(ctx, created.ID); !errors.Is(err, ErrRecipeNotFound) {
+			t.Errorf("GetRecipe(deleted): expected ErrRecipeNotFound, got %v", err)
+		}
+		if err := store.DeleteRecipe(ctx, created.ID); !errors.Is(err, ErrRecipeNotFound) {
+			t.Errorf("DeleteRecipe(deleted): expected ErrRecipeNotFound, got %v", err)
+		}
+	})
+}
+
+// testPantryStoreConformance runs the shared PantryStore contract, including purchases
+// that move items off the list. newStore must return a fresh, empty store for every call.
+func testPantryStoreConformance(t *testing.T, newStore func(t *testing.T) interface {
+	ItemStore
+	PantryStore
+}) {
+	t.Helper()
+	ctx := context.Background()
+	flour := PantryItem{Name: " Flour ", Stock: 1.5, Unit: "kg", Threshold: 0.5, BestBefore: "2026-12-31"}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.CreatePantryItem(ctx, flour)
+		if err != nil {
+			t.Fatalf("CreatePantryItem failed: %v", err)
+		}
+		if created.ID <= 0 || created.CreatedAt.IsZero() || created.Name != "Flour" {
+			t.Errorf("Unexpected created pantry item: %+v", created)
+		}
+		got, err := store.GetPantryItem(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetPantryItem failed: %v", err)
+		}
+		if got.Stock != 1.5 || got.Unit != "kg" || got.Threshold != 0.5 || got.BestBefore != "2026-12-31" {
+			t.Errorf("Expected the stored fields back, got %+v", got)
+		}
+		if _, err := store.GetPantryItem(ctx, created.ID+1000); !errors.Is(err, ErrPantryItemNotFound) {
+			t.Errorf("GetPantryItem(missing): expected ErrPantryItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("CreateValidation", func(t *testing.T) {
+		store := newStore(t)
+		for _, item := range []PantryItem{
+			{Name: ""},
+			{Name: "Rice", Stock: -1},
+			{Name: "Rice", Threshold: MaxPantryStock + 1},
+			{Name: "Rice", BestBefore: "31/12/2026"},
+			{Name: "Rice", Unit: strings.Repeat("g", MaxUnitLength+1)},
+		} {
+			if _, err := store.CreatePantryItem(ctx, item); !errors.Is(err, ErrValidation) {
+				t.Errorf("CreatePantryItem(%+v): expected ErrValidation, got %v", item, err)
+			}
+		}
+	})
+
+	t.Run("NamesAreUnique", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.CreatePantryItem(ctx, flour); err != nil {
+			t.Fatalf("CreatePantryItem failed: %v", err)
+		}
+		if _, err := store.CreatePantryItem(ctx, PantryItem{Name: "FLOUR"}); !errors.Is(err, ErrPantryItemExists) {
+			t.Errorf("Expected ErrPantryItemExists for a name differing in case, got %v", err)
+		}
+		sugar, err := store.CreatePantryItem(ctx, PantryItem{Name: "Sugar"})
+		if err != nil {
+			t.Fatalf("CreatePantryItem failed: %v", err)
+		}
+		sugar.Name = "flour"
+		if _, err := store.UpdatePantryItem(ctx, sugar); !errors.Is(err, ErrPantryItemExists) {
+			t.Errorf("Expected ErrPantryItemExists when renaming onto another item, got %v", err)
+		}
+	})
+
+	t.Run("ListByName", func(t *testing.T) {
+		store := newStore(t)
+		for _, name := range []string{"rice", "Oats", "Beans"} {
+			if _, err := store.CreatePantryItem(ctx, PantryItem{Name: name}); err != nil {
+				t.Fatalf("CreatePantryItem failed: %v", err)
+			}
+		}
+		items, err := store.ListPantry(ctx)
+		if err != nil {
+			t.Fatalf("ListPantry failed: %v", err)
+		}
+		if len(items) != 3 || items[0].Name != "Beans" || items[1].Name != "Oats" || items[2].Name != "rice" {
+			t.Errorf("Expected pantry items ordered by name, got %+v", items)
+		}
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.CreatePantryItem(ctx, flour)
+		if err != nil {
+			t.Fatalf("CreatePantryItem failed: %v", err)
+		}
+		changed := PantryItem{ID: created.ID, Name: "Flour", Stock: 0.25, Unit: "kg"}
+		updated, err := store.UpdatePantryItem(ctx, changed)
+		if err != nil {
+			t.Fatalf("UpdatePantryItem failed: %v", err)
+		}
+		if updated.Stock != 0.25 || updated.Threshold != 0 || updated.BestBefore != "" || !updated.CreatedAt.Equal(created.CreatedAt) {
+			t.Errorf("Expected every field to be replaced and CreatedAt kept, got %+v", updated)
+		}
+		if err := store.DeletePantryItem(ctx, created.ID); err != nil {
+			t.Fatalf("DeletePantryItem failed: %v", err)
+		}
+		if err := store.DeletePantryItem(ctx, created.ID); !errors.Is(err, ErrPantryItemNotFound) {
+			t.Errorf("DeletePantryItem(deleted): expected ErrPantryItemNotFound, got %v", err)
+		}
+		if _, err := store.UpdatePantryItem(ctx, changed); !errors.Is(err, ErrPantryItemNotFound) {
+			t.Errorf("UpdatePantryItem(deleted): expected ErrPantryItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("ConsumeStock", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.CreatePantryItem(ctx, flour)
+		if err != nil {
+			t.Fatalf("CreatePantryItem failed: %v", err)
+		}
+		got, err := store.ConsumeStock(ctx, created.ID, 0.2)
+		if err != nil {
+			t.Fatalf("ConsumeStock failed: %v", err)
+		}
+		if got.Stock != 1.3 {
+			t.Errorf("Expected stock 1.3, got %v", got.Stock)
+		}
+		if got, err = store.ConsumeStock(ctx, created.ID, 5); err != nil || got.Stock != 0 {
+			t.Errorf("Expected stock to stop at 0, got %v (err %v)", got.Stock, err)
+		}
+		if _, err := store.ConsumeStock(ctx, created.ID+1000, 1); !errors.Is(err, ErrPantryItemNotFound) {
+			t.Errorf("ConsumeStock(missing): expected ErrPantryItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("MovePurchased", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.CreatePantryItem(ctx, PantryItem{Name: "Flour", Stock: 1, Unit: "kg", Threshold: 2}); err != nil {
+			t.Fatalf("CreatePantryItem failed: %v", err)
+		}
+		bought, err := store.Create(ctx, Item{Name: "flour", Quantity: "1.5 kg"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		got, err := store.MovePurchased(ctx, bought.ID, PantryItem{Name: "flour", Stock: 1500, Unit: "g", BestBefore: "2027-01-31"})
+		if err != nil {
+			t.Fatalf("MovePurchased failed: %v", err)
+		}
+		if got.Name != "Flour" || got.Stock != 2.5 || got.Unit != "kg" || got.Threshold != 2 || got.BestBefore != "2027-01-31" {
+			t.Errorf("Expected 1500 g to be added to the existing pantry item as 1.5 kg, got %+v", got)
+		}
+		if _, err := store.Get(ctx, bought.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected the bought item to leave the list, got %v", err)
+		}
+
+		// A unit that cannot be converted leaves the stock alone, but the item is bought
+		bag, err := store.Create(ctx, Item{Name: "Flour", Quantity: "2 bags"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		got, err = store.MovePurchased(ctx, bag.ID, PantryItem{Name: "Flour", Stock: 2, Unit: "bags"})
+		if err != nil {
+			t.Fatalf("MovePurchased failed: %v", err)
+		}
+		if got.Stock != 2.5 || got.Unit != "kg" {
+			t.Errorf("Expected 2 bags to leave 2.5 kg of Flour unchanged, got %+v", got)
+		}
+		if _, err := store.Get(ctx, bag.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected the bought item to leave the list, got %v", err)
+		}
+
+		eggs, err := store.Create(ctx, Item{Name: "Eggs", Quantity: "6"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		got, err = store.MovePurchased(ctx, eggs.ID, PantryItem{Name: "Eggs", Stock: 6})
+		if err != nil {
+			t.Fatalf("MovePurchased failed: %v", err)
+		}
+		if got.ID <= 0 || got.Stock != 6 || got.Unit != "" || got.BestBefore != "" {
+			t.Errorf("Expected a new pantry item, got %+v", got)
+		}
+		if items, _ := store.ListPantry(ctx); len(items) != 2 {
+			t.Errorf("Expected 2 pantry items, got %d", len(items))
+		}
+
+		if _, err := store.MovePurchased(ctx, eggs.ID, PantryItem{Name: "Eggs", Stock: 6}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("MovePurchased(missing item): expected ErrNotFound, got %v", err)
+		}
+		if got, _ := store.GetPantryItem(ctx, got.ID); got.Stock != 6 {
+			t.Errorf("Expected a failed purchase not to change the stock, got %v", got.Stock)
+		}
+	})
+}
+
+// testRecurringStoreConformance runs the shared RecurringStore contract. newStore must
+// return a fresh, empty store for every call.
+func testRecurringStoreConformance(t *testing.T, newStore func(t *testing.T) RecurringStore) {
+	t.Helper()
+	ctx := context.Background()
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	milk := RecurringItem{Name: " Milk ", Quantity: "2 l", Schedule: "weekly on  Monday", NextRun: monday}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.CreateRecurring(ctx, milk)
+		if err != nil {
+			t.Fatalf("CreateRecurring failed: %v", err)
+		}
+		if created.ID <= 0 || created.CreatedAt.IsZero() || created.Name != "Milk" || created.Schedule != "weekly on Monday" {
+			t.Errorf("Unexpected created recurring item: %+v", created)
+		}
+		got, err := store.GetRecurring(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetRecurring failed: %v", err)
+		}
+		if got.Quantity != "2 l" || !got.NextRun.Equal(monday) || got.LastRun != nil {
+			t.Errorf("Expected the stored fields back, got %+v", got)
+		}
+		if _, err := store.GetRecurring(ctx, created.ID+1000); !errors.Is(err, ErrRecurringItemNotFound) {
+			t.Errorf("GetRecurring(missing): expected ErrRecurringItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("CreateValidation", func(t *testing.T) {
+		store := newStore(t)
+		for _, item := range []RecurringItem{
+			{Name: "", Quantity: "1", Schedule: "daily"},
+			{Name: "Bread", Quantity: "1", Schedule: ""},
+			{Name: "Bread", Quantity: "1", Schedule: "every 0 days"},
+			{Name: "Bread", Quantity: "1", Schedule: "weekly on someday"},
+			{Name: "Bread", Quantity: "1", Schedule: "0 0 30 2 *"},
+		} {
+			if _, err := store.CreateRecurring(ctx, item); !errors.Is(err, ErrValidation) {
+				t.Errorf("CreateRecurring(%+v): expected ErrValidation, got %v", item, err)
+			}
+		}
+	})
+
+	t.Run("ListByName", func(t *testing.T) {
+		store := newStore(t)
+		for _, name := range []string{"milk", "Eggs", "Bread"} {
+			if _, err := store.CreateRecurring(ctx, RecurringItem{Name: name, Quantity: "1", Schedule: "daily"}); err != nil {
+				t.Fatalf("CreateRecurring failed: %v", err)
+			}
+		}
+		items, err := store.ListRecurring(ctx)
+		if err != nil {
+			t.Fatalf("ListRecurring failed: %v", err)
+		}
+		if len(items) != 3 || items[0].Name != "Bread" || items[1].Name != "Eggs" || items[2].Name != "milk" {
+			t.Errorf("Expected recurring items ordered by name, got %+v", items)
+		}
+	})
+
+	t.Run("DueAndRecordRun", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.CreateRecurring(ctx, milk)
+		if err != nil {
+			t.Fatalf("CreateRecurring failed: %v", err)
+		}
+		later := milk
+		later.Name, later.NextRun = "Eggs", monday.Add(time.Hour)
+		if _, err := store.CreateRecurring(ctx, later); err != nil {
+			t.Fatalf("CreateRecurring failed: %v", err)
+		}
+
+		if due, err := store.DueRecurring(ctx, monday.Add(-time.Millisecond)); err != nil || len(due) != 0 {
+			t.Errorf("Expected nothing due before the next run, got %+v (err %v)", due, err)
+		}
+		due, err := store.DueRecurring(ctx, monday.Add(2*time.Hour))
+		if err != nil {
+			t.Fatalf("DueRecurring failed: %v", err)
+		}
+		if len(due) != 2 || due[0].ID != created.ID || due[1].Name != "Eggs" {
+			t.Errorf("Expected both items due, earliest first, got %+v", due)
+		}
+
+		ranAt := monday.Add(90 * time.Second)
+		next := monday.AddDate(0, 0, 7)
+		if err := store.RecordRun(ctx, created.ID, ranAt, next); err != nil {
+			t.Fatalf("RecordRun failed: %v", err)
+		}
+		got, err := store.GetRecurring(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetRecurring failed: %v", err)
+		}
+		if got.LastRun == nil || !got.LastRun.Equal(ranAt) || !got.NextRun.Equal(next) {
+			t.Errorf("Expected the run to be recorded, got %+v", got)
+		}
+		if due, _ := store.DueRecurring(ctx, monday.Add(2*time.Hour)); len(due) != 1 || due[0].Name != "Eggs" {
+			t.Errorf("Expected only Eggs to stay due, got %+v", due)
+		}
+		if err := store.RecordRun(ctx, created.ID+1000, ranAt, next); !errors.Is(err, ErrRecurringItemNotFound) {
+			t.Errorf("RecordRun(missing): expected ErrRecurringItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.CreateRecurring(ctx, milk)
+		if err != nil {
+			t.Fatalf("CreateRecurring failed: %v", err)
+		}
+		ranAt := monday.Add(time.Minute)
+		if err := store.RecordRun(ctx, created.ID, ranAt, monday.AddDate(0, 0, 7)); err != nil {
+			t.Fatalf("RecordRun failed: %v", err)
+		}
+		changed := RecurringItem{ID: created.ID, Name: "Oat milk", Quantity: "1 l", Schedule: "every 3 days", NextRun: monday.AddDate(0, 0, 1)}
+		updated, err := store.UpdateRecurring(ctx, changed)
+		if err != nil {
+			t.Fatalf("UpdateRecurring failed: %v", err)
+		}
+		if updated.Name != "Oat milk" || updated.Schedule != "every 3 days" || !updated.NextRun.Equal(changed.NextRun) ||
+			updated.LastRun == nil || !updated.LastRun.Equal(ranAt) || !updated.CreatedAt.Equal(created.CreatedAt) {
+			t.Errorf("Expected the definition to be replaced and the last run kept, got %+v", updated)
+		}
+		if err := store.DeleteRecurring(ctx, created.ID); err != nil {
+			t.Fatalf("DeleteRecurring failed: %v", err)
+		}
+		if err := store.DeleteRecurring(ctx, created.ID); !errors.Is(err, ErrRecurringItemNotFound) {
+			t.Errorf("DeleteRecurring(deleted): expected ErrRecurringItemNotFound, got %v", err)
+		}
+		if _, err := store.UpdateRecurring(ctx, changed); !errors.Is(err, ErrRecurringItemNotFound) {
+			t.Errorf("UpdateRecurring(deleted): expected ErrRecurringItemNotFound, got %v", err)
+		}
+	})
+}
+
+// --- PriceStore Conformance Suite ---
+
+// testPriceStoreConformance checks item prices, remembered prices and the budget.
+func testPriceStoreConformance(t *testing.T, newStore func(t *testing.T) interface {
+	ItemStore
+	PriceStore
+}) {
+	t.Helper()
+	ctx := context.Background()
+	price := func(p int64) *int64 { return &p }
+
+	t.Run("ItemPrices", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.Create(ctx, Item{Name: "Milk", Quantity: "2 l", UnitPrice: price(129), Currency: "eur"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		got, err := store.Get(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if got.UnitPrice == nil || *got.UnitPrice != 129 || got.Currency != "EUR" {
+			t.Errorf("Expected the price to be stored, got %+v", got)
+		}
+		cleared, err := store.Update(ctx, Item{ID: created.ID, Name: "Milk", Quantity: "2 l"})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if got, _ := store.Get(ctx, created.ID); cleared.UnitPrice != nil || got.UnitPrice != nil || got.Currency != "" {
+			t.Errorf("Expected Update to clear the price, got %+v", got)
+		}
+
+		for _, item := range []Item{
+			{Name: "Bread", Quantity: "1", UnitPrice: price(-1), Currency: "EUR"},
+			{Name: "Bread", Quantity: "1", UnitPrice: price(MaxUnitPrice + 1), Currency: "EUR"},
+			{Name: "Bread", Quantity: "1", UnitPrice: price(100), Currency: "EURO"},
+			{Name: "Bread", Quantity: "1", UnitPrice: price(100), Currency: "ABC"},
+			{Name: "Bread", Quantity: "1", UnitPrice: price(100)},
+			{Name: "Bread", Quantity: "1", Currency: "EUR"},
+		} {
+			if _, err := store.Create(ctx, item); !errors.Is(err, ErrValidation) {
+				t.Errorf("Create(%v %q): expected ErrValidation, got %v", item.UnitPrice, item.Currency, err)
+			}
+		}
+	})
+
+	t.Run("RecordAndLookUp", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.LastPrice(ctx, "Milk"); !errors.Is(err, ErrPriceNotFound) {
+			t.Errorf("LastPrice(unknown): expected ErrPriceNotFound, got %v", err)
+		}
+		first, err := store.RecordPrice(ctx, Price{Name: "milk", UnitPrice: 119, Currency: "EUR"})
+		if err != nil {
+			t.Fatalf("RecordPrice failed: %v", err)
+		}
+		if first.UpdatedAt.IsZero() {
+			t.Error("Expected UpdatedAt to be set")
+		}
+		if _, err := store.RecordPrice(ctx, Price{Name: " Milk ", UnitPrice: 129, Currency: "eur"}); err != nil {
+			t.Fatalf("RecordPrice failed: %v", err)
+		}
+		if _, err := store.RecordPrice(ctx, Price{Name: "Bread", UnitPrice: 250, Currency: "CHF"}); err != nil {
+			t.Fatalf("RecordPrice failed: %v", err)
+		}
+		got, err := store.LastPrice(ctx, "MILK")
+		if err != nil {
+			t.Fatalf("LastPrice failed: %v", err)
+		}
+		if got.Name != "Milk" || got.UnitPrice != 129 || got.Currency != "EUR" {
+			t.Errorf("Expected the newest price to replace the old one, got %+v", got)
+		}
+		prices, err := store.ListPrices(ctx)
+		if err != nil {
+			t.Fatalf("ListPrices failed: %v", err)
+		}
+		if len(prices) != 2 || prices[0].Name != "Bread" || prices[1].Name != "Milk" {
+			t.Errorf("Expected one price per name ordered by name, got %+v", prices)
+		}
+		if _, err := store.RecordPrice(ctx, Price{Name: "", UnitPrice: 1, Currency: "EUR"}); !errors.Is(err, ErrValidation) {
+			t.Errorf("RecordPrice(no name): expected ErrValidation, got %v", err)
+		}
+	})
+
+	t.Run("Budget", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.GetBudget(ctx); !errors.Is(err, ErrBudgetNotSet) {
+			t.Errorf("GetBudget(unset): expected ErrBudgetNotSet, got %v", err)
+		}
+		if _, err := store.SetBudget(ctx, Money{Amount: 5000, Currency: "eur"}); err != nil {
+			t.Fatalf("SetBudget failed: %v", err)
+		}
+		if _, err := store.SetBudget(ctx, Money{Amount: 7500, Currency: "USD"}); err != nil {
+			t.Fatalf("SetBudget failed: %v", err)
+		}
+		if got, err := store.GetBudget(ctx); err != nil || got != (Money{Amount: 7500, Currency: "USD"}) {
+			t.Errorf("Expected the budget to be replaced, got %+v (err %v)", got, err)
+		}
+		if _, err := store.SetBudget(ctx, Money{Amount: -1, Currency: "USD"}); !errors.Is(err, ErrValidation) {
+			t.Errorf("SetBudget(negative): expected ErrValidation, got %v", err)
+		}
+		if err := store.DeleteBudget(ctx); err != nil {
+			t.Fatalf("DeleteBudget failed: %v", err)
+		}
+		if err := store.DeleteBudget(ctx); !errors.Is(err, ErrBudgetNotSet) {
+			t.Errorf("DeleteBudget(unset): expected ErrBudgetNotSet, got %v", err)
+		}
+	})
+}
+
+// testHistoryStoreConformance checks that suggestions match by prefix and rank by
+// frequency and recency.
+func testHistoryStoreConformance(t *testing.T, newStore func(t *testing.T) HistoryStore) {
+	t.Helper()
+	ctx := context.Background()
+	day := 24 * time.Hour
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("RankAndPrefix", func(t *testing.T) {
+		store := newStore(t)
+		for _, use := range []struct {
+			item Item
+			at   time.Time
+		}{
+			{Item{Name: "Milk", Quantity: "1 l"}, start},
+			{Item{Name: "milk", Quantity: "2 l"}, start.Add(day)},
+			{Item{Name: "Mints", Quantity: "1"}, start.Add(60 * day)},
+			{Item{Name: "Minced meat", Quantity: "500 g"}, start.Add(50 * day)},
+			{Item{Name: "Bread", Quantity: "1"}, start.Add(60 * day)},
+		} {
+			if err := store.RecordUse(ctx, use.item, use.at); err != nil {
+				t.Fatalf("RecordUse failed: %v", err)
+			}
+		}
+		// Two uses 60 days ago are worth half of one today
+		got, err := store.Suggest(ctx, "MI", 10)
+		if err != nil {
+			t.Fatalf("Suggest failed: %v", err)
+		}
+		var names []string
+		for _, s := range got {
+			names = append(names, s.Name)
+		}
+		if strings.Join(names, ",") != "Mints,Minced meat,milk" {
+			t.Errorf("Expected suggestions ranked by recency and frequency, got %v", names)
+		}
+		if milk := got[len(got)-1]; milk.Quantity != "2 l" || milk.Uses != 2 || !milk.LastUsed.Equal(start.Add(day)) {
+			t.Errorf("Expected the last quantity, two uses and the last use time, got %+v", milk)
+		}
+
+		// Frequent uses outrank a single newer one
+		for i := 0; i < 3; i++ {
+			if err := store.RecordUse(ctx, Item{Name: "Milk", Quantity: "1 l"}, start.Add(55*day)); err != nil {
+				t.Fatalf("RecordUse failed: %v", err)
+			}
+		}
+		if got, _ := store.Suggest(ctx, "mi", 1); len(got) != 1 || got[0].Name != "Milk" || got[0].Uses != 5 {
+			t.Errorf("Expected Milk to rank first after more uses, got %+v", got)
+		}
+		if got, _ := store.Suggest(ctx, "", 10); len(got) != 4 {
+			t.Errorf("Expected an empty prefix to match every name, got %+v", got)
+		}
+		if got, _ := store.Suggest(ctx, "x", 10); got == nil || len(got) != 0 {
+			t.Errorf("Expected an empty, non-nil slice, got %#v", got)
+		}
+	})
+
+	t.Run("WildcardsAreLiteral", func(t *testing.T) {
+		store := newStore(t)
+		for _, name := range []string{"100% juice", "1000 napkins", "a_b", "axb"} {
+			if err := store.RecordUse(ctx, Item{Name: name, Quantity: "1"}, start); err != nil {
+				t.Fatalf("RecordUse failed: %v", err)
+			}
+		}
+		if got, _ := store.Suggest(ctx, "100%", 10); len(got) != 1 || got[0].Name != "100% juice" {
+			t.Errorf("Expected %% to match literally, got %+v", got)
+		}
+		if got, _ := store.Suggest(ctx, "a_", 10); len(got) != 1 || got[0].Name != "a_b" {
+			t.Errorf("Expected _ to match literally, got %+v", got)
+		}
+	})
+}
+
+// testSearchStoreConformance checks full-text and fuzzy matches over the list and the
+// item history.
+func testSearchStoreConformance(t *testing.T, newStore func(t *testing.T) interface {
+	ItemStore
+	HistoryStore
+	SearchStore
+}) {
+	t.Helper()
+	ctx := context.Background()
+
+	t.Run("ListAndHistory", func(t *testing.T) {
+		store := newStore(t)
+		tomatoes, err := store.Create(ctx, Item{Name: "Cherry tomatoes", Quantity: "250 g"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := store.Create(ctx, Item{Name: "Milk", Quantity: "1 l"}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		for _, item := range []Item{{Name: "Tomato sauce", Quantity: "1 jar"}, {Name: "Cherry tomatoes", Quantity: "250 g"}} {
+			if err := store.RecordUse(ctx, item, time.Now()); err != nil {
+				t.Fatalf("RecordUse failed: %v", err)
+			}
+		}
+
+		results, err := store.Search(ctx, []string{"tomatos"}, 10)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, r.Source+":"+r.Name)
+		}
+		if strings.Join(got, ",") != "list:Cherry tomatoes,history:Cherry tomatoes,history:Tomato sauce" {
+			t.Errorf("Expected fuzzy matches from the list and the history, got %v", got)
+		}
+		if len(results) == 3 {
+			if results[0].ID == nil || *results[0].ID != tomatoes.ID || results[0].UID != tomatoes.UID ||
+				results[1].ID != nil || results[1].UID != "" || results[0].Quantity != "250 g" {
+				t.Errorf("Expected the list item's IDs and none for history, got %+v", results[:2])
+			}
+			if results[0].Score <= 0 || results[0].Score >= 0.5 || results[2].Score > results[0].Score {
+				t.Errorf("Expected fuzzy scores below 0.5, best first, got %v and %v", results[0].Score, results[2].Score)
+			}
+		}
+
+		// Full-text matches on word prefixes outrank fuzzy ones
+		results, err = store.Search(ctx, []string{"tomato"}, 10)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 3 || results[0].Name != "Tomato sauce" || results[0].Score < 0.5 {
+			t.Errorf("Expected the exact word first with a score of at least 0.5, got %+v", results)
+		}
+		if results, _ := store.Search(ctx, []string{"cherry", "tom"}, 1); len(results) != 1 || results[0].Source != SearchSourceList {
+			t.Errorf("Expected every term to match and the limit to apply, got %+v", results)
+		}
+		if results, _ := store.Search(ctx, []string{"bread"}, 10); results == nil || len(results) != 0 {
+			t.Errorf("Expected an empty, non-nil slice, got %#v", results)
+		}
+	})
+}
+
+// testSyncStoreConformance checks the change feed and offline mutations.
+func testSyncStoreConformance(t *testing.T, newStore func(t *testing.T) interface {
+	ItemStore
+	SyncStore
+}) {
+	t.Helper()
+	ctx := context.Background()
+	str := func(s string) *string { return &s }
+	const uid = "0192a8e4-7c1b-7def-8a12-3456789abcde"
+
+	t.Run("ChangeFeed", func(t *testing.T) {
+		store := newStore(t)
+		milk, err := store.Create(ctx, Item{Name: "Milk", Quantity: "1 l"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		bread, err := store.Create(ctx, Item{Name: "Bread", Quantity: "1"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		items, tombstones, err := store.Changes(ctx, 0, 10)
+		if err != nil {
+			t.Fatalf("Changes failed: %v", err)
+		}
+		if len(items) != 2 || items[0].ID != milk.ID || items[1].ID != bread.ID || len(tombstones) != 0 || items[0].Version >= items[1].Version {
+			t.Fatalf("Expected both items in version order, got %+v %+v", items, tombstones)
+		}
+		since := items[1].Version
+
+		if _, err := store.Update(ctx, Item{ID: milk.ID, Name: "Milk", Quantity: "2 l"}); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if err := store.Delete(ctx, bread.ID); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		items, tombstones, err = store.Changes(ctx, since, 10)
+		if err != nil {
+			t.Fatalf("Changes failed: %v", err)
+		}
+		if len(items) != 1 || items[0].Quantity != "2 l" || items[0].Version <= since {
+			t.Errorf("Expected the updated item with a new version, got %+v", items)
+		}
+		if len(tombstones) != 1 || tombstones[0].ID != bread.ID || tombstones[0].Version <= items[0].Version || tombstones[0].DeletedAt.IsZero() {
+			t.Errorf("Expected a tombstone after the update, got %+v", tombstones)
+		}
+		if items, tombstones, _ := store.Changes(ctx, tombstones[0].Version, 10); len(items) != 0 || len(tombstones) != 0 {
+			t.Errorf("Expected no changes after the last version, got %+v %+v", items, tombstones)
+		}
+		if items, _, _ := store.Changes(ctx, 0, 1); len(items) != 1 {
+			t.Errorf("Expected the limit to apply, got %+v", items)
+		}
+	})
+
+	t.Run("Mutations", func(t *testing.T) {
+		store := newStore(t)
+		at := time.Now().UTC().Add(-time.Hour).Truncate(time.Millisecond)
+		created, err := store.ApplyMutation(ctx, Mutation{Op: MutationUpsert, UID: strings.ToUpper(uid), At: at, Name: str(" Oat  milk "), Quantity: str("1 l")})
+		if err != nil {
+			t.Fatalf("ApplyMutation failed: %v", err)
+		}
+		if created.Status != MutationCreated || created.Item == nil || created.Item.UID != uid || created.Item.Name != "Oat milk" || created.ID == 0 || created.Item.Version == 0 {
+			t.Fatalf("Expected a created item with the normalized UID and name, got %+v", created)
+		}
+		again, err := store.ApplyMutation(ctx, Mutation{Op: MutationUpsert, UID: uid, At: at, Name: str("Oat milk"), Quantity: str("1 l")})
+		if err != nil || again.Status != MutationUnchanged || len(again.Conflicts) != 0 || again.ID != created.ID {
+			t.Errorf("Expected a retried mutation to change nothing, got %+v (err %v)", again, err)
+		}
+
+		// Per field: a later quantity wins, an earlier name loses
+		updated, err := store.ApplyMutation(ctx, Mutation{Op: MutationUpsert, UID: uid, At: at.Add(-time.Minute), Name: str("Soy milk")})
+		if err != nil || updated.Status != MutationUnchanged || len(updated.Conflicts) != 1 || updated.Conflicts[0].Field != "name" || !updated.Conflicts[0].At.Equal(at) {
+			t.Errorf("Expected an older name to conflict, got %+v (err %v)", updated, err)
+		}
+		price := int64(199)
+		updated, err = store.ApplyMutation(ctx, Mutation{Op: MutationUpsert, UID: uid, At: at.Add(time.Minute), Quantity: str("2 l"), UnitPrice: optionalPrice{Set: true, Value: &price}, Currency: "eur"})
+		if err != nil || updated.Status != MutationUpdated || updated.Item.Quantity != "2 l" || updated.Item.Currency != "EUR" || updated.Item.Version <= created.Item.Version {
+			t.Errorf("Expected a newer quantity and price to apply, got %+v (err %v)", updated, err)
+		}
+		if got, _ := store.Get(ctx, created.ID); got.Name != "Oat milk" || got.Quantity != "2 l" || got.UnitPrice == nil || *got.UnitPrice != 199 {
+			t.Errorf("Expected the stored item to match, got %+v", got)
+		}
+
+		// A plain update counts as made now, so an offline delete from before loses
+		if _, err := store.Update(ctx, Item{ID: created.ID, Name: "Oat milk", Quantity: "3 l", UnitPrice: &price, Currency: "EUR"}); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		kept, err := store.ApplyMutation(ctx, Mutation{Op: MutationDelete, UID: uid, At: at.Add(2 * time.Minute)})
+		if err != nil || kept.Status != MutationUnchanged || len(kept.Conflicts) != 1 || kept.Conflicts[0].Field != "quantity" || kept.Item == nil {
+			t.Errorf("Expected the delete to lose to the newer quantity, got %+v (err %v)", kept, err)
+		}
+		deleted, err := store.ApplyMutation(ctx, Mutation{Op: MutationDelete, ID: created.ID, At: time.Now().UTC().Add(time.Minute)})
+		if err != nil || deleted.Status != MutationDeleted || deleted.UID != uid {
+			t.Fatalf("Expected a later delete by ID to win, got %+v (err %v)", deleted, err)
+		}
+		if _, err := store.Get(ctx, created.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected the item to be gone, got %v", err)
+		}
+		_, tombstones, _ := store.Changes(ctx, created.Item.Version, 10)
+		if len(tombstones) != 1 || tombstones[0].UID != uid || tombstones[0].ID != created.ID {
+			t.Errorf("Expected a tombstone with the UID, got %+v", tombstones)
+		}
+		revived, err := store.ApplyMutation(ctx, Mutation{Op: MutationUpsert, UID: uid, At: time.Now().UTC().Add(time.Hour), Quantity: str("1 l")})
+		if err != nil || revived.Status != MutationUnchanged || len(revived.Conflicts) != 1 || revived.Conflicts[0].Reason != ConflictDeleted {
+			t.Errorf("Expected a deleted item to stay deleted, got %+v (err %v)", revived, err)
+		}
+		if again, err := store.ApplyMutation(ctx, Mutation{Op: MutationDelete, UID: uid, At: at}); err != nil || again.Status != MutationUnchanged || len(again.Conflicts) != 0 {
+			t.Errorf("Expected a repeated delete to change nothing, got %+v (err %v)", again, err)
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		store := newStore(t)
+		at := time.Now().UTC()
+		if r, err := store.ApplyMutation(ctx, Mutation{Op: MutationUpsert, UID: uid, At: at, Quantity: str("1")}); err != nil || r.Status != MutationRejected || r.Error == "" {
+			t.Errorf("Expected a new item without a name to be rejected, got %+v (err %v)", r, err)
+		}
+		if r, err := store.ApplyMutation(ctx, Mutation{Op: MutationUpsert, ID: 999, At: at, Quantity: str("1")}); err != nil || r.Status != MutationRejected {
+			t.Errorf("Expected an unknown ID to be rejected, got %+v (err %v)", r, err)
+		}
+		if _, err := store.ApplyMutation(ctx, Mutation{Op: MutationUpsert, UID: "not-a-uuid", At: at, Name: str("Tea"), Quantity: str("1")}); !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected ErrValidation for a bad UID, got %v", err)
+		}
+	})
+}
+
+// testUIDStoreConformance checks that items get UUIDv7 UIDs and can be found by them.
+func testUIDStoreConformance(t *testing.T, newStore func(t *testing.T) interface {
+	ItemStore
+	UIDStore
+	SyncStore
+}) {
+	t.Helper()
+	ctx := context.Background()
+	store := newStore(t)
+
+	milk, err := store.Create(ctx, Item{Name: "Milk", Quantity: "1 l"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	bread, err := store.Create(ctx, Item{Name: "Bread", Quantity: "1"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if uid, ok := parseUUID(milk.UID); !ok || uid != milk.UID || uid[14] != '7' || milk.UID == bread.UID {
+		t.Fatalf("Expected distinct lower-case UUIDv7 UIDs, got %q and %q", milk.UID, bread.UID)
+	}
+	got, err := store.GetByUID(ctx, milk.UID)
+	if err != nil || got.ID != milk.ID || got.UID != milk.UID || got.Name != "Milk" {
+		t.Errorf("Expected GetByUID to find Milk, got %+v (err %v)", got, err)
+	}
+	if got, _ := store.Get(ctx, bread.ID); got.UID != bread.UID {
+		t.Errorf("Expected Get to return the UID, got %+v", got)
+	}
+	if updated, err := store.Update(ctx, Item{ID: milk.ID, Name: "Milk", Quantity: "2 l"}); err != nil || updated.UID != milk.UID {
+		t.Errorf("Expected Update to keep the UID, got %+v (err %v)", updated, err)
+	}
+	if items, _ := store.List(ctx); len(items) != 2 || items[0].UID == "" || items[1].UID == "" {
+		t.Errorf("Expected List to return the UIDs, got %+v", items)
+	}
+
+	// Items created through sync keep the client's UID, whatever its version
+	const clientUID = "6f1c3a52-9d0e-4b7a-8c21-0d4e5f6a7b8c"
+	name, quantity := "Tea", "1"
+	if _, err := store.ApplyMutation(ctx, Mutation{Op: MutationUpsert, UID: clientUID, At: time.Now(), Name: &name, Quantity: &quantity}); err != nil {
+		t.Fatalf("ApplyMutation failed: %v", err)
+	}
+	if got, err := store.GetByUID(ctx, clientUID); err != nil || got.Name != "Tea" {
+		t.Errorf("Expected GetByUID to find the synced item, got %+v (err %v)", got, err)
+	}
+
+	if err := store.Delete(ctx, milk.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.GetByUID(ctx, milk.UID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a deleted item, got %v", err)
+	}
+	if _, tombstones, _ := store.Changes(ctx, 0, 10); len(tombstones) != 1 || tombstones[0].UID != milk.UID {
+		t.Errorf("Expected the tombstone to carry the UID, got %+v", tombstones)
+	}
+}
+
+// testLayoutStoreConformance checks stores, their sections, mappings and check-offs.
+func testLayoutStoreConformance(t *testing.T, newStore func(t *testing.T) LayoutStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	t.Run("CreateGetUpdateDelete", func(t *testing.T) {
+		store := newStore(t)
+		created, err := store.CreateStore(ctx, StoreLayout{Name: " Corner  Shop ", Sections: []string{"Produce", "Bakery", "Dairy"}})
+		if err != nil {
+			t.Fatalf("CreateStore failed: %v", err)
+		}
+		if created.ID == 0 || created.CreatedAt.IsZero() || created.Name != "Corner Shop" || created.LastCheckOff != nil {
+			t.Errorf("Expected a normalized store with ID and CreatedAt, got %+v", created)
+		}
+		got, err := store.GetStore(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetStore failed: %v", err)
+		}
+		if strings.Join(got.Sections, ",") != "Produce,Bakery,Dairy" {
+			t.Errorf("Expected the sections in walking order, got %v", got.Sections)
+		}
+
+		updated, err := store.UpdateStore(ctx, StoreLayout{ID: created.ID, Name: "Corner Shop", Sections: []string{"Dairy", "Produce"}})
+		if err != nil {
+			t.Fatalf("UpdateStore failed: %v", err)
+		}
+		if !updated.CreatedAt.Equal(created.CreatedAt) {
+			t.Errorf("Expected UpdateStore to keep CreatedAt, got %v want %v", updated.CreatedAt, created.CreatedAt)
+		}
+		if got, _ := store.GetStore(ctx, created.ID); strings.Join(got.Sections, ",") != "Dairy,Produce" {
+			t.Errorf("Expected the sections to be replaced, got %v", got.Sections)
+		}
+		if _, err := store.UpdateStore(ctx, StoreLayout{ID: 9999, Name: "Gone", Sections: []string{"A"}}); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("UpdateStore(missing): expected ErrStoreNotFound, got %v", err)
+		}
+
+		if _, err := store.CreateStore(ctx, StoreLayout{Name: "a market", Sections: []string{"Entrance"}}); err != nil {
+			t.Fatalf("CreateStore failed: %v", err)
+		}
+		stores, err := store.ListStores(ctx)
+		if err != nil {
+			t.Fatalf("ListStores failed: %v", err)
+		}
+		if len(stores) != 2 || stores[0].Name != "a market" || stores[1].Name != "Corner Shop" {
+			t.Errorf("Expected stores ordered by name ignoring case, got %+v", stores)
+		}
+
+		if err := store.DeleteStore(ctx, created.ID); err != nil {
+			t.Fatalf("DeleteStore failed: %v", err)
+		}
+		if _, err := store.GetStore(ctx, created.ID); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("GetStore(deleted): expected ErrStoreNotFound, got %v", err)
+		}
+		if err := store.DeleteStore(ctx, created.ID); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("DeleteStore(deleted): expected ErrStoreNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		store := newStore(t)
+		tooMany := make([]string, MaxStoreSections+1)
+		for i := range tooMany {
+			tooMany[i] = fmt.Sprintf("Aisle %d", i+1)
+		}
+		for _, invalid := range []StoreLayout{
+			{Name: "", Sections: []string{"A"}},
+			{Name: "Shop"},
+			{Name: "Shop", Sections: []string{"Dairy", "dairy"}},
+			{Name: "Shop", Sections: []string{" "}},
+			{Name: "Shop", Sections: []string{strings.Repeat("x", MaxSectionLength+1)}},
+			{Name: "Shop", Sections: tooMany},
+		} {
+			if _, err := store.CreateStore(ctx, invalid); !errors.Is(err, ErrValidation) {
+				t.Errorf("CreateStore(%q, %d sections): expected ErrValidation, got %v", invalid.Name, len(invalid.Sections), err)
+			}
+		}
+	})
+
+	t.Run("Mappings", func(t *testing.T) {
+		store := newStore(t)
+		shop, err := store.CreateStore(ctx, StoreLayout{Name: "Shop", Sections: []string{"Produce", "Dairy"}})
+		if err != nil {
+			t.Fatalf("CreateStore failed: %v", err)
+		}
+		if mappings, err := store.ListMappings(ctx, shop.ID); err != nil || len(mappings) != 0 || mappings == nil {
+			t.Errorf("Expected no mappings as an empty slice, got %v (err %v)", mappings, err)
+		}
+		if _, err := store.GetMapping(ctx, shop.ID, "Milk"); !errors.Is(err, ErrMappingNotFound) {
+			t.Errorf("GetMapping(unknown): expected ErrMappingNotFound, got %v", err)
+		}
+
+		set, err := store.SetMapping(ctx, shop.ID, SectionMapping{Name: "milk", Section: "Produce", Learned: true})
+		if err != nil {
+			t.Fatalf("SetMapping failed: %v", err)
+		}
+		if set.UpdatedAt.IsZero() || !set.Learned {
+			t.Errorf("Expected a learned mapping with UpdatedAt, got %+v", set)
+		}
+		if _, err := store.SetMapping(ctx, shop.ID, SectionMapping{Name: "Milk", Section: "Dairy"}); err != nil {
+			t.Fatalf("SetMapping failed: %v", err)
+		}
+		if _, err := store.SetMapping(ctx, shop.ID, SectionMapping{Name: "Apples", Section: "Produce"}); err != nil {
+			t.Fatalf("SetMapping failed: %v", err)
+		}
+		got, err := store.GetMapping(ctx, shop.ID, "MILK")
+		if err != nil {
+			t.Fatalf("GetMapping failed: %v", err)
+		}
+		if got.Name != "Milk" || got.Section != "Dairy" || got.Learned {
+			t.Errorf("Expected the explicit mapping to replace the learned one, got %+v", got)
+		}
+		mappings, err := store.ListMappings(ctx, shop.ID)
+		if err != nil {
+			t.Fatalf("ListMappings failed: %v", err)
+		}
+		if len(mappings) != 2 || mappings[0].Name != "Apples" || mappings[1].Name != "Milk" {
+			t.Errorf("Expected one mapping per name ordered by name, got %+v", mappings)
+		}
+
+		if err := store.DeleteMapping(ctx, shop.ID, "apples"); err != nil {
+			t.Fatalf("DeleteMapping failed: %v", err)
+		}
+		if err := store.DeleteMapping(ctx, shop.ID, "apples"); !errors.Is(err, ErrMappingNotFound) {
+			t.Errorf("DeleteMapping(deleted): expected ErrMappingNotFound, got %v", err)
+		}
+		if _, err := store.SetMapping(ctx, 9999, SectionMapping{Name: "Milk", Section: "Dairy"}); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("SetMapping(missing store): expected ErrStoreNotFound, got %v", err)
+		}
+		if _, err := store.ListMappings(ctx, 9999); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("ListMappings(missing store): expected ErrStoreNotFound, got %v", err)
+		}
+		if _, err := store.SetMapping(ctx, shop.ID, SectionMapping{Name: "Milk"}); !errors.Is(err, ErrValidation) {
+			t.Errorf("SetMapping(no section): expected ErrValidation, got %v", err)
+		}
+
+		// Mappings go with their store
+		if err := store.DeleteStore(ctx, shop.ID); err != nil {
+			t.Fatalf("DeleteStore failed: %v", err)
+		}
+		if _, err := store.GetMapping(ctx, shop.ID, "Milk"); !errors.Is(err, ErrMappingNotFound) {
+			t.Errorf("GetMapping(deleted store): expected ErrMappingNotFound, got %v", err)
+		}
+	})
+
+	t.Run("CheckOffs", func(t *testing.T) {
+		store := newStore(t)
+		shop, err := store.CreateStore(ctx, StoreLayout{Name: "Shop", Sections: []string{"Produce"}})
+		if err != nil {
+			t.Fatalf("CreateStore failed: %v", err)
+		}
+		at := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+		if err := store.RecordCheckOff(ctx, shop.ID, CheckOff{Name: "Apples", Section: "Produce", At: at}); err != nil {
+			t.Fatalf("RecordCheckOff failed: %v", err)
+		}
+		if err := store.RecordCheckOff(ctx, shop.ID, CheckOff{Name: "Milk", At: at.Add(time.Minute)}); err != nil {
+			t.Fatalf("RecordCheckOff failed: %v", err)
+		}
+		got, err := store.GetStore(ctx, shop.ID)
+		if err != nil {
+			t.Fatalf("GetStore failed: %v", err)
+		}
+		if got.LastCheckOff == nil || got.LastCheckOff.Name != "Milk" || got.LastCheckOff.Section != "" || !got.LastCheckOff.At.Equal(at.Add(time.Minute)) {
+			t.Errorf("Expected the last check-off to be kept, got %+v", got.LastCheckOff)
+		}
+		updated, err := store.UpdateStore(ctx, StoreLayout{ID: shop.ID, Name: "Shop", Sections: []string{"Produce", "Dairy"}})
+		if err != nil {
+			t.Fatalf("UpdateStore failed: %v", err)
+		}
+		if updated.LastCheckOff == nil || updated.LastCheckOff.Name != "Milk" {
+			t.Errorf("Expected UpdateStore to keep the last check-off, got %+v", updated.LastCheckOff)
+		}
+		if err := store.RecordCheckOff(ctx, 9999, CheckOff{Name: "Milk", At: at}); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("RecordCheckOff(missing store): expected ErrStoreNotFound, got %v", err)
+		}
+	})
+}
+
+// TestPostgresStoreConformance runs the conformance suite against a real database.
+// It is skipped unless TEST_DATABASE_URL points at a disposable PostgreSQL database.
+func TestPostgresStoreConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set, skipping PostgreSQL conformance tests")
+	}
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer pool.Close()
+	if err := CreateSchemaIfNotExists(context.Background(), pool); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	testItemStoreConformance(t, func(t *testing.T) ItemStore {
+		resetPostgresTables(t, pool, "items", "item_tombstones")
+		return NewPostgresStore(pool)
+	})
+	testRecipeStoreConformance(t, func(t *testing.T) RecipeStore {
+		resetPostgresTables(t, pool, "recipes", "recipe_ingredients")
+		return NewPostgresStore(pool)
+	})
+	testPantryStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		PantryStore
+	} {
+		resetPostgresTables(t, pool, "items", "item_tombstones", "pantry_items")
+		return NewPostgresStore(pool)
+	})
+	testRecurringStoreConformance(t, func(t *testing.T) RecurringStore {
+		resetPostgresTables(t, pool, "recurring_items")
+		return NewPostgresStore(pool)
+	})
+	testPriceStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		PriceStore
+	} {
+		resetPostgresTables(t, pool, "items", "item_tombstones", "item_prices", "list_budget")
+		return NewPostgresStore(pool)
+	})
+	testLayoutStoreConformance(t, func(t *testing.T) LayoutStore {
+		resetPostgresTables(t, pool, "stores", "store_sections", "section_mappings")
+		return NewPostgresStore(pool)
+	})
+	testHistoryStoreConformance(t, func(t *testing.T) HistoryStore {
+		resetPostgresTables(t, pool, "item_history")
+		return NewPostgresStore(pool)
+	})
+	testSearchStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		HistoryStore
+		SearchStore
+	} {
+		resetPostgresTables(t, pool, "items", "item_tombstones", "item_history")
+		return NewPostgresStore(pool)
+	})
+	testSyncStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		SyncStore
+	} {
+		resetPostgresTables(t, pool, "items", "item_tombstones")
+		return NewPostgresStore(pool)
+	})
+	testUIDStoreConformance(t, func(t *testing.T) interface {
+		ItemStore
+		UIDStore
+		SyncStore
+	} {
+		resetPostgresTables(t, pool, "items", "item_tombstones")
+		return NewPostgresStore(pool)
+	})
+}
+
+// BenchmarkPostgresItemWrites measures concurrent item writes, which the items_track_changes
+// advisory lock serializes from the trigger to commit. Run it with -cpu 1,4,16 to see what
+// the single version order costs. It is skipped unless TEST_DATABASE_URL is set.
+func BenchmarkPostgresItemWrites(b *testing.B) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		b.Skip("TEST_DATABASE_URL not set, skipping PostgreSQL benchmark")
+	}
+	ctx := context.Background()
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		b.Fatalf("ParseConfig failed: %v", err)
+	}
+	config.MaxConns = 64
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		b.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer pool.Close()
+	if err := CreateSchemaIfNotExists(ctx, pool); err != nil {
+		b.Fatalf("Failed to create schema: %v", err)
+	}
+	defer pool.Exec(ctx, "TRUNCATE items, item_tombstones RESTART IDENTITY")
+	store := NewPostgresStore(pool)
+
+	b.Run("Create", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := store.Create(ctx, Item{Name: "Milk", Quantity: "1"}); err != nil {
+					b.Errorf("Create failed: %v", err)
+					return
+				}
+			}
+		})
+	})
+	b.Run("Update", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			item, err := store.Create(ctx, Item{Name: "Bread", Quantity: "1"})
+			if err != nil {
+				b.Errorf("Create failed: %v", err)
+				return
+			}
+			for n := 2; pb.Next(); n++ {
+				item.Quantity = strconv.Itoa(n)
+				if _, err := store.Update(ctx, item); err != nil {
+					b.Errorf("Update failed: %v", err)
+					return
+				}
+			}
+		})
+	})
+}
+
+// resetPostgresTables empties tables between conformance runs. item_versions belongs to
+// no column, so RESTART IDENTITY leaves it alone; it is restarted with the items table so
+// every run numbers versions from 1, as on a fresh database.
+func resetPostgresTables(t *testing.T, pool *pgxpool.Pool, tables ...string) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := pool.Exec(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" RESTART IDENTITY"); err != nil {
+		t.Fatalf("Failed to reset %s: %v", strings.Join(tables, ", "), err)
+	}
+	for _, table := range tables {
+		if table == "items" {
+			if _, err := pool.Exec(ctx, "ALTER SEQUENCE item_versions RESTART"); err != nil {
+				t.Fatalf("Failed to restart item versions: %v", err)
+			}
+		}
+	}
+}
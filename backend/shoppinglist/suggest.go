@@ -0,0 +1,142 @@
+package shoppinglist
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Item History and Suggestions ---
+
+// Suggestion is a name from the item history, with the quantity it was last added with.
+type Suggestion struct {
+	Name     string    `json:"name"`
+	Quantity string    `json:"quantity"`
+	Uses     int       `json:"uses"`      // How often an item with this name was added
+	LastUsed time.Time `json:"last_used"` // When it was last added
+}
+
+// Suggestion limits for GET /items/suggest.
+const (
+	DefaultSuggestions = 10
+	MaxSuggestions     = 50
+)
+
+// HistoryStore remembers every item name ever added, even after the item is deleted,
+// and suggests names by prefix. The built-in stores implement it next to ItemStore.
+//
+// Suggestions are ranked by a score that combines frequency and recency (see
+// historyRank). The score is kept up to date on every use, so ranking needs no scan
+// of old items.
+type HistoryStore interface {
+	// RecordUse counts one more use of the item's name (ignoring case) at the given time
+	// and remembers its quantity.
+	RecordUse(ctx context.Context, item Item, at time.Time) error
+	// Suggest returns up to limit names starting with prefix (ignoring case), best
+	// ranked first. An empty prefix matches every name.
+	Suggest(ctx context.Context, prefix string, limit int) ([]Suggestion, error)
+}
+
+// historyEpoch and historyHalfLife define the rank of a name. Every use adds
+// 2^((at - historyEpoch) / historyHalfLife) to the name's score, so a use counts twice
+// as much as one a half-life earlier. Ranks are the log2 of the score: they grow by one
+// every half-life instead of overflowing, and since all scores decay at the same rate
+// they never need updating to stay comparable.
+var historyEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const historyHalfLife = 30 * 24 * time.Hour
+
+// historyRank is the rank of a single use at the given time.
+func historyRank(at time.Time) float64 {
+	return float64(at.Sub(historyEpoch)) / float64(historyHalfLife)
+}
+
+// addHistoryRanks returns log2(2^a + 2^b), the rank after adding a use ranked b to a
+// name ranked a, without computing either power. The SQL stores do the same in their
+// upserts.
+func addHistoryRanks(a, b float64) float64 {
+	hi, lo := math.Max(a, b), math.Min(a, b)
+	return hi + math.Log2(1+math.Exp2(lo-hi))
+}
+
+// sortHistory orders history entries by rank, best first, then by name.
+func sortHistory(entries []historyEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Rank != entries[j].Rank {
+			return entries[i].Rank > entries[j].Rank
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+}
+
+// historyEntry is a Suggestion with its rank, as kept by MemoryStore.
+type historyEntry struct {
+	Suggestion
+	Rank float64 `json:"rank"`
+}
+
+// escapeLike escapes the LIKE wildcards in s, for the default backslash escape.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// recordUse adds an item to the history. Suggestions are a convenience, so failures are
+// only logged.
+func (s *Server) recordUse(ctx context.Context, item Item) {
+	if s.history == nil {
+		return
+	}
+	if err := s.history.RecordUse(ctx, item, s.now()); err != nil {
+		loggerFrom(ctx).Error("Error recording item history", "name", item.Name, "err", err)
+	}
+}
+
+// --- Suggestion Handler ---
+
+// suggestHandler serves GET /items/suggest?prefix=mi&limit=10.
+func (s *Server) suggestHandler(w http.ResponseWriter, r *http.Request) {
+	if s.history == nil {
+		s.writeStatus(w, r, http.StatusNotFound, "No such endpoint")
+		return
+	}
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r, "GET")
+		return
+	}
+
+	query := r.URL.Query()
+	var fields []FieldError
+	prefix := ""
+	if strings.TrimSpace(query.Get("prefix")) != "" {
+		normalized, msg := normalizeText(query.Get("prefix"), MaxNameLength)
+		if msg != "" {
+			fields = append(fields, FieldError{Field: "prefix", Message: msg})
+		}
+		prefix = normalized
+	}
+	limit := DefaultSuggestions
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxSuggestions {
+			fields = append(fields, FieldError{Field: "limit", Message: fmt.Sprintf("must be a whole number between 1 and %d", MaxSuggestions)})
+		}
+		limit = n
+	}
+	if len(fields) > 0 {
+		s.writeQueryProblem(w, r, fields...)
+		return
+	}
+
+	suggestions, err := s.history.Suggest(r.Context(), prefix, limit)
+	if err != nil {
+		s.requestLogger(r).Error("Error getting suggestions", "prefix", prefix, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, r, http.StatusOK, suggestions)
+}
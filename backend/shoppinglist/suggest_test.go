@@ -0,0 +1,87 @@
+package shoppinglist
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAddHistoryRanks(t *testing.T) {
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	rank := addHistoryRanks(historyRank(now), historyRank(now))
+	if want := historyRank(now) + 1; math.Abs(rank-want) > 1e-9 {
+		t.Errorf("Expected two uses at once to rank one half-life later, got %v want %v", rank, want)
+	}
+	// A use a half-life ago counts half as much as one now
+	old := historyRank(now.Add(-historyHalfLife))
+	if want := historyRank(now) + math.Log2(1.5); math.Abs(addHistoryRanks(old, historyRank(now))-want) > 1e-9 {
+		t.Errorf("Expected log2(1.5) on top of the newer use, got %v", addHistoryRanks(old, historyRank(now)))
+	}
+}
+
+func TestSuggestHandler(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	srv.now = func() time.Time { return now }
+
+	add := func(body string) Item {
+		t.Helper()
+		rr := serve(srv, "POST", "/items", body)
+		var item Item
+		if err := json.Unmarshal(rr.Body.Bytes(), &item); err != nil || rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+		return item
+	}
+	suggest := func(target string) []Suggestion {
+		t.Helper()
+		rr := serve(srv, "GET", target, "")
+		var got []Suggestion
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		return got
+	}
+
+	for i := 0; i < 3; i++ {
+		milk := add(`{"name":"Milk","quantity":"2 l"}`)
+		if rr := serve(srv, "DELETE", "/items/"+jsonNumber(milk.ID), ""); rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+		}
+	}
+	now = now.Add(7 * 24 * time.Hour)
+	add(`{"name":"Mince","quantity":"500 g"}`)
+	add(`{"name":"Bread","quantity":"1"}`)
+
+	// Deleted items are still suggested, and three uses last week beat one today
+	got := suggest("/items/suggest?prefix=mi")
+	if len(got) != 2 || got[0].Name != "Milk" || got[0].Quantity != "2 l" || got[0].Uses != 3 || got[1].Name != "Mince" {
+		t.Errorf("Expected Milk then Mince, got %+v", got)
+	}
+	if got := suggest("/items/suggest?limit=1"); len(got) != 1 || got[0].Name != "Milk" {
+		t.Errorf("Expected the best suggestion without a prefix, got %+v", got)
+	}
+	if got := suggest("/items/suggest?prefix=%20%20BR"); len(got) != 1 || got[0].Name != "Bread" {
+		t.Errorf("Expected a normalized, case-insensitive prefix, got %+v", got)
+	}
+
+	rr := serve(srv, "GET", "/items/suggest?limit=0", "")
+	if p := decodeProblem(t, rr); rr.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "limit" {
+		t.Errorf("Expected a limit error, got %d %+v", rr.Code, p)
+	}
+	if rr := serve(srv, "POST", "/items/suggest", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
+
+func TestSuggestNotServedWithoutHistoryStore(t *testing.T) {
+	srv := newTestServer(t, struct{ ItemStore }{NewMemoryStore()})
+	if rr := serve(srv, "GET", "/items/suggest?prefix=mi", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+	if rr := serve(srv, "POST", "/items", `{"name":"Milk","quantity":"1"}`); rr.Code != http.StatusCreated {
+		t.Errorf("Expected adding items to work without a history, got %d", rr.Code)
+	}
+}
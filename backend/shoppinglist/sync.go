@@ -0,0 +1,457 @@
+package shoppinglist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// --- Offline Sync ---
+
+// SyncItem is an item as the sync feed sends it, with the version of its last change.
+type SyncItem struct {
+	Item
+	Version int64 `json:"version"`
+}
+
+// Tombstone records a deleted item, so clients that synced it learn it is gone.
+type Tombstone struct {
+	ID        int       `json:"id,omitempty"` // Left out once Options.RejectSerialIDs is set
+	UID       string    `json:"uid,omitempty"`
+	Version   int64     `json:"version"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// ChangeSet is a page of the sync feed: the items changed and deleted since a token, in
+// version order. Next is the token for the following page, or for the next sync once
+// More is false.
+type ChangeSet struct {
+	Upserts    []SyncItem  `json:"upserts"`
+	Tombstones []Tombstone `json:"tombstones"`
+	Next       string      `json:"next"`
+	More       bool        `json:"more"`
+}
+
+// Mutation operations.
+const (
+	MutationUpsert = "upsert"
+	MutationDelete = "delete"
+)
+
+// Mutation is a change a client made, possibly offline. It targets an item by its UID,
+// which the client chooses for items it creates, or by ID. An upsert sets only the
+// fields it carries; unit_price and currency are one field, price.
+type Mutation struct {
+	Op        string        `json:"op"`
+	UID       string        `json:"uid,omitempty"`
+	ID        int           `json:"id,omitempty"`
+	At        time.Time     `json:"at"` // When the client made the change
+	Name      *string       `json:"name,omitempty"`
+	Quantity  *string       `json:"quantity,omitempty"`
+	UnitPrice optionalPrice `json:"unit_price"`
+	Currency  string        `json:"currency,omitempty"`
+}
+
+// optionalPrice is the unit_price of a mutation, which may be left out (unchanged),
+// null (no price) or a number.
+type optionalPrice struct {
+	Set   bool
+	Value *int64
+}
+
+func (p *optionalPrice) UnmarshalJSON(data []byte) error {
+	p.Set = true
+	if string(data) == "null" {
+		p.Value = nil
+		return nil
+	}
+	return json.Unmarshal(data, &p.Value)
+}
+
+// Mutation result statuses.
+const (
+	MutationCreated   = "created"
+	MutationUpdated   = "updated"
+	MutationDeleted   = "deleted"
+	MutationUnchanged = "unchanged"
+	MutationRejected  = "rejected"
+)
+
+// MutationResult reports what became of a mutation. Item is the item afterwards, unless
+// it is deleted. Conflicts lists the fields that kept a newer value.
+type MutationResult struct {
+	UID       string         `json:"uid,omitempty"`
+	ID        int            `json:"id,omitempty"`
+	Status    string         `json:"status"`
+	Item      *SyncItem      `json:"item,omitempty"`
+	Conflicts []SyncConflict `json:"conflicts,omitempty"`
+	Error     string         `json:"error,omitempty"` // Why it was rejected
+}
+
+// Conflict reasons.
+const (
+	ConflictNewer   = "newer"   // The field was changed after the mutation
+	ConflictDeleted = "deleted" // The item was deleted
+)
+
+// SyncConflict is a field of a mutation that lost to another change, made at At. Field is
+// name, quantity, price or, when the item was deleted, item.
+type SyncConflict struct {
+	Field  string    `json:"field"`
+	Reason string    `json:"reason"`
+	At     time.Time `json:"at"`
+}
+
+// Sync limits.
+const (
+	DefaultSyncChanges = 500
+	MaxSyncChanges     = 1000
+	MaxSyncMutations   = 500
+)
+
+// SyncStore keeps a change feed of the items and applies offline mutations. The built-in
+// stores implement it next to ItemStore.
+//
+// Every write to an item, through sync or not, gives it the next version from one
+// sequence, and every delete leaves a tombstone with a version from the same sequence.
+// A version is never visible before all lower ones are, so a client that saw version N
+// has seen every change up to N.
+type SyncStore interface {
+	// Changes returns up to limit items and up to limit tombstones with a version above
+	// since, each in version order.
+	Changes(ctx context.Context, since int64, limit int) ([]SyncItem, []Tombstone, error)
+	// ApplyMutation resolves a mutation against the stored item with resolveMutation and
+	// writes the outcome, atomically.
+	ApplyMutation(ctx context.Context, m Mutation) (MutationResult, error)
+}
+
+// syncRecord is an item with the time each field last changed, for conflict resolution.
+// Changes outside sync count at the time the store wrote them.
+type syncRecord struct {
+	SyncItem
+	NameAt     time.Time
+	QuantityAt time.Time
+	PriceAt    time.Time
+}
+
+// Sync plan actions.
+const (
+	syncCreate = "create"
+	syncUpdate = "update"
+	syncDelete = "delete"
+)
+
+// syncPlan is what a store writes for a mutation: nothing, the item in Record, or a
+// delete. Result is reported once the write succeeded.
+type syncPlan struct {
+	Action string
+	Record syncRecord
+	Result MutationResult
+}
+
+// resolveMutation decides a mutation with last-writer-wins per field. current is the
+// targeted item, or nil if there is none; deleted is its tombstone, if it was deleted.
+//
+// A field changes when the mutation is later than the field's last change; otherwise it
+// is reported as a conflict, unless it already has the mutation's value. Ties keep the
+// stored value. A delete loses to any field changed after it, and a deleted item stays
+// deleted. Applying a mutation twice changes nothing the second time, so clients can
+// retry a batch.
+func resolveMutation(current *syncRecord, deleted *Tombstone, m Mutation) syncPlan {
+	plan := syncPlan{Result: MutationResult{UID: m.UID, ID: m.ID, Status: MutationUnchanged}}
+	switch {
+	case deleted != nil:
+		if m.Op == MutationUpsert {
+			plan.Result.Conflicts = []SyncConflict{{Field: "item", Reason: ConflictDeleted, At: deleted.DeletedAt}}
+		}
+		return plan
+	case current == nil && m.Op == MutationDelete:
+		return plan
+	case current == nil && m.UID == "":
+		plan.Result.Status, plan.Result.Error = MutationRejected, fmt.Sprintf("no item with ID %d", m.ID)
+		return plan
+	case current == nil:
+		if m.Name == nil || m.Quantity == nil {
+			plan.Result.Status, plan.Result.Error = MutationRejected, "a new item needs a name and a quantity"
+			return plan
+		}
+		plan.Action, plan.Result.Status = syncCreate, MutationCreated
+		plan.Record = syncRecord{
+			SyncItem:   SyncItem{Item: Item{UID: m.UID, Name: *m.Name, Quantity: *m.Quantity, UnitPrice: m.UnitPrice.Value, Currency: m.Currency}},
+			NameAt:     m.At,
+			QuantityAt: m.At,
+			PriceAt:    m.At,
+		}
+		return plan
+	}
+
+	rec := *current
+	plan.Record = rec
+	plan.Result.UID, plan.Result.ID = rec.UID, rec.ID
+	var conflicts []SyncConflict
+	newer := func(field string, at time.Time) bool {
+		if m.At.After(at) {
+			return false
+		}
+		conflicts = append(conflicts, SyncConflict{Field: field, Reason: ConflictNewer, At: at})
+		return true
+	}
+
+	if m.Op == MutationDelete {
+		// Any field changed after the delete saves the item
+		for _, f := range []struct {
+			name string
+			at   time.Time
+		}{{"name", rec.NameAt}, {"quantity", rec.QuantityAt}, {"price", rec.PriceAt}} {
+			if f.at.After(m.At) {
+				conflicts = append(conflicts, SyncConflict{Field: f.name, Reason: ConflictNewer, At: f.at})
+			}
+		}
+		if len(conflicts) > 0 {
+			plan.Result.Item, plan.Result.Conflicts = &rec.SyncItem, conflicts
+			return plan
+		}
+		plan.Action, plan.Result.Status = syncDelete, MutationDeleted
+		return plan
+	}
+
+	changed := false
+	if m.Name != nil && *m.Name != rec.Name && !newer("name", rec.NameAt) {
+		rec.Name, rec.NameAt, changed = *m.Name, m.At, true
+	}
+	if m.Quantity != nil && *m.Quantity != rec.Quantity && !newer("quantity", rec.QuantityAt) {
+		rec.Quantity, rec.QuantityAt, changed = *m.Quantity, m.At, true
+	}
+	if m.UnitPrice.Set && !samePrice(m.UnitPrice.Value, m.Currency, rec.UnitPrice, rec.Currency) && !newer("price", rec.PriceAt) {
+		rec.UnitPrice, rec.Currency, rec.PriceAt, changed = m.UnitPrice.Value, m.Currency, m.At, true
+	}
+	if changed {
+		plan.Action, plan.Result.Status = syncUpdate, MutationUpdated
+		plan.Record = rec
+	}
+	plan.Result.Item, plan.Result.Conflicts = &rec.SyncItem, conflicts
+	return plan
+}
+
+// samePrice reports whether two unit prices with their currencies are equal.
+func samePrice(a *int64, currencyA string, b *int64, currencyB string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b && currencyA == currencyB
+}
+
+// normalizeMutation checks a mutation and normalizes its fields with limits, returning a
+// *ValidationError listing every invalid field.
+func normalizeMutation(m Mutation, limits FieldLimits) (Mutation, error) {
+	var fields []FieldError
+	switch m.Op {
+	case MutationUpsert:
+	case MutationDelete:
+		if m.Name != nil || m.Quantity != nil || m.UnitPrice.Set || m.Currency != "" {
+			fields = append(fields, FieldError{Field: "op", Message: "delete must not set fields"})
+		}
+	default:
+		fields = append(fields, FieldError{Field: "op", Message: `must be "upsert" or "delete"`})
+	}
+	switch {
+	case m.UID != "" && m.ID != 0:
+		fields = append(fields, FieldError{Field: "uid", Message: "must not be set together with id"})
+	case m.UID != "":
+		uid, ok := parseUUID(m.UID)
+		if !ok {
+			fields = append(fields, FieldError{Field: "uid", Message: "must be a UUID"})
+		}
+		m.UID = uid
+	case m.ID < 0:
+		fields = append(fields, FieldError{Field: "id", Message: "must be a positive integer"})
+	case m.ID == 0:
+		fields = append(fields, FieldError{Field: "uid", Message: "is required unless id is set"})
+	}
+	if m.At.IsZero() {
+		fields = append(fields, FieldError{Field: "at", Message: "is required"})
+	}
+
+	// Check the fields that are set like an item, with placeholders for the others
+	item := Item{Name: "-", Quantity: "-", UnitPrice: m.UnitPrice.Value, Currency: m.Currency}
+	if m.Name != nil {
+		item.Name = *m.Name
+	}
+	if m.Quantity != nil {
+		item.Quantity = *m.Quantity
+	}
+	item, err := normalizeItem(item, limits)
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		fields = append(fields, invalid.Fields...)
+	}
+	if len(fields) > 0 {
+		return Mutation{}, &ValidationError{Fields: fields}
+	}
+	if m.Name != nil {
+		m.Name = &item.Name
+	}
+	if m.Quantity != nil {
+		m.Quantity = &item.Quantity
+	}
+	m.Currency = item.Currency
+	m.At = m.At.UTC()
+	return m, nil
+}
+
+// mergeChanges builds a page of at most limit changes from items and tombstones that
+// each hold up to limit+1 changes above since, in version order.
+func mergeChanges(since int64, items []SyncItem, tombstones []Tombstone, limit int) ChangeSet {
+	changes := ChangeSet{Upserts: []SyncItem{}, Tombstones: []Tombstone{}, Next: strconv.FormatInt(since, 10)}
+	i, j := 0, 0
+	for n := 0; i < len(items) || j < len(tombstones); n++ {
+		if n == limit {
+			changes.More = true
+			break
+		}
+		var version int64
+		if j == len(tombstones) || (i < len(items) && items[i].Version < tombstones[j].Version) {
+			version = items[i].Version
+			changes.Upserts = append(changes.Upserts, items[i])
+			i++
+		} else {
+			version = tombstones[j].Version
+			changes.Tombstones = append(changes.Tombstones, tombstones[j])
+			j++
+		}
+		changes.Next = strconv.FormatInt(version, 10)
+	}
+	return changes
+}
+
+// --- Sync Handlers ---
+
+// syncHandler serves GET /sync?since={token} and POST /sync.
+func (s *Server) syncHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.changesHandler(w, r)
+	case http.MethodPost:
+		s.mutationsHandler(w, r)
+	default:
+		s.methodNotAllowed(w, r, "GET, POST")
+	}
+}
+
+// changesHandler serves the change feed. Without a token it starts from the beginning,
+// leaving out tombstones since the client has nothing to delete yet.
+func (s *Server) changesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var fields []FieldError
+	var since int64
+	if v := query.Get("since"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			fields = append(fields, FieldError{Field: "since", Message: "must be a token from an earlier sync"})
+		}
+		since = n
+	}
+	limit := DefaultSyncChanges
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxSyncChanges {
+			fields = append(fields, FieldError{Field: "limit", Message: fmt.Sprintf("must be a whole number between 1 and %d", MaxSyncChanges)})
+		}
+		limit = n
+	}
+	if len(fields) > 0 {
+		s.writeQueryProblem(w, r, fields...)
+		return
+	}
+
+	items, tombstones, err := s.sync.Changes(r.Context(), since, limit+1)
+	if err != nil {
+		s.requestLogger(r).Error("Error getting changes", "since", since, "err", err)
+		s.writeError(w, r, err)
+		return
+	}
+	if since == 0 {
+		tombstones = nil
+	}
+	s.writeJSON(w, r, http.StatusOK, s.publicChanges(mergeChanges(since, items, tombstones, limit)))
+}
+
+// syncBatch is the body of POST /sync.
+type syncBatch struct {
+	Mutations []Mutation `json:"mutations"`
+}
+
+// mutationsHandler applies a batch of mutations in order, each on its own. If one fails,
+// the ones before it stay applied; clients retry the whole batch, which is safe since
+// mutations are idempotent.
+func (s *Server) mutationsHandler(w http.ResponseWriter, r *http.Request) {
+	var batch syncBatch
+	if !s.decodeJSON(w, r, &batch) {
+		return
+	}
+	var fields []FieldError
+	if len(batch.Mutations) > MaxSyncMutations {
+		fields = append(fields, FieldError{Field: "mutations", Message: fmt.Sprintf("must not have more than %d entries", MaxSyncMutations)})
+	}
+	now := s.now().UTC()
+	for i, m := range batch.Mutations {
+		m, err := normalizeMutation(m, s.limits)
+		var invalid *ValidationError
+		if errors.As(err, &invalid) {
+			for _, f := range invalid.Fields {
+				fields = append(fields, FieldError{Field: fmt.Sprintf("mutations[%d].%s", i, f.Field), Message: f.Message})
+			}
+			continue
+		}
+		if s.rejectSerialIDs && m.ID != 0 {
+			fields = append(fields, FieldError{Field: fmt.Sprintf("mutations[%d].id", i), Message: "is not accepted; use uid"})
+			continue
+		}
+		// A clock running ahead must not win every conflict
+		if m.At.After(now) {
+			m.At = now
+		}
+		batch.Mutations[i] = m
+	}
+	if len(fields) > 0 {
+		s.writeProblem(w, r, Problem{
+			Type:   problemTypeValidation,
+			Title:  "Invalid sync batch",
+			Status: http.StatusBadRequest,
+			Detail: fields[0].Field + " " + fields[0].Message,
+			Errors: fields,
+		})
+		return
+	}
+
+	results := make([]MutationResult, 0, len(batch.Mutations))
+	for _, m := range batch.Mutations {
+		result, err := s.sync.ApplyMutation(r.Context(), m)
+		if err != nil {
+			s.requestLogger(r).Warn("Error applying mutation", "uid", m.UID, "id", m.ID, "err", err)
+			s.writeError(w, r, err)
+			return
+		}
+		s.mutated(r.Context(), m, result)
+		results = append(results, s.publicResult(result))
+	}
+	s.writeJSON(w, r, http.StatusOK, map[string][]MutationResult{"results": results})
+}
+
+// mutated does for a synced item what addItem does for others: new items go into the
+// history, and explicit prices are remembered.
+func (s *Server) mutated(ctx context.Context, m Mutation, result MutationResult) {
+	if result.Item == nil {
+		return
+	}
+	if result.Status == MutationCreated {
+		s.recordUse(ctx, result.Item.Item)
+	}
+	if m.UnitPrice.Value != nil && (result.Status == MutationCreated || result.Status == MutationUpdated) {
+		s.rememberPrice(ctx, result.Item.Item)
+	}
+}
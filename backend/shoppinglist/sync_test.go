@@ -0,0 +1,174 @@
+package shoppinglist
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestResolveMutation(t *testing.T) {
+	str := func(s string) *string { return &s }
+	t0 := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	current := &syncRecord{
+		SyncItem:   SyncItem{Item: Item{ID: 7, UID: "u", Name: "Milk", Quantity: "1 l"}, Version: 3},
+		NameAt:     t0,
+		QuantityAt: t0.Add(time.Hour),
+		PriceAt:    t0,
+	}
+
+	tests := []struct {
+		name      string
+		current   *syncRecord
+		deleted   *Tombstone
+		m         Mutation
+		action    string
+		status    string
+		conflicts []string
+	}{
+		{"NewItem", nil, nil, Mutation{Op: MutationUpsert, UID: "u", At: t0, Name: str("Milk"), Quantity: str("1")}, syncCreate, MutationCreated, nil},
+		{"NewItemWithoutName", nil, nil, Mutation{Op: MutationUpsert, UID: "u", At: t0, Quantity: str("1")}, "", MutationRejected, nil},
+		{"UnknownID", nil, nil, Mutation{Op: MutationUpsert, ID: 9, At: t0, Quantity: str("1")}, "", MutationRejected, nil},
+		{"DeleteUnknown", nil, nil, Mutation{Op: MutationDelete, UID: "u", At: t0}, "", MutationUnchanged, nil},
+		{"LaterFieldWins", current, nil, Mutation{Op: MutationUpsert, UID: "u", At: t0.Add(time.Minute), Name: str("Oat milk"), Quantity: str("2 l")}, syncUpdate, MutationUpdated, []string{"quantity"}},
+		{"TieKeepsStored", current, nil, Mutation{Op: MutationUpsert, UID: "u", At: t0, Name: str("Oat milk")}, "", MutationUnchanged, []string{"name"}},
+		{"SameValueIsNoConflict", current, nil, Mutation{Op: MutationUpsert, UID: "u", At: t0, Name: str("Milk")}, "", MutationUnchanged, nil},
+		{"PriceCleared", current, nil, Mutation{Op: MutationUpsert, UID: "u", At: t0.Add(time.Minute), UnitPrice: optionalPrice{Set: true}}, "", MutationUnchanged, nil},
+		{"DeleteLosesToLaterEdit", current, nil, Mutation{Op: MutationDelete, UID: "u", At: t0.Add(time.Minute)}, "", MutationUnchanged, []string{"quantity"}},
+		{"DeleteWinsTie", current, nil, Mutation{Op: MutationDelete, UID: "u", At: t0.Add(time.Hour)}, syncDelete, MutationDeleted, nil},
+		{"DeletedStaysDeleted", nil, &Tombstone{ID: 7, UID: "u", DeletedAt: t0}, Mutation{Op: MutationUpsert, UID: "u", At: t0.Add(time.Hour), Name: str("Milk")}, "", MutationUnchanged, []string{"item"}},
+		{"DeleteAgain", nil, &Tombstone{ID: 7, UID: "u", DeletedAt: t0}, Mutation{Op: MutationDelete, UID: "u", At: t0}, "", MutationUnchanged, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := resolveMutation(tt.current, tt.deleted, tt.m)
+			if plan.Action != tt.action || plan.Result.Status != tt.status {
+				t.Errorf("Expected %q/%q, got %q/%q", tt.action, tt.status, plan.Action, plan.Result.Status)
+			}
+			var fields []string
+			for _, c := range plan.Result.Conflicts {
+				fields = append(fields, c.Field)
+			}
+			if len(fields) != len(tt.conflicts) || (len(fields) > 0 && fields[0] != tt.conflicts[0]) {
+				t.Errorf("Expected conflicts %v, got %v", tt.conflicts, fields)
+			}
+		})
+	}
+
+	// A later name applies even though the quantity conflicts
+	plan := resolveMutation(current, nil, tests[4].m)
+	if plan.Record.Name != "Oat milk" || plan.Record.Quantity != "1 l" || !plan.Record.NameAt.Equal(t0.Add(time.Minute)) {
+		t.Errorf("Expected only the name to change, got %+v", plan.Record)
+	}
+	if current.Name != "Milk" {
+		t.Errorf("Expected the current record to be left alone, got %+v", current)
+	}
+}
+
+func TestMergeChanges(t *testing.T) {
+	items := []SyncItem{{Version: 2}, {Version: 5}}
+	tombstones := []Tombstone{{Version: 3}, {Version: 6}}
+
+	page := mergeChanges(1, items, tombstones, 3)
+	if len(page.Upserts) != 2 || len(page.Tombstones) != 1 || page.Next != "5" || !page.More {
+		t.Errorf("Expected versions 2, 3 and 5 with more to come, got %+v", page)
+	}
+	page = mergeChanges(5, nil, tombstones[1:], 3)
+	if len(page.Tombstones) != 1 || page.Next != "6" || page.More {
+		t.Errorf("Expected the last page, got %+v", page)
+	}
+	if page := mergeChanges(6, nil, nil, 3); page.Next != "6" || page.Upserts == nil || page.Tombstones == nil {
+		t.Errorf("Expected an empty page keeping the token, got %+v", page)
+	}
+}
+
+func TestSyncHandler(t *testing.T) {
+	srv := newTestServer(t, NewMemoryStore())
+	changes := func(target string) ChangeSet {
+		t.Helper()
+		rr := serve(srv, "GET", target, "")
+		var got ChangeSet
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		return got
+	}
+	push := func(body string) []MutationResult {
+		t.Helper()
+		rr := serve(srv, "POST", "/sync", body)
+		var got struct {
+			Results []MutationResult `json:"results"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d with JSON, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		return got.Results
+	}
+
+	rr := serve(srv, "POST", "/items", `{"name":"Bread","quantity":"1"}`)
+	var bread Item
+	if err := json.Unmarshal(rr.Body.Bytes(), &bread); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	at := time.Now().UTC().Add(-time.Minute).Format(time.RFC3339Nano)
+	later := time.Now().UTC().Add(time.Minute).Format(time.RFC3339Nano)
+	results := push(`{"mutations":[
+		{"op":"upsert","uid":"0192a8e4-7c1b-7def-8a12-3456789abcde","at":"` + at + `","name":"Milk","quantity":"1 l","unit_price":129,"currency":"EUR"},
+		{"op":"upsert","id":` + jsonNumber(bread.ID) + `,"at":"` + later + `","quantity":"2"}
+	]}`)
+	if len(results) != 2 || results[0].Status != MutationCreated || results[1].Status != MutationUpdated || results[1].Item.Quantity != "2" {
+		t.Fatalf("Expected a created and an updated item, got %+v", results)
+	}
+	milk := results[0].Item
+
+	first := changes("/sync?limit=1")
+	if len(first.Upserts) != 1 || first.Upserts[0].ID != milk.ID || !first.More {
+		t.Fatalf("Expected the first page to hold Milk, got %+v", first)
+	}
+	second := changes("/sync?since=" + first.Next)
+	if len(second.Upserts) != 1 || second.Upserts[0].ID != bread.ID || second.More {
+		t.Fatalf("Expected the second page to hold Bread, got %+v", second)
+	}
+
+	// A delete shows up as a tombstone, except for a client starting from scratch
+	if rr := serve(srv, "DELETE", "/items/"+jsonNumber(bread.ID), ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if got := changes("/sync?since=" + second.Next); len(got.Tombstones) != 1 || got.Tombstones[0].ID != bread.ID || len(got.Upserts) != 0 {
+		t.Errorf("Expected a tombstone for Bread, got %+v", got)
+	}
+	if got := changes("/sync"); len(got.Tombstones) != 0 || len(got.Upserts) != 1 {
+		t.Errorf("Expected only Milk from scratch, got %+v", got)
+	}
+
+	// A clock running ahead is clamped, so a later change still wins
+	results = push(`{"mutations":[{"op":"upsert","uid":"` + milk.UID + `","at":"2100-01-01T00:00:00Z","quantity":"2 l"}]}`)
+	if results[0].Status != MutationUpdated {
+		t.Fatalf("Expected the update to apply, got %+v", results)
+	}
+	later = time.Now().UTC().Add(time.Minute).Format(time.RFC3339Nano)
+	results = push(`{"mutations":[{"op":"upsert","uid":"` + milk.UID + `","at":"` + later + `","quantity":"3 l","unit_price":null}]}`)
+	if results[0].Status != MutationUpdated || results[0].Item.Quantity != "3 l" || results[0].Item.UnitPrice != nil {
+		t.Errorf("Expected the later update to win and clear the price, got %+v", results)
+	}
+
+	rr = serve(srv, "POST", "/sync", `{"mutations":[{"op":"upsert","uid":"nope","at":"`+at+`"},{"op":"delete","id":1,"at":"`+at+`","name":"x"}]}`)
+	p := decodeProblem(t, rr)
+	if rr.Code != http.StatusBadRequest || len(p.Errors) != 2 || p.Errors[0].Field != "mutations[0].uid" || p.Errors[1].Field != "mutations[1].op" {
+		t.Errorf("Expected errors for both mutations, got %d %+v", rr.Code, p)
+	}
+	rr = serve(srv, "GET", "/sync?since=abc", "")
+	if p := decodeProblem(t, rr); rr.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "since" {
+		t.Errorf("Expected a since error, got %d %+v", rr.Code, p)
+	}
+	if rr := serve(srv, "PUT", "/sync", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
+
+func TestSyncNotServedWithoutSyncStore(t *testing.T) {
+	srv := newTestServer(t, struct{ ItemStore }{NewMemoryStore()})
+	if rr := serve(srv, "GET", "/sync", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
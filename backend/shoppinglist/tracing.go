@@ -0,0 +1,109 @@
+package shoppinglist
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// --- OpenTelemetry Tracing ---
+
+// tracerName identifies spans created by this package (the instrumentation scope).
+const tracerName = "backend/shoppinglist"
+
+// tracingMiddleware starts a server span per request, continuing the trace from an
+// incoming W3C traceparent header (e.g. set by nginx or the browser). The trace ID is
+// added to the request-scoped logger so log lines can be matched to traces.
+func (s *Server) tracingMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		_, route := mux.Handler(r)
+
+		// Span names must stay low-cardinality: use the route pattern, never the raw path
+		name := r.Method
+		if route != "" {
+			name += " " + route
+		}
+		ctx, span := s.tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			ctx = ContextWithLogger(ctx, loggerFrom(ctx).With("trace_id", sc.TraceID().String()))
+		}
+
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK // Handler wrote nothing
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// --- pgx Query Tracing ---
+
+// QueryTracer creates a client span for every query run on a pgx connection. ConnectDB
+// installs one automatically; services that build their own pool can set it on
+// pgxpool.Config.ConnConfig.Tracer.
+type QueryTracer struct {
+	tracer trace.Tracer
+}
+
+// NewQueryTracer returns a QueryTracer that records spans with tp.
+func NewQueryTracer(tp trace.TracerProvider) *QueryTracer {
+	return &QueryTracer{tracer: tp.Tracer(tracerName)}
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system.name", "postgresql"),
+		attribute.String("db.query.text", data.SQL), // Parameterised, so no values leak into traces
+	}
+	if conn != nil {
+		attrs = append(attrs, attribute.String("db.namespace", conn.Config().Database))
+	}
+	ctx, _ = t.tracer.Start(ctx, querySpanName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	} else {
+		span.SetAttributes(attribute.Int64("db.response.affected_rows", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
+
+// querySpanName returns the SQL verb (e.g. "SELECT"), which keeps span names low-cardinality.
+func querySpanName(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "postgresql"
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,180 @@
+package shoppinglist
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTracerProvider returns a provider that exports finished spans synchronously to memory.
+func newTracerProvider(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return tp, exp
+}
+
+// findSpan returns the exported span with the given name, failing the test if there is none.
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	t.Fatalf("No span named %q, got %v", name, names)
+	return tracetest.SpanStub{}
+}
+
+// spanAttr returns the value of key on span, or an invalid Value if it is not set.
+func spanAttr(span tracetest.SpanStub, key string) attribute.Value {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingServerSpans(t *testing.T) {
+	tp, exp := newTracerProvider(t)
+	var logs bytes.Buffer
+	srv, err := New(Options{Store: NewMemoryStore(), TracerProvider: tp, Logger: slog.New(slog.NewJSONHandler(&logs, nil))})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentID = "00f067aa0ba902b7"
+	req := httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"Milk","quantity":"1"}`))
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+
+	spans := exp.GetSpans()
+	server := findSpan(t, spans, "POST /items")
+	store := findSpan(t, spans, "addItem")
+
+	// The server span continues the caller's trace
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("Expected a server span, got %v", server.SpanKind)
+	}
+	if got := server.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("Expected trace ID %s from traceparent, got %s", traceID, got)
+	}
+	if got := server.Parent.SpanID().String(); got != parentID || !server.Parent.IsRemote() {
+		t.Errorf("Expected remote parent %s, got %s", parentID, got)
+	}
+	if route := spanAttr(server, "http.route").AsString(); route != "/items" {
+		t.Errorf("Expected http.route /items, got %q", route)
+	}
+	if status := spanAttr(server, "http.response.status_code").AsInt64(); status != http.StatusCreated {
+		t.Errorf("Expected http.response.status_code %d, got %d", http.StatusCreated, status)
+	}
+
+	// The store operation is a child of the server span
+	if store.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("Expected addItem to be a child of the server span")
+	}
+
+	// Logs written while serving the request carry the trace ID
+	if !strings.Contains(logs.String(), `"trace_id":"`+traceID+`"`) {
+		t.Errorf("Expected trace_id in request logs, got %q", logs.String())
+	}
+}
+
+func TestTracingErrorStatus(t *testing.T) {
+	mock, cleanup := newMockPool(t)
+	defer cleanup()
+	mock.ExpectQuery("SELECT id, name, quantity, unit_price, currency, created_at FROM items").WillReturnError(errors.New("connection reset"))
+
+	tp, exp := newTracerProvider(t)
+	srv, err := New(Options{Store: NewPostgresStore(mock), TracerProvider: tp})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil))
+
+	spans := exp.GetSpans()
+	if server := findSpan(t, spans, "GET /items"); server.Status.Code != codes.Error {
+		t.Errorf("Expected server span status Error, got %v", server.Status.Code)
+	}
+	if store := findSpan(t, spans, "getItems"); store.Status.Code != codes.Error || len(store.Events) == 0 {
+		t.Errorf("Expected getItems span with Error status and a recorded error, got %+v", store.Status)
+	}
+}
+
+func TestTracingNotFoundIsNotAnError(t *testing.T) {
+	tp, exp := newTracerProvider(t)
+	srv, err := New(Options{Store: NewMemoryStore(), TracerProvider: tp})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/items/42", nil))
+
+	spans := exp.GetSpans()
+	for _, name := range []string{"DELETE /items/", "deleteItem"} {
+		if s := findSpan(t, spans, name); s.Status.Code == codes.Error {
+			t.Errorf("%s: expected a 404 not to mark the span as failed", name)
+		}
+	}
+}
+
+func TestQueryTracer(t *testing.T) {
+	tp, exp := newTracerProvider(t)
+	qt := NewQueryTracer(tp)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "getItems")
+
+	t.Run("Success", func(t *testing.T) {
+		exp.Reset()
+		qctx := qt.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "  select id FROM items WHERE id = $1", Args: []any{7}})
+		qt.TraceQueryEnd(qctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+		span := findSpan(t, exp.GetSpans(), "SELECT")
+		if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Error("Expected the query span to be a child of the active span")
+		}
+		if span.SpanKind != trace.SpanKindClient {
+			t.Errorf("Expected a client span, got %v", span.SpanKind)
+		}
+		if got := spanAttr(span, "db.query.text").AsString(); !strings.Contains(got, "$1") {
+			t.Errorf("Expected the parameterised query text, got %q", got)
+		}
+		if rows := spanAttr(span, "db.response.affected_rows").AsInt64(); rows != 1 {
+			t.Errorf("Expected 1 affected row, got %d", rows)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		exp.Reset()
+		qctx := qt.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "DELETE FROM items WHERE id = $1"})
+		qt.TraceQueryEnd(qctx, nil, pgx.TraceQueryEndData{Err: errors.New("deadlock detected")})
+
+		span := findSpan(t, exp.GetSpans(), "DELETE")
+		if span.Status.Code != codes.Error || span.Status.Description != "deadlock detected" {
+			t.Errorf("Expected Error status with the query error, got %+v", span.Status)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package shoppinglist
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// --- Item Validation ---
+
+// Hard limits on item fields, in characters (Unicode code points). The database enforces
+// them with CHECK constraints, so FieldLimits may lower but never raise them.
+const (
+	MaxNameLength     = 200
+	MaxQuantityLength = 100
+)
+
+// FieldLimits caps the length of item fields, in characters after normalization.
+// A zero field means the hard limit (MaxNameLength, MaxQuantityLength).
+type FieldLimits struct {
+	MaxName     int
+	MaxQuantity int
+}
+
+// validate reports limits outside 0..the hard limit.
+func (l FieldLimits) validate() error {
+	if l.MaxName < 0 || l.MaxName > MaxNameLength {
+		return fmt.Errorf("FieldLimits.MaxName %d: must not be negative or above %d", l.MaxName, MaxNameLength)
+	}
+	if l.MaxQuantity < 0 || l.MaxQuantity > MaxQuantityLength {
+		return fmt.Errorf("FieldLimits.MaxQuantity %d: must not be negative or above %d", l.MaxQuantity, MaxQuantityLength)
+	}
+	return nil
+}
+
+// withDefaults fills zero limits with the hard limits.
+func (l FieldLimits) withDefaults() FieldLimits {
+	if l.MaxName == 0 {
+		l.MaxName = MaxNameLength
+	}
+	if l.MaxQuantity == 0 {
+		l.MaxQuantity = MaxQuantityLength
+	}
+	return l
+}
+
+// normalizeItem cleans up the name and quantity of item and checks them against limits,
+// returning a *ValidationError listing every invalid field. Every ItemStore runs it with
+// the hard limits before writing, so stored values are always normalized.
+func normalizeItem(item Item, limits FieldLimits) (Item, error) {
+	limits = limits.withDefaults()
+	var fields []FieldError
+	check := func(field string, value *string, maxLen int) {
+		normalized, msg := normalizeText(*value, maxLen)
+		if msg != "" {
+			fields = append(fields, FieldError{Field: field, Message: msg})
+			return
+		}
+		*value = normalized
+	}
+	check("name", &item.Name, limits.MaxName)
+	check("quantity", &item.Quantity, limits.MaxQuantity)
+	fields = checkItemPrice(&item, fields)
+	if len(fields) > 0 {
+		return Item{}, &ValidationError{Fields: fields}
+	}
+	return item, nil
+}
+
+// normalizeText returns s with zero-width spaces removed, every run of whitespace
+// (including tabs, newlines and no-break spaces) collapsed to one space, surrounding
+// whitespace trimmed, and in Unicode NFC form. If s cannot be stored, msg says why.
+func normalizeText(s string, maxLen int) (normalized, msg string) {
+	if !utf8.ValidString(s) {
+		return "", "must be valid UTF-8"
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range s {
+		switch {
+		case isZeroWidthSpace(r):
+			continue
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case unicode.IsControl(r):
+			return "", "must not contain control characters"
+		case unicode.Is(unicode.Bidi_Control, r):
+			return "", "must not contain bidirectional control characters"
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+	}
+
+	normalized = norm.NFC.String(b.String())
+	switch n := utf8.RuneCountInString(normalized); {
+	case n == 0:
+		return "", "cannot be empty"
+	case n > maxLen:
+		return "", fmt.Sprintf("must be at most %d characters", maxLen)
+	}
+	return normalized, ""
+}
+
+// isZeroWidthSpace reports whether r is an invisible spacing character that is dropped
+// during normalization. The zero-width (non-)joiners are kept: emoji sequences and
+// several scripts need them.
+func isZeroWidthSpace(r rune) bool {
+	switch r {
+	case '\u200b', '\u2060', '\ufeff': // Zero width space, word joiner, byte order mark
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package shoppinglist
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// --- Validation Tests ---
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name, in, want, msg string
+	}{
+		{name: "Plain", in: "Milk", want: "Milk"},
+		{name: "Trimmed", in: "  Milk \n", want: "Milk"},
+		{name: "CollapsesWhitespace", in: "Whole\t\tmilk\r\n2%", want: "Whole milk 2%"},
+		{name: "NoBreakSpaces", in: "1\u00a0kg\u3000bag", want: "1 kg bag"},
+		{name: "DropsZeroWidthSpaces", in: "\ufeffMi\u200blk\u2060", want: "Milk"},
+		{name: "KeepsJoiners", in: "\U0001F468\u200d\U0001F373", want: "\U0001F468\u200d\U0001F373"},
+		{name: "NFC", in: "Jalapen\u0303o", want: "Jalape\u00f1o"},
+		{name: "Empty", in: "", msg: "cannot be empty"},
+		{name: "OnlyInvisible", in: " \u200b\t", msg: "cannot be empty"},
+		{name: "ControlCharacter", in: "Milk\x00", msg: "must not contain control characters"},
+		{name: "Escape", in: "\x1b[31mMilk", msg: "must not contain control characters"},
+		{name: "BidiOverride", in: "Milk\u202e2", msg: "must not contain bidirectional control characters"},
+		{name: "BidiIsolate", in: "\u2067Milk", msg: "must not contain bidirectional control characters"},
+		{name: "InvalidUTF8", in: "Milk\xff", msg: "must be valid UTF-8"},
+		{name: "AtLimit", in: strings.Repeat("\u00e9", 15), want: strings.Repeat("\u00e9", 15)},
+		{name: "TooLong", in: strings.Repeat("a", 16), msg: "must be at most 15 characters"},
+		{name: "LengthAfterNormalization", in: "  " + strings.Repeat("e\u0301", 15) + "  ", want: strings.Repeat("\u00e9", 15)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, msg := normalizeText(tt.in, 15)
+			if got != tt.want || msg != tt.msg {
+				t.Errorf("normalizeText(%q) = %q, %q; expected %q, %q", tt.in, got, msg, tt.want, tt.msg)
+			}
+		})
+	}
+}
+
+func TestNormalizeItemLimits(t *testing.T) {
+	_, err := normalizeItem(Item{Name: "Sourdough", Quantity: "1 loaf, sliced"}, FieldLimits{MaxName: 5, MaxQuantity: 5})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	want := "invalid item: name must be at most 5 characters; quantity must be at most 5 characters"
+	if verr.Error() != want {
+		t.Errorf("Expected %q, got %q", want, verr.Error())
+	}
+
+	// Zero limits fall back to the hard limits
+	if _, err := normalizeItem(Item{Name: strings.Repeat("a", MaxNameLength), Quantity: "1"}, FieldLimits{}); err != nil {
+		t.Errorf("Expected a name at the hard limit to pass, got %v", err)
+	}
+}
+
+func TestAddItemFieldLimits(t *testing.T) {
+	store := NewMemoryStore()
+	srv, err := New(Options{Store: store, FieldLimits: FieldLimits{MaxName: 8}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	post := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, httptest.NewRequest("POST", "/items", strings.NewReader(body)))
+		return rr
+	}
+
+	rr := post(`{"name":"Sourdough bread","quantity":"1"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	p := decodeProblem(t, rr)
+	if len(p.Errors) != 1 || p.Errors[0] != (FieldError{Field: "name", Message: "must be at most 8 characters"}) {
+		t.Errorf("Expected a name length error, got %+v", p.Errors)
+	}
+
+	rr = post(`{"name":" Bread\u202e ","quantity":"1"}`)
+	if p := decodeProblem(t, rr); rr.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "name" {
+		t.Errorf("Expected a 400 for a bidi override, got %d %+v", rr.Code, p)
+	}
+	if items, _ := store.List(t.Context()); len(items) != 0 {
+		t.Errorf("Expected rejected items not to be stored, got %d", len(items))
+	}
+}
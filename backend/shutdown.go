@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// --- Graceful Shutdown ---
+
+// forcedShutdownGrace is how long cancelled requests get to return after the shutdown
+// timeout expires, before storage is closed underneath them.
+const forcedShutdownGrace = 2 * time.Second
+
+// drainCloser is the app being served: *shoppinglist.Server, or the startupGate in front of it.
+type drainCloser interface {
+	Drain()
+	Close() error
+}
+
+// shutdownConfig controls how the server drains on SIGINT/SIGTERM.
+type shutdownConfig struct {
+	// PreStopDelay keeps serving (with /readyz failing) so load balancers and
+	// Kubernetes endpoints stop routing here before connections are closed.
+	PreStopDelay time.Duration
+	// Timeout bounds how long in-flight requests may take to finish.
+	Timeout time.Duration
+}
+
+// serve runs server on ln (over TLS if server.TLSConfig is set) until ctx is cancelled, then shuts down in order: mark the app as
+// draining, wait PreStopDelay, stop accepting connections and wait up to Timeout for in-flight
+// requests, cancel the contexts of any that are still running (long polls, streams), and
+// finally close the app's storage once no handler can use it.
+func serve(ctx context.Context, server *http.Server, ln net.Listener, app drainCloser, cfg shutdownConfig) error {
+	// Request contexts derive from baseCtx, so cancelling it reaches every in-flight handler
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	server.BaseContext = func(net.Listener) context.Context { return baseCtx }
+
+	var inflight sync.WaitGroup
+	next := server.Handler
+	server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inflight.Add(1)
+		defer inflight.Done()
+		next.ServeHTTP(w, r)
+	})
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if server.TLSConfig != nil {
+			// Certificates come from TLSConfig.GetCertificate
+			serveErr <- server.ServeTLS(ln, "", "")
+			return
+		}
+		serveErr <- server.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// Serve failed before any shutdown was requested
+		return errors.Join(err, app.Close())
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, marking server as not ready", "pre_stop_delay", cfg.PreStopDelay.String())
+	app.Drain()
+	time.Sleep(cfg.PreStopDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	var shutdownErr error
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Graceful shutdown timed out, cancelling in-flight requests", "timeout", cfg.Timeout.String())
+		cancelRequests()
+		shutdownErr = errors.Join(err, server.Close())
+		if !waitTimeout(&inflight, forcedShutdownGrace) {
+			slog.Error("Requests still running after cancellation; closing storage anyway")
+		}
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		shutdownErr = errors.Join(shutdownErr, err)
+	}
+
+	// Storage goes last: every handler that could use the pool has returned
+	if err := app.Close(); err != nil {
+		return errors.Join(shutdownErr, err)
+	}
+	slog.Info("Server stopped")
+	return shutdownErr
+}
+
+// waitTimeout waits for wg and reports whether it finished within d.
+func waitTimeout(wg *sync.WaitGroup, d time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
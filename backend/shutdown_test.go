@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/shoppinglist"
+)
+
+// --- Graceful Shutdown Tests ---
+
+// eventLog records the order in which things happen across goroutines.
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *eventLog) add(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *eventLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.events, ",")
+}
+
+// closeRecordingStore is a MemoryStore that logs when it is closed.
+type closeRecordingStore struct {
+	*shoppinglist.MemoryStore
+	log *eventLog
+}
+
+func (s closeRecordingStore) Close() error {
+	s.log.add("store closed")
+	return nil
+}
+
+// testServe starts serve on a loopback listener. The app answers /slow with slow, and the
+// returned channel yields serve's result once cancel has been called and shutdown finished.
+func testServe(t *testing.T, cfg shutdownConfig, log *eventLog, slow http.HandlerFunc) (baseURL string, cancel context.CancelFunc, result <-chan error) {
+	t.Helper()
+	intercept := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/slow" {
+				slow(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	app, err := shoppinglist.New(shoppinglist.Options{
+		Store:      closeRecordingStore{MemoryStore: shoppinglist.NewMemoryStore(), log: log},
+		Middleware: []shoppinglist.Middleware{intercept},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serve(ctx, &http.Server{Handler: app}, ln, app, cfg) }()
+	t.Cleanup(cancel)
+	return "http://" + ln.Addr().String(), cancel, done
+}
+
+// waitResult waits for serve to return, failing the test if it hangs.
+func waitResult(t *testing.T, result <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return")
+		return nil
+	}
+}
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	var log eventLog
+	started := make(chan struct{})
+	release := make(chan struct{})
+	baseURL, cancel, result := testServe(t, shutdownConfig{PreStopDelay: 300 * time.Millisecond, Timeout: 5 * time.Second}, &log,
+		func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			if r.Context().Err() != nil {
+				t.Error("Expected an in-flight request within the timeout to keep a live context")
+			}
+			log.add("handler done")
+			w.WriteHeader(http.StatusOK)
+		})
+
+	slowStatus := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(baseURL + "/slow")
+		if err != nil {
+			t.Errorf("In-flight request failed: %v", err)
+			slowStatus <- 0
+			return
+		}
+		resp.Body.Close()
+		slowStatus <- resp.StatusCode
+	}()
+	<-started
+	cancel()
+
+	// During the pre-stop delay the server still answers, but reports not ready
+	deadline := time.Now().Add(time.Second)
+	for {
+		resp, err := http.Get(baseURL + "/readyz")
+		if err != nil {
+			t.Fatalf("Request during pre-stop delay failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected /readyz to report 503 after the signal, got %d", resp.StatusCode)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(release)
+	if status := <-slowStatus; status != http.StatusOK {
+		t.Errorf("Expected the in-flight request to complete with 200, got %d", status)
+	}
+	if err := waitResult(t, result); err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+	if got := log.String(); got != "handler done,store closed" {
+		t.Errorf("Expected the store to close after the request finished, got %q", got)
+	}
+
+	// The listener is closed once serve returns
+	if _, err := http.Get(baseURL + "/livez"); err == nil {
+		t.Error("Expected new connections to be refused after shutdown")
+	}
+}
+
+func TestServeCancelsLongRequests(t *testing.T) {
+	var log eventLog
+	started := make(chan struct{})
+	baseURL, cancel, result := testServe(t, shutdownConfig{Timeout: 100 * time.Millisecond}, &log,
+		func(w http.ResponseWriter, r *http.Request) {
+			// A stream that only ends when its context does, like an SSE subscription
+			w.WriteHeader(http.StatusOK)
+			http.NewResponseController(w).Flush()
+			close(started)
+			<-r.Context().Done()
+			log.add("handler cancelled")
+		})
+
+	go func() {
+		// Keep reading so the client does not hang up before the server does
+		if resp, err := http.Get(baseURL + "/slow"); err == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	err := waitResult(t, result)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the shutdown timeout to be reported, got %v", err)
+	}
+	if got := log.String(); got != "handler cancelled,store closed" {
+		t.Errorf("Expected the long request to be cancelled before the store closed, got %q", got)
+	}
+}
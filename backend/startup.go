@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"backend/shoppinglist"
+)
+
+// --- Resilient Startup ---
+
+// backoff retries a startup operation with exponential backoff and full jitter, so that
+// replicas restarting together do not hammer the database in lockstep.
+type backoff struct {
+	Initial time.Duration // Upper bound of the first delay
+	Max     time.Duration // Upper bound of any single delay
+	MaxWait time.Duration // Give up once this much time has passed since the first attempt
+	// AttemptTimeout bounds a single attempt, so an unresponsive host counts as a failure
+	// instead of stalling startup. Zero means no limit.
+	AttemptTimeout time.Duration
+}
+
+// delay returns a random wait before retry number attempt (starting at 1):
+// uniform in [0, min(Max, Initial*2^(attempt-1))].
+func (b backoff) delay(attempt int) time.Duration {
+	ceiling := b.Initial
+	for i := 1; i < attempt && ceiling < b.Max; i++ {
+		ceiling *= 2
+	}
+	if ceiling > b.Max {
+		ceiling = b.Max
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
+
+// retry calls op until it succeeds, ctx is cancelled or MaxWait has elapsed, logging every
+// failed attempt. It returns the last error from op.
+func (b backoff) retry(ctx context.Context, what string, op func(ctx context.Context) error) error {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		err := b.attempt(ctx, op)
+		if err == nil {
+			if attempt > 1 {
+				slog.Info("Startup operation succeeded", "operation", what, "attempts", attempt)
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		wait := b.delay(attempt)
+		if time.Since(start)+wait > b.MaxWait {
+			return fmt.Errorf("%s: giving up after %d attempts in %s: %w", what, attempt, time.Since(start).Round(time.Millisecond), err)
+		}
+		slog.Warn("Startup operation failed, retrying", "operation", what, "attempt", attempt, "retry_in", wait.Round(time.Millisecond).String(), "err", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt calls op once, within AttemptTimeout if set.
+func (b backoff) attempt(ctx context.Context, op func(ctx context.Context) error) error {
+	if b.AttemptTimeout <= 0 {
+		return op(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, b.AttemptTimeout)
+	defer cancel()
+	return op(ctx)
+}
+
+// startApp builds the shoppinglist.Server. Given a database config it connects and applies
+// migrations, retrying both with b; other storage backends start in a single attempt.
+func startApp(ctx context.Context, opts shoppinglist.Options, db *shoppinglist.DBConfig, b backoff) (*shoppinglist.Server, error) {
+	if db == nil {
+		return shoppinglist.New(opts)
+	}
+	var app *shoppinglist.Server
+	err := b.retry(ctx, "database startup", func(ctx context.Context) error {
+		pool, err := shoppinglist.ConnectDB(ctx, *db)
+		if err != nil {
+			return err
+		}
+		opts.Pool = pool
+		if app, err = shoppinglist.New(opts); err != nil {
+			pool.Close()
+			return err
+		}
+		return nil
+	})
+	return app, err
+}
+
+// startupGate is the http.Server's handler from the moment it listens. Until the app is
+// ready it answers /livez with 200 (the process is healthy, just waiting for its database)
+// and everything else with 503; afterwards it forwards to the app.
+type startupGate struct {
+	app atomic.Pointer[shoppinglist.Server]
+}
+
+// ready switches the gate over to app.
+func (g *startupGate) ready(app *shoppinglist.Server) {
+	g.app.Store(app)
+}
+
+func (g *startupGate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if app := g.app.Load(); app != nil {
+		app.ServeHTTP(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Path == "/livez" {
+		fmt.Fprintln(w, `{"status":"ok"}`)
+		return
+	}
+	w.Header().Set("Retry-After", "5")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	fmt.Fprintln(w, `{"status":"starting"}`)
+}
+
+// Drain implements drainCloser; there is nothing to drain before the app is ready.
+func (g *startupGate) Drain() {
+	if app := g.app.Load(); app != nil {
+		app.Drain()
+	}
+}
+
+// Close implements drainCloser by closing the app, if it was started.
+func (g *startupGate) Close() error {
+	if app := g.app.Load(); app != nil {
+		return app.Close()
+	}
+	return nil
+}
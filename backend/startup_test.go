@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/shoppinglist"
+)
+
+// --- Resilient Startup Tests ---
+
+func TestBackoffDelay(t *testing.T) {
+	b := backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+	ceilings := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, ceiling := range ceilings {
+		attempt := i + 1
+		for range 200 {
+			if d := b.delay(attempt); d < 0 || d > ceiling {
+				t.Fatalf("delay(%d) = %v, expected within [0, %v]", attempt, d, ceiling)
+			}
+		}
+	}
+	if d := b.delay(1000); d > time.Second {
+		t.Errorf("delay(1000) = %v, expected the Max cap to hold without overflow", d)
+	}
+}
+
+func TestBackoffRetry(t *testing.T) {
+	b := backoff{Initial: time.Millisecond, Max: 4 * time.Millisecond, MaxWait: time.Second}
+	errDown := errors.New("connection refused")
+
+	t.Run("SucceedsAfterFailures", func(t *testing.T) {
+		attempts := 0
+		err := b.retry(context.Background(), "test", func(ctx context.Context) error {
+			if attempts++; attempts < 4 {
+				return errDown
+			}
+			return nil
+		})
+		if err != nil || attempts != 4 {
+			t.Errorf("Expected success on attempt 4, got %d attempts and err %v", attempts, err)
+		}
+	})
+
+	t.Run("GivesUpAfterMaxWait", func(t *testing.T) {
+		short := backoff{Initial: 5 * time.Millisecond, Max: 10 * time.Millisecond, MaxWait: 50 * time.Millisecond}
+		start := time.Now()
+		err := short.retry(context.Background(), "test", func(ctx context.Context) error { return errDown })
+		if !errors.Is(err, errDown) || !strings.Contains(err.Error(), "giving up") {
+			t.Errorf("Expected a giving-up error wrapping the last failure, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected to give up near MaxWait, took %v", elapsed)
+		}
+	})
+
+	t.Run("StopsWhenCancelled", func(t *testing.T) {
+		slow := backoff{Initial: time.Hour, Max: time.Hour, MaxWait: 24 * time.Hour}
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+		err := slow.retry(ctx, "test", func(ctx context.Context) error { return errDown })
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestStartAppRetriesDatabase(t *testing.T) {
+	// Nothing listens on port 1, so every attempt fails fast with "connection refused"
+	db := &shoppinglist.DBConfig{Host: "127.0.0.1", Port: 1, User: "user", Password: "password", DBName: "shopping", SSLMode: "disable"}
+	b := backoff{Initial: 5 * time.Millisecond, Max: 20 * time.Millisecond, MaxWait: 100 * time.Millisecond}
+
+	_, err := startApp(context.Background(), shoppinglist.Options{}, db, b)
+	if err == nil || !strings.Contains(err.Error(), "database startup: giving up") {
+		t.Errorf("Expected startApp to give up after retrying, got %v", err)
+	}
+}
+
+func TestStartupGate(t *testing.T) {
+	gate := &startupGate{}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		gate.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	// Before the app is ready: alive, but not serving
+	if rr := get("/livez"); rr.Code != http.StatusOK {
+		t.Errorf("GET /livez while starting: expected %d, got %d", http.StatusOK, rr.Code)
+	}
+	for _, path := range []string{"/readyz", "/items"} {
+		if rr := get(path); rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") == "" {
+			t.Errorf("GET %s while starting: expected 503 with Retry-After, got %d", path, rr.Code)
+		}
+	}
+	gate.Drain()
+	if err := gate.Close(); err != nil {
+		t.Errorf("Close before ready: expected nil, got %v", err)
+	}
+
+	app, err := shoppinglist.New(shoppinglist.Options{Store: shoppinglist.NewMemoryStore()})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	gate.ready(app)
+	if rr := get("/items"); rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Errorf("GET /items once ready: expected 200 [], got %d %q", rr.Code, rr.Body.String())
+	}
+}
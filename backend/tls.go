@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// --- HTTPS ---
+
+// certCheckInterval is how often a handshake may stat the certificate files for changes.
+const certCheckInterval = 10 * time.Second
+
+// tlsVersion maps a TLS_MIN_VERSION value to its crypto/tls constant.
+func tlsVersion(v string) (uint16, error) {
+	switch v {
+	case "1.2", "":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("invalid TLS_MIN_VERSION %q (expected \"1.2\" or \"1.3\")", v)
+	}
+}
+
+// newTLSConfig returns the server TLS config for cfg, or nil when HTTPS is not enabled.
+// The certificate is loaded now, so a bad file fails startup, and reloaded when it changes.
+func newTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" {
+		return nil, nil
+	}
+	minVersion, err := tlsVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	certs := &certReloader{certFile: cfg.CertFile, keyFile: cfg.KeyFile, interval: certCheckInterval}
+	if err := certs.load(); err != nil {
+		return nil, err
+	}
+	return &tls.Config{MinVersion: minVersion, GetCertificate: certs.GetCertificate}, nil
+}
+
+// certReloader serves a certificate from disk and picks up a renewed one (cert-manager,
+// certbot) without a restart. The files are checked lazily during handshakes, at most
+// once per interval, so there is no goroutine to stop.
+type certReloader struct {
+	certFile, keyFile string
+	interval          time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	lastCheck time.Time
+}
+
+// load reads the key pair and remembers the files' modification times.
+func (r *certReloader) load() error {
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	r.cert, r.certMod, r.keyMod, r.lastCheck = &cert, certMod, keyMod, time.Now()
+	return nil
+}
+
+func (r *certReloader) modTimes() (certMod, keyMod time.Time, err error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return certMod, keyMod, fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return certMod, keyMod, fmt.Errorf("loading TLS key: %w", err)
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate. If the files changed but cannot be
+// loaded (for example mid-rotation, with only the certificate replaced), the previous
+// certificate stays in use and the reload is retried on a later handshake.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.lastCheck) < r.interval {
+		return r.cert, nil
+	}
+	r.lastCheck = time.Now()
+	certMod, keyMod, err := r.modTimes()
+	if err == nil && certMod.Equal(r.certMod) && keyMod.Equal(r.keyMod) {
+		return r.cert, nil
+	}
+	if err == nil {
+		err = r.load()
+	}
+	if err != nil {
+		slog.Warn("Could not reload TLS certificate, keeping the current one", "err", err)
+	} else {
+		slog.Info("Reloaded TLS certificate", "cert_file", r.certFile)
+	}
+	return r.cert, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/shoppinglist"
+)
+
+// --- HTTPS Tests ---
+
+// writeCertificate writes a self-signed certificate for 127.0.0.1 with the given common
+// name into dir as cert.pem and key.pem, and returns it parsed.
+func writeCertificate(t *testing.T, dir, commonName string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cert.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "key.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate failed: %v", err)
+	}
+	return cert
+}
+
+// touch moves a file's modification time forward, as a renewal would.
+func touch(t *testing.T, path string, mod time.Time) {
+	t.Helper()
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		tc, err := newTLSConfig(TLSConfig{})
+		if err != nil || tc != nil {
+			t.Errorf("Expected nil config and error without a certificate, got %v, %v", tc, err)
+		}
+	})
+
+	t.Run("MinVersion", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCertificate(t, dir, "first")
+		tc, err := newTLSConfig(TLSConfig{CertFile: filepath.Join(dir, "cert.pem"), KeyFile: filepath.Join(dir, "key.pem"), MinVersion: "1.3"})
+		if err != nil {
+			t.Fatalf("newTLSConfig failed: %v", err)
+		}
+		if tc.MinVersion != tls.VersionTLS13 {
+			t.Errorf("Expected TLS 1.3 minimum, got %x", tc.MinVersion)
+		}
+	})
+
+	t.Run("InvalidFiles", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := newTLSConfig(TLSConfig{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: filepath.Join(dir, "missing.pem")}); err == nil {
+			t.Error("Expected an error for missing files")
+		}
+		if _, err := tlsVersion("1.1"); err == nil {
+			t.Error("Expected an error for TLS 1.1")
+		}
+	})
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCertificate(t, dir, "first")
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	commonName := func() string {
+		t.Helper()
+		cert, err := r.GetCertificate(nil)
+		if err != nil {
+			t.Fatalf("GetCertificate failed: %v", err)
+		}
+		parsed, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatalf("ParseCertificate failed: %v", err)
+		}
+		return parsed.Subject.CommonName
+	}
+
+	// A renewed certificate is picked up on the next handshake
+	writeCertificate(t, dir, "second")
+	later := time.Now().Add(time.Minute)
+	touch(t, certFile, later)
+	touch(t, keyFile, later)
+	if got := commonName(); got != "second" {
+		t.Errorf("Expected the renewed certificate, got %q", got)
+	}
+
+	// A broken replacement keeps the current certificate in use
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	touch(t, certFile, later.Add(time.Minute))
+	if got := commonName(); got != "second" {
+		t.Errorf("Expected to keep the last good certificate, got %q", got)
+	}
+
+	// Within the check interval the files are not looked at
+	r.interval = time.Hour
+	writeCertificate(t, dir, "third")
+	touch(t, certFile, later.Add(2*time.Minute))
+	if got := commonName(); got != "second" {
+		t.Errorf("Expected no reload within the interval, got %q", got)
+	}
+}
+
+func TestServeTLS(t *testing.T) {
+	dir := t.TempDir()
+	cert := writeCertificate(t, dir, "backend")
+	tc, err := newTLSConfig(TLSConfig{CertFile: filepath.Join(dir, "cert.pem"), KeyFile: filepath.Join(dir, "key.pem"), MinVersion: "1.3"})
+	if err != nil {
+		t.Fatalf("newTLSConfig failed: %v", err)
+	}
+	app, err := shoppinglist.New(shoppinglist.Options{Store: shoppinglist.NewMemoryStore()})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- serve(ctx, &http.Server{Handler: app, TLSConfig: tc}, ln, app, shutdownConfig{Timeout: time.Second})
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	get := func(maxVersion uint16) (*http.Response, error) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, MaxVersion: maxVersion},
+			ForceAttemptHTTP2: true,
+		}}
+		return client.Get("https://" + ln.Addr().String() + "/livez")
+	}
+
+	resp, err := get(0)
+	if err != nil {
+		t.Fatalf("HTTPS request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ProtoMajor != 2 {
+		t.Errorf("Expected 200 over HTTP/2, got %d over %s", resp.StatusCode, resp.Proto)
+	}
+	if _, err := get(tls.VersionTLS12); err == nil {
+		t.Error("Expected a TLS 1.2 client to be rejected with a 1.3 minimum")
+	}
+
+	cancel()
+	if err := waitResult(t, result); err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+}
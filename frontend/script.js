@@ -30,9 +30,10 @@ const renderList = (items) => {
     }
     items.forEach(item => {
         const li = document.createElement('li');
+        // Address items by their public UID; the serial ID is only a fallback for old backends
         li.innerHTML = `
             <span><strong>${escapeHtml(item.name)}</strong> - ${escapeHtml(item.quantity)}</span>
-            <button class="delete-btn" data-id="${item.id}">Delete</button>
+            <button class="delete-btn" data-id="${escapeHtml(item.uid)}">Delete</button>
         `;
         // Add event listener to the delete button
         li.querySelector('.delete-btn').addEventListener('click', handleDeleteItem);
@@ -62,8 +63,9 @@ const handleAddItem = async (event) => {
         });
 
         if (!response.ok) {
-             const errorData = await response.text(); // Read error response if any
-             throw new Error(`HTTP error! status: ${response.status} - ${errorData}`);
+             // Errors are application/problem+json; show the detail when there is one
+             const problem = await response.json().catch(() => ({}));
+             throw new Error(`HTTP error! status: ${response.status} - ${problem.detail || problem.title || ''}`);
         }
 
         // Clear the form
@@ -89,7 +91,7 @@ const handleDeleteItem = async (event) => {
     }
 
     try {
-        const response = await fetch(`${apiUrl}/${itemId}`, {
+        const response = await fetch(`${apiUrl}/${encodeURIComponent(itemId)}`, {
             method: 'DELETE',
         });
 